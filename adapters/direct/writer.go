@@ -97,6 +97,14 @@ func (mw *Writer) AddEntity(ent tt.Entity) (string, error) {
 		mw.Reader.RiderCategoryList = append(mw.Reader.RiderCategoryList, *v)
 	case *gtfs.FareProduct:
 		mw.Reader.FareProductList = append(mw.Reader.FareProductList, *v)
+	case *gtfs.Location:
+		mw.Reader.LocationList = append(mw.Reader.LocationList, *v)
+	case *gtfs.LocationGroup:
+		mw.Reader.LocationGroupList = append(mw.Reader.LocationGroupList, *v)
+	case *gtfs.LocationGroupStop:
+		mw.Reader.LocationGroupStopList = append(mw.Reader.LocationGroupStopList, *v)
+	case *gtfs.BookingRule:
+		mw.Reader.BookingRuleList = append(mw.Reader.BookingRuleList, *v)
 	default:
 		mw.Reader.OtherList = append(mw.Reader.OtherList, v)
 	}
@@ -289,6 +289,126 @@ func NewAmbiguousRiderCategoryError(fareProductID string, riderCategories ...str
 
 ////////////////////////////
 
+// AmbiguousFareLegRuleError reports when fare leg rules with different leg groups match the same legs with equal rule_priority.
+type AmbiguousFareLegRuleError struct {
+	LegGroupID      string
+	OtherLegGroupID string
+	RulePriority    int64
+	bc
+}
+
+func (e *AmbiguousFareLegRuleError) Error() string {
+	return fmt.Sprintf(
+		"fare leg rule with leg_group_id '%s' matches the same legs as leg_group_id '%s' with equal rule_priority %d",
+		e.LegGroupID,
+		e.OtherLegGroupID,
+		e.RulePriority,
+	)
+}
+
+// NewAmbiguousFareLegRuleError returns a new AmbiguousFareLegRuleError.
+func NewAmbiguousFareLegRuleError(legGroupID string, otherLegGroupID string, rulePriority int64) *AmbiguousFareLegRuleError {
+	return &AmbiguousFareLegRuleError{
+		LegGroupID:      legGroupID,
+		OtherLegGroupID: otherLegGroupID,
+		RulePriority:    rulePriority,
+		bc:              bc{Field: "leg_group_id", Value: legGroupID},
+	}
+}
+
+////////////////////////////
+
+// TimeframeOverlapError reports when two timeframes with the same timeframe_group_id and service_id overlap in time.
+type TimeframeOverlapError struct {
+	TimeframeGroupID string
+	ServiceID        string
+	StartTime        string
+	EndTime          string
+	OtherStartTime   string
+	OtherEndTime     string
+	bc
+}
+
+func (e *TimeframeOverlapError) Error() string {
+	return fmt.Sprintf(
+		"timeframe_group_id '%s' for service_id '%s' with interval %s -> %s overlaps another timeframe with interval %s -> %s",
+		e.TimeframeGroupID,
+		e.ServiceID,
+		e.StartTime,
+		e.EndTime,
+		e.OtherStartTime,
+		e.OtherEndTime,
+	)
+}
+
+////////////////////////////
+
+// AreaWithoutStopsError reports when an area is not associated with any stops in stop_areas.txt.
+type AreaWithoutStopsError struct {
+	AreaID string
+	bc
+}
+
+func (e *AreaWithoutStopsError) Error() string {
+	return fmt.Sprintf("area '%s' does not contain any stops", e.AreaID)
+}
+
+// NewAreaWithoutStopsError returns a new AreaWithoutStopsError.
+func NewAreaWithoutStopsError(areaID string) *AreaWithoutStopsError {
+	return &AreaWithoutStopsError{
+		AreaID: areaID,
+		bc:     bc{Field: "area_id", Value: areaID},
+	}
+}
+
+////////////////////////////
+
+// FareProductWithoutMediaError reports when a fare product does not specify fare_media_id but the feed declares fare media.
+type FareProductWithoutMediaError struct {
+	FareProductID string
+	bc
+}
+
+func (e *FareProductWithoutMediaError) Error() string {
+	return fmt.Sprintf("fare_product_id '%s' does not specify fare_media_id, but fare_media.txt is present", e.FareProductID)
+}
+
+// NewFareProductWithoutMediaError returns a new FareProductWithoutMediaError.
+func NewFareProductWithoutMediaError(fareProductID string) *FareProductWithoutMediaError {
+	return &FareProductWithoutMediaError{
+		FareProductID: fareProductID,
+		bc:            bc{Field: "fare_media_id"},
+	}
+}
+
+////////////////////////////
+
+// ConflictingRouteNetworkError reports when networks are assigned in route_networks.txt and routes.network_id is also used.
+type ConflictingRouteNetworkError struct {
+	RouteID   string
+	NetworkID string
+	bc
+}
+
+func (e *ConflictingRouteNetworkError) Error() string {
+	return fmt.Sprintf(
+		"route_networks.txt assigns route '%s' to network '%s', but routes.txt also assigns networks using network_id",
+		e.RouteID,
+		e.NetworkID,
+	)
+}
+
+// NewConflictingRouteNetworkError returns a new ConflictingRouteNetworkError.
+func NewConflictingRouteNetworkError(routeID string, networkID string) *ConflictingRouteNetworkError {
+	return &ConflictingRouteNetworkError{
+		RouteID:   routeID,
+		NetworkID: networkID,
+		bc:        bc{Field: "route_id", Value: routeID},
+	}
+}
+
+////////////////////////////
+
 ////////////////////////////
 // Entity level errors
 ////////////////////////////
@@ -17,10 +17,12 @@ import (
 
 	_ "github.com/interline-io/transitland-lib/ext/plus"
 	_ "github.com/interline-io/transitland-lib/filters"
+	_ "github.com/interline-io/transitland-lib/netex"
 	_ "github.com/interline-io/transitland-lib/tlcsv"
 	_ "github.com/interline-io/transitland-lib/tldb"
 	_ "github.com/interline-io/transitland-lib/tldb/postgres"
 	_ "github.com/interline-io/transitland-lib/tldb/sqlite"
+	_ "github.com/interline-io/transitland-lib/tlparquet"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -62,8 +64,14 @@ func init() {
 		tlcli.CobraHelper(&cmds.DeleteCommand{}, pc, "delete"),
 		tlcli.CobraHelper(&cmds.ValidatorCommand{}, pc, "validate"),
 		tlcli.CobraHelper(&cmds.RTConvertCommand{}, pc, "rt-convert"),
+		tlcli.CobraHelper(&cmds.SchedulerCommand{}, pc, "scheduler"),
 		tlcli.CobraHelper(&diff.Command{}, pc, "diff"),
 		tlcli.CobraHelper(&tlxy.PolylinesCommand{}, pc, "polylines-create"),
+		tlcli.CobraHelper(&cmds.PMTilesCommand{}, pc, "pmtiles-create"),
+		tlcli.CobraHelper(&cmds.GbfsArchiveCommand{}, pc, "gbfs-archive"),
+		tlcli.CobraHelper(&cmds.GbfsAvailabilityCommand{}, pc, "gbfs-availability"),
+		tlcli.CobraHelper(&cmds.MdsFetchCommand{}, pc, "mds-fetch"),
+		tlcli.CobraHelper(&cmds.FareCalcCommand{}, pc, "fare-calc"),
 		tlcli.CobraHelper(&cmds.ServerCommand{}, pc, "server"),
 		tlcli.CobraHelper(&versionCommand{}, pc, "version"),
 		tlcli.CobraHelper(&cmds.DBMigrateCommand{}, pc, "dbmigrate"),
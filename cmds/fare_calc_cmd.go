@@ -0,0 +1,140 @@
+package cmds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/ext"
+	"github.com/interline-io/transitland-lib/fares"
+	"github.com/interline-io/transitland-lib/tlcli"
+	"github.com/spf13/pflag"
+)
+
+// FareCalcCommand calculates candidate fares for a journey using GTFS Fares v2 rules.
+type FareCalcCommand struct {
+	Request       fares.Request
+	ReaderPath    string
+	LegsFile      string
+	OutputFile    string
+	MaxCandidates int
+	Candidates    []fares.Candidate
+	legs          []string
+}
+
+func (cmd *FareCalcCommand) HelpDesc() (string, string) {
+	a := "Calculate fares for a journey using GTFS Fares v2 rules"
+	b := "Each --leg is a comma separated route_id, from_stop_id, to_stop_id, departure time and arrival time, with times in RFC3339 format. Alternatively, --legs-file specifies a JSON file containing legs, rider_category_id and fare_media_id. Candidate fares are written as JSON, ordered by number of unmatched legs and then by total."
+	return a, b
+}
+
+func (cmd *FareCalcCommand) HelpExample() string {
+	return `
+% {{.ParentCommand}} {{.Command}} --fare-media card --leg "CITY,STAGECOACH,NANAA,2008-01-05T08:00:00-08:00,2008-01-05T08:20:00-08:00" feed.zip
+`
+}
+
+func (cmd *FareCalcCommand) HelpArgs() string {
+	return "[flags] <reader>"
+}
+
+func (cmd *FareCalcCommand) AddFlags(fl *pflag.FlagSet) {
+	fl.StringArrayVar(&cmd.legs, "leg", nil, "Journey leg; format is route_id,from_stop_id,to_stop_id,departure,arrival")
+	fl.StringVar(&cmd.LegsFile, "legs-file", "", "Read journey from JSON file")
+	fl.StringVar(&cmd.Request.RiderCategoryID, "rider-category", "", "Rider category ID; defaults to the feed's default fare category")
+	fl.StringVar(&cmd.Request.FareMediaID, "fare-media", "", "Fare media ID")
+	fl.IntVar(&cmd.MaxCandidates, "max-candidates", fares.DefaultMaxCandidates, "Maximum number of fare combinations to evaluate; results are incomplete if reached")
+	fl.StringVarP(&cmd.OutputFile, "out", "o", "", "Write output to file; defaults to stdout")
+}
+
+func (cmd *FareCalcCommand) Parse(args []string) error {
+	fl := tlcli.NewNArgs(args)
+	if fl.NArg() < 1 {
+		return errors.New("requires input reader")
+	}
+	cmd.ReaderPath = fl.Arg(0)
+	if cmd.LegsFile != "" {
+		data, err := os.ReadFile(cmd.LegsFile)
+		if err != nil {
+			return err
+		}
+		req := fares.Request{}
+		if err := json.Unmarshal(data, &req); err != nil {
+			return fmt.Errorf("invalid --legs-file: %w", err)
+		}
+		cmd.Request.Legs = append(cmd.Request.Legs, req.Legs...)
+		if cmd.Request.RiderCategoryID == "" {
+			cmd.Request.RiderCategoryID = req.RiderCategoryID
+		}
+		if cmd.Request.FareMediaID == "" {
+			cmd.Request.FareMediaID = req.FareMediaID
+		}
+	}
+	for _, v := range cmd.legs {
+		leg, err := parseFareLeg(v)
+		if err != nil {
+			return fmt.Errorf("invalid --leg '%s': %w", v, err)
+		}
+		cmd.Request.Legs = append(cmd.Request.Legs, leg)
+	}
+	if len(cmd.Request.Legs) == 0 {
+		return errors.New("requires at least one --leg or --legs-file")
+	}
+	return nil
+}
+
+func (cmd *FareCalcCommand) Run(ctx context.Context) error {
+	reader, err := ext.OpenReader(cmd.ReaderPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	calc, err := fares.NewCalculatorFromReader(reader)
+	if err != nil {
+		return err
+	}
+	calc.MaxCandidates = cmd.MaxCandidates
+	cmd.Candidates, err = calc.Calculate(cmd.Request)
+	if errors.Is(err, fares.ErrTooManyCandidates) {
+		log.For(ctx).Warn().Int("max_candidates", calc.MaxCandidates).Msg("reached maximum fare candidates, results are incomplete")
+	} else if err != nil {
+		return err
+	}
+	var w io.Writer = os.Stdout
+	if cmd.OutputFile != "" {
+		f, err := os.Create(cmd.OutputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cmd.Candidates)
+}
+
+func parseFareLeg(v string) (fares.Leg, error) {
+	leg := fares.Leg{}
+	parts := strings.Split(v, ",")
+	if len(parts) != 5 {
+		return leg, errors.New("expected 5 values")
+	}
+	leg.RouteID = parts[0]
+	leg.FromStopID = parts[1]
+	leg.ToStopID = parts[2]
+	var err error
+	if leg.Departure, err = time.Parse(time.RFC3339, parts[3]); err != nil {
+		return leg, err
+	}
+	if leg.Arrival, err = time.Parse(time.RFC3339, parts[4]); err != nil {
+		return leg, err
+	}
+	return leg, nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/interline-io/log"
 	"github.com/interline-io/transitland-lib/dmfr"
 	"github.com/interline-io/transitland-lib/fetch"
+	"github.com/interline-io/transitland-lib/request"
 	"github.com/interline-io/transitland-lib/stats"
 	"github.com/interline-io/transitland-lib/tldb"
 	"github.com/interline-io/transitland-lib/validator"
@@ -29,6 +30,8 @@ type FetchCommandResult struct {
 type FetchCommand struct {
 	Options     fetch.StaticFetchOptions
 	SecretsFile string
+	ProfileFile string
+	HostLimit   request.HostLimit
 	CreateFeed  bool
 	Workers     int
 	Fail        bool
@@ -60,12 +63,16 @@ func (cmd *FetchCommand) AddFlags(fl *pflag.FlagSet) {
 	fl.BoolVar(&cmd.Fail, "fail", false, "Exit with error code if any fetch is not successful")
 	fl.BoolVar(&cmd.DryRun, "dry-run", false, "Dry run; print feeds that would be imported and exit")
 	fl.BoolVar(&cmd.Options.StrictValidation, "strict", false, "Reject feeds with validation errors")
+	fl.StringVar(&cmd.ProfileFile, "profile", "", "Validation profile (YAML or JSON) with severity overrides, rule parameters, and suppressions; applied before --strict")
 	fl.BoolVar(&cmd.Options.AllowFTPFetch, "allow-ftp-fetch", false, "Allow fetching from FTP urls")
 	fl.BoolVar(&cmd.Options.AllowS3Fetch, "allow-s3-fetch", false, "Allow fetching from S3 urls")
 	fl.BoolVar(&cmd.Options.AllowLocalFetch, "allow-local-fetch", false, "Allow fetching from filesystem directories/zip files")
+	fl.IntVar(&cmd.HostLimit.MaxConcurrency, "host-max-concurrency", 0, "Maximum concurrent requests to each host; override per feed with the fetch_max_concurrency tag (default: unlimited)")
+	fl.IntVar(&cmd.HostLimit.RequestsPerMinute, "host-requests-per-minute", 0, "Maximum requests per minute to each host; override per feed with the fetch_requests_per_minute tag (default: unlimited)")
+	fl.BoolVar(&cmd.Options.Unconditional, "unconditional", false, "Do not send If-None-Match/If-Modified-Since headers from the previous fetch")
 	fl.BoolVar(&cmd.Options.SaveValidationReport, "validation-report", false, "Save validation report")
 	fl.StringVar(&cmd.Options.ValidationReportStorage, "validation-report-storage", "", "Storage path for saving validation report JSON")
-	fl.StringVar(&cmd.Options.Storage, "storage", ".", "Storage destination; can be s3://... az://... or path to a directory")
+	fl.StringVar(&cmd.Options.Storage, "storage", ".", "Storage destination; can be s3://... az://... gs://... davs://... or path to a directory; prefix with cas+ to store zip entries by content hash")
 }
 
 func (cmd *FetchCommand) Parse(args []string) error {
@@ -73,6 +80,13 @@ func (cmd *FetchCommand) Parse(args []string) error {
 		cmd.DBURL = os.Getenv("TL_DATABASE_URL")
 	}
 	cmd.FeedIDs = args
+	if cmd.ProfileFile != "" {
+		profile, err := validator.LoadProfile(cmd.ProfileFile)
+		if err != nil {
+			return err
+		}
+		cmd.Options.ValidatorOptions.Profile = profile
+	}
 	return nil
 }
 
@@ -96,6 +110,10 @@ func (cmd *FetchCommand) Run(ctx context.Context) error {
 		}
 		cmd.Options.Secrets = r.Secrets
 	}
+	if cmd.Options.HostLimiter == nil {
+		// Shared by all workers
+		cmd.Options.HostLimiter = request.NewHostLimiter(cmd.HostLimit)
+	}
 	if cmd.Options.FetchedAt.IsZero() {
 		cmd.Options.FetchedAt = time.Now()
 	}
@@ -247,6 +265,8 @@ func fetchWorker(ctx context.Context, adapter tldb.Adapter, DryRun bool, jobs <-
 			log.For(ctx).Error().Err(fatalError).Msgf("Feed %s (id:%d): url: %s critical error: %s (t:%0.2fs)", job.OnestopID, job.Options.FeedID, result.URL, fatalError.Error(), t2)
 		} else if result.FetchError != nil {
 			log.For(ctx).Error().Err(result.FetchError).Msgf("Feed %s (id:%d): url: %s fetch error: %s (t:%0.2fs)", job.OnestopID, job.Options.FeedID, result.URL, result.FetchError.Error(), t2)
+		} else if fv != nil && result.NotModified {
+			log.For(ctx).Info().Msgf("Feed %s (id:%d): url: %s not modified, existing sha1: %s (id:%d) (t:%0.2fs)", job.OnestopID, job.Options.FeedID, fv.URL, fv.SHA1, fv.ID, t2)
 		} else if fv != nil && result.Found {
 			log.For(ctx).Info().Msgf("Feed %s (id:%d): url: %s found sha1: %s (id:%d) (t:%0.2fs)", job.OnestopID, job.Options.FeedID, fv.URL, fv.SHA1, fv.ID, t2)
 		} else if fv != nil {
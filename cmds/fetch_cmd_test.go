@@ -38,6 +38,7 @@ func TestFetchCommand(t *testing.T) {
 		command            []string
 		fail               bool
 		strict             bool
+		profile            string
 	}{
 		{
 			name:    "single fetch",
@@ -89,6 +90,14 @@ func TestFetchCommand(t *testing.T) {
 			strict:             true,
 			fatalErrorContains: "strict validation failed",
 		},
+		{
+			name:    "strict validation with profile",
+			fvcount: 2,
+			feeds:   []dmfr.Feed{f200, fvErrorExample},
+			fail:    true,
+			strict:  true,
+			profile: testpath.RelPath("testdata/validator/profiles/warnings.yaml"),
+		},
 	}
 	ctx := context.TODO()
 	for _, exp := range cases {
@@ -103,6 +112,7 @@ func TestFetchCommand(t *testing.T) {
 			c.Options.Storage = tmpDir
 			c.Options.StrictValidation = exp.strict
 			c.Fail = exp.fail
+			c.ProfileFile = exp.profile
 			if err := c.Parse(exp.command); err != nil {
 				t.Fatal(err)
 			}
@@ -0,0 +1,247 @@
+package cmds
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/internal/gbfs"
+	"github.com/interline-io/transitland-lib/request"
+	"github.com/interline-io/transitland-lib/tlcli"
+	"github.com/spf13/pflag"
+)
+
+// GbfsArchiveCommand periodically fetches a GBFS feed and saves each snapshot to storage.
+type GbfsArchiveCommand struct {
+	Options  gbfs.Options
+	Interval time.Duration
+	Count    int
+}
+
+func (cmd *GbfsArchiveCommand) HelpDesc() (string, string) {
+	a := "Archive snapshots of a GBFS feed"
+	b := "Fetches all files listed in a GBFS auto-discovery file and saves them as a single JSON snapshot to --storage, at <prefix>/<date>/<unix time>.json. With --interval, fetches repeatedly until stopped or --count snapshots have been saved. GBFS v2 and v3 feeds are supported. Use gbfs-availability to summarize archived snapshots."
+	return a, b
+}
+
+func (cmd *GbfsArchiveCommand) HelpArgs() string {
+	return "[flags] <gbfs.json url>"
+}
+
+func (cmd *GbfsArchiveCommand) AddFlags(fl *pflag.FlagSet) {
+	fl.StringVar(&cmd.Options.Storage, "storage", ".", "Storage destination; can be s3://... az://... gs://... davs://... or path to a directory")
+	fl.StringVar(&cmd.Options.ArchivePrefix, "prefix", "", "Storage key prefix for snapshots (default: gbfs/<url host>)")
+	fl.DurationVar(&cmd.Interval, "interval", 0, "Time between snapshots; if not set, save a single snapshot")
+	fl.IntVar(&cmd.Count, "count", 0, "Stop after saving this many snapshots (default: unlimited when --interval is set)")
+	fl.BoolVar(&cmd.Options.AllowLocalFetch, "allow-local-fetch", false, "Allow fetching from filesystem directories/zip files")
+}
+
+func (cmd *GbfsArchiveCommand) Parse(args []string) error {
+	fl := tlcli.NewNArgs(args)
+	if fl.NArg() < 1 {
+		return errors.New("requires gbfs.json url")
+	}
+	cmd.Options.FeedURL = fl.Arg(0)
+	if cmd.Options.Storage == "" {
+		return errors.New("--storage is required")
+	}
+	if cmd.Interval < 0 {
+		return errors.New("--interval must not be negative")
+	}
+	if cmd.Options.ArchivePrefix == "" {
+		u, err := url.Parse(cmd.Options.FeedURL)
+		if err != nil {
+			return err
+		}
+		cmd.Options.ArchivePrefix = "gbfs/" + u.Host
+	}
+	return nil
+}
+
+func (cmd *GbfsArchiveCommand) Run(ctx context.Context) error {
+	if cmd.Interval == 0 {
+		return cmd.archive(ctx)
+	}
+	ticker := time.NewTicker(cmd.Interval)
+	defer ticker.Stop()
+	saved := 0
+	for {
+		// Keep archiving after failed fetches
+		if err := cmd.archive(ctx); err != nil {
+			log.For(ctx).Error().Err(err).Str("url", cmd.Options.FeedURL).Msg("gbfs-archive: failed to save snapshot")
+		} else {
+			saved++
+		}
+		if cmd.Count > 0 && saved >= cmd.Count {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cmd *GbfsArchiveCommand) archive(ctx context.Context) error {
+	opts := cmd.Options
+	opts.FetchedAt = time.Now().In(time.UTC)
+	_, result, err := gbfs.Fetch(ctx, nil, opts)
+	if err != nil {
+		return err
+	}
+	log.For(ctx).Info().Str("url", opts.FeedURL).Str("key", result.ArchiveKey).Msg("gbfs-archive: saved snapshot")
+	return nil
+}
+
+////////
+
+// GbfsAvailabilityCommand summarizes station availability from archived GBFS snapshots.
+type GbfsAvailabilityCommand struct {
+	Storage    string
+	Prefix     string
+	OutputFile string
+	Language   string
+	Interval   time.Duration
+	Start      string
+	End        string
+	start      time.Time
+	end        time.Time
+}
+
+func (cmd *GbfsAvailabilityCommand) HelpDesc() (string, string) {
+	a := "Summarize station availability from archived GBFS snapshots"
+	b := "Reads snapshots saved by gbfs-archive and writes a CSV time series with one row for each system, station and --interval: the number of snapshots, the average, minimum and maximum bikes and docks available, and the fraction of snapshots where the station was empty (no bikes available) or full (no docks available). Systems are identified by the auto-discovery url of the snapshot, so archives of several systems may be summarized together. Stations that are not installed are skipped."
+	return a, b
+}
+
+func (cmd *GbfsAvailabilityCommand) HelpArgs() string {
+	return "[flags] <storage>"
+}
+
+func (cmd *GbfsAvailabilityCommand) AddFlags(fl *pflag.FlagSet) {
+	fl.StringVar(&cmd.Prefix, "prefix", "gbfs", "Storage key prefix for snapshots")
+	fl.StringVarP(&cmd.OutputFile, "out", "o", "", "Write output to file; defaults to stdout")
+	fl.StringVar(&cmd.Language, "language", "", "Language to use for feeds with multiple languages (default: first language)")
+	fl.DurationVar(&cmd.Interval, "interval", time.Hour, "Time series bucket size")
+	fl.StringVar(&cmd.Start, "start", "", "Only include snapshots fetched on or after this date (YYYY-MM-DD)")
+	fl.StringVar(&cmd.End, "end", "", "Only include snapshots fetched before this date (YYYY-MM-DD)")
+}
+
+func (cmd *GbfsAvailabilityCommand) Parse(args []string) error {
+	fl := tlcli.NewNArgs(args)
+	if fl.NArg() < 1 {
+		return errors.New("requires storage")
+	}
+	cmd.Storage = fl.Arg(0)
+	if cmd.Interval <= 0 {
+		return errors.New("--interval must be positive")
+	}
+	var err error
+	if cmd.Start != "" {
+		if cmd.start, err = time.Parse("2006-01-02", cmd.Start); err != nil {
+			return fmt.Errorf("invalid --start: %w", err)
+		}
+	}
+	if cmd.End != "" {
+		if cmd.end, err = time.Parse("2006-01-02", cmd.End); err != nil {
+			return fmt.Errorf("invalid --end: %w", err)
+		}
+	}
+	return nil
+}
+
+func (cmd *GbfsAvailabilityCommand) Run(ctx context.Context) error {
+	store, err := request.GetStore(cmd.Storage)
+	if err != nil {
+		return err
+	}
+	keys, err := store.ListKeys(ctx, cmd.Prefix)
+	if err != nil {
+		return err
+	}
+	agg := gbfs.NewAvailabilityAggregator(cmd.Interval)
+	count := 0
+	for _, key := range keys {
+		if !strings.HasSuffix(key, ".json") {
+			continue
+		}
+		s, err := gbfs.ReadSnapshot(ctx, store, key)
+		if err != nil {
+			log.For(ctx).Error().Err(err).Str("key", key).Msg("gbfs-availability: skipping snapshot")
+			continue
+		}
+		if (!cmd.start.IsZero() && s.FetchedAt.Before(cmd.start)) || (!cmd.end.IsZero() && !s.FetchedAt.Before(cmd.end)) {
+			continue
+		}
+		feeds, err := s.Feeds(ctx, cmd.Language)
+		if err != nil {
+			log.For(ctx).Error().Err(err).Str("key", key).Msg("gbfs-availability: skipping snapshot")
+			continue
+		}
+		// Station status does not vary by language
+		if len(feeds) > 0 {
+			agg.Add(s.URL, s.FetchedAt, feeds[0].StationStatus)
+			count++
+		}
+	}
+	log.For(ctx).Info().Int("snapshots", count).Msg("gbfs-availability: read snapshots")
+
+	var w io.Writer = os.Stdout
+	if cmd.OutputFile != "" {
+		f, err := os.Create(cmd.OutputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return writeAvailabilityCsv(w, agg.Results())
+}
+
+func writeAvailabilityCsv(w io.Writer, results []gbfs.StationAvailability) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"system_url",
+		"station_id",
+		"start",
+		"samples",
+		"bikes_available_avg",
+		"bikes_available_min",
+		"bikes_available_max",
+		"docks_available_avg",
+		"docks_available_min",
+		"docks_available_max",
+		"empty_fraction",
+		"full_fraction",
+	})
+	ff := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 3, 64)
+	}
+	for _, r := range results {
+		cw.Write([]string{
+			r.System,
+			r.StationID,
+			r.Start.Format(time.RFC3339),
+			strconv.Itoa(r.Samples),
+			ff(r.BikesAvailableAvg),
+			strconv.Itoa(r.BikesAvailableMin),
+			strconv.Itoa(r.BikesAvailableMax),
+			ff(r.DocksAvailableAvg),
+			strconv.Itoa(r.DocksAvailableMin),
+			strconv.Itoa(r.DocksAvailableMax),
+			ff(r.EmptyFraction),
+			ff(r.FullFraction),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
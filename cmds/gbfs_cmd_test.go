@@ -0,0 +1,115 @@
+package cmds
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/interline-io/transitland-lib/internal/gbfs"
+	"github.com/interline-io/transitland-lib/testdata"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGbfsArchiveCommand(t *testing.T) {
+	ctx := context.TODO()
+	srv := gbfs.NewTestGbfsServer("", testdata.Path("server/gbfs-v3"))
+	srv.Version = "3.0"
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	storage := t.TempDir()
+
+	// Archive a snapshot
+	archiveCmd := GbfsArchiveCommand{}
+	archiveCmd.Options.Storage = storage
+	archiveCmd.Options.ArchivePrefix = "gbfs/test"
+	if err := archiveCmd.Parse([]string{ts.URL + "/gbfs.json"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := archiveCmd.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Summarize
+	outfn := filepath.Join(t.TempDir(), "availability.csv")
+	availCmd := GbfsAvailabilityCommand{Prefix: "gbfs/test", OutputFile: outfn, Interval: time.Hour}
+	if err := availCmd.Parse([]string{storage}); err != nil {
+		t.Fatal(err)
+	}
+	if err := availCmd.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(outfn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Equal(t, 3, len(rows)) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"system_url", "station_id"}, rows[0][0:2])
+	assert.Equal(t, ts.URL+"/gbfs.json", rows[1][0])
+	assert.Equal(t, []string{"station1", "1", "4.000", "4", "4", "5.000", "5", "5", "0.000", "0.000"}, append(rows[1][1:2], rows[1][3:]...))
+	assert.Equal(t, "station2", rows[2][1])
+	assert.Equal(t, "1.000", rows[2][10])
+}
+
+func TestGbfsAvailabilityCommand_MultipleSystems(t *testing.T) {
+	ctx := context.TODO()
+	storage := t.TempDir()
+	var systemUrls []string
+	for _, prefix := range []string{"gbfs/a", "gbfs/b"} {
+		srv := gbfs.NewTestGbfsServer("", testdata.Path("server/gbfs-v3"))
+		srv.Version = "3.0"
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		archiveCmd := GbfsArchiveCommand{}
+		archiveCmd.Options.Storage = storage
+		archiveCmd.Options.ArchivePrefix = prefix
+		if err := archiveCmd.Parse([]string{ts.URL + "/gbfs.json"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := archiveCmd.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		systemUrls = append(systemUrls, ts.URL+"/gbfs.json")
+	}
+
+	// Summarize all archives; the same station IDs are kept separate for each system
+	outfn := filepath.Join(t.TempDir(), "availability.csv")
+	availCmd := GbfsAvailabilityCommand{Prefix: "gbfs", OutputFile: outfn, Interval: time.Hour}
+	if err := availCmd.Parse([]string{storage}); err != nil {
+		t.Fatal(err)
+	}
+	if err := availCmd.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(outfn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Equal(t, 5, len(rows)) {
+		t.FailNow()
+	}
+	counts := map[string]int{}
+	for _, row := range rows[1:] {
+		counts[row[0]+":"+row[1]] += 1
+		assert.Equal(t, "1", row[3], "expected one sample per system and station")
+	}
+	for _, u := range systemUrls {
+		assert.Equal(t, 1, counts[u+":station1"])
+		assert.Equal(t, 1, counts[u+":station2"])
+	}
+}
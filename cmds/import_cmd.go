@@ -60,12 +60,13 @@ func (cmd *ImportCommand) AddFlags(fl *pflag.FlagSet) {
 	fl.IntVar(&cmd.Limit, "limit", 0, "Import at most n feeds")
 	fl.BoolVar(&cmd.Fail, "fail", false, "Exit with error code if any fetch is not successful")
 	fl.StringVar(&cmd.DBURL, "dburl", "", "Database URL (default: $TL_DATABASE_URL)")
-	fl.StringVar(&cmd.Options.Storage, "storage", ".", "Storage location; can be s3://... az://... or path to a directory")
+	fl.StringVar(&cmd.Options.Storage, "storage", ".", "Storage location; can be s3://... az://... gs://... davs://... or path to a directory")
 	fl.StringVar(&cmd.CoverDate, "date", "", "Service on date")
 	fl.StringVar(&cmd.FetchedSince, "fetched-since", "", "Fetched since")
 	fl.BoolVar(&cmd.Latest, "latest", false, "Only import latest feed version available for each feed")
 	fl.BoolVar(&cmd.DryRun, "dryrun", false, "Dry run; print feeds that would be imported and exit")
 	fl.BoolVar(&cmd.Options.Activate, "activate", false, "Set as active feed version after import")
+	fl.BoolVar(&cmd.Options.RecordChanges, "record-changes", false, "Compare with the previous feed version of the same feed and save the changes")
 	// Copy options
 	fl.Float64Var(&cmd.Options.SimplifyShapes, "simplify-shapes", 0.0, "Simplify shapes with this tolerance (ex. 0.000005)")
 	fl.BoolVar(&cmd.Options.InterpolateStopTimes, "interpolate-stop-times", false, "Interpolate missing StopTime arrival/departure values")
@@ -188,6 +189,7 @@ func (cmd *ImportCommand) Run(ctx context.Context) error {
 			FeedVersionID: fvid,
 			Storage:       cmd.Options.Storage,
 			Activate:      cmd.Options.Activate,
+			RecordChanges: cmd.Options.RecordChanges,
 			Options:       cmd.Options.Options,
 		}
 	}
@@ -0,0 +1,142 @@
+package cmds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/dmfr"
+	"github.com/interline-io/transitland-lib/internal/mds"
+	"github.com/interline-io/transitland-lib/tldb"
+	"github.com/spf13/pflag"
+)
+
+// MdsFetchCommand fetches trips, status changes and vehicles from MDS provider feeds.
+type MdsFetchCommand struct {
+	Options     mds.Options
+	SecretsFile string
+	DBURL       string
+	Fail        bool
+	FeedIDs     []string
+	Results     []mds.Result
+	Adapter     tldb.Adapter // allow for mocks
+	start       string
+	end         string
+	endpoints   string
+}
+
+func (cmd *MdsFetchCommand) HelpDesc() (string, string) {
+	a := "Fetch trips, status changes and vehicles from MDS provider feeds"
+	b := "Requests the MDS provider API at each feed's mds_provider url, following all pages, and saves the results to the database. Trips and status changes are requested one hour at a time between --start and --end; previously fetched records in that range are replaced. If no feeds are specified, all feeds with an mds_provider url are fetched."
+	return a, b
+}
+
+func (cmd *MdsFetchCommand) HelpArgs() string {
+	return "[flags] [feeds...]"
+}
+
+func (cmd *MdsFetchCommand) AddFlags(fl *pflag.FlagSet) {
+	fl.StringVar(&cmd.DBURL, "dburl", "", "Database URL (default: $TL_DATABASE_URL)")
+	fl.StringVar(&cmd.SecretsFile, "secrets", "", "Path to DMFR Secrets file")
+	fl.StringVar(&cmd.start, "start", "", "Start of time range, e.g. 2020-02-06T12:00:00Z (default: start of the previous hour)")
+	fl.StringVar(&cmd.end, "end", "", "End of time range, e.g. 2020-02-06T13:00:00Z (default: start of the current hour)")
+	fl.StringVar(&cmd.endpoints, "endpoints", "trips,status_changes,vehicles", "Comma separated list of endpoints to fetch")
+	fl.StringVar(&cmd.Options.Version, "mds-version", mds.DefaultVersion, "MDS provider API version")
+	fl.BoolVar(&cmd.Fail, "fail", false, "Exit with error code if any fetch is not successful")
+	fl.BoolVar(&cmd.Options.AllowLocalFetch, "allow-local-fetch", false, "Allow fetching from local urls")
+}
+
+func (cmd *MdsFetchCommand) Parse(args []string) error {
+	if cmd.DBURL == "" {
+		cmd.DBURL = os.Getenv("TL_DATABASE_URL")
+	}
+	cmd.FeedIDs = args
+	now := time.Now().In(time.UTC).Truncate(time.Hour)
+	cmd.Options.Start = now.Add(-time.Hour)
+	cmd.Options.End = now
+	if cmd.start != "" {
+		t, err := time.Parse(time.RFC3339, cmd.start)
+		if err != nil {
+			return fmt.Errorf("invalid --start: %w", err)
+		}
+		cmd.Options.Start = t
+	}
+	if cmd.end != "" {
+		t, err := time.Parse(time.RFC3339, cmd.end)
+		if err != nil {
+			return fmt.Errorf("invalid --end: %w", err)
+		}
+		cmd.Options.End = t
+	}
+	if !cmd.Options.Start.Before(cmd.Options.End) {
+		return errors.New("--start must be before --end")
+	}
+	if cmd.endpoints != "" {
+		cmd.Options.Endpoints = strings.Split(cmd.endpoints, ",")
+	}
+	return nil
+}
+
+// Run executes this command.
+func (cmd *MdsFetchCommand) Run(ctx context.Context) error {
+	if cmd.SecretsFile != "" {
+		r, err := dmfr.LoadAndParseRegistry(cmd.SecretsFile)
+		if err != nil {
+			return err
+		}
+		cmd.Options.Secrets = r.Secrets
+	}
+	if cmd.Adapter == nil {
+		writer, err := tldb.OpenWriter(cmd.DBURL, true)
+		if err != nil {
+			return err
+		}
+		cmd.Adapter = writer.Adapter
+		defer writer.Close()
+	}
+	if len(cmd.FeedIDs) == 0 {
+		feeds := []dmfr.Feed{}
+		if err := cmd.Adapter.Select(ctx, &feeds, "select * from current_feeds where deleted_at is null order by id"); err != nil {
+			return err
+		}
+		for _, feed := range feeds {
+			if feed.URLs.MdsProvider != "" {
+				cmd.FeedIDs = append(cmd.FeedIDs, feed.FeedID)
+			}
+		}
+	}
+
+	log.For(ctx).Info().Msgf("Fetching %d MDS feeds", len(cmd.FeedIDs))
+	var fetchErr error
+	for _, osid := range cmd.FeedIDs {
+		feed := dmfr.Feed{}
+		if err := cmd.Adapter.Get(ctx, &feed, "select * from current_feeds where onestop_id = ?", osid); err != nil {
+			return fmt.Errorf("problem with feed '%s': %s", osid, err.Error())
+		}
+		opts := cmd.Options // copy
+		opts.FeedID = feed.ID
+		var result mds.Result
+		if err := cmd.Adapter.Tx(func(atx tldb.Adapter) error {
+			var err error
+			result, err = mds.Fetch(ctx, atx, opts)
+			return err
+		}); err != nil {
+			return err
+		}
+		cmd.Results = append(cmd.Results, result)
+		if result.FetchError != nil {
+			log.For(ctx).Error().Err(result.FetchError).Msgf("Feed %s: fetch failed", osid)
+			fetchErr = result.FetchError
+			continue
+		}
+		log.For(ctx).Info().Msgf("Feed %s: trips: %d status changes: %d vehicles: %d", osid, result.TripCount, result.StatusChangeCount, result.VehicleCount)
+	}
+	if cmd.Fail && fetchErr != nil {
+		return fetchErr
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package cmds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/adapters/empty"
+	"github.com/interline-io/transitland-lib/copier"
+	"github.com/interline-io/transitland-lib/ext"
+	"github.com/interline-io/transitland-lib/ext/builders"
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/tlcli"
+	"github.com/interline-io/transitland-lib/tlxy"
+	"github.com/interline-io/transitland-lib/tlxy/mvt"
+	"github.com/interline-io/transitland-lib/tlxy/pmtiles"
+	"github.com/interline-io/transitland-lib/tt"
+	"github.com/spf13/pflag"
+	"github.com/twpayne/go-geom"
+)
+
+// PMTilesCommand renders the stops and routes in a feed to a PMTiles archive.
+type PMTilesCommand struct {
+	MinZoom    int
+	MaxZoom    int
+	ReaderPath string
+	OutputPath string
+}
+
+func (cmd *PMTilesCommand) HelpDesc() (string, string) {
+	a := "Render stops and route geometries in a feed to a PMTiles vector tile archive"
+	b := "The archive contains two layers, stops and routes, with the same properties as the tiles served by the REST API."
+	return a, b
+}
+
+func (cmd *PMTilesCommand) HelpArgs() string {
+	return "[flags] <reader> <output.pmtiles>"
+}
+
+func (cmd *PMTilesCommand) AddFlags(fl *pflag.FlagSet) {
+	fl.IntVar(&cmd.MinZoom, "min-zoom", 4, "Minimum zoom level")
+	fl.IntVar(&cmd.MaxZoom, "max-zoom", 14, "Maximum zoom level")
+}
+
+func (cmd *PMTilesCommand) Parse(args []string) error {
+	fl := tlcli.NewNArgs(args)
+	if fl.NArg() < 2 {
+		return errors.New("requires input reader and output file")
+	}
+	cmd.ReaderPath = fl.Arg(0)
+	cmd.OutputPath = fl.Arg(1)
+	if cmd.MinZoom < 0 || cmd.MaxZoom > mvt.MaxZoom || cmd.MinZoom > cmd.MaxZoom {
+		return fmt.Errorf("invalid zoom range: %d to %d", cmd.MinZoom, cmd.MaxZoom)
+	}
+	return nil
+}
+
+func (cmd *PMTilesCommand) Run(ctx context.Context) error {
+	reader, err := ext.OpenReader(cmd.ReaderPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// Collect entities; nothing needs to be written
+	collector := newTileCollector()
+	cpOpts := copier.Options{}
+	cpOpts.AddExtension(builders.NewRouteGeometryBuilder())
+	cpOpts.AddExtension(builders.NewOnestopIDBuilder())
+	cpOpts.AddExtension(collector)
+	if _, err := copier.CopyWithOptions(ctx, reader, &empty.Writer{}, cpOpts); err != nil {
+		return err
+	}
+	layers := collector.Layers()
+
+	// Render tiles
+	w := pmtiles.NewWriter(pmtiles.TileTypeMVT)
+	var bbox *tlxy.BoundingBox
+	for _, layer := range layers {
+		for _, f := range layer.Features {
+			bbox = extendBbox(bbox, f.Geometry)
+		}
+	}
+	if bbox == nil {
+		return errors.New("no stops or routes with geometries")
+	}
+	w.Bounds = *bbox
+	for z := cmd.MinZoom; z <= cmd.MaxZoom; z++ {
+		tileLayers := map[mvt.Tile][]mvt.Layer{}
+		for li, layer := range layers {
+			for _, f := range layer.Features {
+				for _, tile := range mvt.GeometryTiles(z, f.Geometry) {
+					tl := tileLayers[tile]
+					if len(tl) == 0 {
+						tl = make([]mvt.Layer, len(layers))
+						for i := range layers {
+							tl[i].Name = layers[i].Name
+						}
+						tileLayers[tile] = tl
+					}
+					tl[li].Features = append(tl[li].Features, f)
+				}
+			}
+		}
+		for tile, tl := range tileLayers {
+			data, err := mvt.Encode(tile, tl)
+			if err != nil {
+				return err
+			}
+			if len(data) == 0 {
+				continue
+			}
+			if err := w.AddTile(tile.Z, tile.X, tile.Y, data); err != nil {
+				return err
+			}
+		}
+		log.For(ctx).Info().Int("zoom", z).Int("tiles", len(tileLayers)).Msg("rendered tiles")
+	}
+
+	// Write archive
+	outf, err := os.Create(cmd.OutputPath)
+	if err != nil {
+		return err
+	}
+	defer outf.Close()
+	name := strings.TrimSuffix(filepath.Base(cmd.OutputPath), filepath.Ext(cmd.OutputPath))
+	metadata := map[string]any{
+		"name":   name,
+		"format": "pbf",
+		"vector_layers": []map[string]any{
+			{"id": "stops", "minzoom": cmd.MinZoom, "maxzoom": cmd.MaxZoom, "fields": tileStopFields},
+			{"id": "routes", "minzoom": cmd.MinZoom, "maxzoom": cmd.MaxZoom, "fields": tileRouteFields},
+		},
+	}
+	if err := w.Write(outf, metadata); err != nil {
+		return err
+	}
+	log.For(ctx).Info().Int("tiles", w.Len()).Str("output", cmd.OutputPath).Msg("wrote pmtiles archive")
+	return outf.Close()
+}
+
+var tileStopFields = map[string]string{
+	"stop_id":             "String",
+	"stop_name":           "String",
+	"stop_code":           "String",
+	"location_type":       "Number",
+	"wheelchair_boarding": "Number",
+	"onestop_id":          "String",
+}
+
+var tileRouteFields = map[string]string{
+	"route_id":          "String",
+	"route_short_name":  "String",
+	"route_long_name":   "String",
+	"route_type":        "Number",
+	"route_color":       "String",
+	"route_text_color":  "String",
+	"onestop_id":        "String",
+	"agency_id":         "String",
+	"agency_name":       "String",
+	"agency_onestop_id": "String",
+}
+
+// tileCollector is a copier extension that keeps the entities needed to render tiles.
+type tileCollector struct {
+	stops           []*gtfs.Stop
+	routes          []*gtfs.Route
+	agencies        map[string]*gtfs.Agency
+	routeGeoms      map[string]geom.T
+	stopOnestopIDs  map[string]string
+	routeOnestopIDs map[string]string
+	agencyOnestops  map[string]string
+}
+
+func newTileCollector() *tileCollector {
+	return &tileCollector{
+		agencies:        map[string]*gtfs.Agency{},
+		routeGeoms:      map[string]geom.T{},
+		stopOnestopIDs:  map[string]string{},
+		routeOnestopIDs: map[string]string{},
+		agencyOnestops:  map[string]string{},
+	}
+}
+
+func (c *tileCollector) AfterWrite(eid string, ent tt.Entity, emap *tt.EntityMap) error {
+	switch v := ent.(type) {
+	case *gtfs.Stop:
+		if v.Geometry.Valid {
+			c.stops = append(c.stops, v)
+		}
+	case *gtfs.Route:
+		c.routes = append(c.routes, v)
+	case *gtfs.Agency:
+		c.agencies[eid] = v
+	case *builders.RouteGeometry:
+		if v.CombinedGeometry.Valid {
+			c.routeGeoms[v.RouteID] = v.CombinedGeometry.Val
+		} else if v.Geometry.Valid {
+			c.routeGeoms[v.RouteID] = v.Geometry.Val
+		}
+	case *builders.StopOnestopID:
+		c.stopOnestopIDs[v.StopID] = v.OnestopID
+	case *builders.RouteOnestopID:
+		c.routeOnestopIDs[v.RouteID] = v.OnestopID
+	case *builders.AgencyOnestopID:
+		c.agencyOnestops[v.AgencyID] = v.OnestopID
+	}
+	return nil
+}
+
+// Layers returns the stops and routes tile layers.
+func (c *tileCollector) Layers() []mvt.Layer {
+	stops := mvt.Layer{Name: "stops"}
+	for i, stop := range c.stops {
+		props := map[string]any{}
+		setTileString(props, "stop_id", stop.StopID.Val)
+		setTileString(props, "stop_name", stop.StopName.Val)
+		setTileString(props, "stop_code", stop.StopCode.Val)
+		setTileString(props, "onestop_id", c.stopOnestopIDs[stop.StopID.Val])
+		props["location_type"] = stop.LocationType.Val
+		props["wheelchair_boarding"] = stop.WheelchairBoarding.Val
+		stops.Features = append(stops.Features, &mvt.Feature{
+			ID:         uint64(i + 1),
+			Geometry:   stop.Geometry.Val,
+			Properties: props,
+		})
+	}
+	routes := mvt.Layer{Name: "routes"}
+	for i, route := range c.routes {
+		g, ok := c.routeGeoms[route.RouteID.Val]
+		if !ok {
+			continue
+		}
+		props := map[string]any{}
+		setTileString(props, "route_id", route.RouteID.Val)
+		setTileString(props, "route_short_name", route.RouteShortName.Val)
+		setTileString(props, "route_long_name", route.RouteLongName.Val)
+		setTileString(props, "route_color", route.RouteColor.Val)
+		setTileString(props, "route_text_color", route.RouteTextColor.Val)
+		setTileString(props, "onestop_id", c.routeOnestopIDs[route.RouteID.Val])
+		props["route_type"] = route.RouteType.Val
+		if agency, ok := c.agencies[route.AgencyID.Val]; ok {
+			setTileString(props, "agency_id", agency.AgencyID.Val)
+			setTileString(props, "agency_name", agency.AgencyName.Val)
+			setTileString(props, "agency_onestop_id", c.agencyOnestops[agency.AgencyID.Val])
+		}
+		routes.Features = append(routes.Features, &mvt.Feature{
+			ID:         uint64(i + 1),
+			Geometry:   g,
+			Properties: props,
+		})
+	}
+	return []mvt.Layer{stops, routes}
+}
+
+func setTileString(props map[string]any, key string, value string) {
+	if value != "" {
+		props[key] = value
+	}
+}
+
+func extendBbox(bbox *tlxy.BoundingBox, g geom.T) *tlxy.BoundingBox {
+	if g == nil || g.Empty() {
+		return bbox
+	}
+	b := g.Bounds()
+	gb := tlxy.BoundingBox{MinLon: b.Min(0), MinLat: b.Min(1), MaxLon: b.Max(0), MaxLat: b.Max(1)}
+	if bbox == nil {
+		return &gb
+	}
+	bbox.MinLon = min(bbox.MinLon, gb.MinLon)
+	bbox.MinLat = min(bbox.MinLat, gb.MinLat)
+	bbox.MaxLon = max(bbox.MaxLon, gb.MaxLon)
+	bbox.MaxLat = max(bbox.MaxLat, gb.MaxLat)
+	return bbox
+}
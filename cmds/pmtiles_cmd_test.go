@@ -0,0 +1,80 @@
+package cmds
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/interline-io/transitland-lib/internal/testutil"
+	"github.com/interline-io/transitland-lib/tlxy"
+	"github.com/interline-io/transitland-lib/tlxy/mvt"
+	"github.com/interline-io/transitland-lib/tlxy/pmtiles"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPMTilesCommand(t *testing.T) {
+	ctx := context.TODO()
+	outfn := filepath.Join(t.TempDir(), "bart.pmtiles")
+	cmd := PMTilesCommand{MinZoom: 8, MaxZoom: 14}
+	if err := cmd.Parse([]string{testutil.ExampleFeedBART.URL, outfn}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(outfn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := pmtiles.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint8(8), r.Header.MinZoom)
+	assert.Equal(t, uint8(14), r.Header.MaxZoom)
+	assert.Equal(t, uint8(pmtiles.TileTypeMVT), r.Header.TileType)
+	md, err := r.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "bart", md["name"])
+
+	// Embarcadero
+	tile := mvt.TileForPoint(14, tlxy.Point{Lon: -122.396742, Lat: 37.792976})
+	data, err := r.Tile(tile.Z, tile.X, tile.Y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers, err := mvt.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	features := map[string][]*mvt.Feature{}
+	for _, layer := range layers {
+		features[layer.Name] = layer.Features
+	}
+	var found *mvt.Feature
+	for _, f := range features["stops"] {
+		if f.Properties["stop_id"] == "EMBR" {
+			found = f
+		}
+	}
+	if assert.NotNil(t, found, "expected stop EMBR") {
+		assert.Equal(t, "Embarcadero", found.Properties["stop_name"])
+		assert.NotEmpty(t, found.Properties["onestop_id"])
+	}
+	agencies := map[any]bool{}
+	for _, f := range features["routes"] {
+		agencies[f.Properties["agency_onestop_id"]] = true
+		assert.Equal(t, int64(1), f.Properties["route_type"])
+		assert.NotEmpty(t, f.Properties["route_color"])
+	}
+	assert.True(t, agencies["o-9q9-bayarearapidtransit"], "expected bart routes")
+
+	t.Run("invalid zoom", func(t *testing.T) {
+		cmd := PMTilesCommand{MinZoom: 10, MaxZoom: 4}
+		assert.Error(t, cmd.Parse([]string{testutil.ExampleFeedBART.URL, outfn}))
+	})
+}
@@ -58,7 +58,7 @@ func (cmd *RebuildStatsCommand) AddFlags(fl *pflag.FlagSet) {
 	fl.StringSliceVar(&cmd.FVSHA1, "fv-sha1", nil, "Feed version SHA1")
 	fl.IntVar(&cmd.Workers, "workers", 1, "Worker threads")
 	fl.StringVar(&cmd.DBURL, "dburl", "", "Database URL (default: $TL_DATABASE_URL)")
-	fl.StringVar(&cmd.Options.Storage, "storage", "", "Storage destination; can be s3://... az://... or path to a directory")
+	fl.StringVar(&cmd.Options.Storage, "storage", "", "Storage destination; can be s3://... az://... gs://... davs://... or path to a directory; prefix with cas+ to store zip entries by content hash")
 	fl.BoolVar(&cmd.Options.SaveValidationReport, "validation-report", false, "Save validation report")
 	fl.StringVar(&cmd.Options.ValidationReportStorage, "validation-report-storage", "", "Storage path for saving validation report JSON")
 }
@@ -0,0 +1,499 @@
+package cmds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/dmfr"
+	"github.com/interline-io/transitland-lib/fetch"
+	"github.com/interline-io/transitland-lib/importer"
+	"github.com/interline-io/transitland-lib/request"
+	"github.com/interline-io/transitland-lib/server/jobs"
+	localjobs "github.com/interline-io/transitland-lib/server/jobs/local"
+	"github.com/interline-io/transitland-lib/server/jobs/pgjobs"
+	"github.com/interline-io/transitland-lib/stats"
+	"github.com/interline-io/transitland-lib/tlcli"
+	"github.com/interline-io/transitland-lib/tldb"
+	sq "github.com/irees/squirrel"
+	"github.com/spf13/pflag"
+)
+
+// Import policies
+const (
+	ImportPolicyNone         = "none"
+	ImportPolicyLatest       = "latest"
+	ImportPolicyLatestStrict = "latest-strict"
+)
+
+const (
+	schedulerFetchJobType  = "scheduler-fetch"
+	schedulerImportJobType = "scheduler-import"
+	schedulerHistoryLimit  = 16
+)
+
+// SchedulerCommand fetches feeds defined in a DMFR database on a per-feed interval,
+// and imports and activates new feed versions according to an import policy.
+type SchedulerCommand struct {
+	FetchOptions  fetch.StaticFetchOptions
+	ImportOptions importer.Options
+	ImportPolicy  string
+	Interval      time.Duration
+	MaxBackoff    time.Duration
+	Poll          time.Duration
+	JobQueue      string
+	Workers       int
+	SecretsFile   string
+	HostLimit     request.HostLimit
+	DBURL         string
+	FeedIDs       []string
+	Adapter       tldb.Adapter  // allow for mocks
+	Queue         jobs.JobQueue // allow for mocks
+	now           func() time.Time
+	pending       map[int]time.Time
+	pendingLock   sync.Mutex
+}
+
+func (cmd *SchedulerCommand) HelpDesc() (string, string) {
+	return "Continuously fetch, import and activate feeds", "The scheduler reads feeds from the database and fetches each feed when its fetch interval has elapsed. The interval for a feed is the feed state fetch_wait value (in seconds) if set, otherwise --interval. Each consecutive failed fetch doubles the wait, up to --max-backoff. New feed versions are imported according to --import-policy: `none` never imports, `latest` imports a new feed version if it is the most recent version of its feed, and `latest-strict` additionally requires that the feed version passed validation without errors. Imported feed versions are activated when --activate is set. All work is performed through a job queue; use `--job-queue postgres` to share work between multiple scheduler processes."
+}
+
+func (cmd *SchedulerCommand) HelpArgs() string {
+	return "[flags] [feeds...]"
+}
+
+func (cmd *SchedulerCommand) AddFlags(fl *pflag.FlagSet) {
+	fl.StringVar(&cmd.DBURL, "dburl", "", "Database URL (default: $TL_DATABASE_URL)")
+	fl.StringVar(&cmd.SecretsFile, "secrets", "", "Path to DMFR Secrets file")
+	fl.StringVar(&cmd.JobQueue, "job-queue", "local", "Job queue backend: local or postgres")
+	fl.IntVar(&cmd.Workers, "workers", 1, "Worker threads")
+	fl.DurationVar(&cmd.Interval, "interval", 24*time.Hour, "Default time between fetches of each feed")
+	fl.DurationVar(&cmd.MaxBackoff, "max-backoff", 7*24*time.Hour, "Maximum time between fetches of a feed with failed fetches")
+	fl.DurationVar(&cmd.Poll, "poll", 1*time.Minute, "Time between checks for feeds to fetch")
+	fl.StringVar(&cmd.ImportPolicy, "import-policy", ImportPolicyLatest, "Import policy for new feed versions: none, latest, or latest-strict")
+	fl.BoolVar(&cmd.ImportOptions.Activate, "activate", false, "Set as active feed version after import")
+	fl.BoolVar(&cmd.ImportOptions.RecordChanges, "record-changes", false, "Compare with the previous feed version of the same feed and save the changes")
+	fl.BoolVar(&cmd.FetchOptions.StrictValidation, "strict", false, "Reject feeds with validation errors")
+	fl.BoolVar(&cmd.FetchOptions.AllowFTPFetch, "allow-ftp-fetch", false, "Allow fetching from FTP urls")
+	fl.BoolVar(&cmd.FetchOptions.AllowS3Fetch, "allow-s3-fetch", false, "Allow fetching from S3 urls")
+	fl.BoolVar(&cmd.FetchOptions.AllowLocalFetch, "allow-local-fetch", false, "Allow fetching from filesystem directories/zip files")
+	fl.IntVar(&cmd.HostLimit.MaxConcurrency, "host-max-concurrency", 0, "Maximum concurrent requests to each host; override per feed with the fetch_max_concurrency tag (default: unlimited)")
+	fl.IntVar(&cmd.HostLimit.RequestsPerMinute, "host-requests-per-minute", 0, "Maximum requests per minute to each host; override per feed with the fetch_requests_per_minute tag (default: unlimited)")
+	fl.BoolVar(&cmd.FetchOptions.SaveValidationReport, "validation-report", false, "Save validation report")
+	fl.StringVar(&cmd.FetchOptions.ValidationReportStorage, "validation-report-storage", "", "Storage path for saving validation report JSON")
+	fl.StringVar(&cmd.FetchOptions.Storage, "storage", ".", "Storage destination; can be s3://... az://... gs://... davs://... or path to a directory; prefix with cas+ to store zip entries by content hash")
+	// Copy options
+	fl.StringSliceVar(&cmd.ImportOptions.ExtensionDefs, "ext", nil, "Include GTFS Extension")
+	fl.Float64Var(&cmd.ImportOptions.SimplifyShapes, "simplify-shapes", 0.0, "Simplify shapes with this tolerance (ex. 0.000005)")
+	fl.BoolVar(&cmd.ImportOptions.InterpolateStopTimes, "interpolate-stop-times", false, "Interpolate missing StopTime arrival/departure values")
+	fl.BoolVar(&cmd.ImportOptions.DeduplicateJourneyPatterns, "deduplicate-stop-times", false, "Deduplicate StopTimes using Journey Patterns")
+	fl.BoolVar(&cmd.ImportOptions.CreateMissingShapes, "create-missing-shapes", false, "Create missing Shapes from Trip stop-to-stop geometries")
+	fl.BoolVar(&cmd.ImportOptions.SimplifyCalendars, "simplify-calendars", false, "Attempt to simplify CalendarDates into regular Calendars")
+	fl.BoolVar(&cmd.ImportOptions.NormalizeTimezones, "normalize-timezones", false, "Normalize timezones and apply default stop timezones based on agency and parent stops")
+}
+
+// Parse command line flags
+func (cmd *SchedulerCommand) Parse(args []string) error {
+	fl := tlcli.NewNArgs(args)
+	cmd.FeedIDs = fl.Args()
+	if cmd.DBURL == "" {
+		cmd.DBURL = os.Getenv("TL_DATABASE_URL")
+	}
+	switch cmd.ImportPolicy {
+	case ImportPolicyNone, ImportPolicyLatest, ImportPolicyLatestStrict:
+	default:
+		return fmt.Errorf("unknown import policy: %s", cmd.ImportPolicy)
+	}
+	if cmd.Interval <= 0 {
+		return errors.New("--interval must be positive")
+	}
+	if cmd.Poll <= 0 {
+		return errors.New("--poll must be positive")
+	}
+	return nil
+}
+
+// Run this command
+func (cmd *SchedulerCommand) Run(ctx context.Context) error {
+	if cmd.Workers < 1 {
+		cmd.Workers = 1
+	}
+	if cmd.SecretsFile != "" {
+		r, err := dmfr.LoadAndParseRegistry(cmd.SecretsFile)
+		if err != nil {
+			return err
+		}
+		cmd.FetchOptions.Secrets = r.Secrets
+	}
+	if cmd.FetchOptions.HostLimiter == nil {
+		cmd.FetchOptions.HostLimiter = request.NewHostLimiter(cmd.HostLimit)
+	}
+	if cmd.Adapter == nil {
+		writer, err := tldb.OpenWriter(cmd.DBURL, true)
+		if err != nil {
+			return err
+		}
+		cmd.Adapter = writer.Adapter
+		defer writer.Close()
+	}
+	if err := cmd.initQueue(); err != nil {
+		return err
+	}
+
+	// Start workers
+	queueDone := make(chan error, 1)
+	go func() {
+		queueDone <- cmd.Queue.Run(ctx)
+	}()
+
+	// Check feeds until stopped
+	log.For(ctx).Info().Str("import_policy", cmd.ImportPolicy).Bool("activate", cmd.ImportOptions.Activate).Dur("interval", cmd.Interval).Dur("poll", cmd.Poll).Msg("scheduler: started")
+	ticker := time.NewTicker(cmd.Poll)
+	defer ticker.Stop()
+	for {
+		if err := cmd.Schedule(ctx); err != nil {
+			log.For(ctx).Error().Err(err).Msg("scheduler: failed to check feeds")
+		}
+		select {
+		case <-ctx.Done():
+			log.For(ctx).Info().Msg("scheduler: stopping")
+			stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 1*time.Minute)
+			defer cancel()
+			return cmd.Queue.Stop(stopCtx)
+		case err := <-queueDone:
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cmd *SchedulerCommand) initQueue() error {
+	if cmd.Queue == nil {
+		switch cmd.JobQueue {
+		case "", "local":
+			cmd.Queue = jobs.NewJobLogger(localjobs.NewLocalJobs())
+		case "postgres":
+			cmd.Queue = jobs.NewJobLogger(pgjobs.NewPostgresJobs(cmd.Adapter.DBX(), "scheduler"))
+		default:
+			return fmt.Errorf("unknown job queue: %s", cmd.JobQueue)
+		}
+		if err := cmd.Queue.AddQueue("default", cmd.Workers); err != nil {
+			return err
+		}
+	}
+	if err := cmd.Queue.AddJobType(func() jobs.JobWorker { return &schedulerFetchJob{cmd: cmd} }); err != nil {
+		return err
+	}
+	return cmd.Queue.AddJobType(func() jobs.JobWorker { return &schedulerImportJob{cmd: cmd} })
+}
+
+// Schedule checks each feed and enqueues fetch jobs for feeds that are due.
+func (cmd *SchedulerCommand) Schedule(ctx context.Context) error {
+	now := cmd.getNow()
+	feeds, err := cmd.getFeedSchedules(ctx)
+	if err != nil {
+		return err
+	}
+	enqueued := 0
+	for _, fs := range feeds {
+		d := scheduleFeed(now, fs, cmd.Interval, cmd.MaxBackoff)
+		if d.Fetch && cmd.isPending(fs.FeedID, fs.LastFetchedAt, now) {
+			d.Fetch = false
+			d.Reason = "fetch already queued"
+		}
+		logEvent := log.For(ctx).Debug()
+		if d.Fetch {
+			logEvent = log.For(ctx).Info()
+		}
+		logEvent = logEvent.
+			Str("feed_id", fs.OnestopID).
+			Int("feed_db_id", fs.FeedID).
+			Int("failures", fs.Failures).
+			Str("reason", d.Reason)
+		if !d.NextFetch.IsZero() {
+			logEvent = logEvent.Time("next_fetch", d.NextFetch)
+		}
+		if !d.Fetch {
+			logEvent.Msg("scheduler: skip fetch")
+			continue
+		}
+		logEvent.Msg("scheduler: enqueue fetch")
+		job := jobs.Job{
+			JobType: schedulerFetchJobType,
+			JobArgs: jobs.JobArgs{"feed_id": fs.FeedID},
+			Unique:  true,
+		}
+		if err := cmd.Queue.AddJob(ctx, job); err != nil {
+			return err
+		}
+		cmd.setPending(fs.FeedID, now)
+		enqueued++
+	}
+	log.For(ctx).Info().Int("feeds", len(feeds)).Int("enqueued", enqueued).Msg("scheduler: checked feeds")
+	return nil
+}
+
+// feedSchedule contains the state used to decide when to fetch a feed.
+type feedSchedule struct {
+	FeedID        int
+	OnestopID     string
+	URL           string
+	FetchWait     time.Duration
+	LastFetchedAt time.Time
+	Failures      int
+}
+
+type scheduleDecision struct {
+	Fetch     bool
+	Reason    string
+	NextFetch time.Time
+}
+
+// scheduleFeed decides if a feed should be fetched at the specified time.
+// The wait after the last fetch is the feed's fetch wait, or the default interval,
+// doubled for each consecutive failed fetch and limited to maxBackoff.
+func scheduleFeed(now time.Time, fs feedSchedule, interval time.Duration, maxBackoff time.Duration) scheduleDecision {
+	if fs.URL == "" {
+		return scheduleDecision{Reason: "no static_current url"}
+	}
+	if fs.LastFetchedAt.IsZero() {
+		return scheduleDecision{Fetch: true, Reason: "never fetched"}
+	}
+	wait := interval
+	if fs.FetchWait > 0 {
+		wait = fs.FetchWait
+	}
+	reason := "interval elapsed"
+	if fs.Failures > 0 {
+		reason = fmt.Sprintf("retry after %d failed fetches", fs.Failures)
+		for i := 0; i < fs.Failures && (maxBackoff <= 0 || wait < maxBackoff); i++ {
+			wait = wait * 2
+		}
+		if maxBackoff > 0 && wait > maxBackoff {
+			wait = maxBackoff
+		}
+	}
+	next := fs.LastFetchedAt.Add(wait)
+	if now.Before(next) {
+		if fs.Failures > 0 {
+			reason = fmt.Sprintf("backoff after %d failed fetches", fs.Failures)
+		} else {
+			reason = "fetched recently"
+		}
+		return scheduleDecision{Reason: reason, NextFetch: next}
+	}
+	return scheduleDecision{Fetch: true, Reason: reason, NextFetch: next}
+}
+
+func (cmd *SchedulerCommand) getFeedSchedules(ctx context.Context) ([]feedSchedule, error) {
+	q := cmd.Adapter.Sqrl().
+		Select("*").
+		From("current_feeds").
+		Where("deleted_at IS NULL").
+		Where("spec = ?", "gtfs").
+		OrderBy("id")
+	if len(cmd.FeedIDs) > 0 {
+		q = q.Where(sq.Eq{"onestop_id": cmd.FeedIDs})
+	}
+	qstr, qargs, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var feeds []dmfr.Feed
+	if err := cmd.Adapter.Select(ctx, &feeds, qstr, qargs...); err != nil {
+		return nil, err
+	}
+	var ret []feedSchedule
+	for _, feed := range feeds {
+		fs := feedSchedule{
+			FeedID:    feed.ID,
+			OnestopID: feed.FeedID,
+			URL:       feed.URLs.StaticCurrent,
+		}
+		// Create feed state if not exists
+		feedState, err := stats.GetFeedState(ctx, cmd.Adapter, feed.ID)
+		if err != nil {
+			return nil, err
+		}
+		if feedState.FetchWait.Valid {
+			fs.FetchWait = time.Duration(feedState.FetchWait.Val) * time.Second
+		}
+		// Count consecutive failed fetches
+		var fetches []dmfr.FeedFetch
+		if err := cmd.Adapter.Select(
+			ctx,
+			&fetches,
+			"SELECT * FROM feed_fetches WHERE feed_id = ? AND url_type = ? ORDER BY fetched_at DESC, id DESC LIMIT ?",
+			feed.ID,
+			"static_current",
+			schedulerHistoryLimit,
+		); err != nil {
+			return nil, err
+		}
+		if len(fetches) > 0 {
+			fs.LastFetchedAt = fetches[0].FetchedAt.Val
+		}
+		for _, ff := range fetches {
+			if ff.Success {
+				break
+			}
+			fs.Failures++
+		}
+		ret = append(ret, fs)
+	}
+	return ret, nil
+}
+
+func (cmd *SchedulerCommand) getNow() time.Time {
+	if cmd.now != nil {
+		return cmd.now()
+	}
+	return time.Now().UTC()
+}
+
+// isPending checks if this process enqueued a fetch that has not yet been recorded.
+// Stale entries are ignored after one interval in case the job was lost.
+func (cmd *SchedulerCommand) isPending(feedId int, lastFetchedAt time.Time, now time.Time) bool {
+	cmd.pendingLock.Lock()
+	defer cmd.pendingLock.Unlock()
+	t, ok := cmd.pending[feedId]
+	if !ok {
+		return false
+	}
+	if lastFetchedAt.After(t) || now.Sub(t) > cmd.Interval {
+		delete(cmd.pending, feedId)
+		return false
+	}
+	return true
+}
+
+func (cmd *SchedulerCommand) setPending(feedId int, t time.Time) {
+	cmd.pendingLock.Lock()
+	defer cmd.pendingLock.Unlock()
+	if cmd.pending == nil {
+		cmd.pending = map[int]time.Time{}
+	}
+	cmd.pending[feedId] = t
+}
+
+func (cmd *SchedulerCommand) clearPending(feedId int) {
+	cmd.pendingLock.Lock()
+	defer cmd.pendingLock.Unlock()
+	delete(cmd.pending, feedId)
+}
+
+//////////
+
+// schedulerFetchJob fetches a single feed and enqueues an import job for new feed versions.
+type schedulerFetchJob struct {
+	FeedID int `json:"feed_id"`
+	cmd    *SchedulerCommand
+}
+
+func (job *schedulerFetchJob) Kind() string {
+	return schedulerFetchJobType
+}
+
+func (job *schedulerFetchJob) Run(ctx context.Context) error {
+	cmd := job.cmd
+	defer cmd.clearPending(job.FeedID)
+	feed := dmfr.Feed{}
+	if err := cmd.Adapter.Get(ctx, &feed, "SELECT * FROM current_feeds WHERE id = ?", job.FeedID); err != nil {
+		return err
+	}
+	opts := cmd.FetchOptions // copy
+	opts.FeedID = feed.ID
+	opts.URLType = "static_current"
+	opts.FeedURL = feed.URLs.StaticCurrent
+	opts.FetchedAt = cmd.getNow()
+	var result fetch.StaticFetchResult
+	if err := cmd.Adapter.Tx(func(atx tldb.Adapter) error {
+		var fatalError error
+		result, fatalError = fetch.StaticFetch(ctx, atx, opts)
+		return fatalError
+	}); err != nil {
+		return err
+	}
+
+	// Check result
+	fv := result.FeedVersion
+	if result.FetchError != nil {
+		log.For(ctx).Info().Str("feed_id", feed.FeedID).Str("url", result.URL).Str("fetch_error", result.FetchError.Error()).Msg("scheduler: fetch failed")
+		return nil
+	} else if fv == nil {
+		log.For(ctx).Info().Str("feed_id", feed.FeedID).Str("url", result.URL).Msg("scheduler: fetch returned no feed version")
+		return nil
+	} else if result.Found {
+		log.For(ctx).Info().Str("feed_id", feed.FeedID).Str("sha1", fv.SHA1).Int("feed_version_id", fv.ID).Msg("scheduler: fetched existing feed version, skip import")
+		return nil
+	}
+	log.For(ctx).Info().Str("feed_id", feed.FeedID).Str("sha1", fv.SHA1).Int("feed_version_id", fv.ID).Msg("scheduler: fetched new feed version")
+
+	// Apply import policy
+	switch cmd.ImportPolicy {
+	case ImportPolicyNone, "":
+		log.For(ctx).Info().Str("feed_id", feed.FeedID).Int("feed_version_id", fv.ID).Msg("scheduler: import policy is none, skip import")
+		return nil
+	case ImportPolicyLatestStrict:
+		if vr := result.FeedVersionValidatorResult; vr == nil || len(vr.Errors) > 0 {
+			log.For(ctx).Info().Str("feed_id", feed.FeedID).Int("feed_version_id", fv.ID).Msg("scheduler: feed version did not pass strict validation, skip import")
+			return nil
+		}
+	}
+	log.For(ctx).Info().Str("feed_id", feed.FeedID).Int("feed_version_id", fv.ID).Msg("scheduler: enqueue import")
+	return cmd.Queue.AddJob(ctx, jobs.Job{
+		JobType: schedulerImportJobType,
+		JobArgs: jobs.JobArgs{"feed_version_id": fv.ID},
+		Unique:  true,
+	})
+}
+
+// schedulerImportJob imports, and optionally activates, a feed version if it is the latest version of its feed.
+type schedulerImportJob struct {
+	FeedVersionID int `json:"feed_version_id"`
+	cmd           *SchedulerCommand
+}
+
+func (job *schedulerImportJob) Kind() string {
+	return schedulerImportJobType
+}
+
+func (job *schedulerImportJob) Run(ctx context.Context) error {
+	cmd := job.cmd
+	fv := dmfr.FeedVersion{}
+	fv.ID = job.FeedVersionID
+	if err := cmd.Adapter.Find(ctx, &fv); err != nil {
+		return err
+	}
+	// A newer feed version may have been fetched since this job was created
+	latestId := 0
+	if err := cmd.Adapter.Get(ctx, &latestId, "SELECT id FROM feed_versions WHERE feed_id = ? ORDER BY fetched_at DESC, id DESC LIMIT 1", fv.FeedID); err != nil {
+		return err
+	}
+	if latestId != fv.ID {
+		log.For(ctx).Info().Int("feed_version_id", fv.ID).Int("latest_feed_version_id", latestId).Msg("scheduler: feed version is not the latest, skip import")
+		return nil
+	}
+	opts := cmd.ImportOptions // copy
+	opts.FeedVersionID = fv.ID
+	opts.Storage = cmd.FetchOptions.Storage
+	result, err := importer.ImportFeedVersion(ctx, cmd.Adapter, opts)
+	fvi := result.FeedVersionImport
+	if err != nil && fvi.InProgress {
+		// Import record could not be saved
+		return err
+	} else if !fvi.Success {
+		log.For(ctx).Info().Int("feed_version_id", fv.ID).Str("exception", fvi.ExceptionLog).Msg("scheduler: import failed")
+		return nil
+	}
+	if opts.Activate {
+		log.For(ctx).Info().Int("feed_version_id", fv.ID).Msg("scheduler: imported and activated feed version")
+	} else {
+		log.For(ctx).Info().Int("feed_version_id", fv.ID).Msg("scheduler: imported feed version")
+	}
+	return nil
+}
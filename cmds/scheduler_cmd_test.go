@@ -0,0 +1,185 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/interline-io/transitland-lib/dmfr"
+	"github.com/interline-io/transitland-lib/internal/testdb"
+	"github.com/interline-io/transitland-lib/internal/testpath"
+	"github.com/interline-io/transitland-lib/server/jobs"
+	localjobs "github.com/interline-io/transitland-lib/server/jobs/local"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleFeed(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	interval := 24 * time.Hour
+	maxBackoff := 72 * time.Hour
+	tcs := []struct {
+		name      string
+		fs        feedSchedule
+		fetch     bool
+		nextFetch time.Time
+	}{
+		{"no url", feedSchedule{}, false, time.Time{}},
+		{"never fetched", feedSchedule{URL: "http://example.com"}, true, time.Time{}},
+		{"fetched recently", feedSchedule{URL: "http://example.com", LastFetchedAt: now.Add(-time.Hour)}, false, now.Add(23 * time.Hour)},
+		{"interval elapsed", feedSchedule{URL: "http://example.com", LastFetchedAt: now.Add(-25 * time.Hour)}, true, now.Add(-time.Hour)},
+		{"fetch wait", feedSchedule{URL: "http://example.com", LastFetchedAt: now.Add(-2 * time.Hour), FetchWait: time.Hour}, true, now.Add(-time.Hour)},
+		{"backoff", feedSchedule{URL: "http://example.com", LastFetchedAt: now.Add(-25 * time.Hour), Failures: 1}, false, now.Add(23 * time.Hour)},
+		{"backoff elapsed", feedSchedule{URL: "http://example.com", LastFetchedAt: now.Add(-49 * time.Hour), Failures: 1}, true, now.Add(-time.Hour)},
+		{"max backoff", feedSchedule{URL: "http://example.com", LastFetchedAt: now.Add(-73 * time.Hour), Failures: 10}, true, now.Add(-time.Hour)},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			d := scheduleFeed(now, tc.fs, interval, maxBackoff)
+			assert.Equal(t, tc.fetch, d.Fetch, d.Reason)
+			assert.Equal(t, tc.nextFetch, d.NextFetch)
+			assert.NotEmpty(t, d.Reason)
+		})
+	}
+}
+
+// testSchedulerQueue records jobs and runs them on request.
+type testSchedulerQueue struct {
+	queued []jobs.Job
+	*localjobs.LocalJobs
+}
+
+func (q *testSchedulerQueue) AddJob(ctx context.Context, job jobs.Job) error {
+	q.queued = append(q.queued, job)
+	return nil
+}
+
+func (q *testSchedulerQueue) runAll(t *testing.T, ctx context.Context) []string {
+	var ran []string
+	for len(q.queued) > 0 {
+		job := q.queued[0]
+		q.queued = q.queued[1:]
+		if err := q.RunJob(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+		ran = append(ran, fmt.Sprintf("%s:%v", job.JobType, job.JobArgs))
+	}
+	return ran
+}
+
+func TestSchedulerCommand(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, err := os.ReadFile(testpath.RelPath(filepath.Join("testdata", r.URL.Path)))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Write(buf)
+	}))
+	defer ts.Close()
+	ctx := context.Background()
+	newScheduler := func(t *testing.T, policy string, feeds ...dmfr.Feed) (*SchedulerCommand, *testSchedulerQueue) {
+		adapter := testdb.TempSqliteAdapter()
+		for _, feed := range feeds {
+			testdb.ShouldInsert(t, adapter, &feed)
+		}
+		q := &testSchedulerQueue{LocalJobs: localjobs.NewLocalJobs()}
+		cmd := &SchedulerCommand{
+			Adapter:      adapter,
+			Queue:        q,
+			ImportPolicy: policy,
+			Interval:     24 * time.Hour,
+			MaxBackoff:   7 * 24 * time.Hour,
+			Poll:         time.Minute,
+		}
+		cmd.FetchOptions.Storage = t.TempDir()
+		cmd.ImportOptions.Activate = true
+		if err := cmd.initQueue(); err != nil {
+			t.Fatal(err)
+		}
+		return cmd, q
+	}
+	f200 := dmfr.Feed{FeedID: "f-200", Spec: "gtfs", URLs: dmfr.FeedUrls{StaticCurrent: fmt.Sprintf("%s/gtfs-examples/example.zip", ts.URL)}}
+	f404 := dmfr.Feed{FeedID: "f-404", Spec: "gtfs", URLs: dmfr.FeedUrls{StaticCurrent: fmt.Sprintf("%s/404", ts.URL)}}
+	fErrors := dmfr.Feed{FeedID: "f-error", Spec: "gtfs", URLs: dmfr.FeedUrls{StaticCurrent: fmt.Sprintf("%s/gtfs-examples/example-errors.zip", ts.URL)}}
+	fNoURL := dmfr.Feed{FeedID: "f-no-url", Spec: "gtfs"}
+
+	t.Run("fetch, import and activate", func(t *testing.T) {
+		now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		cmd, q := newScheduler(t, ImportPolicyLatest, f200, f404, fNoURL)
+		cmd.now = func() time.Time { return now }
+		if err := cmd.Schedule(ctx); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 2, len(q.queued))
+		// Fetch jobs are not enqueued twice
+		if err := cmd.Schedule(ctx); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 2, len(q.queued))
+		// Fetch jobs, then import job for new feed version
+		ran := q.runAll(t, ctx)
+		assert.Equal(t, 3, len(ran))
+		fvs := []dmfr.FeedVersion{}
+		testdb.ShouldSelect(t, cmd.Adapter, &fvs, "SELECT * FROM feed_versions")
+		if assert.Equal(t, 1, len(fvs)) {
+			fvis := []dmfr.FeedVersionImport{}
+			testdb.ShouldSelect(t, cmd.Adapter, &fvis, "SELECT * FROM feed_version_gtfs_imports WHERE feed_version_id = ?", fvs[0].ID)
+			if assert.Equal(t, 1, len(fvis)) {
+				assert.True(t, fvis[0].Success)
+			}
+			fs := dmfr.FeedState{}
+			testdb.ShouldGet(t, cmd.Adapter, &fs, "SELECT * FROM feed_states WHERE feed_id = ?", fvs[0].FeedID)
+			assert.Equal(t, fvs[0].ID, fs.FeedVersionID.Int())
+		}
+		// Nothing to do until the interval elapses
+		if err := cmd.Schedule(ctx); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 0, len(q.queued))
+		// Failed feed is in backoff
+		now = now.Add(25 * time.Hour)
+		if err := cmd.Schedule(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if assert.Equal(t, 1, len(q.queued)) {
+			assert.EqualValues(t, fvs[0].FeedID, q.queued[0].JobArgs["feed_id"])
+		}
+		// Existing feed version is not imported again
+		ran = q.runAll(t, ctx)
+		assert.Equal(t, 1, len(ran))
+		// Backoff has elapsed
+		now = now.Add(24 * time.Hour)
+		if err := cmd.Schedule(ctx); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 2, len(q.queued))
+	})
+
+	t.Run("import policy", func(t *testing.T) {
+		tcs := []struct {
+			policy     string
+			importJobs int
+		}{
+			{ImportPolicyNone, 0},
+			{ImportPolicyLatest, 2},
+			{ImportPolicyLatestStrict, 1},
+		}
+		for _, tc := range tcs {
+			t.Run(tc.policy, func(t *testing.T) {
+				cmd, q := newScheduler(t, tc.policy, f200, fErrors)
+				if err := cmd.Schedule(ctx); err != nil {
+					t.Fatal(err)
+				}
+				q.runAll(t, ctx)
+				count := 0
+				testdb.ShouldGet(t, cmd.Adapter, &count, "SELECT count(*) FROM feed_version_gtfs_imports")
+				assert.Equal(t, tc.importJobs, count)
+			})
+		}
+	})
+}
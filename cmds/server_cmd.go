@@ -18,11 +18,17 @@ import (
 	"github.com/interline-io/log"
 	tl "github.com/interline-io/transitland-lib"
 	"github.com/interline-io/transitland-lib/dmfr"
+	"github.com/interline-io/transitland-lib/request"
 	"github.com/interline-io/transitland-lib/server/auth/authn"
 	"github.com/interline-io/transitland-lib/server/auth/mw/usercheck"
 	"github.com/interline-io/transitland-lib/server/dbutil"
+	"github.com/interline-io/transitland-lib/server/jobs"
+	localjobs "github.com/interline-io/transitland-lib/server/jobs/local"
+	"github.com/interline-io/transitland-lib/server/jobs/pgjobs"
+	"github.com/interline-io/transitland-lib/server/jobserver"
 	"github.com/interline-io/transitland-lib/server/meters"
 	localmeter "github.com/interline-io/transitland-lib/server/meters/local"
+	"github.com/interline-io/transitland-lib/server/metrics"
 	"github.com/interline-io/transitland-lib/tldb"
 	"github.com/interline-io/transitland-lib/tldb/querylogger"
 
@@ -39,9 +45,14 @@ import (
 	// Import drivers
 	_ "github.com/interline-io/transitland-lib/tldb/postgres"
 
+	// Import metric providers
+	_ "github.com/interline-io/transitland-lib/server/metrics/local"
+	_ "github.com/interline-io/transitland-lib/server/metrics/prometheus"
+
 	// Import routers
 	_ "github.com/interline-io/transitland-lib/server/directions/awsrouter"
 	_ "github.com/interline-io/transitland-lib/server/directions/linerouter"
+	_ "github.com/interline-io/transitland-lib/server/directions/raptor"
 	_ "github.com/interline-io/transitland-lib/server/directions/tlrouter"
 	_ "github.com/interline-io/transitland-lib/server/directions/valhalla"
 )
@@ -61,6 +72,11 @@ type ServerCommand struct {
 	DBURL                   string
 	RedisURL                string
 	MaxRadius               float64
+	Metrics                 metrics.Config
+	JobQueue                string
+	JobWorkers              int
+	HostLimit               request.HostLimit
+	AllowedOrigins          []string
 	secrets                 []dmfr.Secret
 }
 
@@ -87,6 +103,13 @@ func (cmd *ServerCommand) AddFlags(fl *pflag.FlagSet) {
 	fl.IntVar(&cmd.LoaderBatchSize, "loader-batch-size", 100, "GraphQL Loader batch size")
 	fl.IntVar(&cmd.LoaderStopTimeBatchSize, "loader-stop-time-batch-size", 1, "GraphQL Loader batch size for StopTimes")
 	fl.Float64Var(&cmd.MaxRadius, "max-radius", 100_000, "Maximum radius for nearby stops")
+	fl.StringVar(&cmd.JobQueue, "job-queue", "", "Job queue backend: local or postgres (default: no job queue)")
+	fl.IntVar(&cmd.JobWorkers, "job-workers", 0, "Number of job workers; with zero workers, jobs are queued but not run by this process")
+	fl.IntVar(&cmd.HostLimit.MaxConcurrency, "host-max-concurrency", 0, "Maximum concurrent requests to each host for fetches started by the server; override per feed with the fetch_max_concurrency tag (default: unlimited)")
+	fl.IntVar(&cmd.HostLimit.RequestsPerMinute, "host-requests-per-minute", 0, "Maximum requests per minute to each host for fetches started by the server; override per feed with the fetch_requests_per_minute tag (default: unlimited)")
+	fl.StringSliceVar(&cmd.AllowedOrigins, "allowed-origins", nil, "Origins allowed for CORS requests and WebSocket subscriptions, e.g. https://*.example.com (default: any origin for CORS requests, same origin for WebSocket subscriptions)")
+	fl.BoolVar(&cmd.Metrics.EnableMetrics, "enable-metrics", false, "Enable metrics endpoint at /metrics")
+	fl.StringVar(&cmd.Metrics.MetricsProvider, "metrics-provider", "local", "Metrics provider: local or prometheus")
 }
 
 func (cmd *ServerCommand) Parse(args []string) error {
@@ -139,7 +162,7 @@ func (cmd *ServerCommand) Run(ctx context.Context) error {
 	}
 
 	// Create RTFinder, GbfsFinder
-	var rtFinder model.RTFinder
+	var rtFinder *rtfinder.Finder
 	var gbfsFinder model.GbfsFinder
 	if redisClient != nil {
 		// Use redis backed finders
@@ -151,6 +174,32 @@ func (cmd *ServerCommand) Run(ctx context.Context) error {
 		gbfsFinder = gbfsfinder.NewFinder(nil)
 	}
 
+	// Job queue
+	var jobQueue jobs.JobQueue
+	switch cmd.JobQueue {
+	case "":
+	case "local":
+		jobQueue = jobs.NewJobLogger(localjobs.NewLocalJobs())
+	case "postgres":
+		jobQueue = jobs.NewJobLogger(pgjobs.NewPostgresJobs(db, ""))
+	default:
+		return fmt.Errorf("unknown job queue: %s", cmd.JobQueue)
+	}
+	if jobQueue != nil {
+		if err := jobQueue.AddQueue("default", cmd.JobWorkers); err != nil {
+			return err
+		}
+	}
+
+	// Metrics
+	metricProvider, err := metrics.NewProvider(cmd.Metrics)
+	if err != nil {
+		return err
+	}
+	if metricProvider != nil {
+		metricProvider.AddStatusSource(&serverStatus{rtFinder: rtFinder, db: db})
+	}
+
 	// Setup config
 	cfg := model.Config{
 		Finder:                  dbFinder,
@@ -164,12 +213,29 @@ func (cmd *ServerCommand) Run(ctx context.Context) error {
 		LoaderBatchSize:         cmd.LoaderBatchSize,
 		LoaderStopTimeBatchSize: cmd.LoaderStopTimeBatchSize,
 		MaxRadius:               cmd.MaxRadius,
+		Metrics:                 metricProvider,
+		JobQueue:                jobQueue,
+		HostLimiter:             request.NewHostLimiter(cmd.HostLimit), // shared by all fetches
+		AllowedOrigins:          cmd.AllowedOrigins,
+	}
+
+	// Start job workers
+	if jobQueue != nil && cmd.JobWorkers > 0 {
+		go func() {
+			if err := jobQueue.Run(model.WithConfig(ctx, cfg)); err != nil {
+				log.For(ctx).Error().Err(err).Msg("job queue stopped")
+			}
+		}()
 	}
 
 	// Setup router
 	root := chi.NewRouter()
+	corsOrigins := cmd.AllowedOrigins
+	if len(corsOrigins) == 0 {
+		corsOrigins = []string{"https://*", "http://*"}
+	}
 	root.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
+		AllowedOrigins:   corsOrigins,
 		AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"content-type", "apikey", "authorization"},
 		AllowCredentials: true,
@@ -202,6 +268,17 @@ func (cmd *ServerCommand) Run(ctx context.Context) error {
 
 	// Metering and metrics
 	meterProvider := localmeter.NewLocalMeterProvider()
+	if metricProvider != nil {
+		if h := metricProvider.MetricsHandler(); h != nil {
+			root.Handle("/metrics", h)
+		}
+	}
+	withMetric := func(name string) func(http.Handler) http.Handler {
+		if metricProvider == nil {
+			return func(next http.Handler) http.Handler { return next }
+		}
+		return metrics.WithMetric(metricProvider.NewApiMetric(name))
+	}
 
 	// GraphQL API
 	graphqlServer, err := gql.NewServer()
@@ -209,6 +286,7 @@ func (cmd *ServerCommand) Run(ctx context.Context) error {
 		return err
 	} else {
 		r := chi.NewRouter()
+		r.Use(withMetric("graphql"))
 		r.Use(meters.WithMeter(meterProvider, "graphql", 1.0, nil))
 		r.Mount("/", graphqlServer)
 		root.Mount("/query", r)
@@ -220,20 +298,49 @@ func (cmd *ServerCommand) Run(ctx context.Context) error {
 		return err
 	} else {
 		r := chi.NewRouter()
+		r.Use(withMetric("rest"))
 		r.Use(meters.WithMeter(meterProvider, "rest", 1.0, nil))
 		r.Mount("/", restServer)
 		root.Mount("/rest", r)
 	}
 
+	// Job API
+	if jobQueue != nil {
+		jobServer, err := jobserver.NewServer("default", cmd.JobWorkers)
+		if err != nil {
+			return err
+		}
+		root.Mount("/jobs", jobServer)
+	}
+
 	// GraphQL Playground
 	root.Handle("/", playground.Handler("GraphQL playground", "/query"))
 
+	// GraphQL subscriptions over WebSocket
+	// These connections are long lived and require connection hijacking,
+	// which is not supported by the timeout, logging and metering response wrappers.
+	// CORS does not apply to WebSocket upgrades; origins are checked by the GraphQL server using the config.
+	wsRoot := chi.NewRouter()
+	wsRoot.Use(model.AddConfig(cfg))
+	wsRoot.Use(usercheck.AdminDefaultMiddleware("admin"))
+	wsRoot.Use(log.RequestIDMiddleware)
+	wsRoot.Use(log.RequestIDLoggingMiddleware)
+	wsRoot.Use(model.AddPerms(cfg.Checker))
+	wsRoot.Mount("/query", graphqlServer)
+
 	// Start server
 	timeOut := time.Duration(cmd.Timeout) * time.Second
+	timeoutHandler := http.TimeoutHandler(root, timeOut, "timeout")
 	addr := fmt.Sprintf("%s:%s", "0.0.0.0", cmd.Port)
 	log.For(ctx).Info().Msgf("Listening on: %s", addr)
 	srv := &http.Server{
-		Handler:      http.TimeoutHandler(root, timeOut, "timeout"),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if gql.IsWebsocketUpgrade(r) {
+				wsRoot.ServeHTTP(w, r)
+				return
+			}
+			timeoutHandler.ServeHTTP(w, r)
+		}),
 		Addr:         addr,
 		WriteTimeout: 2 * timeOut,
 		ReadTimeout:  2 * timeOut,
@@ -241,6 +348,22 @@ func (cmd *ServerCommand) Run(ctx context.Context) error {
 	return srv.ListenAndServe()
 }
 
+// serverStatus provides values for metrics that are read at collection time.
+type serverStatus struct {
+	rtFinder *rtfinder.Finder
+	db       tldb.Ext
+}
+
+func (s *serverStatus) RTTimestamps(ctx context.Context) map[string]uint64 {
+	return s.rtFinder.SourceTimestamps(ctx)
+}
+
+func (s *serverStatus) ActiveFeedVersionCount(ctx context.Context) (int, error) {
+	count := 0
+	err := s.db.QueryRowContext(ctx, "select count(*) from feed_states where feed_version_id is not null").Scan(&count)
+	return count, err
+}
+
 ////////////
 
 // Read version from compiled in git details
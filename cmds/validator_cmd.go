@@ -2,14 +2,14 @@ package cmds
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"time"
 
 	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/dmfr"
 	"github.com/interline-io/transitland-lib/ext"
-	"github.com/interline-io/transitland-lib/internal/snakejson"
 	"github.com/interline-io/transitland-lib/tlcli"
 	"github.com/interline-io/transitland-lib/tldb"
 	"github.com/interline-io/transitland-lib/validator"
@@ -21,20 +21,27 @@ type ValidatorCommand struct {
 	Options                 validator.Options
 	rtFiles                 []string
 	OutputFile              string
+	OutputFormat            string
 	DBURL                   string
 	FVID                    int
 	extensionDefs           []string
 	SaveValidationReport    bool
 	ValidationReportStorage string
+	SecretsFile             string
+	ProfileFile             string
+	rtFeedID                string
 	readerPath              string
 }
 
 func (cmd *ValidatorCommand) HelpDesc() (string, string) {
-	return "Validate a GTFS feed", "The validate command performs a basic validation on a data source and writes the results to standard out."
+	return "Validate a GTFS feed", "The validate command performs a basic validation on a data source and writes the results to standard out. With -o, a validation report is also written to a file as JSON, SARIF (for code scanning tools), JUnit XML (for CI dashboards), or a self-contained HTML page. When validating a directory, file locations in SARIF and JUnit reports include the directory path so that annotations point at the offending line. A validation profile (--profile) can change the severity of error codes or error types (error, warning, or off), set parameters for best practices rules, and suppress known issues by filename and entity ID until an expiry date."
 }
 
 func (cmd *ValidatorCommand) HelpExample() string {
-	return `% {{.ParentCommand}} {{.Command}} "https://www.bart.gov/dev/schedules/google_transit.zip"`
+	return `% {{.ParentCommand}} {{.Command}} "https://www.bart.gov/dev/schedules/google_transit.zip"
+% {{.ParentCommand}} {{.Command}} -o report.sarif gtfs/
+% {{.ParentCommand}} {{.Command}} --best-practices --profile profile.yaml gtfs/
+% {{.ParentCommand}} {{.Command}} -o report.html --best-practices "https://www.bart.gov/dev/schedules/google_transit.zip"`
 }
 
 func (cmd *ValidatorCommand) HelpArgs() string {
@@ -43,13 +50,19 @@ func (cmd *ValidatorCommand) HelpArgs() string {
 
 func (cmd *ValidatorCommand) AddFlags(fl *pflag.FlagSet) {
 	fl.StringSliceVar(&cmd.extensionDefs, "ext", nil, "Include GTFS Extension")
-	fl.StringVar(&cmd.OutputFile, "o", "", "Write validation report as JSON to file")
+	fl.StringVarP(&cmd.OutputFile, "o", "o", "", "Write validation report to file")
+	fl.StringVar(&cmd.OutputFormat, "format", "", "Validation report format: json, sarif, junit, or html; defaults to the format matching the -o file extension, or json")
 	fl.BoolVar(&cmd.Options.BestPractices, "best-practices", false, "Include Best Practices validations")
+	fl.StringVar(&cmd.ProfileFile, "profile", "", "Validation profile (YAML or JSON) with severity overrides, rule parameters, and suppressions")
 	fl.BoolVar(&cmd.Options.IncludeRealtimeJson, "rt-json", false, "Include GTFS-RT proto messages as JSON in validation report")
 	fl.BoolVar(&cmd.SaveValidationReport, "validation-report", false, "Save static validation report in database")
 	fl.StringVar(&cmd.ValidationReportStorage, "validation-report-storage", "", "Storage path for saving validation report JSON")
 	fl.IntVar(&cmd.FVID, "save-fvid", 0, "Save report to feed version ID")
 	fl.StringSliceVar(&cmd.rtFiles, "rt", nil, "Include GTFS-RT proto message in validation report")
+	fl.StringVar(&cmd.Options.RealtimeAuthorization.Type, "rt-auth-type", "", "Authorization type for GTFS-RT urls, e.g. header or oauth2_client_credentials")
+	fl.StringVar(&cmd.Options.RealtimeAuthorization.ParamName, "rt-auth-param", "", "Authorization parameter name for GTFS-RT urls")
+	fl.StringVar(&cmd.SecretsFile, "secrets", "", "Path to DMFR Secrets file")
+	fl.StringVar(&cmd.rtFeedID, "rt-feed-id", "", "Feed ID used to select a secret for GTFS-RT urls")
 	fl.IntVar(&cmd.Options.ErrorLimit, "error-limit", 1000, "Max number of detailed errors per error group")
 }
 
@@ -62,9 +75,39 @@ func (cmd *ValidatorCommand) Parse(args []string) error {
 		cmd.DBURL = os.Getenv("TL_DATABASE_URL")
 	}
 	cmd.readerPath = fl.Arg(0)
+	if cmd.OutputFormat == "" {
+		cmd.OutputFormat = validator.ReportFormatFromFilename(cmd.OutputFile)
+	}
+	switch cmd.OutputFormat {
+	case validator.ReportFormatJSON, validator.ReportFormatSARIF, validator.ReportFormatJUnit, validator.ReportFormatHTML:
+	default:
+		return fmt.Errorf("unknown --format '%s'", cmd.OutputFormat)
+	}
 	cmd.Options.ValidateRealtimeMessages = cmd.rtFiles
 	cmd.Options.ExtensionDefs = cmd.extensionDefs
 	cmd.Options.EvaluateAt = time.Now().In(time.UTC)
+	if cmd.ProfileFile != "" {
+		profile, err := validator.LoadProfile(cmd.ProfileFile)
+		if err != nil {
+			return err
+		}
+		cmd.Options.Profile = profile
+	}
+	if cmd.Options.RealtimeAuthorization.Type != "" {
+		if cmd.SecretsFile == "" || cmd.rtFeedID == "" {
+			return errors.New("--rt-auth-type requires --secrets and --rt-feed-id")
+		}
+		r, err := dmfr.LoadAndParseRegistry(cmd.SecretsFile)
+		if err != nil {
+			return err
+		}
+		feed := dmfr.Feed{FeedID: cmd.rtFeedID, Authorization: cmd.Options.RealtimeAuthorization}
+		secret, err := feed.MatchSecrets(r.Secrets, "")
+		if err != nil {
+			return err
+		}
+		cmd.Options.RealtimeSecret = secret
+	}
 	return nil
 }
 
@@ -90,12 +133,21 @@ func (cmd *ValidatorCommand) Run(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
-		b, err := json.MarshalIndent(snakejson.SnakeMarshaller{Value: result}, "", "  ")
-		if err != nil {
+		reportOpts := validator.ReportOptions{Format: cmd.OutputFormat}
+		if fi, err := os.Stat(cmd.readerPath); err == nil && fi.IsDir() {
+			// File locations are relative to the feed directory, e.g. for CI annotations
+			if reportOpts.SourcePath, err = validator.ReportSourcePath(cmd.readerPath); err != nil {
+				f.Close()
+				return err
+			}
+		}
+		if err := validator.WriteReport(f, result, reportOpts); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
 			return err
 		}
-		f.Write(b)
-		f.Close()
 	}
 
 	// Save to database
@@ -291,6 +291,7 @@ func NewCopier(ctx context.Context, reader adapters.Reader, writer adapters.Writ
 			&rules.CalendarDuplicateDates{},
 			&rules.FareProductRiderCategoryDefaultCheck{},
 			&rules.TransferStopLocationTypeCheck{},
+			&rules.LocationIDConflictCheck{},
 		)
 	}
 
@@ -615,7 +616,11 @@ func (copier *Copier) Copy(ctx context.Context) (*Result, error) {
 				}),
 			)
 		},
+		func() error { return batchCopy(copier, batchChan(r.Locations(), bs, nil)) },
+		func() error { return batchCopy(copier, batchChan(r.LocationGroups(), bs, nil)) },
+		func() error { return batchCopy(copier, batchChan(r.LocationGroupStops(), bs, nil)) },
 		copier.copyCalendars,
+		func() error { return batchCopy(copier, batchChan(r.BookingRules(), bs, nil)) },
 		copier.copyTripsAndStopTimes,
 		func() error { return batchCopy(copier, batchChan(r.Pathways(), bs, nil)) },
 		func() error { return batchCopy(copier, batchChan(r.FareAttributes(), bs, nil)) },
@@ -889,7 +894,7 @@ func (copier *Copier) copyTripsAndStopTimes() error {
 			trip.StopTimes = sts
 
 			// Set StopPattern
-			patkey := stopPatternKey(trip.StopTimes)
+			patkey := StopPatternKey(trip.StopTimes)
 			if pat, ok := stopPatterns[patkey]; ok {
 				trip.StopPatternID.SetInt(pat)
 			} else {
@@ -897,8 +902,11 @@ func (copier *Copier) copyTripsAndStopTimes() error {
 				stopPatterns[patkey] = trip.StopPatternID.Int()
 			}
 
+			// Flex trips do not have fixed stop locations or times
+			isFlex := hasFlexStopTimes(trip.StopTimes)
+
 			// Create missing shape if necessary
-			if !trip.ShapeID.Valid && copier.options.CreateMissingShapes {
+			if !trip.ShapeID.Valid && copier.options.CreateMissingShapes && !isFlex {
 				// Note: if the trip has errors, may result in unused shapes!
 				if shapeid, ok := stopPatternShapeIDs[trip.StopPatternID.Int()]; ok {
 					trip.ShapeID.Set(shapeid)
@@ -915,7 +923,7 @@ func (copier *Copier) copyTripsAndStopTimes() error {
 			}
 
 			// Interpolate stop times
-			if copier.options.InterpolateStopTimes {
+			if copier.options.InterpolateStopTimes && !isFlex {
 				if stoptimes2, err := copier.geomCache.InterpolateStopTimes(trip); err != nil {
 					trip.AddWarning(err)
 				} else {
@@ -986,7 +994,7 @@ func (copier *Copier) copyTripsAndStopTimes() error {
 func (copier *Copier) logCount(ent tt.Entity) {
 	out := []string{}
 	fn := ent.Filename()
-	fnr := strings.ReplaceAll(fn, ".txt", "")
+	fnr := strings.ReplaceAll(strings.ReplaceAll(fn, ".txt", ""), ".geojson", "")
 	saved := copier.result.EntityCount[fn]
 	out = append(out, fmt.Sprintf("Saved %d %s", saved, fnr))
 	evt := copier.log.Info().Str("filename", fn).Int("saved", saved)
@@ -190,6 +190,20 @@ func (cr *Result) HandleError(fn string, errs []error) {
 	}
 }
 
+// HandleWarning .
+func (cr *Result) HandleWarning(fn string, warns []error) {
+	for _, err := range warns {
+		key := getErrorKey(err)
+		v, ok := cr.Warnings[key]
+		if !ok {
+			v = NewValidationErrorGroup(err, cr.ErrorLimit)
+			v.Level = 1
+			cr.Warnings[key] = v
+		}
+		v.Add(err)
+	}
+}
+
 // HandleEntityErrors .
 func (cr *Result) HandleEntityErrors(ent tt.Entity, errs []error, warns []error) {
 	// Get entity line, if available
@@ -8,10 +8,11 @@ import (
 	"github.com/interline-io/transitland-lib/gtfs"
 )
 
-func stopPatternKey(stoptimes []gtfs.StopTime) string {
+// StopPatternKey returns a key identifying the sequence of stops visited by a trip.
+func StopPatternKey(stoptimes []gtfs.StopTime) string {
 	key := make([]string, len(stoptimes))
 	for i := 0; i < len(stoptimes); i++ {
-		key[i] = stoptimes[i].StopID.Val
+		key[i] = stopTimeStopKey(&stoptimes[i])
 	}
 	return strings.Join(key, string(byte(0)))
 }
@@ -44,6 +45,37 @@ func journeyPatternKey(trip *gtfs.Trip) string {
 			st.DropOffType.Val,
 			st.Timepoint.Val,
 		)))
+		if st.IsFlex() {
+			m.Write([]byte(fmt.Sprintf(
+				"%s-%s-%d-%d-%s-%s",
+				st.LocationGroupID.Val,
+				st.LocationID.Val,
+				st.StartPickupDropOffWindow.Val,
+				st.EndPickupDropOffWindow.Val,
+				st.PickupBookingRuleID.Val,
+				st.DropOffBookingRuleID.Val,
+			)))
+		}
 	}
 	return fmt.Sprintf("%x", m.Sum(nil))
 }
+
+// stopTimeStopKey returns the stop, location group, or location referenced by a StopTime.
+func stopTimeStopKey(st *gtfs.StopTime) string {
+	if st.LocationGroupID.Val != "" {
+		return "location_group:" + st.LocationGroupID.Val
+	} else if st.LocationID.Val != "" {
+		return "location:" + st.LocationID.Val
+	}
+	return st.StopID.Val
+}
+
+// hasFlexStopTimes returns true if any StopTime uses GTFS-Flex locations or windows.
+func hasFlexStopTimes(stoptimes []gtfs.StopTime) bool {
+	for i := 0; i < len(stoptimes); i++ {
+		if stoptimes[i].IsFlex() {
+			return true
+		}
+	}
+	return false
+}
@@ -8,7 +8,7 @@ import (
 	"github.com/interline-io/transitland-lib/tt"
 )
 
-func Benchmark_stopPatternKey(b *testing.B) {
+func Benchmark_StopPatternKey(b *testing.B) {
 	stoptimes := []gtfs.StopTime{}
 	for i := 0; i < 50; i++ {
 		stoptimes = append(stoptimes, gtfs.StopTime{StopID: tt.NewString(fmt.Sprintf("%d", i*100))})
@@ -16,7 +16,7 @@ func Benchmark_stopPatternKey(b *testing.B) {
 	m := map[string]int{}
 	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
-		key := stopPatternKey(stoptimes)
+		key := StopPatternKey(stoptimes)
 		m[key]++
 	}
 }
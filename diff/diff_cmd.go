@@ -6,6 +6,7 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"sort"
@@ -27,18 +28,20 @@ type Command struct {
 	ShowAdded   bool
 	ShowDeleted bool
 	CheckFiles  []string
+	Report      string
+	ReportOpts  ReportOptions
 	readerPathA string
 	readerPathB string
 }
 
 func (cmd *Command) HelpDesc() (string, string) {
 	a := "Calculate difference between two feeds, writing output in a GTFS-like format"
-	b := "This command is experimental; it may provide incorrect results or crash on large feeds."
+	b := "This command is experimental; it may provide incorrect results or crash on large feeds.\n\nUse --report json or --report markdown to write a semantic change report instead of GTFS-like output. The report lists routes added and removed, stops added, removed and moved, calendar changes, per-route trip count and service hour changes for each day, and fare changes. In report mode the output path is optional; the report is written to stdout by default."
 	return a, b
 }
 
 func (cmd *Command) HelpArgs() string {
-	return "[flags] <feed1> <feed2> <output>"
+	return "[flags] <feed1> <feed2> [output]"
 }
 
 func (cmd *Command) AddFlags(fl *pflag.FlagSet) {
@@ -47,6 +50,9 @@ func (cmd *Command) AddFlags(fl *pflag.FlagSet) {
 	fl.BoolVar(&cmd.ShowAdded, "added", false, "Show entities added in second file")
 	fl.BoolVar(&cmd.ShowDeleted, "deleted", false, "Show entities deleted from first file")
 	fl.BoolVar(&cmd.RawDiff, "raw", false, "Diff based on raw CSV contents")
+	fl.StringVar(&cmd.Report, "report", "", "Write a semantic change report instead of GTFS-like output: json or markdown")
+	fl.Float64Var(&cmd.ReportOpts.StopDistance, "stop-distance", 10, "Report stops moved by more than this distance in meters")
+	fl.IntVar(&cmd.ReportOpts.Days, "days", 28, "Number of days to compare service levels, starting with the first day both feeds are active")
 }
 
 func (cmd *Command) Parse(args []string) error {
@@ -54,10 +60,15 @@ func (cmd *Command) Parse(args []string) error {
 	if fl.NArg() < 2 {
 		return errors.New("requires two input readers")
 	}
-	if fl.NArg() < 3 {
+	switch cmd.Report {
+	case "", "json", "markdown":
+	default:
+		return fmt.Errorf("unknown report format '%s'", cmd.Report)
+	}
+	if fl.NArg() < 3 && cmd.Report == "" {
 		return errors.New("requires output directory")
 	}
-	if !cmd.ShowAdded && !cmd.ShowDeleted && !cmd.ShowSame && !cmd.ShowDiff {
+	if cmd.Report == "" && !cmd.ShowAdded && !cmd.ShowDeleted && !cmd.ShowSame && !cmd.ShowDiff {
 		log.Print("Using default mode of -same -diff -added -deleted")
 		cmd.ShowAdded = true
 		cmd.ShowDeleted = true
@@ -66,7 +77,9 @@ func (cmd *Command) Parse(args []string) error {
 	}
 	cmd.readerPathA = fl.Arg(0)
 	cmd.readerPathB = fl.Arg(1)
-	cmd.Outpath = fl.Arg(2)
+	if fl.NArg() > 2 {
+		cmd.Outpath = fl.Arg(2)
+	}
 	return nil
 }
 
@@ -85,6 +98,9 @@ func (cmd *Command) Run(ctx context.Context) error {
 	if err := readerB.Open(); err != nil {
 		return err
 	}
+	if cmd.Report != "" {
+		return cmd.writeReport(ctx, readerA, readerB)
+	}
 	var df1 *diffAdapter
 	var df2 *diffAdapter
 	if cmd.RawDiff {
@@ -202,6 +218,26 @@ func (cmd *Command) Run(ctx context.Context) error {
 	return nil
 }
 
+func (cmd *Command) writeReport(ctx context.Context, readerA adapters.Reader, readerB adapters.Reader) error {
+	report, err := NewReport(ctx, readerA, readerB, cmd.ReportOpts)
+	if err != nil {
+		return err
+	}
+	var out io.Writer = os.Stdout
+	if cmd.Outpath != "" {
+		f, err := os.Create(cmd.Outpath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if cmd.Report == "markdown" {
+		return report.WriteMarkdown(out)
+	}
+	return report.WriteJSON(out)
+}
+
 type canFileInfos interface {
 	tlcsv.Adapter
 	FileInfos() ([]os.FileInfo, error)
@@ -0,0 +1,626 @@
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/interline-io/transitland-lib/adapters"
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/service"
+	"github.com/interline-io/transitland-lib/tlxy"
+)
+
+// ReportOptions configures a semantic change report.
+type ReportOptions struct {
+	// StopDistance is the minimum distance in meters for a stop to be reported as moved.
+	StopDistance float64
+	// Days is the number of days of service to compare, starting with the first day both feeds are active.
+	Days int
+}
+
+// Report is a semantic change report between two feeds.
+type Report struct {
+	FeedA         string               `json:"feed_a"`
+	FeedB         string               `json:"feed_b"`
+	Routes        RouteChanges         `json:"routes"`
+	Stops         StopChanges          `json:"stops"`
+	Calendar      CalendarChanges      `json:"calendar"`
+	ServiceLevels []RouteServiceChange `json:"service_levels"`
+	Fares         FareChanges          `json:"fares"`
+}
+
+// RouteChanges lists routes added and removed.
+type RouteChanges struct {
+	Added   []RouteSummary `json:"added"`
+	Removed []RouteSummary `json:"removed"`
+}
+
+// RouteSummary identifies a route.
+type RouteSummary struct {
+	RouteID        string `json:"route_id"`
+	RouteShortName string `json:"route_short_name,omitempty"`
+	RouteLongName  string `json:"route_long_name,omitempty"`
+	RouteType      int    `json:"route_type"`
+}
+
+// StopChanges lists stops added, removed and moved.
+type StopChanges struct {
+	Added   []StopSummary `json:"added"`
+	Removed []StopSummary `json:"removed"`
+	Moved   []StopMove    `json:"moved"`
+}
+
+// StopSummary identifies a stop.
+type StopSummary struct {
+	StopID   string  `json:"stop_id"`
+	StopName string  `json:"stop_name,omitempty"`
+	Lon      float64 `json:"stop_lon"`
+	Lat      float64 `json:"stop_lat"`
+	located  bool
+}
+
+// StopMove is a stop present in both feeds with a changed location.
+type StopMove struct {
+	StopID   string      `json:"stop_id"`
+	StopName string      `json:"stop_name,omitempty"`
+	Before   StopSummary `json:"before"`
+	After    StopSummary `json:"after"`
+	Distance float64     `json:"distance"`
+}
+
+// CalendarChanges lists changes to the feed service window and individual services.
+type CalendarChanges struct {
+	Before          ServiceWindow   `json:"before"`
+	After           ServiceWindow   `json:"after"`
+	ServicesAdded   []string        `json:"services_added"`
+	ServicesRemoved []string        `json:"services_removed"`
+	ServicesChanged []ServiceChange `json:"services_changed"`
+}
+
+// ServiceWindow is a range of service dates.
+type ServiceWindow struct {
+	StartDate string `json:"start_date,omitempty"`
+	EndDate   string `json:"end_date,omitempty"`
+}
+
+// ServiceChange is a service present in both feeds with a changed window or days of week.
+type ServiceChange struct {
+	ServiceID  string        `json:"service_id"`
+	Before     ServiceWindow `json:"before"`
+	After      ServiceWindow `json:"after"`
+	DaysBefore string        `json:"days_before"`
+	DaysAfter  string        `json:"days_after"`
+}
+
+// RouteServiceChange lists the days where scheduled service for a route changed.
+type RouteServiceChange struct {
+	RouteID string             `json:"route_id"`
+	Days    []DayServiceChange `json:"days"`
+	Total   DayServiceChange   `json:"total"`
+}
+
+// DayServiceChange compares trip counts and service hours for a single day.
+type DayServiceChange struct {
+	Date               string  `json:"date,omitempty"`
+	TripsBefore        int     `json:"trips_before"`
+	TripsAfter         int     `json:"trips_after"`
+	ServiceHoursBefore float64 `json:"service_hours_before"`
+	ServiceHoursAfter  float64 `json:"service_hours_after"`
+}
+
+// FareChanges lists fares added, removed and changed.
+type FareChanges struct {
+	Added   []FareSummary `json:"added"`
+	Removed []FareSummary `json:"removed"`
+	Changed []FareChange  `json:"changed"`
+}
+
+// FareSummary identifies a fare_attributes.txt fare or fare_products.txt product.
+type FareSummary struct {
+	FareID   string  `json:"fare_id"`
+	Filename string  `json:"filename"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// FareChange is a fare present in both feeds with a changed amount or currency.
+type FareChange struct {
+	FareID   string      `json:"fare_id"`
+	Filename string      `json:"filename"`
+	Before   FareSummary `json:"before"`
+	After    FareSummary `json:"after"`
+}
+
+// NewReport compares two feeds and returns a semantic change report.
+// Stop times are streamed grouped by trip, so only per-trip summaries are held in memory.
+func NewReport(ctx context.Context, readerA adapters.Reader, readerB adapters.Reader, opts ReportOptions) (*Report, error) {
+	if opts.Days <= 0 {
+		opts.Days = 28
+	}
+	fa, err := readFeedSummary(ctx, readerA)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := readFeedSummary(ctx, readerB)
+	if err != nil {
+		return nil, err
+	}
+	report := Report{
+		FeedA: readerA.String(),
+		FeedB: readerB.String(),
+	}
+	report.compareRoutes(fa, fb)
+	report.compareStops(fa, fb, opts.StopDistance)
+	report.compareCalendars(fa, fb)
+	report.compareServiceLevels(fa, fb, opts.Days)
+	report.compareFares(fa, fb)
+	return &report, nil
+}
+
+func (report *Report) compareRoutes(fa, fb *feedSummary) {
+	for _, k := range sortedKeys(fa.routes) {
+		if _, ok := fb.routes[k]; !ok {
+			report.Routes.Removed = append(report.Routes.Removed, fa.routes[k])
+		}
+	}
+	for _, k := range sortedKeys(fb.routes) {
+		if _, ok := fa.routes[k]; !ok {
+			report.Routes.Added = append(report.Routes.Added, fb.routes[k])
+		}
+	}
+}
+
+func (report *Report) compareStops(fa, fb *feedSummary, minDistance float64) {
+	for _, k := range sortedKeys(fa.stops) {
+		a := fa.stops[k]
+		b, ok := fb.stops[k]
+		if !ok {
+			report.Stops.Removed = append(report.Stops.Removed, a)
+			continue
+		}
+		if !a.located || !b.located {
+			// Stops without coordinates, e.g. generic nodes, can not be compared
+			continue
+		}
+		d := tlxy.DistanceHaversine(tlxy.Point{Lon: a.Lon, Lat: a.Lat}, tlxy.Point{Lon: b.Lon, Lat: b.Lat})
+		if d > minDistance {
+			report.Stops.Moved = append(report.Stops.Moved, StopMove{
+				StopID:   k,
+				StopName: b.StopName,
+				Before:   a,
+				After:    b,
+				Distance: d,
+			})
+		}
+	}
+	for _, k := range sortedKeys(fb.stops) {
+		if _, ok := fa.stops[k]; !ok {
+			report.Stops.Added = append(report.Stops.Added, fb.stops[k])
+		}
+	}
+}
+
+func (report *Report) compareCalendars(fa, fb *feedSummary) {
+	report.Calendar.Before = fa.serviceWindow()
+	report.Calendar.After = fb.serviceWindow()
+	for _, k := range sortedKeys(fa.services) {
+		a := fa.services[k]
+		b, ok := fb.services[k]
+		if !ok {
+			report.Calendar.ServicesRemoved = append(report.Calendar.ServicesRemoved, k)
+			continue
+		}
+		wa, wb := serviceWindow(a), serviceWindow(b)
+		da, db := serviceDays(a), serviceDays(b)
+		if wa != wb || da != db {
+			report.Calendar.ServicesChanged = append(report.Calendar.ServicesChanged, ServiceChange{
+				ServiceID:  k,
+				Before:     wa,
+				After:      wb,
+				DaysBefore: da,
+				DaysAfter:  db,
+			})
+		}
+	}
+	for _, k := range sortedKeys(fb.services) {
+		if _, ok := fa.services[k]; !ok {
+			report.Calendar.ServicesAdded = append(report.Calendar.ServicesAdded, k)
+		}
+	}
+}
+
+// compareServiceLevels compares trips and service hours for each route and day,
+// starting with the first day both feeds are active.
+func (report *Report) compareServiceLevels(fa, fb *feedSummary, days int) {
+	sa, ea := fa.servicePeriod()
+	sb, eb := fb.servicePeriod()
+	start, end := sa, ea
+	if sb.After(start) {
+		start = sb
+	}
+	if eb.Before(end) {
+		end = eb
+	}
+	if start.IsZero() || end.Before(start) {
+		return
+	}
+	if limit := start.AddDate(0, 0, days-1); limit.Before(end) {
+		end = limit
+	}
+	routeIDs := map[string]bool{}
+	for k := range fa.routeServices {
+		routeIDs[k] = true
+	}
+	for k := range fb.routeServices {
+		routeIDs[k] = true
+	}
+	for _, rid := range sortedKeys(routeIDs) {
+		change := RouteServiceChange{RouteID: rid}
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			a := fa.dayStats(rid, d)
+			b := fb.dayStats(rid, d)
+			change.Total.TripsBefore += a.trips
+			change.Total.TripsAfter += b.trips
+			change.Total.ServiceHoursBefore += a.hours()
+			change.Total.ServiceHoursAfter += b.hours()
+			if a == b {
+				continue
+			}
+			change.Days = append(change.Days, DayServiceChange{
+				Date:               d.Format("2006-01-02"),
+				TripsBefore:        a.trips,
+				TripsAfter:         b.trips,
+				ServiceHoursBefore: a.hours(),
+				ServiceHoursAfter:  b.hours(),
+			})
+		}
+		if len(change.Days) > 0 {
+			report.ServiceLevels = append(report.ServiceLevels, change)
+		}
+	}
+}
+
+func (report *Report) compareFares(fa, fb *feedSummary) {
+	for _, k := range sortedKeys(fa.fares) {
+		a := fa.fares[k]
+		b, ok := fb.fares[k]
+		if !ok {
+			report.Fares.Removed = append(report.Fares.Removed, a)
+		} else if a != b {
+			report.Fares.Changed = append(report.Fares.Changed, FareChange{
+				FareID:   a.FareID,
+				Filename: a.Filename,
+				Before:   a,
+				After:    b,
+			})
+		}
+	}
+	for _, k := range sortedKeys(fb.fares) {
+		if _, ok := fa.fares[k]; !ok {
+			report.Fares.Added = append(report.Fares.Added, fb.fares[k])
+		}
+	}
+}
+
+// WriteJSON writes the report as JSON.
+func (report *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// WriteMarkdown writes the report as Markdown.
+func (report *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Feed changes\n\n")
+	fmt.Fprintf(&b, "Comparing `%s` to `%s`.\n\n", report.FeedA, report.FeedB)
+
+	fmt.Fprintf(&b, "## Routes\n\n")
+	if len(report.Routes.Added) == 0 && len(report.Routes.Removed) == 0 {
+		fmt.Fprintf(&b, "No routes added or removed.\n\n")
+	} else {
+		fmt.Fprintf(&b, "| Change | route_id | route_short_name | route_long_name | route_type |\n|---|---|---|---|---|\n")
+		for _, r := range report.Routes.Added {
+			fmt.Fprintf(&b, "| added | %s | %s | %s | %d |\n", mdCell(r.RouteID), mdCell(r.RouteShortName), mdCell(r.RouteLongName), r.RouteType)
+		}
+		for _, r := range report.Routes.Removed {
+			fmt.Fprintf(&b, "| removed | %s | %s | %s | %d |\n", mdCell(r.RouteID), mdCell(r.RouteShortName), mdCell(r.RouteLongName), r.RouteType)
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "## Stops\n\n")
+	if len(report.Stops.Added) == 0 && len(report.Stops.Removed) == 0 && len(report.Stops.Moved) == 0 {
+		fmt.Fprintf(&b, "No stops added, removed or moved.\n\n")
+	} else {
+		fmt.Fprintf(&b, "| Change | stop_id | stop_name | Distance (m) |\n|---|---|---|---|\n")
+		for _, s := range report.Stops.Added {
+			fmt.Fprintf(&b, "| added | %s | %s | |\n", mdCell(s.StopID), mdCell(s.StopName))
+		}
+		for _, s := range report.Stops.Removed {
+			fmt.Fprintf(&b, "| removed | %s | %s | |\n", mdCell(s.StopID), mdCell(s.StopName))
+		}
+		for _, s := range report.Stops.Moved {
+			fmt.Fprintf(&b, "| moved | %s | %s | %0.1f |\n", mdCell(s.StopID), mdCell(s.StopName), s.Distance)
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "## Calendar\n\n")
+	fmt.Fprintf(&b, "Service window changed from %s – %s to %s – %s.\n\n",
+		report.Calendar.Before.StartDate,
+		report.Calendar.Before.EndDate,
+		report.Calendar.After.StartDate,
+		report.Calendar.After.EndDate,
+	)
+	if len(report.Calendar.ServicesAdded) > 0 {
+		fmt.Fprintf(&b, "Services added: %s\n\n", strings.Join(report.Calendar.ServicesAdded, ", "))
+	}
+	if len(report.Calendar.ServicesRemoved) > 0 {
+		fmt.Fprintf(&b, "Services removed: %s\n\n", strings.Join(report.Calendar.ServicesRemoved, ", "))
+	}
+	if len(report.Calendar.ServicesChanged) > 0 {
+		fmt.Fprintf(&b, "| service_id | Before | After | Days before | Days after |\n|---|---|---|---|---|\n")
+		for _, s := range report.Calendar.ServicesChanged {
+			fmt.Fprintf(&b, "| %s | %s – %s | %s – %s | %s | %s |\n", mdCell(s.ServiceID), s.Before.StartDate, s.Before.EndDate, s.After.StartDate, s.After.EndDate, s.DaysBefore, s.DaysAfter)
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "## Service levels\n\n")
+	if len(report.ServiceLevels) == 0 {
+		fmt.Fprintf(&b, "No changes to trips or service hours.\n\n")
+	}
+	for _, r := range report.ServiceLevels {
+		fmt.Fprintf(&b, "### Route %s\n\n", mdCell(r.RouteID))
+		fmt.Fprintf(&b, "| Date | Trips before | Trips after | Service hours before | Service hours after |\n|---|---|---|---|---|\n")
+		for _, d := range r.Days {
+			fmt.Fprintf(&b, "| %s | %d | %d | %0.2f | %0.2f |\n", d.Date, d.TripsBefore, d.TripsAfter, d.ServiceHoursBefore, d.ServiceHoursAfter)
+		}
+		fmt.Fprintf(&b, "| **Total** | %d | %d | %0.2f | %0.2f |\n\n", r.Total.TripsBefore, r.Total.TripsAfter, r.Total.ServiceHoursBefore, r.Total.ServiceHoursAfter)
+	}
+
+	fmt.Fprintf(&b, "## Fares\n\n")
+	if len(report.Fares.Added) == 0 && len(report.Fares.Removed) == 0 && len(report.Fares.Changed) == 0 {
+		fmt.Fprintf(&b, "No fares added, removed or changed.\n\n")
+	} else {
+		fmt.Fprintf(&b, "| Change | Filename | fare_id | Before | After |\n|---|---|---|---|---|\n")
+		for _, f := range report.Fares.Added {
+			fmt.Fprintf(&b, "| added | %s | %s | | %0.2f %s |\n", f.Filename, mdCell(f.FareID), f.Amount, mdCell(f.Currency))
+		}
+		for _, f := range report.Fares.Removed {
+			fmt.Fprintf(&b, "| removed | %s | %s | %0.2f %s | |\n", f.Filename, mdCell(f.FareID), f.Amount, mdCell(f.Currency))
+		}
+		for _, f := range report.Fares.Changed {
+			fmt.Fprintf(&b, "| changed | %s | %s | %0.2f %s | %0.2f %s |\n", f.Filename, mdCell(f.FareID), f.Before.Amount, mdCell(f.Before.Currency), f.After.Amount, mdCell(f.After.Currency))
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mdCellReplacer escapes characters that would end a Markdown table cell or row.
+var mdCellReplacer = strings.NewReplacer("\\", "\\\\", "|", "\\|", "\r\n", " ", "\n", " ", "\r", " ")
+
+// mdCell escapes a value for use in a Markdown table cell.
+func mdCell(s string) string {
+	return mdCellReplacer.Replace(s)
+}
+
+//////////
+
+// feedSummary holds the per-entity summaries needed to compare two feeds.
+type feedSummary struct {
+	routes        map[string]RouteSummary
+	stops         map[string]StopSummary
+	services      map[string]*service.Service
+	fares         map[string]FareSummary
+	routeServices map[string]map[string]dayStats
+}
+
+// dayStats is the number of scheduled trips and total trip duration in seconds.
+type dayStats struct {
+	trips   int
+	seconds int
+}
+
+func (s dayStats) hours() float64 {
+	return float64(s.seconds) / 3600
+}
+
+func readFeedSummary(ctx context.Context, reader adapters.Reader) (*feedSummary, error) {
+	fs := feedSummary{
+		routes:        map[string]RouteSummary{},
+		stops:         map[string]StopSummary{},
+		services:      map[string]*service.Service{},
+		fares:         map[string]FareSummary{},
+		routeServices: map[string]map[string]dayStats{},
+	}
+	for ent := range reader.Routes() {
+		fs.routes[ent.RouteID.Val] = RouteSummary{
+			RouteID:        ent.RouteID.Val,
+			RouteShortName: ent.RouteShortName.Val,
+			RouteLongName:  ent.RouteLongName.Val,
+			RouteType:      ent.RouteType.Int(),
+		}
+	}
+	for ent := range reader.Stops() {
+		c := ent.Coordinates()
+		fs.stops[ent.StopID.Val] = StopSummary{
+			StopID:   ent.StopID.Val,
+			StopName: ent.StopName.Val,
+			Lon:      c[0],
+			Lat:      c[1],
+			located:  ent.Geometry.Valid && ent.Geometry.Val != nil,
+		}
+	}
+	for _, svc := range service.NewServicesFromReader(reader) {
+		fs.services[svc.ServiceID.Val] = svc
+	}
+	for ent := range reader.FareAttributes() {
+		fs.fares["fare_attributes.txt:"+ent.FareID.Val] = FareSummary{
+			FareID:   ent.FareID.Val,
+			Filename: ent.Filename(),
+			Amount:   ent.Price.Val,
+			Currency: ent.CurrencyType.Val,
+		}
+	}
+	for ent := range reader.FareProducts() {
+		// Fare products may have multiple rows for rider categories and fare media
+		fareID := ent.FareProductID.Val
+		if ent.RiderCategoryID.Val != "" || ent.FareMediaID.Val != "" {
+			fareID = fmt.Sprintf("%s (%s:%s)", fareID, ent.RiderCategoryID.Val, ent.FareMediaID.Val)
+		}
+		fs.fares["fare_products.txt:"+fareID] = FareSummary{
+			FareID:   fareID,
+			Filename: ent.Filename(),
+			Amount:   ent.Amount.Val,
+			Currency: ent.Currency.Val,
+		}
+	}
+
+	// Summarize trips by route and service
+	type tripInfo struct {
+		routeID   string
+		serviceID string
+	}
+	trips := map[string]tripInfo{}
+	for ent := range reader.Trips() {
+		trips[ent.TripID.Val] = tripInfo{routeID: ent.RouteID.Val, serviceID: ent.ServiceID.Val}
+	}
+	freqs := map[string][]gtfs.Frequency{}
+	for ent := range reader.Frequencies() {
+		freqs[ent.TripID.Val] = append(freqs[ent.TripID.Val], ent)
+	}
+	for sts := range reader.StopTimesByTripID() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(sts) == 0 {
+			continue
+		}
+		trip, ok := trips[sts[0].TripID.Val]
+		if !ok {
+			continue
+		}
+		duration := tripDuration(sts)
+		count := 1
+		if fqs, ok := freqs[sts[0].TripID.Val]; ok {
+			count = 0
+			for _, fq := range fqs {
+				count += fq.RepeatCount()
+			}
+		}
+		rs, ok := fs.routeServices[trip.routeID]
+		if !ok {
+			rs = map[string]dayStats{}
+			fs.routeServices[trip.routeID] = rs
+		}
+		s := rs[trip.serviceID]
+		s.trips += count
+		s.seconds += count * duration
+		rs[trip.serviceID] = s
+	}
+	return &fs, nil
+}
+
+// dayStats returns the scheduled trips and service seconds for a route on a date.
+func (fs *feedSummary) dayStats(routeID string, d time.Time) dayStats {
+	ret := dayStats{}
+	for serviceID, s := range fs.routeServices[routeID] {
+		svc, ok := fs.services[serviceID]
+		if !ok || !svc.IsActive(d) {
+			continue
+		}
+		ret.trips += s.trips
+		ret.seconds += s.seconds
+	}
+	return ret
+}
+
+// servicePeriod returns the earliest and latest service dates in the feed.
+func (fs *feedSummary) servicePeriod() (time.Time, time.Time) {
+	var start, end time.Time
+	for _, svc := range fs.services {
+		a, b := svc.ServicePeriod()
+		if start.IsZero() || a.Before(start) {
+			start = a
+		}
+		if end.IsZero() || b.After(end) {
+			end = b
+		}
+	}
+	return start, end
+}
+
+func (fs *feedSummary) serviceWindow() ServiceWindow {
+	start, end := fs.servicePeriod()
+	return newServiceWindow(start, end)
+}
+
+func serviceWindow(svc *service.Service) ServiceWindow {
+	return newServiceWindow(svc.ServicePeriod())
+}
+
+func newServiceWindow(start time.Time, end time.Time) ServiceWindow {
+	if start.IsZero() || end.IsZero() {
+		return ServiceWindow{}
+	}
+	return ServiceWindow{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+	}
+}
+
+// serviceDays returns the days of week as a string of 0s and 1s, starting with Monday.
+func serviceDays(svc *service.Service) string {
+	var b strings.Builder
+	for _, dow := range []int{1, 2, 3, 4, 5, 6, 0} {
+		v, _ := svc.GetWeekday(dow)
+		fmt.Fprintf(&b, "%d", v)
+	}
+	return b.String()
+}
+
+// tripDuration returns the seconds between the first departure and last arrival.
+func tripDuration(sts []gtfs.StopTime) int {
+	var start, end int
+	for _, st := range sts {
+		if st.DepartureTime.Valid {
+			start = st.DepartureTime.Int()
+			break
+		}
+		if st.ArrivalTime.Valid {
+			start = st.ArrivalTime.Int()
+			break
+		}
+	}
+	for i := len(sts) - 1; i >= 0; i-- {
+		if st := sts[i]; st.ArrivalTime.Valid {
+			end = st.ArrivalTime.Int()
+			break
+		} else if st.DepartureTime.Valid {
+			end = st.DepartureTime.Int()
+			break
+		}
+	}
+	if end < start {
+		return 0
+	}
+	return end - start
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
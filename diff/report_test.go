@@ -0,0 +1,175 @@
+package diff
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/interline-io/transitland-lib/internal/testutil"
+	"github.com/interline-io/transitland-lib/tlcsv"
+	"github.com/stretchr/testify/assert"
+)
+
+// newModifiedExample returns a copy of the example feed with the replacements applied to each file.
+func newModifiedExample(t *testing.T, replacements map[string][][2]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS(testutil.ExampleDir.URL)); err != nil {
+		t.Fatal(err)
+	}
+	for fn, reps := range replacements {
+		data, err := os.ReadFile(filepath.Join(dir, fn))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := string(data)
+		for _, rep := range reps {
+			if !strings.Contains(s, rep[0]) {
+				t.Fatalf("%s does not contain '%s'", fn, rep[0])
+			}
+			s = strings.Replace(s, rep[0], rep[1], 1)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fn), []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func newTestReport(t *testing.T, pathA string, pathB string) *Report {
+	t.Helper()
+	readerA, err := tlcsv.NewReader(pathA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readerB, err := tlcsv.NewReader(pathB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := NewReport(context.Background(), readerA, readerB, ReportOptions{StopDistance: 10, Days: 28})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func TestNewReport(t *testing.T) {
+	pathB := newModifiedExample(t, map[string][][2]string{
+		"routes.txt": {
+			{"AAMV,DTA,50,Airport - Amargosa Valley,,3,,,\n", "NEW,DTA,60,New Route,,3,,,\n"},
+		},
+		"stops.txt": {
+			{"AMV,Amargosa Valley (Demo),,36.641496", "AMV,Amargosa Valley (Demo),,36.651496"},
+		},
+		"fare_attributes.txt": {
+			{"p,1.25,USD", "p,1.50,USD"},
+		},
+		"calendar.txt": {
+			{"WE,0,0,0,0,0,1,1,20070101,20101231", "WE,0,0,0,0,0,1,1,20070101,20091231"},
+		},
+		"trips.txt": {
+			{"BFC,FULLW,BFC2,to Bullfrog,1,2,\n", ""},
+			{"AAMV,WE,AAMV1,to Amargosa Valley,0,,\n", ""},
+			{"AAMV,WE,AAMV2,to Airport,1,,\n", ""},
+			{"AAMV,WE,AAMV3,to Amargosa Valley,0,,\n", ""},
+			{"AAMV,WE,AAMV4,to Airport,1,,\n", ""},
+		},
+	})
+	report := newTestReport(t, testutil.ExampleDir.URL, pathB)
+	t.Run("routes", func(t *testing.T) {
+		if assert.Equal(t, 1, len(report.Routes.Removed)) {
+			assert.Equal(t, "AAMV", report.Routes.Removed[0].RouteID)
+		}
+		if assert.Equal(t, 1, len(report.Routes.Added)) {
+			assert.Equal(t, "NEW", report.Routes.Added[0].RouteID)
+		}
+	})
+	t.Run("stops", func(t *testing.T) {
+		assert.Equal(t, 0, len(report.Stops.Added))
+		assert.Equal(t, 0, len(report.Stops.Removed))
+		if assert.Equal(t, 1, len(report.Stops.Moved)) {
+			assert.Equal(t, "AMV", report.Stops.Moved[0].StopID)
+			assert.InDelta(t, 1112, report.Stops.Moved[0].Distance, 1)
+		}
+	})
+	t.Run("calendar", func(t *testing.T) {
+		assert.Equal(t, "2007-01-01", report.Calendar.Before.StartDate)
+		assert.Equal(t, "2010-12-31", report.Calendar.After.EndDate)
+		if assert.Equal(t, 1, len(report.Calendar.ServicesChanged)) {
+			sc := report.Calendar.ServicesChanged[0]
+			assert.Equal(t, "WE", sc.ServiceID)
+			assert.Equal(t, "2010-12-31", sc.Before.EndDate)
+			assert.Equal(t, "2009-12-31", sc.After.EndDate)
+		}
+	})
+	t.Run("service levels", func(t *testing.T) {
+		changes := map[string]RouteServiceChange{}
+		for _, c := range report.ServiceLevels {
+			changes[c.RouteID] = c
+		}
+		assert.Equal(t, 2, len(changes))
+		bfc, ok := changes["BFC"]
+		if !ok {
+			t.Fatal("expected changes for route BFC")
+		}
+		assert.Equal(t, 28, len(bfc.Days))
+		for _, d := range bfc.Days {
+			assert.Equal(t, 2, d.TripsBefore)
+			assert.Equal(t, 1, d.TripsAfter)
+			assert.Greater(t, d.ServiceHoursBefore, d.ServiceHoursAfter)
+		}
+		aamv, ok := changes["AAMV"]
+		if !ok {
+			t.Fatal("expected changes for route AAMV")
+		}
+		// Weekend service only
+		assert.Equal(t, 8, len(aamv.Days))
+		assert.Equal(t, 0, aamv.Total.TripsAfter)
+	})
+	t.Run("fares", func(t *testing.T) {
+		if assert.Equal(t, 1, len(report.Fares.Changed)) {
+			assert.Equal(t, "p", report.Fares.Changed[0].FareID)
+			assert.Equal(t, 1.25, report.Fares.Changed[0].Before.Amount)
+			assert.Equal(t, 1.50, report.Fares.Changed[0].After.Amount)
+		}
+	})
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := report.WriteMarkdown(&buf); err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, buf.String(), "| removed | AAMV |")
+		assert.Contains(t, buf.String(), "### Route BFC")
+	})
+}
+
+func TestNewReport_Same(t *testing.T) {
+	report := newTestReport(t, testutil.ExampleDir.URL, testutil.ExampleDir.URL)
+	assert.Equal(t, 0, len(report.Routes.Added)+len(report.Routes.Removed))
+	assert.Equal(t, 0, len(report.Stops.Added)+len(report.Stops.Removed)+len(report.Stops.Moved))
+	assert.Equal(t, 0, len(report.Calendar.ServicesChanged))
+	assert.Equal(t, 0, len(report.ServiceLevels))
+	assert.Equal(t, 0, len(report.Fares.Added)+len(report.Fares.Removed)+len(report.Fares.Changed))
+}
+
+func TestNewReport_Markdown(t *testing.T) {
+	pathB := newModifiedExample(t, map[string][][2]string{
+		"routes.txt": {
+			{"AAMV,DTA,50,Airport - Amargosa Valley,,3,,,\n", "NEW,DTA,60,\"Airport | Valley\nExpress\",,3,,,\n"},
+		},
+		"stops.txt": {
+			{"BEATTY_AIRPORT,Nye County Airport (Demo),,36.868446,-116.784582", "BEATTY_AIRPORT,Nye County Airport (Demo),,,"},
+		},
+	})
+	report := newTestReport(t, testutil.ExampleDir.URL, pathB)
+	// Stops without coordinates are not reported as moved
+	assert.Equal(t, 0, len(report.Stops.Moved))
+	var buf bytes.Buffer
+	if err := report.WriteMarkdown(&buf); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, buf.String(), "| added | NEW | 60 | Airport \\| Valley Express | 3 |\n")
+}
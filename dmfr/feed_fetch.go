@@ -17,6 +17,9 @@ type FeedFetch struct {
 	ResponseTtfbMs       tt.Int
 	ResponseTimeMs       tt.Int
 	ResponseSHA1         tt.String
+	ResponseETag         tt.String `db:"response_etag"`
+	ResponseLastModified tt.String
+	NotModified          bool   // server responded 304 Not Modified to a conditional request
 	FeedVersionID        tt.Int // optional field, don't use FeedVersionEntity
 	ValidationDurationMs tt.Int
 	UploadDurationMs     tt.Int
@@ -0,0 +1,39 @@
+package dmfr
+
+import (
+	"strconv"
+
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+// FeedVersionChanges is a summary of differences between a feed version and the previous version of the same feed.
+type FeedVersionChanges struct {
+	PreviousFeedVersionID int
+	AddedCount            tt.Counts // per file
+	RemovedCount          tt.Counts // per file
+	ChangedCount          tt.Counts // per file
+	RoutesAdded           tt.Strings
+	RoutesRemoved         tt.Strings
+	StopsAdded            tt.Strings
+	StopsRemoved          tt.Strings
+	tt.FeedVersionEntity
+	tt.DatabaseEntity
+	tt.Timestamps
+}
+
+// NewFeedVersionChanges returns an initialized FeedVersionChanges.
+func NewFeedVersionChanges() *FeedVersionChanges {
+	fvc := FeedVersionChanges{}
+	fvc.AddedCount = tt.Counts{}
+	fvc.RemovedCount = tt.Counts{}
+	fvc.ChangedCount = tt.Counts{}
+	return &fvc
+}
+
+func (fvc *FeedVersionChanges) EntityID() string {
+	return strconv.Itoa(fvc.ID)
+}
+
+func (FeedVersionChanges) TableName() string {
+	return "feed_version_changes"
+}
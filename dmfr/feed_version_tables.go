@@ -52,6 +52,7 @@ func GetFeedVersionTables() FeedVersionTables {
 			"feed_version_stop_onestop_ids",
 		},
 		ImportDerivedTables: []string{
+			"feed_version_changes",
 			"tl_feed_version_geometries",
 			"tl_route_headways",
 			"tl_agency_places",
@@ -80,6 +81,7 @@ func GetFeedVersionTables() FeedVersionTables {
 		GtfsAnonTables: []string{
 			"gtfs_stop_times",
 			"gtfs_stop_areas",
+			"gtfs_location_group_stops",
 			"gtfs_transfers",
 			"gtfs_calendar_dates",
 			"gtfs_feed_infos",
@@ -100,6 +102,9 @@ func GetFeedVersionTables() FeedVersionTables {
 			"gtfs_areas",
 			"gtfs_pathways",
 			"gtfs_fare_attributes",
+			"gtfs_booking_rules",
+			"gtfs_location_groups",
+			"gtfs_locations",
 			"gtfs_trips",
 			"gtfs_shapes",
 			"gtfs_calendars",
@@ -0,0 +1,86 @@
+package dmfr
+
+import (
+	"strconv"
+
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+// MdsTrip is a trip from an MDS provider trips endpoint.
+type MdsTrip struct {
+	FeedID        int
+	ProviderID    tt.String
+	DeviceID      tt.String
+	VehicleID     tt.String
+	VehicleType   tt.String
+	TripID        string
+	TripDuration  tt.Int // seconds
+	TripDistance  tt.Int // meters
+	StartTime     tt.Time
+	EndTime       tt.Time
+	StartGeometry tt.Point
+	EndGeometry   tt.Point
+	StartZone     tt.String // geohash of start location
+	EndZone       tt.String // geohash of end location
+	tt.DatabaseEntity
+	tt.Timestamps
+}
+
+func (ent *MdsTrip) EntityID() string {
+	return strconv.Itoa(ent.ID)
+}
+
+func (MdsTrip) TableName() string {
+	return "tl_mds_trips"
+}
+
+// MdsStatusChange is a vehicle event from an MDS provider status_changes endpoint.
+type MdsStatusChange struct {
+	FeedID       int
+	ProviderID   tt.String
+	DeviceID     tt.String
+	VehicleID    tt.String
+	VehicleType  tt.String
+	VehicleState tt.String
+	EventTypes   tt.Strings
+	EventTime    tt.Time
+	Geometry     tt.Point
+	Zone         tt.String // geohash of event location
+	BatteryPct   tt.Float
+	TripID       tt.String
+	tt.DatabaseEntity
+	tt.Timestamps
+}
+
+func (ent *MdsStatusChange) EntityID() string {
+	return strconv.Itoa(ent.ID)
+}
+
+func (MdsStatusChange) TableName() string {
+	return "tl_mds_status_changes"
+}
+
+// MdsVehicle is the most recent state of a vehicle from an MDS provider vehicles endpoint.
+type MdsVehicle struct {
+	FeedID         int
+	ProviderID     tt.String
+	DeviceID       tt.String
+	VehicleID      tt.String
+	VehicleType    tt.String
+	LastState      tt.String
+	LastEventTypes tt.Strings
+	LastEventTime  tt.Time
+	Geometry       tt.Point
+	Zone           tt.String // geohash of current location
+	BatteryPct     tt.Float
+	tt.DatabaseEntity
+	tt.Timestamps
+}
+
+func (ent *MdsVehicle) EntityID() string {
+	return strconv.Itoa(ent.ID)
+}
+
+func (MdsVehicle) TableName() string {
+	return "tl_mds_vehicles"
+}
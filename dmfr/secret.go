@@ -13,10 +13,15 @@ type Secret struct {
 	AWSRegion          string `json:"aws_region"`
 	AWSAccessKeyID     string `json:"aws_access_key_id"`
 	AWSSecretAccessKey string `json:"aws_secret_access_key"`
+	GCPCredentialsFile string `json:"gcp_credentials_file"`
 	FeedID             string `json:"feed_id"`
 	Filename           string `json:"filename"`
 	URLType            string `json:"url_type"`
 	ReplaceUrl         string `json:"replace_url"`
+	TokenURL           string `json:"token_url"`
+	ClientID           string `json:"client_id"`
+	ClientSecret       string `json:"client_secret"`
+	Scope              string `json:"scope"`
 }
 
 // MatchFilename finds secrets associated with a DMFR filename.
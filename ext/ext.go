@@ -20,6 +20,7 @@ type extensionFactory func(string) (Extension, error)
 
 var readerFactories = map[string]readerFactory{}
 var writerFactories = map[string]writerFactory{}
+var writerSuffixes = map[string]string{}
 var extensionFactories = map[string]extensionFactory{}
 
 // RegisterReader registers a Reader.
@@ -50,6 +51,17 @@ func RegisterWriter(name string, factory writerFactory) error {
 	return nil
 }
 
+// RegisterWriterSuffix registers a file suffix that selects a Writer when no scheme is provided.
+func RegisterWriterSuffix(suffix string, name string) error {
+	_, registered := writerSuffixes[suffix]
+	if registered {
+		return fmt.Errorf("writer suffix '%s' already registered", suffix)
+	}
+	log.Tracef("registering writer suffix: %s -> %s", suffix, name)
+	writerSuffixes[suffix] = name
+	return nil
+}
+
 // RegisterExtension registers an Extension.
 func RegisterExtension(name string, factory extensionFactory) error {
 	_, registered := extensionFactories[name]
@@ -86,12 +98,19 @@ func OpenReader(addr string) (adapters.Reader, error) {
 	return r, nil
 }
 
-// NewWriter uses the scheme prefix as the driver name, defaulting to csv.
+// NewWriter uses the scheme prefix as the driver name, then any registered file suffix, defaulting to csv.
 func NewWriter(addr string) (adapters.Writer, error) {
 	scheme := strings.Split(addr, "://")
 	driver := "csv"
 	if len(scheme) > 1 {
 		driver = scheme[0]
+	} else {
+		for suffix, name := range writerSuffixes {
+			if strings.HasSuffix(strings.ToLower(addr), suffix) {
+				driver = name
+				break
+			}
+		}
 	}
 	if f, ok := writerFactories[driver]; ok {
 		return f(addr)
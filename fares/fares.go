@@ -0,0 +1,428 @@
+// Package fares calculates journey fares from GTFS Fares v2 rules.
+package fares
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/service"
+)
+
+// DefaultMaxCandidates is the default limit on fare combinations evaluated for a request,
+// including paying separately for legs where a transfer rule applies.
+var DefaultMaxCandidates = 1000
+
+// Leg is a single transit leg of a journey.
+type Leg struct {
+	RouteID    string    `json:"route_id"`
+	FromStopID string    `json:"from_stop_id"`
+	ToStopID   string    `json:"to_stop_id"`
+	Departure  time.Time `json:"departure"`
+	Arrival    time.Time `json:"arrival"`
+}
+
+// Request is a journey to price.
+type Request struct {
+	Legs            []Leg  `json:"legs"`
+	RiderCategoryID string `json:"rider_category_id,omitempty"`
+	FareMediaID     string `json:"fare_media_id,omitempty"`
+}
+
+// ProductUse is a fare product paid for one or more legs.
+type ProductUse struct {
+	FareProductID   string  `json:"fare_product_id"`
+	FareProductName string  `json:"fare_product_name,omitempty"`
+	RiderCategoryID string  `json:"rider_category_id,omitempty"`
+	FareMediaID     string  `json:"fare_media_id,omitempty"`
+	Amount          float64 `json:"amount"`
+	Currency        string  `json:"currency"`
+	Legs            []int   `json:"legs"`
+	Transfer        bool    `json:"transfer"`
+}
+
+// Candidate is one way of paying for a journey.
+// Legs that did not match any fare leg rule are listed in UnmatchedLegs and are not included in Total.
+type Candidate struct {
+	Total         float64      `json:"total"`
+	Currency      string       `json:"currency"`
+	Products      []ProductUse `json:"products"`
+	UnmatchedLegs []int        `json:"unmatched_legs,omitempty"`
+}
+
+// Calculator evaluates the fare rules of a single feed.
+type Calculator struct {
+	MaxCandidates       int
+	products            map[string][]gtfs.FareProduct
+	legRules            []gtfs.FareLegRule
+	transferRules       []gtfs.FareTransferRule
+	timeframes          map[string][]gtfs.Timeframe
+	services            map[string]*service.Service
+	stopAreas           map[string][]string
+	stopParents         map[string]string
+	routeNetworks       map[string][]string
+	routeLocations      map[string]*time.Location
+	defaultLocation     *time.Location
+	defaultCategory     string
+	usePriority         bool
+	listedNetworks      map[string]bool
+	listedFromAreas     map[string]bool
+	listedToAreas       map[string]bool
+	listedFromLegGroups map[string]bool
+	listedToLegGroups   map[string]bool
+}
+
+// legOption is a matching fare leg rule and one of its eligible fare products.
+type legOption struct {
+	rule    gtfs.FareLegRule
+	product gtfs.FareProduct
+}
+
+// ErrTooManyCandidates is returned with the candidates found so far when MaxCandidates is reached.
+var ErrTooManyCandidates = errors.New("too many fare candidates, results are incomplete")
+
+// Calculate returns candidate fares for a journey, ordered by number of unmatched legs and then by total.
+// If MaxCandidates is reached, the candidates found so far are returned with ErrTooManyCandidates.
+func (c *Calculator) Calculate(req Request) ([]Candidate, error) {
+	if len(req.Legs) == 0 {
+		return nil, errors.New("no legs")
+	}
+	options := make([][]*legOption, len(req.Legs))
+	for i, leg := range req.Legs {
+		for _, rule := range c.matchLegRules(leg) {
+			for _, p := range c.eligibleProducts(rule.FareProductID.Val, req) {
+				options[i] = append(options[i], &legOption{rule: rule, product: p})
+			}
+		}
+		if len(options[i]) == 0 {
+			options[i] = []*legOption{nil}
+		}
+	}
+
+	// Evaluate each combination of leg options
+	maxCandidates := c.MaxCandidates
+	if maxCandidates <= 0 {
+		maxCandidates = DefaultMaxCandidates
+	}
+	var ret []Candidate
+	seen := map[string]bool{}
+	count := 0
+	truncated := false
+	emit := func(cand Candidate) bool {
+		if count >= maxCandidates {
+			truncated = true
+			return false
+		}
+		count++
+		if key := candidateKey(cand); !seen[key] {
+			seen[key] = true
+			ret = append(ret, cand)
+		}
+		return true
+	}
+	assignment := make([]*legOption, len(req.Legs))
+	var visit func(int) bool
+	visit = func(i int) bool {
+		if i == len(assignment) {
+			return c.evaluate(req, assignment, emit)
+		}
+		for _, opt := range options[i] {
+			assignment[i] = opt
+			if !visit(i + 1) {
+				return false
+			}
+		}
+		return true
+	}
+	visit(0)
+	sort.SliceStable(ret, func(i, j int) bool {
+		if a, b := len(ret[i].UnmatchedLegs), len(ret[j].UnmatchedLegs); a != b {
+			return a < b
+		}
+		return ret[i].Total < ret[j].Total
+	})
+	if truncated {
+		return ret, ErrTooManyCandidates
+	}
+	return ret, nil
+}
+
+// evalState is the state of evaluating a fare leg rule assignment, up to a leg.
+type evalState struct {
+	uses       []ProductUse
+	unmatched  []int
+	prev       *legOption
+	transfers  int
+	prevOwnUse bool // the last use is the previous leg's own fare product
+}
+
+// evaluate applies transfer rules to a fare leg rule for each leg.
+// Where a transfer rule applies, paying for the next leg separately is also evaluated.
+// Returns false if emit stopped the evaluation.
+func (c *Calculator) evaluate(req Request, assignment []*legOption, emit func(Candidate) bool) bool {
+	var step func(int, evalState) bool
+	step = func(i int, st evalState) bool {
+		if i == len(assignment) {
+			if cand, ok := newCandidate(st); ok {
+				return emit(cand)
+			}
+			return true
+		}
+		opt := assignment[i]
+		if opt == nil {
+			st.unmatched = append(slices.Clip(st.unmatched), i)
+			st.prev = nil
+			st.prevOwnUse = false
+			return step(i+1, st)
+		}
+		if st.prev != nil {
+			if tr, trProduct, ok := c.matchTransferRule(req, st.prev, opt, req.Legs[i-1], req.Legs[i], st.transfers); ok {
+				tst := st
+				tst.uses = slices.Clone(st.uses)
+				tst.transfers++
+				legs := []int{i - 1, i}
+				switch tr.FareTransferType.Val {
+				case 0:
+					// A + AB
+					if trProduct != nil {
+						tst.uses = append(tst.uses, newProductUse(*trProduct, legs, true))
+					}
+					tst.prevOwnUse = false
+				case 1:
+					// A + AB + B
+					if trProduct != nil {
+						tst.uses = append(tst.uses, newProductUse(*trProduct, legs, true))
+					}
+					tst.uses = append(tst.uses, newProductUse(opt.product, []int{i}, false))
+					tst.prevOwnUse = true
+				case 2:
+					// AB
+					if tst.prevOwnUse {
+						tst.uses = tst.uses[:len(tst.uses)-1]
+					}
+					if trProduct != nil {
+						tst.uses = append(tst.uses, newProductUse(*trProduct, legs, true))
+					}
+					tst.prevOwnUse = false
+				}
+				tst.prev = opt
+				if !step(i+1, tst) {
+					return false
+				}
+			}
+		}
+		// Pay for this leg separately
+		if opt.rule.TransferOnly.Val == 1 {
+			return true
+		}
+		st.uses = append(slices.Clip(st.uses), newProductUse(opt.product, []int{i}, false))
+		st.prev = opt
+		st.prevOwnUse = true
+		st.transfers = 0
+		return step(i+1, st)
+	}
+	return step(0, evalState{})
+}
+
+// newCandidate totals the fare products used for a journey.
+func newCandidate(st evalState) (Candidate, bool) {
+	cand := Candidate{Products: st.uses, UnmatchedLegs: st.unmatched}
+	for _, use := range st.uses {
+		if cand.Currency != "" && use.Currency != cand.Currency {
+			// Totals across currencies are not meaningful
+			return cand, false
+		}
+		cand.Currency = use.Currency
+		cand.Total += use.Amount
+	}
+	return cand, true
+}
+
+// matchLegRules returns the fare leg rules that apply to a leg.
+func (c *Calculator) matchLegRules(leg Leg) []gtfs.FareLegRule {
+	networks := c.routeNetworks[leg.RouteID]
+	fromAreas := c.areasForStop(leg.FromStopID)
+	toAreas := c.areasForStop(leg.ToStopID)
+	loc := c.routeLocations[leg.RouteID]
+	if loc == nil {
+		loc = c.defaultLocation
+	}
+	var ret []gtfs.FareLegRule
+	for _, rule := range c.legRules {
+		if !c.matchField(rule.NetworkID.Val, networks, c.listedNetworks) {
+			continue
+		}
+		if !c.matchField(rule.FromAreaID.Val, fromAreas, c.listedFromAreas) {
+			continue
+		}
+		if !c.matchField(rule.ToAreaID.Val, toAreas, c.listedToAreas) {
+			continue
+		}
+		if v := rule.FromTimeframeGroupID.Val; v != "" && !c.inTimeframe(v, leg.Departure.In(loc)) {
+			continue
+		}
+		if v := rule.ToTimeframeGroupID.Val; v != "" && !c.inTimeframe(v, leg.Arrival.In(loc)) {
+			continue
+		}
+		ret = append(ret, rule)
+	}
+	if !c.usePriority || len(ret) == 0 {
+		return ret
+	}
+	// Only the highest priority rules apply
+	maxPriority := ret[0].RulePriority.Val
+	for _, rule := range ret {
+		maxPriority = max(maxPriority, rule.RulePriority.Val)
+	}
+	return slices.DeleteFunc(ret, func(rule gtfs.FareLegRule) bool {
+		return rule.RulePriority.Val != maxPriority
+	})
+}
+
+// matchField checks a fare leg rule network or area against the values for a leg.
+// When rule_priority is not used, an empty rule value matches only values not listed by any other rule.
+func (c *Calculator) matchField(ruleValue string, values []string, listed map[string]bool) bool {
+	if ruleValue == "" {
+		if c.usePriority {
+			return true
+		}
+		for _, v := range values {
+			if listed[v] {
+				return false
+			}
+		}
+		return true
+	}
+	return slices.Contains(values, ruleValue)
+}
+
+// matchTransferRule returns the first transfer rule that applies between two legs, and its eligible fare product, if any.
+func (c *Calculator) matchTransferRule(req Request, from *legOption, to *legOption, fromLeg Leg, toLeg Leg, transfers int) (gtfs.FareTransferRule, *gtfs.FareProduct, bool) {
+	fromGroup := from.rule.LegGroupID.Val
+	toGroup := to.rule.LegGroupID.Val
+	for _, tr := range c.transferRules {
+		if v := tr.FromLegGroupID.Val; (v == "" && c.listedFromLegGroups[fromGroup]) || (v != "" && v != fromGroup) {
+			continue
+		}
+		if v := tr.ToLegGroupID.Val; (v == "" && c.listedToLegGroups[toGroup]) || (v != "" && v != toGroup) {
+			continue
+		}
+		if tr.TransferCount.Valid && tr.TransferCount.Val >= 0 && int64(transfers) >= tr.TransferCount.Val {
+			continue
+		}
+		if v := tr.FilterFareProductID.Val; v != "" && v != from.product.FareProductID.Val {
+			continue
+		}
+		if tr.DurationLimit.Valid && !withinDuration(tr, fromLeg, toLeg) {
+			continue
+		}
+		if tr.FareProductID.Val == "" {
+			return tr, nil, true
+		}
+		// Use the least expensive eligible transfer product
+		var trProduct *gtfs.FareProduct
+		for _, p := range c.eligibleProducts(tr.FareProductID.Val, req) {
+			if trProduct == nil || p.Amount.Val < trProduct.Amount.Val {
+				trProduct = &p
+			}
+		}
+		if trProduct != nil {
+			return tr, trProduct, true
+		}
+	}
+	return gtfs.FareTransferRule{}, nil, false
+}
+
+// eligibleProducts returns the fare products with this ID that match the requested rider category and fare media.
+func (c *Calculator) eligibleProducts(fareProductID string, req Request) []gtfs.FareProduct {
+	category := req.RiderCategoryID
+	if category == "" {
+		category = c.defaultCategory
+	}
+	var ret []gtfs.FareProduct
+	for _, p := range c.products[fareProductID] {
+		if v := p.RiderCategoryID.Val; v != "" && category != "" && v != category {
+			continue
+		}
+		if v := p.FareMediaID.Val; v != "" && req.FareMediaID != "" && v != req.FareMediaID {
+			continue
+		}
+		ret = append(ret, p)
+	}
+	return ret
+}
+
+// areasForStop returns the areas containing a stop or its parent station.
+func (c *Calculator) areasForStop(stopID string) []string {
+	ret := slices.Clone(c.stopAreas[stopID])
+	if parent, ok := c.stopParents[stopID]; ok {
+		ret = append(ret, c.stopAreas[parent]...)
+	}
+	return ret
+}
+
+// inTimeframe checks if a local time is within a timeframe group.
+func (c *Calculator) inTimeframe(group string, t time.Time) bool {
+	secs := t.Hour()*3600 + t.Minute()*60 + t.Second()
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	for _, tf := range c.timeframes[group] {
+		start, end := 0, 86400
+		if tf.StartTime.Valid {
+			start = tf.StartTime.Int()
+		}
+		if tf.EndTime.Valid {
+			end = tf.EndTime.Int()
+		}
+		if secs < start || secs >= end {
+			continue
+		}
+		if sid := tf.ServiceID.Val; sid != "" {
+			if svc, ok := c.services[sid]; !ok || !svc.IsActive(date) {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// withinDuration checks the duration_limit of a transfer rule.
+func withinDuration(tr gtfs.FareTransferRule, fromLeg Leg, toLeg Leg) bool {
+	var start, end time.Time
+	switch tr.DurationLimitType.Val {
+	case 0:
+		start, end = fromLeg.Departure, toLeg.Arrival
+	case 1:
+		start, end = fromLeg.Departure, toLeg.Departure
+	case 2:
+		start, end = fromLeg.Arrival, toLeg.Departure
+	case 3:
+		start, end = fromLeg.Arrival, toLeg.Arrival
+	}
+	return end.Sub(start) <= time.Duration(tr.DurationLimit.Val)*time.Second
+}
+
+func newProductUse(p gtfs.FareProduct, legs []int, transfer bool) ProductUse {
+	return ProductUse{
+		FareProductID:   p.FareProductID.Val,
+		FareProductName: p.FareProductName.Val,
+		RiderCategoryID: p.RiderCategoryID.Val,
+		FareMediaID:     p.FareMediaID.Val,
+		Amount:          p.Amount.Val,
+		Currency:        p.Currency.Val,
+		Legs:            legs,
+		Transfer:        transfer,
+	}
+}
+
+func candidateKey(cand Candidate) string {
+	var parts []string
+	for _, use := range cand.Products {
+		parts = append(parts, fmt.Sprintf("%s:%s:%s:%v:%t", use.FareProductID, use.RiderCategoryID, use.FareMediaID, use.Legs, use.Transfer))
+	}
+	return strings.Join(parts, ";") + fmt.Sprintf("|%v", cand.UnmatchedLegs)
+}
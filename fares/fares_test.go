@@ -0,0 +1,236 @@
+package fares
+
+import (
+	"testing"
+	"time"
+
+	"github.com/interline-io/transitland-lib/adapters/direct"
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/internal/testpath"
+	"github.com/interline-io/transitland-lib/tlcsv"
+	"github.com/interline-io/transitland-lib/tt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCalculator(t *testing.T) *Calculator {
+	reader, err := tlcsv.NewReader(testpath.RelPath("testdata/gtfs-examples/example-fares"))
+	require.NoError(t, err)
+	require.NoError(t, reader.Open())
+	defer reader.Close()
+	c, err := NewCalculatorFromReader(reader)
+	require.NoError(t, err)
+	return c
+}
+
+func TestCalculator_Calculate(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2008, 1, day, hour, minute, 0, 0, loc)
+	}
+	leg := func(route string, from string, to string, dep time.Time) Leg {
+		return Leg{RouteID: route, FromStopID: from, ToStopID: to, Departure: dep, Arrival: dep.Add(20 * time.Minute)}
+	}
+	c := newTestCalculator(t)
+	testcases := []struct {
+		name          string
+		req           Request
+		expectTotals  []float64
+		expectProduct []string // fare products of the first candidate
+		expectUnmatch []int    // unmatched legs of the first candidate
+	}{
+		{
+			name:          "local, any media",
+			req:           Request{Legs: []Leg{leg("CITY", "STAGECOACH", "NANAA", at(5, 8, 0))}},
+			expectTotals:  []float64{1.75, 2.00},
+			expectProduct: []string{"local_fare"},
+		},
+		{
+			name:          "local, cash",
+			req:           Request{Legs: []Leg{leg("CITY", "STAGECOACH", "NANAA", at(5, 8, 0))}, FareMediaID: "cash"},
+			expectTotals:  []float64{2.00},
+			expectProduct: []string{"local_fare"},
+		},
+		{
+			name:          "local, senior",
+			req:           Request{Legs: []Leg{leg("CITY", "STAGECOACH", "NANAA", at(5, 8, 0))}, RiderCategoryID: "senior"},
+			expectTotals:  []float64{1.00},
+			expectProduct: []string{"local_fare"},
+		},
+		{
+			name:          "regional, peak",
+			req:           Request{Legs: []Leg{leg("AB", "BEATTY_AIRPORT", "BULLFROG", at(5, 8, 0))}},
+			expectTotals:  []float64{5.00},
+			expectProduct: []string{"regional_peak"},
+		},
+		{
+			name:          "regional, off-peak",
+			req:           Request{Legs: []Leg{leg("AB", "BEATTY_AIRPORT", "BULLFROG", at(5, 10, 0))}},
+			expectTotals:  []float64{4.00},
+			expectProduct: []string{"regional_offpeak"},
+		},
+		{
+			name:          "regional, to area",
+			req:           Request{Legs: []Leg{leg("AAMV", "BEATTY_AIRPORT", "AMV", at(5, 10, 0))}},
+			expectTotals:  []float64{8.00},
+			expectProduct: []string{"valley_fare"},
+		},
+		{
+			name:          "regional, timeframe service not active",
+			req:           Request{Legs: []Leg{leg("AB", "BEATTY_AIRPORT", "BULLFROG", time.Date(2007, 6, 4, 8, 0, 0, 0, loc))}},
+			expectTotals:  []float64{0},
+			expectUnmatch: []int{0},
+		},
+		{
+			name: "local transfer",
+			req: Request{
+				Legs: []Leg{
+					leg("CITY", "STAGECOACH", "NANAA", at(5, 8, 0)),
+					leg("STBA", "STAGECOACH", "BEATTY_AIRPORT", at(5, 8, 30)),
+				},
+				FareMediaID: "card",
+			},
+			expectTotals:  []float64{1.75, 3.50},
+			expectProduct: []string{"local_fare"},
+		},
+		{
+			name: "local transfer, transfer count exceeded",
+			req: Request{
+				Legs: []Leg{
+					leg("CITY", "STAGECOACH", "NANAA", at(5, 8, 0)),
+					leg("CITY", "NANAA", "DADAN", at(5, 8, 30)),
+					leg("CITY", "DADAN", "EMSI", at(5, 9, 0)),
+				},
+				FareMediaID: "card",
+			},
+			expectTotals:  []float64{3.50, 3.50, 5.25},
+			expectProduct: []string{"local_fare", "local_fare"},
+		},
+		{
+			name: "local transfer, duration exceeded",
+			req: Request{
+				Legs: []Leg{
+					leg("CITY", "STAGECOACH", "NANAA", at(5, 8, 0)),
+					leg("CITY", "NANAA", "DADAN", at(5, 10, 0)),
+				},
+				FareMediaID: "card",
+			},
+			expectTotals:  []float64{3.50},
+			expectProduct: []string{"local_fare", "local_fare"},
+		},
+		{
+			name: "local to regional upgrade",
+			req: Request{
+				Legs: []Leg{
+					leg("STBA", "STAGECOACH", "BEATTY_AIRPORT", at(5, 8, 0)),
+					leg("AB", "BEATTY_AIRPORT", "BULLFROG", at(5, 8, 30)),
+				},
+				FareMediaID: "card",
+			},
+			expectTotals:  []float64{3.25, 6.75},
+			expectProduct: []string{"local_fare", "upgrade"},
+		},
+		{
+			name: "regional to local, no transfer rule",
+			req: Request{
+				Legs: []Leg{
+					leg("AB", "BEATTY_AIRPORT", "BULLFROG", at(5, 10, 0)),
+					leg("CITY", "STAGECOACH", "NANAA", at(5, 10, 30)),
+				},
+				FareMediaID: "card",
+			},
+			expectTotals:  []float64{5.75},
+			expectProduct: []string{"regional_offpeak", "local_fare"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cands, err := c.Calculate(tc.req)
+			require.NoError(t, err)
+			var totals []float64
+			for _, cand := range cands {
+				totals = append(totals, cand.Total)
+			}
+			assert.InDeltaSlice(t, tc.expectTotals, totals, 0.001)
+			if len(cands) == 0 {
+				return
+			}
+			var products []string
+			for _, use := range cands[0].Products {
+				products = append(products, use.FareProductID)
+			}
+			assert.Equal(t, tc.expectProduct, products)
+			assert.Equal(t, tc.expectUnmatch, cands[0].UnmatchedLegs)
+		})
+	}
+}
+
+func TestCalculator_NoLegs(t *testing.T) {
+	c := newTestCalculator(t)
+	_, err := c.Calculate(Request{})
+	assert.Error(t, err)
+}
+
+func TestCalculator_DatabaseReader(t *testing.T) {
+	// Database readers return references as internal IDs
+	reader := direct.NewReader()
+	route := gtfs.Route{RouteID: tt.NewString("r1"), AgencyID: tt.NewKey("1")}
+	route.ID = 2
+	reader.RouteList = []gtfs.Route{route}
+	agency := gtfs.Agency{AgencyID: tt.NewString("a1"), AgencyTimezone: tt.NewTimezone("America/Los_Angeles")}
+	agency.ID = 1
+	reader.AgencyList = []gtfs.Agency{agency}
+	network := gtfs.Network{NetworkID: tt.NewString("n1")}
+	network.ID = 3
+	reader.NetworkList = []gtfs.Network{network}
+	reader.RouteNetworkList = []gtfs.RouteNetwork{{RouteID: tt.NewKey("2"), NetworkID: tt.NewKey("3")}}
+	reader.FareProductList = []gtfs.FareProduct{
+		{FareProductID: tt.NewString("single"), Amount: tt.NewCurrencyAmount(2), Currency: tt.NewCurrency("USD")},
+		{FareProductID: tt.NewString("network"), Amount: tt.NewCurrencyAmount(3), Currency: tt.NewCurrency("USD")},
+		{FareProductID: tt.NewString("pass"), Amount: tt.NewCurrencyAmount(4), Currency: tt.NewCurrency("USD")},
+	}
+	reader.FareLegRuleList = []gtfs.FareLegRule{
+		{LegGroupID: tt.NewString("g1"), FareProductID: tt.NewString("single")},
+		{LegGroupID: tt.NewString("g1"), NetworkID: tt.NewString("3"), FareProductID: tt.NewString("network"), RulePriority: tt.NewInt(1)},
+	}
+	reader.FareTransferRuleList = []gtfs.FareTransferRule{
+		{FromLegGroupID: tt.NewString("g1"), ToLegGroupID: tt.NewString("g1"), TransferCount: tt.NewInt(-1), FareTransferType: tt.NewInt(2), FareProductID: tt.NewString("pass")},
+	}
+	c, err := NewCalculatorFromReader(reader)
+	require.NoError(t, err)
+	dep := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	cands, err := c.Calculate(Request{Legs: []Leg{
+		{RouteID: "r1", Departure: dep, Arrival: dep.Add(10 * time.Minute)},
+		{RouteID: "r1", Departure: dep.Add(20 * time.Minute), Arrival: dep.Add(30 * time.Minute)},
+		{RouteID: "r1", Departure: dep.Add(40 * time.Minute), Arrival: dep.Add(50 * time.Minute)},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, 4, len(cands))
+	// Only the highest priority rule applies; the transfer product replaces the previous leg fare
+	var totals []float64
+	for _, cand := range cands {
+		totals = append(totals, cand.Total)
+	}
+	assert.InDeltaSlice(t, []float64{7, 7, 8, 9}, totals, 0.001)
+	if assert.Equal(t, 2, len(cands[2].Products)) {
+		assert.Equal(t, "pass", cands[2].Products[0].FareProductID)
+		assert.Equal(t, []int{0, 1}, cands[2].Products[0].Legs)
+		assert.Equal(t, []int{1, 2}, cands[2].Products[1].Legs)
+	}
+	// Paying for each leg separately is also a candidate
+	for _, use := range cands[3].Products {
+		assert.Equal(t, "network", use.FareProductID)
+		assert.False(t, use.Transfer)
+	}
+
+	// Truncated results are returned with an error
+	c.MaxCandidates = 2
+	cands, err = c.Calculate(Request{Legs: []Leg{
+		{RouteID: "r1", Departure: dep, Arrival: dep.Add(10 * time.Minute)},
+		{RouteID: "r1", Departure: dep.Add(20 * time.Minute), Arrival: dep.Add(30 * time.Minute)},
+		{RouteID: "r1", Departure: dep.Add(40 * time.Minute), Arrival: dep.Add(50 * time.Minute)},
+	}})
+	assert.ErrorIs(t, err, ErrTooManyCandidates)
+	assert.Equal(t, 2, len(cands))
+}
@@ -0,0 +1,189 @@
+package fares
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/interline-io/transitland-lib/adapters"
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/service"
+	"github.com/interline-io/transitland-lib/tldb"
+)
+
+// idMap maps internal database IDs to GTFS IDs.
+// Database readers return references as internal IDs; CSV readers return GTFS IDs and leave entity IDs unset.
+type idMap map[string]string
+
+func (m idMap) add(id int, gtfsID string) {
+	if id > 0 {
+		m[strconv.Itoa(id)] = gtfsID
+	}
+}
+
+func (m idMap) get(v string) string {
+	if gtfsID, ok := m[v]; ok {
+		return gtfsID
+	}
+	return v
+}
+
+// NewCalculatorFromReader loads fare rules and the entities they reference from a reader.
+func NewCalculatorFromReader(reader adapters.Reader) (*Calculator, error) {
+	c := &Calculator{
+		products:            map[string][]gtfs.FareProduct{},
+		timeframes:          map[string][]gtfs.Timeframe{},
+		services:            map[string]*service.Service{},
+		stopAreas:           map[string][]string{},
+		stopParents:         map[string]string{},
+		routeNetworks:       map[string][]string{},
+		routeLocations:      map[string]*time.Location{},
+		defaultLocation:     time.UTC,
+		listedNetworks:      map[string]bool{},
+		listedFromAreas:     map[string]bool{},
+		listedToAreas:       map[string]bool{},
+		listedFromLegGroups: map[string]bool{},
+		listedToLegGroups:   map[string]bool{},
+	}
+
+	// Agencies and routes
+	agencyLocations := map[string]*time.Location{}
+	agencyIDs := idMap{}
+	for ent := range reader.Agencies() {
+		loc, err := time.LoadLocation(ent.AgencyTimezone.Val)
+		if err != nil {
+			return nil, err
+		}
+		agencyIDs.add(ent.ID, ent.AgencyID.Val)
+		agencyLocations[ent.AgencyID.Val] = loc
+		c.defaultLocation = loc
+	}
+	routeIDs := idMap{}
+	for ent := range reader.Routes() {
+		routeIDs.add(ent.ID, ent.RouteID.Val)
+		if loc, ok := agencyLocations[agencyIDs.get(ent.AgencyID.Val)]; ok {
+			c.routeLocations[ent.RouteID.Val] = loc
+		}
+		if ent.NetworkID.Val != "" {
+			c.routeNetworks[ent.RouteID.Val] = append(c.routeNetworks[ent.RouteID.Val], ent.NetworkID.Val)
+		}
+	}
+	networkIDs := idMap{}
+	for ent := range reader.Networks() {
+		networkIDs.add(ent.ID, ent.NetworkID.Val)
+	}
+	for ent := range reader.RouteNetworks() {
+		rid := routeIDs.get(ent.RouteID.Val)
+		c.routeNetworks[rid] = append(c.routeNetworks[rid], networkIDs.get(ent.NetworkID.Val))
+	}
+
+	// Stops and areas
+	stopIDs := idMap{}
+	for ent := range reader.Stops() {
+		stopIDs.add(ent.ID, ent.StopID.Val)
+		if ent.ParentStation.Val != "" {
+			c.stopParents[ent.StopID.Val] = ent.ParentStation.Val
+		}
+	}
+	for k, v := range c.stopParents {
+		c.stopParents[k] = stopIDs.get(v)
+	}
+	areaIDs := idMap{}
+	for ent := range reader.Areas() {
+		areaIDs.add(ent.ID, ent.AreaID.Val)
+	}
+	for ent := range reader.StopAreas() {
+		sid := stopIDs.get(ent.StopID.Val)
+		c.stopAreas[sid] = append(c.stopAreas[sid], areaIDs.get(ent.AreaID.Val))
+	}
+
+	// Services and timeframes
+	serviceIDs := idMap{}
+	calendars, err := readCalendars(reader)
+	if err != nil {
+		return nil, err
+	}
+	for _, ent := range calendars {
+		serviceIDs.add(ent.ID, ent.ServiceID.Val)
+		c.services[ent.ServiceID.Val] = service.NewService(ent)
+	}
+	for ent := range reader.CalendarDates() {
+		sid := serviceIDs.get(ent.ServiceID.Val)
+		svc, ok := c.services[sid]
+		if !ok {
+			svc = service.NewService(gtfs.Calendar{})
+			svc.ServiceID.Set(sid)
+			c.services[sid] = svc
+		}
+		svc.AddCalendarDate(ent)
+	}
+	for ent := range reader.Timeframes() {
+		ent.ServiceID.Val = serviceIDs.get(ent.ServiceID.Val)
+		c.timeframes[ent.TimeframeGroupID.Val] = append(c.timeframes[ent.TimeframeGroupID.Val], ent)
+	}
+
+	// Fare media, products and rules
+	fareMediaIDs := idMap{}
+	for ent := range reader.FareMedia() {
+		fareMediaIDs.add(ent.ID, ent.FareMediaID.Val)
+	}
+	for ent := range reader.RiderCategories() {
+		if ent.IsDefaultFareCategory.Val == 1 {
+			c.defaultCategory = ent.RiderCategoryID.Val
+		}
+	}
+	for ent := range reader.FareProducts() {
+		ent.FareMediaID.Val = fareMediaIDs.get(ent.FareMediaID.Val)
+		c.products[ent.FareProductID.Val] = append(c.products[ent.FareProductID.Val], ent)
+	}
+	for ent := range reader.FareLegRules() {
+		ent.NetworkID.Val = networkIDs.get(ent.NetworkID.Val)
+		ent.FromAreaID.Val = areaIDs.get(ent.FromAreaID.Val)
+		ent.ToAreaID.Val = areaIDs.get(ent.ToAreaID.Val)
+		c.listedNetworks[ent.NetworkID.Val] = true
+		c.listedFromAreas[ent.FromAreaID.Val] = true
+		c.listedToAreas[ent.ToAreaID.Val] = true
+		if ent.RulePriority.Valid {
+			c.usePriority = true
+		}
+		c.legRules = append(c.legRules, ent)
+	}
+	for ent := range reader.FareTransferRules() {
+		c.listedFromLegGroups[ent.FromLegGroupID.Val] = true
+		c.listedToLegGroups[ent.ToLegGroupID.Val] = true
+		c.transferRules = append(c.transferRules, ent)
+	}
+	// Empty values are wildcards, not listed values
+	for _, m := range []map[string]bool{c.listedNetworks, c.listedFromAreas, c.listedToAreas, c.listedFromLegGroups, c.listedToLegGroups} {
+		delete(m, "")
+	}
+	return c, nil
+}
+
+// readCalendars reads calendars from a reader.
+// Database readers select only the columns used for service dates:
+// the generated flag is a bool column that SQLite returns as an integer.
+func readCalendars(reader adapters.Reader) ([]gtfs.Calendar, error) {
+	dbReader, ok := reader.(*tldb.Reader)
+	if !ok {
+		var ret []gtfs.Calendar
+		for ent := range reader.Calendars() {
+			ret = append(ret, ent)
+		}
+		return ret, nil
+	}
+	qstr, args, err := dbReader.Where().
+		RemoveColumns().
+		Columns("id", "service_id", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday", "start_date", "end_date").
+		From("gtfs_calendars").
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var ret []gtfs.Calendar
+	if err := dbReader.Adapter.Select(context.TODO(), &ret, qstr, args...); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
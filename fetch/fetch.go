@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/interline-io/transitland-lib/dmfr"
 	"github.com/interline-io/transitland-lib/request"
+	"github.com/interline-io/transitland-lib/server/metrics"
 	"github.com/interline-io/transitland-lib/tldb"
 	"github.com/interline-io/transitland-lib/tt"
 )
@@ -25,8 +27,20 @@ type Options struct {
 	HideURL         bool
 	FetchedAt       time.Time
 	Secrets         []dmfr.Secret
+	FetchMetric     metrics.FetchMetric
+	// Unconditional disables sending cache validators from the previous fetch.
+	// Realtime fetches are always unconditional.
+	Unconditional bool
+	// HostLimiter, if set, limits requests per host; feeds may override limits with tags
+	HostLimiter *request.HostLimiter
 }
 
+// Feed tags that override HostLimiter defaults for requests made for a feed.
+const (
+	TagFetchMaxConcurrency    = "fetch_max_concurrency"
+	TagFetchRequestsPerMinute = "fetch_requests_per_minute"
+)
+
 // Result contains results of a fetch operation.
 type Result struct {
 	Found          bool
@@ -37,6 +51,7 @@ type Result struct {
 	ResponseTtfbMs int
 	ResponseTimeMs int
 	ResponseSHA1   string
+	NotModified    bool
 	FetchError     error
 	FeedVersionID  tt.Int
 }
@@ -80,6 +95,9 @@ func Fetch(ctx context.Context, atx tldb.Adapter, opts Options, cb FetchValidato
 	if opts.MaxSize > 0 {
 		reqOpts = append(reqOpts, request.WithMaxSize(opts.MaxSize))
 	}
+	if opts.HostLimiter != nil {
+		reqOpts = append(reqOpts, request.WithHostLimiter(opts.HostLimiter), request.WithHostLimit(FeedHostLimit(feed)))
+	}
 	// Get secret and set auth
 	if feed.Authorization.Type != "" {
 		secret, err := feed.MatchSecrets(opts.Secrets, opts.URLType)
@@ -90,6 +108,19 @@ func Fetch(ctx context.Context, atx tldb.Adapter, opts Options, cb FetchValidato
 		reqOpts = append(reqOpts, request.WithAuth(secret, feed.Authorization))
 	}
 
+	// Send cache validators from the last successful fetch of this url
+	var lastFetch *dmfr.FeedFetch
+	if !opts.Unconditional {
+		var err error
+		lastFetch, err = lastConditionalFetch(ctx, atx, feed.ID, opts)
+		if err != nil {
+			return result, err
+		}
+		if lastFetch != nil {
+			reqOpts = append(reqOpts, request.WithValidators(lastFetch.ResponseETag.Val, lastFetch.ResponseLastModified.Val))
+		}
+	}
+
 	// Fetch
 	tmpfile, fetchResponse, fetchFatalError := request.AuthenticatedRequestDownload(ctx, opts.FeedURL, reqOpts...)
 
@@ -105,8 +136,10 @@ func Fetch(ctx context.Context, atx tldb.Adapter, opts Options, cb FetchValidato
 	result.ResponseSHA1 = fetchResponse.ResponseSHA1
 	result.ResponseTimeMs = fetchResponse.ResponseTimeMs
 	result.ResponseTtfbMs = fetchResponse.ResponseTtfbMs
+	result.NotModified = fetchResponse.NotModified
 	if fetchFatalError != nil {
 		// Fatal error
+		opts.addFetchMetric(false)
 		return result, fetchFatalError
 	}
 
@@ -115,7 +148,22 @@ func Fetch(ctx context.Context, atx tldb.Adapter, opts Options, cb FetchValidato
 	newFile := false
 	uploadFile := ""
 	uploadDest := ""
-	if result.FetchError == nil {
+	if result.NotModified && lastFetch != nil {
+		// Nothing to validate; carry forward details from the last fetch
+		result.Found = true
+		result.ResponseSHA1 = lastFetch.ResponseSHA1.Val
+		if lastFetch.FeedVersionID.Valid {
+			result.FeedVersionID.Set(lastFetch.FeedVersionID.Val)
+		}
+		if fetchResponse.ResponseETag == "" {
+			fetchResponse.ResponseETag = lastFetch.ResponseETag.Val
+		}
+		if fetchResponse.ResponseLastModified == "" {
+			fetchResponse.ResponseLastModified = lastFetch.ResponseLastModified.Val
+		}
+	} else if result.NotModified {
+		result.FetchError = errors.New("unexpected not modified response")
+	} else if result.FetchError == nil {
 		vr, err := cb.ValidateResponse(ctx, atx, tmpfile, fetchResponse)
 		if err != nil {
 			return result, err
@@ -165,6 +213,9 @@ func Fetch(ctx context.Context, atx tldb.Adapter, opts Options, cb FetchValidato
 	tlfetch.ResponseTimeMs.SetInt(result.ResponseTimeMs)
 	tlfetch.ResponseTtfbMs.SetInt(result.ResponseTtfbMs)
 	tlfetch.ResponseSHA1.Set(result.ResponseSHA1)
+	tlfetch.ResponseETag.Set(fetchResponse.ResponseETag)
+	tlfetch.ResponseLastModified.Set(fetchResponse.ResponseLastModified)
+	tlfetch.NotModified = result.NotModified
 
 	// Save timing details
 	tlfetch.ValidationDurationMs.SetInt(int(validationDuration.Milliseconds()))
@@ -183,8 +234,47 @@ func Fetch(ctx context.Context, atx tldb.Adapter, opts Options, cb FetchValidato
 		tlfetch.Success = false
 		tlfetch.FetchError.Set(result.FetchError.Error())
 	}
+	opts.addFetchMetric(tlfetch.Success)
 	if _, err := atx.Insert(ctx, &tlfetch); err != nil {
 		return result, err
 	}
 	return result, nil
 }
+
+// FeedHostLimit returns any per-host limits set in feed tags.
+func FeedHostLimit(feed dmfr.Feed) request.HostLimit {
+	limit := request.HostLimit{}
+	if v, ok := feed.Tags.Get(TagFetchMaxConcurrency); ok {
+		limit.MaxConcurrency, _ = strconv.Atoi(v)
+	}
+	if v, ok := feed.Tags.Get(TagFetchRequestsPerMinute); ok {
+		limit.RequestsPerMinute, _ = strconv.Atoi(v)
+	}
+	return limit
+}
+
+// lastConditionalFetch returns the most recent successful fetch of the same url that has cache validators, if any.
+func lastConditionalFetch(ctx context.Context, atx tldb.Adapter, feedID int, opts Options) (*dmfr.FeedFetch, error) {
+	if opts.HideURL {
+		// Fetch records do not include the url, so we can not tell if it has changed
+		return nil, nil
+	}
+	var ents []dmfr.FeedFetch
+	if err := atx.Select(ctx, &ents, `SELECT * FROM feed_fetches WHERE feed_id = ? AND url_type = ? AND url = ? AND success = ? ORDER BY fetched_at DESC, id DESC LIMIT 1`, feedID, opts.URLType, opts.FeedURL, true); err != nil {
+		return nil, err
+	}
+	if len(ents) == 0 {
+		return nil, nil
+	}
+	ent := ents[0]
+	if ent.ResponseETag.Val == "" && ent.ResponseLastModified.Val == "" {
+		return nil, nil
+	}
+	return &ent, nil
+}
+
+func (opts Options) addFetchMetric(success bool) {
+	if opts.FetchMetric != nil {
+		opts.FetchMetric.AddFetch(opts.URLType, success)
+	}
+}
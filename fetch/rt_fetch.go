@@ -35,7 +35,11 @@ func NewRTFetchValidator(opts RTFetchOptions) *RTFetchValidator {
 }
 
 func (r *RTFetchValidator) Fetch(ctx context.Context, atx tldb.Adapter) (RTFetchResult, error) {
-	result, err := Fetch(ctx, atx, r.RTFetchOptions.Options, r)
+	// Realtime messages are not stored for reuse, so a 304 response has nothing to return;
+	// this also avoids looking up the previous fetch on every poll.
+	opts := r.RTFetchOptions.Options
+	opts.Unconditional = true
+	result, err := Fetch(ctx, atx, opts, r)
 	if err != nil {
 		log.For(ctx).Error().Err(err).Msg("fatal error during rt fetch")
 	}
@@ -6,10 +6,12 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/interline-io/transitland-lib/dmfr"
 	"github.com/interline-io/transitland-lib/internal/testdb"
 	"github.com/interline-io/transitland-lib/internal/testpath"
+	"github.com/interline-io/transitland-lib/request"
 	"github.com/interline-io/transitland-lib/tldb"
 	"github.com/stretchr/testify/assert"
 )
@@ -80,3 +82,102 @@ func TestRTFetch(t *testing.T) {
 		})
 	}
 }
+
+func TestRTFetch_NotModified(t *testing.T) {
+	etag := `"example"`
+	alwaysNotModified := false
+	var conditional []bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match") != "")
+		w.Header().Set("ETag", etag)
+		if alwaysNotModified || r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		buf, err := os.ReadFile(testpath.RelPath("testdata/rt/example.pb"))
+		if err != nil {
+			t.Error(err)
+		}
+		w.Write(buf)
+	}))
+	defer ts.Close()
+	ctx := context.TODO()
+	testdb.TempSqlite(func(atx tldb.Adapter) error {
+		url := ts.URL + "/example.pb"
+		feed := testdb.CreateTestFeed(atx, url)
+		opts := RTFetchOptions{Options: Options{FeedID: feed.ID, FeedURL: url}}
+		// Validators from the previous fetch are not sent
+		for i := 0; i < 2; i++ {
+			fr, err := RTFetch(ctx, atx, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Nil(t, fr.FetchError)
+			assert.False(t, fr.NotModified)
+			if assert.NotNil(t, fr.Message) {
+				assert.Equal(t, 26, len(fr.Message.Entity))
+			}
+		}
+		assert.Equal(t, []bool{false, false}, conditional)
+		// An unrequested 304 response is an error and has no message
+		alwaysNotModified = true
+		fr, err := RTFetch(ctx, atx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, fr.NotModified)
+		assert.NotNil(t, fr.FetchError)
+		assert.Nil(t, fr.Message)
+		return nil
+	})
+}
+
+func TestRTFetch_HostLimiter(t *testing.T) {
+	ctx := context.TODO()
+	tcs := []struct {
+		name     string
+		limit    request.HostLimit
+		feedTags map[string]string
+	}{
+		{"default limit", request.HostLimit{RequestsPerMinute: 600}, nil},
+		{"feed tag limit", request.HostLimit{}, map[string]string{TagFetchRequestsPerMinute: "600"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var requestTimes []time.Time
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestTimes = append(requestTimes, time.Now())
+				buf, err := os.ReadFile(testpath.RelPath("testdata/rt/example.pb"))
+				if err != nil {
+					t.Error(err)
+				}
+				w.Write(buf)
+			}))
+			defer ts.Close()
+			testdb.TempSqlite(func(atx tldb.Adapter) error {
+				url := ts.URL + "/example.pb"
+				feed := dmfr.Feed{FeedID: "test-rt"}
+				for k, v := range tc.feedTags {
+					feed.Tags.Set(k, v)
+					feed.Tags.Valid = true
+				}
+				feed.ID = testdb.ShouldInsert(t, atx, &feed)
+				limiter := request.NewHostLimiter(tc.limit)
+				for i := 0; i < 3; i++ {
+					fr, err := RTFetch(ctx, atx, RTFetchOptions{Options: Options{FeedID: feed.ID, FeedURL: url, Storage: t.TempDir(), HostLimiter: limiter}})
+					if err != nil {
+						t.Fatal(err)
+					}
+					assert.Nil(t, fr.FetchError)
+				}
+				return nil
+			})
+			// 600 requests per minute is one request every 100ms
+			if assert.Equal(t, 3, len(requestTimes)) {
+				for i := 1; i < len(requestTimes); i++ {
+					assert.GreaterOrEqual(t, requestTimes[i].Sub(requestTimes[i-1]), 90*time.Millisecond)
+				}
+			}
+		})
+	}
+}
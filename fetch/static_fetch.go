@@ -57,6 +57,16 @@ func (sfv *StaticFetchValidator) Fetch(ctx context.Context, atx tldb.Adapter) (S
 	fetchResult, err := Fetch(ctx, atx, sfv.StaticFetchOptions.Options, sfv)
 	if err != nil {
 		log.For(ctx).Error().Err(err).Msg("fatal error during static fetch")
+	} else if fetchResult.NotModified && fetchResult.FeedVersionID.Valid {
+		// Load the existing feed version
+		fv := dmfr.FeedVersion{}
+		fv.ID = fetchResult.FeedVersionID.Int()
+		if findErr := atx.Find(ctx, &fv); findErr != nil {
+			log.For(ctx).Error().Err(findErr).Msg("could not load feed version for not modified response")
+			err = findErr
+		} else {
+			sfv.FeedVersion = &fv
+		}
 	}
 	staticFetchResult := StaticFetchResult{
 		Result:                     fetchResult,
@@ -70,6 +80,19 @@ func (sfv *StaticFetchValidator) ValidateResponse(ctx context.Context, atx tldb.
 	opts := sfv.StaticFetchOptions
 	fetchValidationResult := FetchValidationResult{}
 
+	// If the response body matches an existing feed version, skip opening the archive
+	if !strings.Contains(opts.FeedURL, "#") && fetchResponse.ResponseSHA1 != "" {
+		if checkFv, err := checkFeedVersion(ctx, atx, fetchResponse.ResponseSHA1, ""); err != nil {
+			// Fatal error
+			return fetchValidationResult, err
+		} else if checkFv != nil {
+			fetchValidationResult.Found = true
+			fetchValidationResult.FeedVersionID.SetInt(checkFv.ID)
+			sfv.FeedVersion = checkFv
+			return fetchValidationResult, nil
+		}
+	}
+
 	// Open reader
 	fragment := ""
 	readerPath := fn
@@ -211,9 +234,16 @@ func (sfv *StaticFetchValidator) ValidateResponse(ctx context.Context, atx tldb.
 }
 
 // Is this SHA1 already present?
+// checkFeedVersion returns an existing feed version matching the SHA1, or the directory SHA1 if not empty.
 func checkFeedVersion(ctx context.Context, atx tldb.Adapter, sha1 string, sha1dir string) (*dmfr.FeedVersion, error) {
 	checkFeedVersion := dmfr.FeedVersion{}
-	err := atx.Get(ctx, &checkFeedVersion, "SELECT * FROM feed_versions WHERE sha1 = ? OR sha1_dir = ? LIMIT 1", sha1, sha1dir)
+	q := "SELECT * FROM feed_versions WHERE sha1 = ? LIMIT 1"
+	args := []any{sha1}
+	if sha1dir != "" {
+		q = "SELECT * FROM feed_versions WHERE sha1 = ? OR sha1_dir = ? LIMIT 1"
+		args = append(args, sha1dir)
+	}
+	err := atx.Get(ctx, &checkFeedVersion, q, args...)
 	if err == nil {
 		return &checkFeedVersion, nil
 	} else if err == sql.ErrNoRows {
@@ -432,3 +432,61 @@ func TestStaticStateFetch_HideURL(t *testing.T) {
 		return nil
 	})
 }
+
+func TestStaticFetch_NotModified(t *testing.T) {
+	etag := `"example"`
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		buf, err := os.ReadFile(ExampleZip.URL)
+		if err != nil {
+			t.Error(err)
+		}
+		w.Write(buf)
+	}))
+	defer ts.Close()
+	ctx := context.TODO()
+	testdb.TempSqlite(func(atx tldb.Adapter) error {
+		feed := testdb.CreateTestFeed(atx, ts.URL)
+		tmpdir := t.TempDir()
+		fr1, err := StaticFetch(ctx, atx, StaticFetchOptions{Options: Options{FeedID: feed.ID, FeedURL: ts.URL, Storage: tmpdir}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.False(t, fr1.NotModified)
+		fr2, err := StaticFetch(ctx, atx, StaticFetchOptions{Options: Options{FeedID: feed.ID, FeedURL: ts.URL, Storage: tmpdir}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 2, requests)
+		assert.True(t, fr2.NotModified)
+		assert.True(t, fr2.Found)
+		assert.Nil(t, fr2.FetchError)
+		assert.Equal(t, 304, fr2.ResponseCode)
+		assert.Equal(t, fr1.FeedVersion.ID, fr2.FeedVersionID.Int())
+		if assert.NotNil(t, fr2.FeedVersion) {
+			assert.Equal(t, ExampleZip.SHA1, fr2.FeedVersion.SHA1)
+		}
+		// Check FeedFetch record
+		tlff := dmfr.FeedFetch{}
+		testdb.ShouldGet(t, atx, &tlff, `SELECT * FROM feed_fetches WHERE feed_id = ? ORDER BY id DESC LIMIT 1`, feed.ID)
+		assert.True(t, tlff.NotModified)
+		assert.True(t, tlff.Success)
+		assert.Equal(t, etag, tlff.ResponseETag.Val)
+		assert.Equal(t, ExampleZip.SHA1, tlff.ResponseSHA1.Val)
+		assert.Equal(t, fr1.FeedVersion.ID, tlff.FeedVersionID.Int())
+		// Unconditional fetch downloads again
+		fr3, err := StaticFetch(ctx, atx, StaticFetchOptions{Options: Options{FeedID: feed.ID, FeedURL: ts.URL, Storage: tmpdir, Unconditional: true}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.False(t, fr3.NotModified)
+		assert.True(t, fr3.Found)
+		return nil
+	})
+}
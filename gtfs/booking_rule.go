@@ -0,0 +1,94 @@
+package gtfs
+
+import (
+	"github.com/interline-io/transitland-lib/causes"
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+// BookingRule booking_rules.txt
+type BookingRule struct {
+	BookingRuleID          tt.String `csv:",required"`
+	BookingType            tt.Int    `csv:",required" enum:"0,1,2"`
+	PriorNoticeDurationMin tt.Int    `range:"0,"`
+	PriorNoticeDurationMax tt.Int    `range:"0,"`
+	PriorNoticeLastDay     tt.Int    `range:"0,"`
+	PriorNoticeLastTime    tt.Seconds
+	PriorNoticeStartDay    tt.Int `range:"0,"`
+	PriorNoticeStartTime   tt.Seconds
+	PriorNoticeServiceID   tt.Key `target:"calendar.txt"`
+	Message                tt.String
+	PickupMessage          tt.String
+	DropOffMessage         tt.String
+	PhoneNumber            tt.String
+	InfoURL                tt.Url
+	BookingURL             tt.Url
+	tt.BaseEntity
+}
+
+// EntityID returns the ID or BookingRuleID.
+func (ent *BookingRule) EntityID() string {
+	return entID(ent.ID, ent.BookingRuleID.Val)
+}
+
+// EntityKey returns the GTFS identifier.
+func (ent *BookingRule) EntityKey() string {
+	return ent.BookingRuleID.Val
+}
+
+// Filename booking_rules.txt
+func (ent *BookingRule) Filename() string {
+	return "booking_rules.txt"
+}
+
+// TableName gtfs_booking_rules
+func (ent *BookingRule) TableName() string {
+	return "gtfs_booking_rules"
+}
+
+func (ent *BookingRule) ConditionalErrors() (errs []error) {
+	bt := ent.BookingType.Val
+	// prior_notice_duration_min: required for booking_type=1, forbidden otherwise
+	if bt == 1 && !ent.PriorNoticeDurationMin.Valid {
+		errs = append(errs, causes.NewConditionallyRequiredFieldError("prior_notice_duration_min"))
+	} else if bt != 1 && ent.PriorNoticeDurationMin.Valid {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("prior_notice_duration_min", ent.PriorNoticeDurationMin.String(), "only allowed when booking_type is 1"))
+	}
+	// prior_notice_duration_max: forbidden for booking_type=0 and booking_type=2
+	if bt != 1 && ent.PriorNoticeDurationMax.Valid {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("prior_notice_duration_max", ent.PriorNoticeDurationMax.String(), "only allowed when booking_type is 1"))
+	}
+	if ent.PriorNoticeDurationMin.Valid && ent.PriorNoticeDurationMax.Valid && ent.PriorNoticeDurationMax.Val < ent.PriorNoticeDurationMin.Val {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("prior_notice_duration_max", ent.PriorNoticeDurationMax.String(), "must be greater than or equal to prior_notice_duration_min"))
+	}
+	// prior_notice_last_day: required for booking_type=2, forbidden otherwise
+	if bt == 2 && !ent.PriorNoticeLastDay.Valid {
+		errs = append(errs, causes.NewConditionallyRequiredFieldError("prior_notice_last_day"))
+	} else if bt != 2 && ent.PriorNoticeLastDay.Valid {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("prior_notice_last_day", ent.PriorNoticeLastDay.String(), "only allowed when booking_type is 2"))
+	}
+	// prior_notice_last_time: required if prior_notice_last_day is defined, forbidden otherwise
+	if ent.PriorNoticeLastDay.Valid && !ent.PriorNoticeLastTime.Valid {
+		errs = append(errs, causes.NewConditionallyRequiredFieldError("prior_notice_last_time"))
+	} else if !ent.PriorNoticeLastDay.Valid && ent.PriorNoticeLastTime.Valid {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("prior_notice_last_time", ent.PriorNoticeLastTime.String(), "requires prior_notice_last_day"))
+	}
+	// prior_notice_start_day: forbidden for booking_type=0, or booking_type=1 with prior_notice_duration_max
+	if ent.PriorNoticeStartDay.Valid {
+		if bt == 0 {
+			errs = append(errs, causes.NewConditionallyForbiddenFieldError("prior_notice_start_day", ent.PriorNoticeStartDay.String(), "not allowed when booking_type is 0"))
+		} else if bt == 1 && ent.PriorNoticeDurationMax.Valid {
+			errs = append(errs, causes.NewConditionallyForbiddenFieldError("prior_notice_start_day", ent.PriorNoticeStartDay.String(), "not allowed when prior_notice_duration_max is present"))
+		}
+	}
+	// prior_notice_start_time: required if prior_notice_start_day is defined, forbidden otherwise
+	if ent.PriorNoticeStartDay.Valid && !ent.PriorNoticeStartTime.Valid {
+		errs = append(errs, causes.NewConditionallyRequiredFieldError("prior_notice_start_time"))
+	} else if !ent.PriorNoticeStartDay.Valid && ent.PriorNoticeStartTime.Valid {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("prior_notice_start_time", ent.PriorNoticeStartTime.String(), "requires prior_notice_start_day"))
+	}
+	// prior_notice_service_id: optional for booking_type=2, forbidden otherwise
+	if bt != 2 && ent.PriorNoticeServiceID.Valid && ent.PriorNoticeServiceID.Val != "" {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("prior_notice_service_id", ent.PriorNoticeServiceID.Val, "only allowed when booking_type is 2"))
+	}
+	return errs
+}
@@ -0,0 +1,53 @@
+package gtfs
+
+import (
+	"errors"
+
+	"github.com/interline-io/transitland-lib/causes"
+	"github.com/interline-io/transitland-lib/tt"
+	"github.com/twpayne/go-geom"
+)
+
+// Location locations.geojson
+type Location struct {
+	LocationID tt.String `csv:",required"`
+	StopName   tt.String
+	StopDesc   tt.String
+	ZoneID     tt.String
+	StopURL    tt.Url
+	Geometry   tt.Geometry `csv:"-" db:"geometry"`
+	tt.BaseEntity
+}
+
+// EntityID returns the ID or LocationID.
+func (ent *Location) EntityID() string {
+	return entID(ent.ID, ent.LocationID.Val)
+}
+
+// EntityKey returns the GTFS identifier.
+func (ent *Location) EntityKey() string {
+	return ent.LocationID.Val
+}
+
+// Filename locations.geojson
+func (ent *Location) Filename() string {
+	return "locations.geojson"
+}
+
+// TableName gtfs_locations
+func (ent *Location) TableName() string {
+	return "gtfs_locations"
+}
+
+func (ent *Location) ConditionalErrors() (errs []error) {
+	if !ent.Geometry.Valid {
+		errs = append(errs, causes.NewRequiredFieldError("geometry"))
+		return errs
+	}
+	switch ent.Geometry.Val.(type) {
+	case *geom.Polygon, *geom.MultiPolygon:
+	default:
+		errs = append(errs, causes.NewInvalidFieldError("geometry", "", errors.New("geometry must be a Polygon or MultiPolygon")))
+	}
+	return errs
+}
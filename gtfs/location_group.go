@@ -0,0 +1,32 @@
+package gtfs
+
+import (
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+// LocationGroup location_groups.txt
+type LocationGroup struct {
+	LocationGroupID   tt.String `csv:",required"`
+	LocationGroupName tt.String
+	tt.BaseEntity
+}
+
+// EntityID returns the ID or LocationGroupID.
+func (ent *LocationGroup) EntityID() string {
+	return entID(ent.ID, ent.LocationGroupID.Val)
+}
+
+// EntityKey returns the GTFS identifier.
+func (ent *LocationGroup) EntityKey() string {
+	return ent.LocationGroupID.Val
+}
+
+// Filename location_groups.txt
+func (ent *LocationGroup) Filename() string {
+	return "location_groups.txt"
+}
+
+// TableName gtfs_location_groups
+func (ent *LocationGroup) TableName() string {
+	return "gtfs_location_groups"
+}
@@ -0,0 +1,32 @@
+package gtfs
+
+import (
+	"fmt"
+
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+// LocationGroupStop location_group_stops.txt
+type LocationGroupStop struct {
+	LocationGroupID tt.Key `csv:",required" target:"location_groups.txt"`
+	StopID          tt.Key `csv:",required" target:"stops.txt"`
+	tt.BaseEntity
+}
+
+// Filename location_group_stops.txt
+func (ent *LocationGroupStop) Filename() string {
+	return "location_group_stops.txt"
+}
+
+// TableName gtfs_location_group_stops
+func (ent *LocationGroupStop) TableName() string {
+	return "gtfs_location_group_stops"
+}
+
+func (ent *LocationGroupStop) DuplicateKey() string {
+	return fmt.Sprintf(
+		"location_group_id:'%s' stop_id:'%s'",
+		ent.LocationGroupID.Val,
+		ent.StopID.Val,
+	)
+}
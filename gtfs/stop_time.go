@@ -10,19 +10,25 @@ import (
 
 // StopTime stop_times.txt
 type StopTime struct {
-	TripID            tt.String `csv:",required" target:"trips.txt"`
-	StopID            tt.String `csv:",required" target:"stops.txt"`
-	StopSequence      tt.Int    `csv:",required"`
-	StopHeadsign      tt.String
-	ArrivalTime       tt.Seconds
-	DepartureTime     tt.Seconds
-	PickupType        tt.Int
-	DropOffType       tt.Int
-	ContinuousPickup  tt.Int
-	ContinuousDropOff tt.Int
-	ShapeDistTraveled tt.Float
-	Timepoint         tt.Int
-	Interpolated      tt.Int `csv:"-"` // interpolated times: 0 for provided, 1 interpolated // TODO: 1 for shape, 2 for straight-line
+	TripID                   tt.String `csv:",required" target:"trips.txt"`
+	StopID                   tt.String `target:"stops.txt"`
+	LocationGroupID          tt.String `target:"location_groups.txt"`
+	LocationID               tt.String `target:"locations.geojson"`
+	StopSequence             tt.Int    `csv:",required"`
+	StopHeadsign             tt.String
+	ArrivalTime              tt.Seconds
+	DepartureTime            tt.Seconds
+	StartPickupDropOffWindow tt.Seconds
+	EndPickupDropOffWindow   tt.Seconds
+	PickupType               tt.Int
+	DropOffType              tt.Int
+	ContinuousPickup         tt.Int
+	ContinuousDropOff        tt.Int
+	ShapeDistTraveled        tt.Float
+	Timepoint                tt.Int
+	PickupBookingRuleID      tt.String `target:"booking_rules.txt"`
+	DropOffBookingRuleID     tt.String `target:"booking_rules.txt"`
+	Interpolated             tt.Int    `csv:"-"` // interpolated times: 0 for provided, 1 interpolated // TODO: 1 for shape, 2 for straight-line
 	tt.MinEntity
 	tt.ErrorEntity
 	tt.ExtraEntity
@@ -44,7 +50,6 @@ func (ent *StopTime) Errors() []error {
 	// Don't use reflection based path
 	errs := []error{}
 	errs = append(errs, tt.CheckPresent("trip_id", ent.TripID.Val)...)
-	errs = append(errs, tt.CheckPresent("stop_id", ent.StopID.Val)...)
 	errs = append(errs, tt.CheckPositiveInt("stop_sequence", ent.StopSequence.Val)...)
 	errs = append(errs, tt.CheckInsideRangeInt("pickup_type", ent.PickupType.Val, 0, 3)...)
 	errs = append(errs, tt.CheckInsideRangeInt("drop_off_type", ent.DropOffType.Val, 0, 3)...)
@@ -59,6 +64,69 @@ func (ent *StopTime) Errors() []error {
 	if at != 0 && dt != 0 && at > dt {
 		errs = append(errs, causes.NewInvalidFieldError("departure_time", ent.DepartureTime.String(), fmt.Errorf("departure_time '%d' must come after arrival_time '%d'", dt, at)))
 	}
+	errs = append(errs, ent.flexErrors()...)
+	return errs
+}
+
+// IsFlex returns true if the StopTime uses GTFS-Flex locations or pickup/drop off windows.
+func (ent *StopTime) IsFlex() bool {
+	return ent.LocationID.Val != "" || ent.LocationGroupID.Val != "" || ent.StartPickupDropOffWindow.Valid || ent.EndPickupDropOffWindow.Valid
+}
+
+// flexErrors checks the conditionally required and forbidden GTFS-Flex fields.
+func (ent *StopTime) flexErrors() []error {
+	var errs []error
+	// Exactly one of stop_id, location_group_id, location_id
+	hasStop := ent.StopID.Val != ""
+	hasGroup := ent.LocationGroupID.Val != ""
+	hasLocation := ent.LocationID.Val != ""
+	if !hasStop && !hasGroup && !hasLocation {
+		errs = append(errs, causes.NewConditionallyRequiredFieldError("stop_id"))
+	}
+	if hasStop && (hasGroup || hasLocation) {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("stop_id", ent.StopID.Val, "cannot be used with location_group_id or location_id"))
+	}
+	if hasGroup && hasLocation {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("location_group_id", ent.LocationGroupID.Val, "cannot be used with location_id"))
+	}
+	// Pickup/drop off windows
+	startWindow := ent.StartPickupDropOffWindow
+	endWindow := ent.EndPickupDropOffWindow
+	hasWindow := startWindow.Valid || endWindow.Valid
+	if (hasGroup || hasLocation) && !startWindow.Valid {
+		errs = append(errs, causes.NewConditionallyRequiredFieldError("start_pickup_drop_off_window"))
+	} else if endWindow.Valid && !startWindow.Valid {
+		errs = append(errs, causes.NewConditionallyRequiredFieldError("start_pickup_drop_off_window"))
+	}
+	if (hasGroup || hasLocation) && !endWindow.Valid {
+		errs = append(errs, causes.NewConditionallyRequiredFieldError("end_pickup_drop_off_window"))
+	} else if startWindow.Valid && !endWindow.Valid {
+		errs = append(errs, causes.NewConditionallyRequiredFieldError("end_pickup_drop_off_window"))
+	}
+	if startWindow.Valid && endWindow.Valid && endWindow.Val < startWindow.Val {
+		errs = append(errs, causes.NewInvalidFieldError("end_pickup_drop_off_window", endWindow.String(), fmt.Errorf("end_pickup_drop_off_window '%s' must come after start_pickup_drop_off_window '%s'", endWindow.String(), startWindow.String())))
+	}
+	if !hasWindow {
+		return errs
+	}
+	if ent.ArrivalTime.Valid {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("arrival_time", ent.ArrivalTime.String(), "cannot be used with pickup/drop off windows"))
+	}
+	if ent.DepartureTime.Valid {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("departure_time", ent.DepartureTime.String(), "cannot be used with pickup/drop off windows"))
+	}
+	if ent.PickupType.Valid && (ent.PickupType.Val == 0 || ent.PickupType.Val == 3) {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("pickup_type", ent.PickupType.String(), "cannot be 0 or 3 with pickup/drop off windows"))
+	}
+	if ent.DropOffType.Valid && ent.DropOffType.Val == 0 {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("drop_off_type", ent.DropOffType.String(), "cannot be 0 with pickup/drop off windows"))
+	}
+	if ent.ContinuousPickup.Valid && ent.ContinuousPickup.Val != 1 {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("continuous_pickup", ent.ContinuousPickup.String(), "must be empty or 1 with pickup/drop off windows"))
+	}
+	if ent.ContinuousDropOff.Valid && ent.ContinuousDropOff.Val != 1 {
+		errs = append(errs, causes.NewConditionallyForbiddenFieldError("continuous_drop_off", ent.ContinuousDropOff.String(), "must be empty or 1 with pickup/drop off windows"))
+	}
 	return errs
 }
 
@@ -68,6 +136,10 @@ func (ent *StopTime) UpdateKeys(emap *tt.EntityMap) error {
 	return tt.FirstError(
 		tt.TrySetField(emap.UpdateKey(&ent.TripID, "trips.txt"), "trip_id"),
 		tt.TrySetField(emap.UpdateKey(&ent.StopID, "stops.txt"), "stop_id"),
+		tt.TrySetField(emap.UpdateKey(&ent.LocationGroupID, "location_groups.txt"), "location_group_id"),
+		tt.TrySetField(emap.UpdateKey(&ent.LocationID, "locations.geojson"), "location_id"),
+		tt.TrySetField(emap.UpdateKey(&ent.PickupBookingRuleID, "booking_rules.txt"), "pickup_booking_rule_id"),
+		tt.TrySetField(emap.UpdateKey(&ent.DropOffBookingRuleID, "booking_rules.txt"), "drop_off_booking_rule_id"),
 	)
 }
 
@@ -82,10 +154,22 @@ func (ent *StopTime) GetString(key string) (string, error) {
 		v = ent.StopHeadsign.Val
 	case "stop_id":
 		v = ent.StopID.Val
+	case "location_group_id":
+		v = ent.LocationGroupID.Val
+	case "location_id":
+		v = ent.LocationID.Val
 	case "arrival_time":
 		v = ent.ArrivalTime.String()
 	case "departure_time":
 		v = ent.DepartureTime.String()
+	case "start_pickup_drop_off_window":
+		v = ent.StartPickupDropOffWindow.String()
+	case "end_pickup_drop_off_window":
+		v = ent.EndPickupDropOffWindow.String()
+	case "pickup_booking_rule_id":
+		v = ent.PickupBookingRuleID.Val
+	case "drop_off_booking_rule_id":
+		v = ent.DropOffBookingRuleID.Val
 	case "stop_sequence":
 		v = ent.StopSequence.String()
 	case "pickup_type":
@@ -120,6 +204,14 @@ func (ent *StopTime) SetString(key, value string) error {
 		ent.StopHeadsign.Set(hi)
 	case "stop_id":
 		ent.StopID.Set(hi)
+	case "location_group_id":
+		ent.LocationGroupID.Set(hi)
+	case "location_id":
+		ent.LocationID.Set(hi)
+	case "pickup_booking_rule_id":
+		ent.PickupBookingRuleID.Set(hi)
+	case "drop_off_booking_rule_id":
+		ent.DropOffBookingRuleID.Set(hi)
 	case "arrival_time":
 		if hi == "" {
 		} else if s, err := tt.NewSecondsFromString(hi); err != nil {
@@ -134,6 +226,20 @@ func (ent *StopTime) SetString(key, value string) error {
 		} else {
 			ent.DepartureTime = s
 		}
+	case "start_pickup_drop_off_window":
+		if hi == "" {
+		} else if s, err := tt.NewSecondsFromString(hi); err != nil {
+			perr = causes.NewFieldParseError("start_pickup_drop_off_window", hi)
+		} else {
+			ent.StartPickupDropOffWindow = s
+		}
+	case "end_pickup_drop_off_window":
+		if hi == "" {
+		} else if s, err := tt.NewSecondsFromString(hi); err != nil {
+			perr = causes.NewFieldParseError("end_pickup_drop_off_window", hi)
+		} else {
+			ent.EndPickupDropOffWindow = s
+		}
 	case "stop_sequence":
 		if a, err := strconv.Atoi(hi); err != nil {
 			perr = causes.NewFieldParseError("stop_sequence", hi)
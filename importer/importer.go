@@ -19,6 +19,7 @@ type Options struct {
 	FeedVersionID int
 	Storage       string
 	Activate      bool
+	RecordChanges bool // compare with the previous feed version after import
 	copier.Options
 }
 
@@ -121,6 +122,12 @@ func ImportFeedVersion(ctx context.Context, adapter tldb.Adapter, opts Options)
 		}
 		return Result{FeedVersionImport: fvi}, errImport
 	}
+	// Compare with the previous feed version after the import is committed
+	if opts.RecordChanges {
+		if err := importFeedVersionChanges(ctx, adapter, fv, opts); err != nil {
+			log.For(ctx).Error().Err(err).Msgf("Error saving changes from previous feed version")
+		}
+	}
 	return Result{FeedVersionImport: fviresult}, nil
 }
 
@@ -129,17 +136,10 @@ func importFeedVersionTx(ctx context.Context, atx tldb.Adapter, fv dmfr.FeedVers
 	fvi := dmfr.FeedVersionImport{}
 	fvi.FeedVersionID = fv.ID
 	// Get Reader
-	tladapter, err := tlcsv.NewStoreAdapter(ctx, opts.Storage, fv.File, fv.Fragment.Val)
-	if err != nil {
-		return fvi, err
-	}
-	reader, err := tlcsv.NewReaderFromAdapter(tladapter)
+	reader, err := openFeedVersionReader(ctx, opts.Storage, fv)
 	if err != nil {
 		return fvi, err
 	}
-	if err := reader.Open(); err != nil {
-		return fvi, err
-	}
 	defer reader.Close()
 
 	// Get writer with existing tx
@@ -179,6 +179,60 @@ func importFeedVersionTx(ctx context.Context, atx tldb.Adapter, fv dmfr.FeedVers
 	return fvi, nil
 }
 
+// importFeedVersionChanges compares the feed version to the most recently fetched previous version of the same feed.
+// Missing or unreadable previous versions are logged and skipped.
+func importFeedVersionChanges(ctx context.Context, atx tldb.Adapter, fv dmfr.FeedVersion, opts Options) error {
+	prev := dmfr.FeedVersion{}
+	if err := atx.Get(
+		ctx,
+		&prev,
+		`SELECT * FROM feed_versions WHERE feed_id = ? AND id <> ? AND fetched_at < (SELECT fetched_at FROM feed_versions WHERE id = ?) ORDER BY fetched_at DESC, id DESC LIMIT 1`,
+		fv.FeedID,
+		fv.ID,
+		fv.ID,
+	); err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	prevReader, err := openFeedVersionReader(ctx, opts.Storage, prev)
+	if err != nil {
+		log.For(ctx).Info().Err(err).Msgf("Could not open previous feed version %d, skipping changes", prev.ID)
+		return nil
+	}
+	defer prevReader.Close()
+	reader, err := openFeedVersionReader(ctx, opts.Storage, fv)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	fvc, err := stats.NewFeedVersionChangesFromReaders(prevReader, reader)
+	if err != nil {
+		log.For(ctx).Info().Err(err).Msgf("Could not compare with previous feed version %d, skipping changes", prev.ID)
+		return nil
+	}
+	fvc.FeedVersionID = fv.ID
+	fvc.PreviousFeedVersionID = prev.ID
+	_, err = atx.Insert(ctx, &fvc)
+	return err
+}
+
+// openFeedVersionReader opens a reader for the feed version archive.
+func openFeedVersionReader(ctx context.Context, storage string, fv dmfr.FeedVersion) (*tlcsv.Reader, error) {
+	tladapter, err := tlcsv.NewStoreAdapter(ctx, storage, fv.File, fv.Fragment.Val)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := tlcsv.NewReaderFromAdapter(tladapter)
+	if err != nil {
+		return nil, err
+	}
+	if err := reader.Open(); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
 func copyResultCounts(result copier.Result) dmfr.FeedVersionImport {
 	fvi := dmfr.NewFeedVersionImport()
 	fvi.InterpolatedStopTimeCount = result.InterpolatedStopTimeCount
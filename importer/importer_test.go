@@ -11,6 +11,7 @@ import (
 	"github.com/interline-io/transitland-lib/internal/testutil"
 	"github.com/interline-io/transitland-lib/tldb"
 	"github.com/interline-io/transitland-lib/tt"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestImportFeedVersion(t *testing.T) {
@@ -56,6 +57,52 @@ func TestImportFeedVersion(t *testing.T) {
 			return nil
 		})
 	})
+	t.Run("ChangesFromPrevious", func(t *testing.T) {
+		testdb.TempSqlite(func(atx tldb.Adapter) error {
+			fn := testpath.RelPath("testdata/gtfs-examples/example-nested-two-feeds.zip")
+			prev := dmfr.FeedVersion{File: fn, FetchedAt: time.Now().Add(-24 * time.Hour)}
+			prev.Fragment = tt.NewString("example1")
+			prevId := testdb.ShouldInsert(t, atx, &prev)
+			fv := dmfr.FeedVersion{File: fn, FetchedAt: time.Now()}
+			fv.Fragment = tt.NewString("example2")
+			fvid := testdb.ShouldInsert(t, atx, &fv)
+			atx2 := testdb.AdapterIgnoreTx{Adapter: atx}
+			if _, err := ImportFeedVersion(ctx, &atx2, Options{FeedVersionID: fvid, Storage: "/", RecordChanges: true}); err != nil {
+				t.Fatal(err)
+			}
+			fvc := dmfr.FeedVersionChanges{}
+			testdb.ShouldGet(t, atx, &fvc, "SELECT * FROM feed_version_changes WHERE feed_version_id = ?", fvid)
+			assert.Equal(t, prevId, fvc.PreviousFeedVersionID)
+			assert.Equal(t, 4, fvc.AddedCount["routes.txt"])
+			assert.Equal(t, 24, fvc.AddedCount["stop_times.txt"])
+			assert.Equal(t, []string{"AAMV", "BFC", "CITY", "STBA"}, fvc.RoutesAdded.Val)
+			assert.Equal(t, 0, len(fvc.RoutesRemoved.Val))
+			// The first feed version has no previous version
+			count := 0
+			testdb.ShouldGet(t, atx, &count, "SELECT count(*) FROM feed_version_changes WHERE feed_version_id = ?", prevId)
+			assert.Equal(t, 0, count)
+			return nil
+		})
+	})
+	t.Run("ChangesNotRecorded", func(t *testing.T) {
+		testdb.TempSqlite(func(atx tldb.Adapter) error {
+			fn := testpath.RelPath("testdata/gtfs-examples/example-nested-two-feeds.zip")
+			prev := dmfr.FeedVersion{File: fn, FetchedAt: time.Now().Add(-24 * time.Hour)}
+			prev.Fragment = tt.NewString("example1")
+			testdb.ShouldInsert(t, atx, &prev)
+			fv := dmfr.FeedVersion{File: fn, FetchedAt: time.Now()}
+			fv.Fragment = tt.NewString("example2")
+			fvid := testdb.ShouldInsert(t, atx, &fv)
+			atx2 := testdb.AdapterIgnoreTx{Adapter: atx}
+			if _, err := ImportFeedVersion(ctx, &atx2, Options{FeedVersionID: fvid, Storage: "/"}); err != nil {
+				t.Fatal(err)
+			}
+			count := 0
+			testdb.ShouldGet(t, atx, &count, "SELECT count(*) FROM feed_version_changes WHERE feed_version_id = ?", fvid)
+			assert.Equal(t, 0, count)
+			return nil
+		})
+	})
 	t.Run("Failed", func(t *testing.T) {
 		fvid := 0
 		err := testdb.TempSqlite(func(atx tldb.Adapter) error {
@@ -0,0 +1,136 @@
+package gbfs
+
+import (
+	"sort"
+	"time"
+)
+
+// StationAvailability summarizes station status samples for a station within a time bucket.
+// Station IDs are only unique within a system, so stations are identified by both.
+type StationAvailability struct {
+	System            string
+	StationID         string
+	Start             time.Time
+	Samples           int
+	BikesAvailableAvg float64
+	BikesAvailableMin int
+	BikesAvailableMax int
+	DocksAvailableAvg float64
+	DocksAvailableMin int
+	DocksAvailableMax int
+	// EmptyFraction is the fraction of samples with no bikes available
+	EmptyFraction float64
+	// FullFraction is the fraction of samples with no docks available
+	FullFraction float64
+}
+
+// AvailabilityAggregator collects station status samples into fixed time buckets.
+type AvailabilityAggregator struct {
+	Interval time.Duration
+	buckets  map[availabilityKey]*availabilityCounts
+}
+
+type availabilityKey struct {
+	system    string
+	stationID string
+	start     int64
+}
+
+type availabilityCounts struct {
+	samples     int
+	bikes       minMaxSum
+	docks       minMaxSum
+	emptyCount  int
+	fullCount   int
+	dockSamples int
+}
+
+type minMaxSum struct {
+	min int
+	max int
+	sum int
+}
+
+func (m *minMaxSum) add(v int, first bool) {
+	if first || v < m.min {
+		m.min = v
+	}
+	if first || v > m.max {
+		m.max = v
+	}
+	m.sum += v
+}
+
+func NewAvailabilityAggregator(interval time.Duration) *AvailabilityAggregator {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &AvailabilityAggregator{
+		Interval: interval,
+		buckets:  map[availabilityKey]*availabilityCounts{},
+	}
+}
+
+// Add adds a station status sample for a system observed at time t. Stations that are not installed are skipped.
+func (a *AvailabilityAggregator) Add(system string, t time.Time, statuses []*StationStatus) {
+	start := t.Truncate(a.Interval).Unix()
+	for _, st := range statuses {
+		if st == nil || !st.StationID.Valid || (st.IsInstalled.Valid && !st.IsInstalled.Val) {
+			continue
+		}
+		key := availabilityKey{system: system, stationID: st.StationID.Val, start: start}
+		c, ok := a.buckets[key]
+		if !ok {
+			c = &availabilityCounts{}
+			a.buckets[key] = c
+		}
+		bikes := int(st.NumBikesAvailable.Val)
+		c.bikes.add(bikes, c.samples == 0)
+		c.samples++
+		if bikes == 0 {
+			c.emptyCount++
+		}
+		if st.NumDocksAvailable.Valid {
+			docks := int(st.NumDocksAvailable.Val)
+			c.docks.add(docks, c.dockSamples == 0)
+			c.dockSamples++
+			if docks == 0 {
+				c.fullCount++
+			}
+		}
+	}
+}
+
+// Results returns the summary for each station and time bucket, ordered by system, station and time.
+func (a *AvailabilityAggregator) Results() []StationAvailability {
+	var ret []StationAvailability
+	for k, c := range a.buckets {
+		sa := StationAvailability{
+			System:            k.system,
+			StationID:         k.stationID,
+			Start:             time.Unix(k.start, 0).In(time.UTC),
+			Samples:           c.samples,
+			BikesAvailableAvg: float64(c.bikes.sum) / float64(c.samples),
+			BikesAvailableMin: c.bikes.min,
+			BikesAvailableMax: c.bikes.max,
+			EmptyFraction:     float64(c.emptyCount) / float64(c.samples),
+		}
+		if c.dockSamples > 0 {
+			sa.DocksAvailableAvg = float64(c.docks.sum) / float64(c.dockSamples)
+			sa.DocksAvailableMin = c.docks.min
+			sa.DocksAvailableMax = c.docks.max
+			sa.FullFraction = float64(c.fullCount) / float64(c.dockSamples)
+		}
+		ret = append(ret, sa)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].System != ret[j].System {
+			return ret[i].System < ret[j].System
+		}
+		if ret[i].StationID != ret[j].StationID {
+			return ret[i].StationID < ret[j].StationID
+		}
+		return ret[i].Start.Before(ret[j].Start)
+	})
+	return ret
+}
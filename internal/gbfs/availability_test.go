@@ -0,0 +1,59 @@
+package gbfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/interline-io/transitland-lib/tt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAvailabilityAggregator(t *testing.T) {
+	status := func(id string, bikes int, docks int) *StationStatus {
+		return &StationStatus{
+			StationID:         tt.NewString(id),
+			NumBikesAvailable: tt.NewInt(bikes),
+			NumDocksAvailable: tt.NewInt(docks),
+		}
+	}
+	t0 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	agg := NewAvailabilityAggregator(time.Hour)
+	agg.Add("sys1", t0, []*StationStatus{status("a", 0, 10), status("b", 5, 0)})
+	agg.Add("sys1", t0.Add(30*time.Minute), []*StationStatus{status("a", 4, 6), status("b", 5, 0)})
+	agg.Add("sys1", t0.Add(90*time.Minute), []*StationStatus{status("a", 2, 8)})
+	agg.Add("sys1", t0, []*StationStatus{{StationID: tt.NewString("c"), IsInstalled: tt.NewBool(false)}})
+	// Same station ID in another system
+	agg.Add("sys2", t0, []*StationStatus{status("a", 10, 0)})
+
+	results := agg.Results()
+	if !assert.Equal(t, 4, len(results)) {
+		t.FailNow()
+	}
+	a := results[0]
+	assert.Equal(t, "sys1", a.System)
+	assert.Equal(t, "a", a.StationID)
+	assert.True(t, t0.Equal(a.Start))
+	assert.Equal(t, 2, a.Samples)
+	assert.Equal(t, 2.0, a.BikesAvailableAvg)
+	assert.Equal(t, 0, a.BikesAvailableMin)
+	assert.Equal(t, 4, a.BikesAvailableMax)
+	assert.Equal(t, 8.0, a.DocksAvailableAvg)
+	assert.Equal(t, 0.5, a.EmptyFraction)
+	assert.Equal(t, 0.0, a.FullFraction)
+
+	a2 := results[1]
+	assert.Equal(t, "a", a2.StationID)
+	assert.True(t, t0.Add(time.Hour).Equal(a2.Start))
+	assert.Equal(t, 1, a2.Samples)
+
+	b := results[2]
+	assert.Equal(t, "b", b.StationID)
+	assert.Equal(t, 1.0, b.FullFraction)
+	assert.Equal(t, 0.0, b.EmptyFraction)
+
+	other := results[3]
+	assert.Equal(t, "sys2", other.System)
+	assert.Equal(t, "a", other.StationID)
+	assert.Equal(t, 1, other.Samples)
+	assert.Equal(t, 10.0, other.BikesAvailableAvg)
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/interline-io/log"
 	"github.com/interline-io/transitland-lib/dmfr"
@@ -13,14 +15,20 @@ import (
 )
 
 type Options struct {
+	// Language limits results to a single language, if set
 	Language string
+	// ArchivePrefix is the key prefix for snapshots saved to Storage; defaults to gbfs/<feed id>
+	ArchivePrefix string
 	fetch.Options
 }
 
 type Result struct {
 	fetch.Result
+	ArchiveKey string
 }
 
+// Fetch fetches all files listed in a GBFS auto-discovery file and returns one feed for each language.
+// If Storage is set, the fetched files are also saved as a snapshot.
 func Fetch(ctx context.Context, atx tldb.Adapter, opts Options) ([]GbfsFeed, Result, error) {
 	result := Result{}
 	var reqOpts []request.RequestOption
@@ -33,25 +41,62 @@ func Fetch(ctx context.Context, atx tldb.Adapter, opts Options) ([]GbfsFeed, Res
 	if opts.AllowS3Fetch {
 		reqOpts = append(reqOpts, request.WithAllowS3)
 	}
+	fetchedAt := opts.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now().In(time.UTC)
+	}
 
 	// Fetch system file
-	systemFile := SystemFile{}
-	fr, err := fetchUnmarshal(opts.FeedURL, &systemFile, reqOpts...)
+	snapshot := Snapshot{
+		URL:       opts.FeedURL,
+		FetchedAt: fetchedAt,
+		Files:     map[string]json.RawMessage{},
+	}
+	data, fr, err := fetchBytes(ctx, opts.FeedURL, reqOpts...)
 	result.ResponseCode = fr.ResponseCode
 	result.ResponseSHA1 = fr.ResponseSHA1
 	result.ResponseSize = fr.ResponseSize
 	if err != nil {
 		return nil, result, err
 	}
+	snapshot.Files[opts.FeedURL] = data
 
 	// Fetch additional data
-	var feeds []GbfsFeed
-	for _, sflang := range systemFile.Data {
-		if sflang == nil {
-			continue
+	systemFeeds, err := snapshot.systemFeeds()
+	if err != nil {
+		return nil, result, err
+	}
+	for _, sflang := range systemFeeds {
+		for _, v := range sflang.Feeds {
+			if _, ok := snapshot.Files[v.URL.Val]; ok {
+				continue
+			}
+			data, _, err := fetchBytes(ctx, v.URL.Val, reqOpts...)
+			if err != nil {
+				log.For(ctx).Info().Err(err).Str("url", v.URL.Val).Msgf("failed to fetch %s", v.Name.Val)
+				continue
+			}
+			snapshot.Files[v.URL.Val] = data
+		}
+	}
+	feeds, err := snapshot.Feeds(ctx, opts.Language)
+	if err != nil {
+		return nil, result, err
+	}
+
+	// Save snapshot
+	if opts.Storage != "" {
+		store, err := request.GetStore(opts.Storage)
+		if err != nil {
+			return nil, result, err
+		}
+		prefix := opts.ArchivePrefix
+		if prefix == "" {
+			prefix = fmt.Sprintf("gbfs/%d", opts.FeedID)
 		}
-		if feed, err := fetchAll(ctx, *sflang); err == nil {
-			feeds = append(feeds, feed)
+		result.ArchiveKey = SnapshotKey(prefix, fetchedAt)
+		if err := ArchiveSnapshot(ctx, store, result.ArchiveKey, &snapshot); err != nil {
+			return nil, result, err
 		}
 	}
 
@@ -83,74 +128,86 @@ func Fetch(ctx context.Context, atx tldb.Adapter, opts Options) ([]GbfsFeed, Res
 	return feeds, result, nil
 }
 
-func fetchAll(ctx context.Context, sf SystemFeeds, reqOpts ...request.RequestOption) (GbfsFeed, error) {
+// parseFeed decodes the files for a single language.
+func parseFeed(ctx context.Context, files map[string]json.RawMessage, sf SystemFeeds, v3 bool, lang string) GbfsFeed {
 	ret := GbfsFeed{}
-	var err error
 	for _, v := range sf.Feeds {
+		data, ok := files[v.URL.Val]
+		if !ok {
+			continue
+		}
+		var err error
+		if v3 {
+			if data, err = normalizeV3(data, lang); err != nil {
+				log.For(ctx).Info().Err(err).Str("url", v.URL.Val).Msgf("failed to parse %s", v.Name.Val)
+				continue
+			}
+		}
 		switch v.Name.Val {
 		case "system_information":
 			e := SystemInformationFile{}
-			_, err = fetchUnmarshal(v.URL.Val, &e, reqOpts...)
+			err = json.Unmarshal(data, &e)
 			ret.SystemInformation = e.Data
 		case "station_information":
 			e := StationInformationFile{}
-			_, err = fetchUnmarshal(v.URL.Val, &e, reqOpts...)
+			err = json.Unmarshal(data, &e)
 			ret.StationInformation = e.Data.Stations
 		case "station_status":
 			e := StationStatusFile{}
-			_, err = fetchUnmarshal(v.URL.Val, &e, reqOpts...)
+			err = json.Unmarshal(data, &e)
 			ret.StationStatus = e.Data.Stations
-		case "free_bike_status":
+		case "free_bike_status", "vehicle_status":
 			e := GbfsFeedData{}
-			_, err = fetchUnmarshal(v.URL.Val, &e, reqOpts...)
+			err = json.Unmarshal(data, &e)
 			if e.Data != nil {
 				ret.Bikes = e.Data.Bikes
 			}
 		case "system_hours":
 			e := GbfsFeedData{}
-			_, err = fetchUnmarshal(v.URL.Val, &e, reqOpts...)
+			err = json.Unmarshal(data, &e)
 			if e.Data != nil {
 				ret.RentalHours = e.Data.RentalHours
 			}
 		case "system_calendar":
 			e := GbfsFeedData{}
-			_, err = fetchUnmarshal(v.URL.Val, &e, reqOpts...)
+			err = json.Unmarshal(data, &e)
 			if e.Data != nil {
 				ret.Calendars = e.Data.Calendars
 			}
 		case "system_regions":
 			e := GbfsFeedData{}
-			_, err = fetchUnmarshal(v.URL.Val, &e, reqOpts...)
+			err = json.Unmarshal(data, &e)
 			if e.Data != nil {
 				ret.Regions = e.Data.Regions
 			}
 		case "system_alerts":
 			e := GbfsFeedData{}
-			_, err = fetchUnmarshal(v.URL.Val, &e, reqOpts...)
+			err = json.Unmarshal(data, &e)
 			if e.Data != nil {
 				ret.Alerts = e.Data.Alerts
 			}
 		case "vehicle_types":
 			e := GbfsFeedData{}
-			_, err = fetchUnmarshal(v.URL.Val, &e, reqOpts...)
+			err = json.Unmarshal(data, &e)
 			if e.Data != nil {
 				ret.VehicleTypes = e.Data.VehicleTypes
 			}
 		case "system_pricing_plans":
 			e := GbfsFeedData{}
-			_, err = fetchUnmarshal(v.URL.Val, &e, reqOpts...)
+			err = json.Unmarshal(data, &e)
 			if e.Data != nil {
 				ret.Plans = e.Data.Plans
 			}
 		case "geofencing_zones":
-			e := GbfsFeedData{}
-			_, err = fetchUnmarshal(v.URL.Val, &e, reqOpts...)
-			if e.Data != nil {
-				ret.GeofencingZones = e.Data.GeofencingZones
+			e := GeofencingZonesFile{}
+			err = json.Unmarshal(data, &e)
+			if e.Data.GeofencingZones != nil {
+				ret.GeofencingZones = []*GeofenceZone{e.Data.GeofencingZones}
 			}
+			ret.GlobalRules = e.Data.GlobalRules
 		case "gbfs_versions":
 			e := GbfsFeedData{}
-			_, err = fetchUnmarshal(v.URL.Val, &e, reqOpts...)
+			err = json.Unmarshal(data, &e)
 			if e.Data != nil {
 				ret.Versions = e.Data.Versions
 			}
@@ -159,18 +216,17 @@ func fetchAll(ctx context.Context, sf SystemFeeds, reqOpts ...request.RequestOpt
 			log.For(ctx).Info().Err(err).Str("url", v.URL.Val).Msgf("failed to parse %s", v.Name.Val)
 		}
 	}
-	return ret, err
+	return ret
 }
 
-func fetchUnmarshal(url string, ent any, reqOpts ...request.RequestOption) (request.FetchResponse, error) {
-	ctx := context.TODO()
+func fetchBytes(ctx context.Context, url string, reqOpts ...request.RequestOption) ([]byte, request.FetchResponse, error) {
 	var out bytes.Buffer
 	fr, err := request.AuthenticatedRequest(ctx, &out, url, reqOpts...)
 	if err != nil {
-		return fr, err
+		return nil, fr, err
 	}
-	if err := json.Unmarshal(out.Bytes(), ent); err != nil {
-		return fr, err
+	if !json.Valid(out.Bytes()) {
+		return nil, fr, fmt.Errorf("invalid json response from '%s'", url)
 	}
-	return fr, nil
+	return out.Bytes(), fr, nil
 }
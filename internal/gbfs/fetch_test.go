@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/interline-io/transitland-lib/request"
 	"github.com/interline-io/transitland-lib/testdata"
 	"github.com/stretchr/testify/assert"
 )
@@ -25,3 +27,112 @@ func TestGbfsFetch(t *testing.T) {
 	}
 	assert.ElementsMatch(t, []string{"Bay Wheels"}, fids)
 }
+
+func TestGbfsFetch_V3(t *testing.T) {
+	srv := NewTestGbfsServer("", testdata.Path("server/gbfs-v3"))
+	srv.Version = "3.0"
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	opts := Options{}
+	opts.FeedURL = fmt.Sprintf("%s/%s", ts.URL, "gbfs.json")
+	feeds, _, err := Fetch(context.Background(), nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Equal(t, 2, len(feeds)) {
+		t.FailNow()
+	}
+	names := map[string]string{}
+	stationNames := map[string]string{}
+	for _, feed := range feeds {
+		lang := feed.SystemInformation.Language.Val
+		names[lang] = feed.SystemInformation.Name.Val
+		for _, st := range feed.StationInformation {
+			if st.StationID.Val == "station1" {
+				stationNames[lang] = st.Name.Val
+			}
+		}
+	}
+	assert.Equal(t, map[string]string{"en": "Example Bike Rental", "fr": "Exemple de location de vélos"}, names)
+	assert.Equal(t, map[string]string{"en": "Central Station", "fr": "Gare Centrale"}, stationNames)
+
+	feed := feeds[0]
+	assert.Equal(t, "Mo-Su 00:00-24:00", feed.SystemInformation.OpeningHours.Val)
+	if assert.Equal(t, 2, len(feed.StationStatus)) {
+		st := feed.StationStatus[0]
+		assert.Equal(t, int64(4), st.NumBikesAvailable.Val)
+		assert.Equal(t, int64(1), st.NumBikesDisabled.Val)
+		assert.Equal(t, int64(1714557570), st.LastReported.Val)
+	}
+	if assert.Equal(t, 1, len(feed.Bikes)) {
+		assert.Equal(t, "vehicle1", feed.Bikes[0].BikeID.Val)
+		assert.Equal(t, int64(1714557300), feed.Bikes[0].LastReported.Val)
+	}
+	if assert.Equal(t, 1, len(feed.GeofencingZones)) && assert.Equal(t, 1, len(feed.GeofencingZones[0].Features)) {
+		props := feed.GeofencingZones[0].Features[0].Properties
+		assert.Equal(t, "Park", props.Name.Val)
+		assert.Equal(t, int64(1714514400), props.Start.Val)
+		if assert.Equal(t, 1, len(props.Rules)) {
+			rule := props.Rules[0]
+			assert.Equal(t, []string{"scooter"}, rule.VehicleTypeID.Val)
+			assert.True(t, rule.RideStartAllowed.Valid)
+			assert.False(t, rule.RideStartAllowed.Val)
+			assert.True(t, rule.RideThroughAllowed.Val)
+			assert.Equal(t, int64(10), rule.MaximumSpeedKph.Val)
+		}
+	}
+	assert.Equal(t, 1, len(feed.GlobalRules))
+}
+
+func TestGbfsFetch_Language(t *testing.T) {
+	srv := NewTestGbfsServer("", testdata.Path("server/gbfs-v3"))
+	srv.Version = "3.0"
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	opts := Options{Language: "fr"}
+	opts.FeedURL = fmt.Sprintf("%s/%s", ts.URL, "gbfs.json")
+	feeds, _, err := Fetch(context.Background(), nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Equal(t, 1, len(feeds)) {
+		assert.Equal(t, "fr", feeds[0].SystemInformation.Language.Val)
+		assert.Equal(t, "Exemple", feeds[0].SystemInformation.ShortName.Val)
+	}
+}
+
+func TestGbfsFetch_Archive(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(NewTestGbfsServer("en", testdata.Path("server/gbfs")))
+	defer ts.Close()
+	fetchedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	opts := Options{ArchivePrefix: "gbfs/test"}
+	opts.FeedURL = fmt.Sprintf("%s/%s", ts.URL, "gbfs.json")
+	opts.FetchedAt = fetchedAt
+	opts.Storage = t.TempDir()
+	feeds, result, err := Fetch(ctx, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "gbfs/test/2024-05-01/1714557600.json", result.ArchiveKey)
+
+	// Read back snapshot and check it parses to the same feeds
+	store, err := request.GetStore(opts.Storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := ReadSnapshot(ctx, store, result.ArchiveKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, fetchedAt.Equal(s.FetchedAt))
+	sfeeds, err := s.Feeds(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Equal(t, len(feeds), len(sfeeds)) {
+		assert.Equal(t, feeds[0].SystemInformation.Name.Val, sfeeds[0].SystemInformation.Name.Val)
+		assert.Equal(t, len(feeds[0].StationStatus), len(sfeeds[0].StationStatus))
+		assert.Greater(t, len(sfeeds[0].StationStatus), 0)
+	}
+}
@@ -16,7 +16,8 @@ type GbfsFeed struct {
 	Calendars          []*SystemCalendar     `json:"calendars,omitempty"`
 	Plans              []*SystemPricingPlan  `json:"plans,omitempty"`
 	Alerts             []*SystemAlert        `json:"alerts,omitempty"`
-	GeofencingZones    []*GeofenceZone       // `json:"geofencing_zones,omitempty"`
+	GeofencingZones    []*GeofenceZone       `json:"geofencing_zones,omitempty"`
+	GlobalRules        []*GeofenceRule       `json:"global_rules,omitempty"`
 }
 
 type GbfsFeedData struct {
@@ -52,6 +53,13 @@ type StationStatusFile struct {
 	}
 }
 
+type GeofencingZonesFile struct {
+	Data struct {
+		GeofencingZones *GeofenceZone   `json:"geofencing_zones,omitempty"`
+		GlobalRules     []*GeofenceRule `json:"global_rules,omitempty"`
+	}
+}
+
 ///////////////
 
 // Main types
@@ -59,6 +67,7 @@ type StationStatusFile struct {
 type SystemInformation struct {
 	SystemID           tt.String   `json:"system_id,omitempty"`
 	Language           tt.String   `json:"language,omitempty"`
+	Languages          tt.Strings  `json:"languages,omitempty"`
 	Name               tt.String   `json:"name,omitempty"`
 	ShortName          tt.String   `json:"short_name,omitempty"`
 	Operator           tt.String   `json:"operator,omitempty"`
@@ -69,6 +78,7 @@ type SystemInformation struct {
 	Email              tt.String   `json:"email,omitempty"`
 	FeedContactEmail   tt.String   `json:"feed_contact_email,omitempty"`
 	Timezone           tt.String   `json:"timezone,omitempty"`
+	OpeningHours       tt.String   `json:"opening_hours,omitempty"`
 	LicenseURL         tt.String   `json:"license_url,omitempty"`
 	TermsURL           tt.String   `json:"terms_url,omitempty"`
 	TermsLastUpdated   tt.Date     `json:"terms_last_updated,omitempty"`
@@ -275,8 +285,8 @@ type AlertTime struct {
 }
 
 type GeofenceZone struct {
-	Type     tt.String
-	Features []*GeofenceFeature
+	Type     tt.String          `json:"type,omitempty"`
+	Features []*GeofenceFeature `json:"features,omitempty"`
 }
 
 type GeofenceFeature struct {
@@ -295,6 +305,8 @@ type GeofenceProperty struct {
 type GeofenceRule struct {
 	VehicleTypeID      tt.Strings `json:"vehicle_type_id,omitempty"`
 	RideAllowed        tt.Bool    `json:"ride_allowed,omitempty"`
+	RideStartAllowed   tt.Bool    `json:"ride_start_allowed,omitempty"`
+	RideEndAllowed     tt.Bool    `json:"ride_end_allowed,omitempty"`
 	RideThroughAllowed tt.Bool    `json:"ride_through_allowed,omitempty"`
 	MaximumSpeedKph    tt.Int     `json:"maximum_speed_kph,omitempty"`
 	StationParking     tt.Bool    `json:"station_parking,omitempty"`
@@ -0,0 +1,125 @@
+package gbfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/interline-io/transitland-lib/request"
+)
+
+// Snapshot contains the raw files of a GBFS feed fetched at the same time, keyed by url.
+type Snapshot struct {
+	URL       string                     `json:"url"`
+	FetchedAt time.Time                  `json:"fetched_at"`
+	Files     map[string]json.RawMessage `json:"files"`
+}
+
+// Feeds parses the snapshot and returns one feed for each language, or only the given language if set.
+func (s *Snapshot) Feeds(ctx context.Context, language string) ([]GbfsFeed, error) {
+	systemFeeds, err := s.systemFeeds()
+	if err != nil {
+		return nil, err
+	}
+	var feeds []GbfsFeed
+	if sf, ok := systemFeeds[""]; ok {
+		// v3 files contain every language
+		for _, lang := range s.languages(*sf) {
+			if language != "" && lang != language {
+				continue
+			}
+			feed := parseFeed(ctx, s.Files, *sf, true, lang)
+			if feed.SystemInformation != nil && lang != "" {
+				feed.SystemInformation.Language.Set(lang)
+			}
+			feeds = append(feeds, feed)
+		}
+		return feeds, nil
+	}
+	var langs []string
+	for lang := range systemFeeds {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		if language != "" && lang != language {
+			continue
+		}
+		if sf := systemFeeds[lang]; sf != nil {
+			feeds = append(feeds, parseFeed(ctx, s.Files, *sf, false, lang))
+		}
+	}
+	return feeds, nil
+}
+
+// systemFeeds returns the files listed in the auto-discovery file by language.
+// The v3 auto-discovery file lists files only once; these are returned with an empty language.
+func (s *Snapshot) systemFeeds() (map[string]*SystemFeeds, error) {
+	data, ok := s.Files[s.URL]
+	if !ok {
+		return nil, errors.New("snapshot does not contain auto-discovery file")
+	}
+	v3File := struct {
+		Data SystemFeeds `json:"data"`
+	}{}
+	if err := json.Unmarshal(data, &v3File); err == nil && len(v3File.Data.Feeds) > 0 {
+		return map[string]*SystemFeeds{"": &v3File.Data}, nil
+	}
+	systemFile := SystemFile{}
+	if err := json.Unmarshal(data, &systemFile); err != nil {
+		return nil, err
+	}
+	return systemFile.Data, nil
+}
+
+// languages returns the languages listed in the v3 system_information file.
+func (s *Snapshot) languages(sf SystemFeeds) []string {
+	for _, v := range sf.Feeds {
+		if v.Name.Val != "system_information" {
+			continue
+		}
+		e := struct {
+			Data struct {
+				Languages []string `json:"languages"`
+			} `json:"data"`
+		}{}
+		if err := json.Unmarshal(s.Files[v.URL.Val], &e); err == nil && len(e.Data.Languages) > 0 {
+			return e.Data.Languages
+		}
+	}
+	return []string{""}
+}
+
+// SnapshotKey returns the storage key for a snapshot, e.g. <prefix>/2024-01-02/1704153600.json
+func SnapshotKey(prefix string, fetchedAt time.Time) string {
+	t := fetchedAt.In(time.UTC)
+	return path.Join(prefix, t.Format("2006-01-02"), fmt.Sprintf("%d.json", t.Unix()))
+}
+
+// ArchiveSnapshot saves a snapshot to a store.
+func ArchiveSnapshot(ctx context.Context, store request.Store, key string, s *Snapshot) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return store.Upload(ctx, key, bytes.NewReader(data))
+}
+
+// ReadSnapshot reads a snapshot from a store.
+func ReadSnapshot(ctx context.Context, store request.Store, key string) (*Snapshot, error) {
+	rdr, _, err := store.Download(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rdr.Close()
+	s := Snapshot{}
+	if err := json.NewDecoder(rdr).Decode(&s); err != nil {
+		return nil, fmt.Errorf("could not read snapshot '%s': %w", key, err)
+	}
+	return &s, nil
+}
@@ -15,6 +15,7 @@ import (
 // Serve a directory of GBFS files. Used for testing.
 type TestGbfsServer struct {
 	Language string
+	Version  string // set to 3.0 to serve a v3 auto-discovery file
 	Path     string
 	fsys     fs.FS
 }
@@ -49,6 +50,9 @@ func (g *TestGbfsServer) open(host string, path string) ([]byte, error) {
 				sfs.Feeds = append(sfs.Feeds, &SystemFeed{Name: tt.NewString(fn), URL: tt.NewString(url)})
 			}
 		}
+		if strings.HasPrefix(g.Version, "3") {
+			return json.Marshal(map[string]any{"version": g.Version, "data": sfs})
+		}
 		sf.Data = map[string]*SystemFeeds{}
 		sf.Data[g.Language] = &sfs
 		data, err := json.Marshal(sf)
@@ -0,0 +1,101 @@
+package gbfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// GBFS v3 renamed several fields and replaced unix timestamps and plain strings
+// with RFC3339 timestamps and localized strings. Files are rewritten into the
+// v2 layout for a single language so that they can share the same types.
+
+// v3Renames maps v3 field names to v2 field names.
+var v3Renames = map[string]string{
+	"vehicles":               "bikes",
+	"vehicle_id":             "bike_id",
+	"num_vehicles_available": "num_bikes_available",
+	"num_vehicles_disabled":  "num_bikes_disabled",
+}
+
+// v3RuleRenames maps v3 field names to v2 field names inside geofencing rules.
+var v3RuleRenames = map[string]string{
+	"vehicle_type_ids": "vehicle_type_id",
+}
+
+// v3Timestamps are fields that are RFC3339 timestamps in v3 and unix timestamps in v2.
+var v3Timestamps = map[string]bool{
+	"last_updated":    true,
+	"last_reported":   true,
+	"available_until": true,
+	"start":           true,
+	"end":             true,
+}
+
+// normalizeV3 rewrites a v3 file into the v2 layout, selecting localized strings in the given language.
+// The first translation is used if no language is given or the language is not available.
+func normalizeV3(data []byte, lang string) ([]byte, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(normalizeV3Value(v, "", lang))
+}
+
+func normalizeV3Value(v any, parentKey string, lang string) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		ret := map[string]any{}
+		for k, val := range vv {
+			nval := normalizeV3Value(val, k, lang)
+			if s, ok := nval.(string); ok && v3Timestamps[k] {
+				if t, err := time.Parse(time.RFC3339, s); err == nil {
+					nval = t.Unix()
+				}
+			}
+			nk := k
+			if rk, ok := v3Renames[k]; ok {
+				nk = rk
+			} else if rk, ok := v3RuleRenames[k]; ok && (parentKey == "rules" || parentKey == "global_rules") {
+				nk = rk
+			}
+			ret[nk] = nval
+		}
+		return ret
+	case []any:
+		if s, ok := localizedString(vv, lang); ok {
+			return s
+		}
+		ret := make([]any, 0, len(vv))
+		for _, val := range vv {
+			ret = append(ret, normalizeV3Value(val, parentKey, lang))
+		}
+		return ret
+	}
+	return v
+}
+
+// localizedString selects a translation from a v3 localized string, e.g. [{"text":"...","language":"en"}]
+func localizedString(v []any, lang string) (string, bool) {
+	if len(v) == 0 {
+		return "", false
+	}
+	ret := ""
+	for i, val := range v {
+		m, ok := val.(map[string]any)
+		if !ok || len(m) != 2 {
+			return "", false
+		}
+		text, ok1 := m["text"].(string)
+		tlang, ok2 := m["language"].(string)
+		if !ok1 || !ok2 {
+			return "", false
+		}
+		if i == 0 || (tlang == lang && lang != "") {
+			ret = text
+		}
+	}
+	return ret, true
+}
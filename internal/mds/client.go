@@ -0,0 +1,143 @@
+package mds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/request"
+)
+
+// DefaultVersion is the MDS provider API version requested if not otherwise specified.
+const DefaultVersion = "1.2.0"
+
+// maxPages limits the number of pages followed for a single request.
+const maxPages = 10000
+
+// Client requests data from an MDS provider API.
+// The trips and status_changes endpoints are queried one hour at a time,
+// and all pages are followed using the links.next url.
+type Client struct {
+	URL     string
+	Version string
+	reqOpts []request.RequestOption
+}
+
+// NewClient returns a new Client for the provider API base url.
+func NewClient(baseUrl string, version string, reqOpts ...request.RequestOption) *Client {
+	if version == "" {
+		version = DefaultVersion
+	}
+	return &Client{
+		URL:     strings.TrimSuffix(baseUrl, "/"),
+		Version: version,
+		reqOpts: reqOpts,
+	}
+}
+
+// Trips returns trips that ended between start and end, in whole hours.
+func (c *Client) Trips(ctx context.Context, start time.Time, end time.Time) ([]Trip, error) {
+	var ret []Trip
+	for _, hour := range hours(start, end) {
+		ents, err := getPages[Trip](ctx, c, c.endpoint("trips", "end_time", hour), "trips")
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, ents...)
+	}
+	return ret, nil
+}
+
+// StatusChanges returns status changes that occurred between start and end, in whole hours.
+func (c *Client) StatusChanges(ctx context.Context, start time.Time, end time.Time) ([]StatusChange, error) {
+	var ret []StatusChange
+	for _, hour := range hours(start, end) {
+		ents, err := getPages[StatusChange](ctx, c, c.endpoint("status_changes", "event_time", hour), "status_changes")
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, ents...)
+	}
+	return ret, nil
+}
+
+// Vehicles returns the current state of each vehicle.
+func (c *Client) Vehicles(ctx context.Context) ([]Vehicle, error) {
+	return getPages[Vehicle](ctx, c, c.URL+"/vehicles", "vehicles")
+}
+
+func (c *Client) endpoint(name string, param string, hour time.Time) string {
+	q := url.Values{}
+	q.Set(param, hour.In(time.UTC).Format("2006-01-02T15"))
+	return fmt.Sprintf("%s/%s?%s", c.URL, name, q.Encode())
+}
+
+func (c *Client) accept() string {
+	return fmt.Sprintf("application/vnd.mds+json;version=%s", majorMinor(c.Version))
+}
+
+type page struct {
+	Version string                     `json:"version"`
+	Data    map[string]json.RawMessage `json:"data"`
+	Links   struct {
+		Next string `json:"next"`
+	} `json:"links"`
+}
+
+func getPages[T any](ctx context.Context, c *Client, ustr string, name string) ([]T, error) {
+	var ret []T
+	seen := map[string]bool{}
+	reqOpts := append([]request.RequestOption{request.WithHeader("Accept", c.accept())}, c.reqOpts...)
+	for ustr != "" {
+		if seen[ustr] || len(seen) >= maxPages {
+			return nil, fmt.Errorf("too many pages or repeated page for %s", name)
+		}
+		seen[ustr] = true
+		log.For(ctx).Trace().Str("url", ustr).Msg("mds: requesting page")
+		var out bytes.Buffer
+		fr, err := request.AuthenticatedRequest(ctx, &out, ustr, reqOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if fr.FetchError != nil {
+			return nil, fr.FetchError
+		}
+		p := page{}
+		if err := json.Unmarshal(out.Bytes(), &p); err != nil {
+			return nil, fmt.Errorf("could not parse %s response: %w", name, err)
+		}
+		if data, ok := p.Data[name]; ok {
+			var ents []T
+			if err := json.Unmarshal(data, &ents); err != nil {
+				return nil, fmt.Errorf("could not parse %s response: %w", name, err)
+			}
+			ret = append(ret, ents...)
+		} else if p.Data == nil {
+			return nil, fmt.Errorf("no data in %s response", name)
+		}
+		ustr = p.Links.Next
+	}
+	return ret, nil
+}
+
+// hours returns the start of each hour between start and end.
+func hours(start time.Time, end time.Time) []time.Time {
+	var ret []time.Time
+	for t := start.In(time.UTC).Truncate(time.Hour); t.Before(end); t = t.Add(time.Hour) {
+		ret = append(ret, t)
+	}
+	return ret
+}
+
+func majorMinor(version string) string {
+	parts := strings.Split(version, ".")
+	if len(parts) > 2 {
+		parts = parts[0:2]
+	}
+	return strings.Join(parts, ".")
+}
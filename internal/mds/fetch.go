@@ -0,0 +1,190 @@
+package mds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/dmfr"
+	"github.com/interline-io/transitland-lib/fetch"
+	"github.com/interline-io/transitland-lib/request"
+	"github.com/interline-io/transitland-lib/tldb"
+	sq "github.com/irees/squirrel"
+)
+
+// Endpoints
+const (
+	EndpointTrips         = "trips"
+	EndpointStatusChanges = "status_changes"
+	EndpointVehicles      = "vehicles"
+)
+
+type Options struct {
+	// Version is the MDS provider API version to request
+	Version string
+	// Start and End select the hours of trips and status changes to fetch
+	Start     time.Time
+	End       time.Time
+	Endpoints []string
+	fetch.Options
+}
+
+type Result struct {
+	TripCount         int
+	StatusChangeCount int
+	VehicleCount      int
+	fetch.Result
+}
+
+// Fetch requests trips, status changes and vehicles from an MDS provider and saves them to the database.
+// Existing trips and status changes for the same feed and time range are replaced, as are all vehicles for the feed.
+// Fatal errors are returned as the error; request errors as Result.FetchError.
+func Fetch(ctx context.Context, atx tldb.Adapter, opts Options) (Result, error) {
+	result := Result{}
+	feed := dmfr.Feed{}
+	if err := atx.Get(ctx, &feed, "select * from current_feeds where id = ?", opts.FeedID); err != nil {
+		return result, err
+	}
+	if opts.FeedURL == "" {
+		opts.FeedURL = feed.URLs.MdsProvider
+	}
+	if opts.URLType == "" {
+		opts.URLType = "mds_provider"
+	}
+	result.URL = opts.FeedURL
+	if len(opts.Endpoints) == 0 {
+		opts.Endpoints = []string{EndpointTrips, EndpointStatusChanges, EndpointVehicles}
+	}
+	if opts.FetchedAt.IsZero() {
+		opts.FetchedAt = time.Now().In(time.UTC)
+	}
+
+	// Fetch and save
+	if opts.FeedURL == "" {
+		result.FetchError = errors.New("no url provided")
+	} else if reqOpts, err := requestOptions(feed, opts); err != nil {
+		result.FetchError = err
+	} else {
+		client := NewClient(opts.FeedURL, opts.Version, reqOpts...)
+		for _, endpoint := range opts.Endpoints {
+			b, err := fetchEndpoint(ctx, client, feed.ID, endpoint, opts, &result)
+			if err != nil {
+				result.FetchError = fmt.Errorf("%s: %w", endpoint, err)
+				break
+			}
+			if err := b.save(ctx, atx); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	// Prepare and save feed fetch record
+	tlfetch := dmfr.FeedFetch{}
+	tlfetch.FeedID = feed.ID
+	tlfetch.URLType = opts.URLType
+	tlfetch.FetchedAt.Set(opts.FetchedAt)
+	if !opts.HideURL {
+		tlfetch.URL = opts.FeedURL
+	}
+	if result.FetchError == nil {
+		tlfetch.Success = true
+	} else {
+		tlfetch.Success = false
+		tlfetch.FetchError.Set(result.FetchError.Error())
+	}
+	if _, err := atx.Insert(ctx, &tlfetch); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func requestOptions(feed dmfr.Feed, opts Options) ([]request.RequestOption, error) {
+	var reqOpts []request.RequestOption
+	if opts.AllowLocalFetch {
+		reqOpts = append(reqOpts, request.WithAllowLocal)
+	}
+	if opts.HostLimiter != nil {
+		reqOpts = append(reqOpts, request.WithHostLimiter(opts.HostLimiter), request.WithHostLimit(fetch.FeedHostLimit(feed)))
+	}
+	if feed.Authorization.Type != "" {
+		secret, err := feed.MatchSecrets(opts.Secrets, opts.URLType)
+		if err != nil {
+			return nil, err
+		}
+		reqOpts = append(reqOpts, request.WithAuth(secret, feed.Authorization))
+	}
+	return reqOpts, nil
+}
+
+// batch contains the entities from an endpoint and the existing rows they replace.
+type batch struct {
+	ents        []any
+	deleteTable string
+	deleteWhere sq.Sqlizer
+}
+
+func (b *batch) save(ctx context.Context, atx tldb.Adapter) error {
+	if _, err := atx.Sqrl().Delete(b.deleteTable).Where(b.deleteWhere).ExecContext(ctx); err != nil {
+		return err
+	}
+	for i := 0; i < len(b.ents); i += 1000 {
+		if _, err := atx.MultiInsert(ctx, b.ents[i:min(i+1000, len(b.ents))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fetchEndpoint(ctx context.Context, client *Client, feedID int, endpoint string, opts Options, result *Result) (*batch, error) {
+	b := batch{}
+	start, end := opts.Start.In(time.UTC).Truncate(time.Hour), opts.End.In(time.UTC)
+	switch endpoint {
+	case EndpointTrips:
+		trips, err := client.Trips(ctx, opts.Start, opts.End)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range trips {
+			b.ents = append(b.ents, t.Entity(feedID))
+		}
+		result.TripCount = len(trips)
+		b.deleteTable = dmfr.MdsTrip{}.TableName()
+		b.deleteWhere = sq.And{sq.Eq{"feed_id": feedID}, sq.GtOrEq{"end_time": start}, sq.Lt{"end_time": hourCeil(end)}}
+	case EndpointStatusChanges:
+		changes, err := client.StatusChanges(ctx, opts.Start, opts.End)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range changes {
+			b.ents = append(b.ents, s.Entity(feedID))
+		}
+		result.StatusChangeCount = len(changes)
+		b.deleteTable = dmfr.MdsStatusChange{}.TableName()
+		b.deleteWhere = sq.And{sq.Eq{"feed_id": feedID}, sq.GtOrEq{"event_time": start}, sq.Lt{"event_time": hourCeil(end)}}
+	case EndpointVehicles:
+		vehicles, err := client.Vehicles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vehicles {
+			b.ents = append(b.ents, v.Entity(feedID))
+		}
+		result.VehicleCount = len(vehicles)
+		b.deleteTable = dmfr.MdsVehicle{}.TableName()
+		b.deleteWhere = sq.Eq{"feed_id": feedID}
+	default:
+		return nil, fmt.Errorf("unknown endpoint '%s'", endpoint)
+	}
+	log.For(ctx).Info().Int("feed_id", feedID).Str("endpoint", endpoint).Int("count", len(b.ents)).Msg("mds: fetched")
+	return &b, nil
+}
+
+// hourCeil returns the end of the hour containing t, or t if t is on an hour.
+func hourCeil(t time.Time) time.Time {
+	if tt := t.Truncate(time.Hour); !tt.Equal(t) {
+		return tt.Add(time.Hour)
+	}
+	return t
+}
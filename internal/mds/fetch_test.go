@@ -0,0 +1,238 @@
+package mds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/interline-io/transitland-lib/dmfr"
+	"github.com/interline-io/transitland-lib/fetch"
+	"github.com/interline-io/transitland-lib/internal/testdb"
+	"github.com/interline-io/transitland-lib/request"
+	"github.com/interline-io/transitland-lib/tldb"
+	"github.com/mmcloughlin/geohash"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestProvider(t *testing.T, token string) *httptest.Server {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Accept") != "application/vnd.mds+json;version=1.2" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		var data map[string]any
+		links := map[string]any{}
+		switch r.URL.Path {
+		case "/trips":
+			if r.URL.Query().Get("end_time") != "2024-05-01T10" {
+				data = map[string]any{"trips": []any{}}
+				break
+			}
+			// Two pages
+			if r.URL.Query().Get("page") == "" {
+				data = map[string]any{"trips": []any{testTrip("trip1", 1714557600000, -122.41, 37.77, -122.40, 37.78)}}
+				links["next"] = fmt.Sprintf("%s/trips?end_time=2024-05-01T10&page=2", ts.URL)
+			} else {
+				data = map[string]any{"trips": []any{testTrip("trip2", 1714559400000, -122.40, 37.78, -122.41, 37.77)}}
+			}
+		case "/status_changes":
+			data = map[string]any{"status_changes": []any{}}
+			if r.URL.Query().Get("event_time") == "2024-05-01T10" {
+				data["status_changes"] = []any{map[string]any{
+					"provider_id":   "p1",
+					"device_id":     "d1",
+					"vehicle_id":    "v1",
+					"vehicle_type":  "scooter",
+					"vehicle_state": "available",
+					"event_types":   []string{"trip_end"},
+					"event_time":    1714557600000,
+					"event_location": map[string]any{
+						"type":     "Feature",
+						"geometry": map[string]any{"type": "Point", "coordinates": []float64{-122.40, 37.78}},
+					},
+					"battery_pct": 0.5,
+				}}
+			}
+		case "/vehicles":
+			data = map[string]any{"vehicles": []any{map[string]any{
+				"provider_id":        "p1",
+				"device_id":          "d1",
+				"vehicle_id":         "v1",
+				"vehicle_type":       "scooter",
+				"last_vehicle_state": "available",
+				"last_event_types":   []string{"trip_end"},
+				"last_event_time":    1714557600000,
+				"current_location": map[string]any{
+					"type":     "Feature",
+					"geometry": map[string]any{"type": "Point", "coordinates": []float64{-122.40, 37.78}},
+				},
+			}}}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"version": "1.2.0", "data": data, "links": links})
+	}))
+	return ts
+}
+
+func testTrip(tripID string, endTime int64, lon1, lat1, lon2, lat2 float64) map[string]any {
+	point := func(lon, lat float64, ts int64) map[string]any {
+		return map[string]any{
+			"type":       "Feature",
+			"geometry":   map[string]any{"type": "Point", "coordinates": []float64{lon, lat}},
+			"properties": map[string]any{"timestamp": ts},
+		}
+	}
+	return map[string]any{
+		"provider_id":   "p1",
+		"device_id":     "d1",
+		"vehicle_id":    "v1",
+		"vehicle_type":  "scooter",
+		"trip_id":       tripID,
+		"trip_duration": 600,
+		"trip_distance": 1500,
+		"start_time":    endTime - 600000,
+		"end_time":      endTime,
+		"route": map[string]any{
+			"type": "FeatureCollection",
+			"features": []any{
+				point(lon2, lat2, endTime),
+				point(lon1, lat1, endTime-600000),
+			},
+		},
+	}
+}
+
+func TestFetch(t *testing.T) {
+	ctx := context.TODO()
+	ts := newTestProvider(t, "secret")
+	defer ts.Close()
+	testdb.TempSqlite(func(atx tldb.Adapter) error {
+		feed := dmfr.Feed{FeedID: "test-mds", Spec: "mds"}
+		feed.URLs.MdsProvider = ts.URL
+		feed.Authorization = dmfr.FeedAuthorization{Type: "header", ParamName: "Authorization"}
+		feed.ID = testdb.ShouldInsert(t, atx, &feed)
+		opts := Options{
+			Start: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			End:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		}
+		opts.FeedID = feed.ID
+		opts.Secrets = []dmfr.Secret{{FeedID: "test-mds", Key: "Bearer secret"}}
+
+		// Fetch twice to check rows are replaced
+		for i := 0; i < 2; i++ {
+			result, err := Fetch(ctx, atx, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.FetchError != nil {
+				t.Fatal(result.FetchError)
+			}
+			assert.Equal(t, 2, result.TripCount)
+			assert.Equal(t, 1, result.StatusChangeCount)
+			assert.Equal(t, 1, result.VehicleCount)
+		}
+
+		var trips []*dmfr.MdsTrip
+		testdb.ShouldSelect(t, atx, &trips, "select * from tl_mds_trips where feed_id = ? order by trip_id", feed.ID)
+		if assert.Equal(t, 2, len(trips)) {
+			trip := trips[0]
+			assert.Equal(t, "trip1", trip.TripID)
+			assert.Equal(t, int64(600), trip.TripDuration.Val)
+			assert.Equal(t, int64(1500), trip.TripDistance.Val)
+			assert.True(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).Equal(trip.EndTime.Val))
+			assert.InDelta(t, -122.41, trip.StartGeometry.X(), 0.0001)
+			assert.InDelta(t, -122.40, trip.EndGeometry.X(), 0.0001)
+			assert.Equal(t, geohash.EncodeWithPrecision(37.77, -122.41, ZonePrecision), trip.StartZone.Val)
+			assert.Equal(t, "9q8yy", trip.StartZone.Val[0:5])
+		}
+		var changes []*dmfr.MdsStatusChange
+		testdb.ShouldSelect(t, atx, &changes, "select * from tl_mds_status_changes where feed_id = ?", feed.ID)
+		if assert.Equal(t, 1, len(changes)) {
+			assert.Equal(t, "available", changes[0].VehicleState.Val)
+			assert.Equal(t, []string{"trip_end"}, changes[0].EventTypes.Val)
+			assert.Equal(t, 0.5, changes[0].BatteryPct.Val)
+		}
+		var vehicles []*dmfr.MdsVehicle
+		testdb.ShouldSelect(t, atx, &vehicles, "select * from tl_mds_vehicles where feed_id = ?", feed.ID)
+		if assert.Equal(t, 1, len(vehicles)) {
+			assert.Equal(t, "available", vehicles[0].LastState.Val)
+		}
+		var fetches []*dmfr.FeedFetch
+		testdb.ShouldSelect(t, atx, &fetches, "select * from feed_fetches where feed_id = ?", feed.ID)
+		if assert.Equal(t, 2, len(fetches)) {
+			assert.True(t, fetches[0].Success)
+			assert.Equal(t, "mds_provider", fetches[0].URLType)
+		}
+		return nil
+	})
+}
+
+func TestFetch_Unauthorized(t *testing.T) {
+	ctx := context.TODO()
+	ts := newTestProvider(t, "secret")
+	defer ts.Close()
+	testdb.TempSqlite(func(atx tldb.Adapter) error {
+		feed := dmfr.Feed{FeedID: "test-mds", Spec: "mds"}
+		feed.URLs.MdsProvider = ts.URL
+		feed.ID = testdb.ShouldInsert(t, atx, &feed)
+		opts := Options{
+			Start: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			End:   time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+		}
+		opts.FeedID = feed.ID
+		result, err := Fetch(ctx, atx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.ErrorContains(t, result.FetchError, "401")
+		tlff := dmfr.FeedFetch{}
+		testdb.ShouldGet(t, atx, &tlff, "select * from feed_fetches where feed_id = ?", feed.ID)
+		assert.False(t, tlff.Success)
+		return nil
+	})
+}
+
+func TestFetch_HostLimit(t *testing.T) {
+	ctx := context.TODO()
+	ts := newTestProvider(t, "secret")
+	defer ts.Close()
+	testdb.TempSqlite(func(atx tldb.Adapter) error {
+		feed := dmfr.Feed{FeedID: "test-mds", Spec: "mds"}
+		feed.URLs.MdsProvider = ts.URL
+		feed.Authorization = dmfr.FeedAuthorization{Type: "header", ParamName: "Authorization"}
+		feed.Tags.Set(fetch.TagFetchRequestsPerMinute, "600")
+		feed.Tags.Valid = true
+		feed.ID = testdb.ShouldInsert(t, atx, &feed)
+		opts := Options{
+			Start: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			End:   time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+		}
+		opts.FeedID = feed.ID
+		opts.Secrets = []dmfr.Secret{{FeedID: "test-mds", Key: "Bearer secret"}}
+		opts.HostLimiter = request.NewHostLimiter(request.HostLimit{})
+
+		// Two pages of trips, status changes, and vehicles: 4 requests, one every 100ms
+		t0 := time.Now()
+		result, err := Fetch(ctx, atx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.FetchError != nil {
+			t.Fatal(result.FetchError)
+		}
+		assert.Equal(t, 2, result.TripCount)
+		assert.GreaterOrEqual(t, time.Since(t0), 280*time.Millisecond)
+		return nil
+	})
+}
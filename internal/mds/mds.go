@@ -0,0 +1,179 @@
+package mds
+
+import (
+	"time"
+
+	"github.com/interline-io/transitland-lib/dmfr"
+	"github.com/interline-io/transitland-lib/tt"
+	"github.com/mmcloughlin/geohash"
+)
+
+// ZonePrecision is the geohash precision used for zones; precision 7 is roughly 150m.
+const ZonePrecision = 7
+
+// Trip is a trip from the trips endpoint.
+type Trip struct {
+	ProviderID   string             `json:"provider_id"`
+	DeviceID     string             `json:"device_id"`
+	VehicleID    string             `json:"vehicle_id"`
+	VehicleType  string             `json:"vehicle_type"`
+	TripID       string             `json:"trip_id"`
+	TripDuration *float64           `json:"trip_duration"`
+	TripDistance *float64           `json:"trip_distance"`
+	StartTime    float64            `json:"start_time"`
+	EndTime      float64            `json:"end_time"`
+	Route        *FeatureCollection `json:"route"`
+}
+
+// StatusChange is a vehicle event from the status_changes endpoint.
+type StatusChange struct {
+	ProviderID    string   `json:"provider_id"`
+	DeviceID      string   `json:"device_id"`
+	VehicleID     string   `json:"vehicle_id"`
+	VehicleType   string   `json:"vehicle_type"`
+	VehicleState  string   `json:"vehicle_state"`
+	EventTypes    []string `json:"event_types"`
+	EventTime     float64  `json:"event_time"`
+	EventLocation *Feature `json:"event_location"`
+	BatteryPct    *float64 `json:"battery_pct"`
+	TripID        string   `json:"trip_id"`
+}
+
+// Vehicle is the current state of a vehicle from the vehicles endpoint.
+type Vehicle struct {
+	ProviderID        string   `json:"provider_id"`
+	DeviceID          string   `json:"device_id"`
+	VehicleID         string   `json:"vehicle_id"`
+	VehicleType       string   `json:"vehicle_type"`
+	LastState         string   `json:"last_state"`
+	LastVehicleState  string   `json:"last_vehicle_state"`
+	LastEventTypes    []string `json:"last_event_types"`
+	LastEventTime     float64  `json:"last_event_time"`
+	LastEventLocation *Feature `json:"last_event_location"`
+	CurrentLocation   *Feature `json:"current_location"`
+	BatteryPct        *float64 `json:"battery_pct"`
+}
+
+// FeatureCollection is a GeoJSON FeatureCollection of timestamped points.
+type FeatureCollection struct {
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON Feature with a Point geometry.
+type Feature struct {
+	Geometry struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		Timestamp float64 `json:"timestamp"`
+	} `json:"properties"`
+}
+
+func (f *Feature) point() (tt.Point, tt.String) {
+	if f == nil || f.Geometry.Type != "Point" || len(f.Geometry.Coordinates) < 2 {
+		return tt.Point{}, tt.String{}
+	}
+	lon, lat := f.Geometry.Coordinates[0], f.Geometry.Coordinates[1]
+	return tt.NewPoint(lon, lat), tt.NewString(geohash.EncodeWithPrecision(lat, lon, ZonePrecision))
+}
+
+// Entity converts a trip to a database entity.
+func (t *Trip) Entity(feedID int) *dmfr.MdsTrip {
+	ent := dmfr.MdsTrip{
+		FeedID:      feedID,
+		ProviderID:  optString(t.ProviderID),
+		DeviceID:    optString(t.DeviceID),
+		VehicleID:   optString(t.VehicleID),
+		VehicleType: optString(t.VehicleType),
+		TripID:      t.TripID,
+		StartTime:   msTime(t.StartTime),
+		EndTime:     msTime(t.EndTime),
+	}
+	if t.TripDuration != nil {
+		ent.TripDuration = tt.NewInt(int(*t.TripDuration))
+	}
+	if t.TripDistance != nil {
+		ent.TripDistance = tt.NewInt(int(*t.TripDistance))
+	}
+	// The route is a list of points ordered by timestamp
+	if t.Route != nil && len(t.Route.Features) > 0 {
+		first, last := 0, 0
+		for i, f := range t.Route.Features {
+			if f.Properties.Timestamp < t.Route.Features[first].Properties.Timestamp {
+				first = i
+			}
+			if f.Properties.Timestamp >= t.Route.Features[last].Properties.Timestamp {
+				last = i
+			}
+		}
+		ent.StartGeometry, ent.StartZone = t.Route.Features[first].point()
+		ent.EndGeometry, ent.EndZone = t.Route.Features[last].point()
+	}
+	return &ent
+}
+
+// Entity converts a status change to a database entity.
+func (s *StatusChange) Entity(feedID int) *dmfr.MdsStatusChange {
+	ent := dmfr.MdsStatusChange{
+		FeedID:       feedID,
+		ProviderID:   optString(s.ProviderID),
+		DeviceID:     optString(s.DeviceID),
+		VehicleID:    optString(s.VehicleID),
+		VehicleType:  optString(s.VehicleType),
+		VehicleState: optString(s.VehicleState),
+		EventTime:    msTime(s.EventTime),
+		TripID:       optString(s.TripID),
+	}
+	if len(s.EventTypes) > 0 {
+		ent.EventTypes = tt.NewStrings(s.EventTypes)
+	}
+	if s.BatteryPct != nil {
+		ent.BatteryPct = tt.NewFloat(*s.BatteryPct)
+	}
+	ent.Geometry, ent.Zone = s.EventLocation.point()
+	return &ent
+}
+
+// Entity converts a vehicle to a database entity.
+func (v *Vehicle) Entity(feedID int) *dmfr.MdsVehicle {
+	ent := dmfr.MdsVehicle{
+		FeedID:        feedID,
+		ProviderID:    optString(v.ProviderID),
+		DeviceID:      optString(v.DeviceID),
+		VehicleID:     optString(v.VehicleID),
+		VehicleType:   optString(v.VehicleType),
+		LastState:     optString(v.LastVehicleState),
+		LastEventTime: msTime(v.LastEventTime),
+	}
+	if !ent.LastState.Valid {
+		ent.LastState = optString(v.LastState)
+	}
+	if len(v.LastEventTypes) > 0 {
+		ent.LastEventTypes = tt.NewStrings(v.LastEventTypes)
+	}
+	if v.BatteryPct != nil {
+		ent.BatteryPct = tt.NewFloat(*v.BatteryPct)
+	}
+	loc := v.CurrentLocation
+	if loc == nil {
+		loc = v.LastEventLocation
+	}
+	ent.Geometry, ent.Zone = loc.point()
+	return &ent
+}
+
+func optString(v string) tt.String {
+	if v == "" {
+		return tt.String{}
+	}
+	return tt.NewString(v)
+}
+
+// msTime converts a timestamp in milliseconds since the epoch.
+func msTime(v float64) tt.Time {
+	if v <= 0 {
+		return tt.Time{}
+	}
+	return tt.NewTime(time.UnixMilli(int64(v)).In(time.UTC))
+}
@@ -32,6 +32,17 @@ func AllEntities(reader adapters.Reader, cb func(tt.Entity)) {
 		}
 	}
 
+	// flex locations
+	for ent := range reader.Locations() {
+		cb(&ent)
+	}
+	for ent := range reader.LocationGroups() {
+		cb(&ent)
+	}
+	for ent := range reader.LocationGroupStops() {
+		cb(&ent)
+	}
+
 	// shapes
 	for ent := range reader.Shapes() {
 		cb(&ent)
@@ -47,6 +58,9 @@ func AllEntities(reader adapters.Reader, cb func(tt.Entity)) {
 	for cd := range reader.CalendarDates() {
 		cb(&cd)
 	}
+	for ent := range reader.BookingRules() {
+		cb(&ent)
+	}
 
 	// trips and stop times
 	for ent := range reader.Trips() {
@@ -82,6 +82,32 @@ var ExampleZipNestedZip = ReaderTester{
 	URL: testpath.RelPath("testdata/gtfs-examples/example-nested-zip.zip#example-nested-zip/example.zip"),
 }
 
+// ExampleFlex - GTFS-Flex test feed
+var ExampleFlex = ReaderTester{
+	URL: testpath.RelPath("testdata/gtfs-examples/example-flex"),
+	Counts: map[string]int{
+		"agency.txt":               1,
+		"routes.txt":               2,
+		"trips.txt":                3,
+		"stops.txt":                3,
+		"stop_times.txt":           7,
+		"calendar.txt":             1,
+		"locations.geojson":        2,
+		"location_groups.txt":      1,
+		"location_group_stops.txt": 2,
+		"booking_rules.txt":        2,
+	},
+	EntityIDs: map[string][]string{
+		"agency.txt":          {"flexagency"},
+		"routes.txt":          {"dial_a_ride", "deviated"},
+		"trips.txt":           {"dar_am", "dar_pm", "dev_1"},
+		"stops.txt":           {"main_st", "oak_st", "hospital"},
+		"locations.geojson":   {"zone_a", "zone_b"},
+		"location_groups.txt": {"downtown"},
+		"booking_rules.txt":   {"same_day", "advance"},
+	},
+}
+
 // ExampleFeedBART - BART test feed
 var ExampleFeedBART = ReaderTester{
 	URL: testpath.RelPath("testdata/gtfs-external/bart.zip"),
@@ -0,0 +1,304 @@
+package netex
+
+import "encoding/xml"
+
+// XML structures for the subset of NeTEx used by the Writer.
+// Element order follows the NeTEx XSD sequence order.
+// Optional lists are slice pointers: encoding/xml writes the parent element for empty slices, but not for nil pointers.
+
+const netexNamespace = "http://www.netex.org.uk/netex"
+const netexVersion = "1.15:NO-NeTEx-networktimetable:1.5"
+
+type ref struct {
+	Ref     string `xml:"ref,attr"`
+	Version string `xml:"version,attr,omitempty"`
+}
+
+type versioned struct {
+	ID      string `xml:"id,attr"`
+	Version string `xml:"version,attr"`
+}
+
+type publicationDelivery struct {
+	XMLName              xml.Name    `xml:"PublicationDelivery"`
+	Xmlns                string      `xml:"xmlns,attr"`
+	XmlnsGml             string      `xml:"xmlns:gml,attr"`
+	Version              string      `xml:"version,attr"`
+	PublicationTimestamp string      `xml:"PublicationTimestamp"`
+	ParticipantRef       string      `xml:"ParticipantRef"`
+	Description          string      `xml:"Description,omitempty"`
+	DataObjects          dataObjects `xml:"dataObjects"`
+}
+
+type dataObjects struct {
+	CompositeFrame compositeFrame `xml:"CompositeFrame"`
+}
+
+type compositeFrame struct {
+	versioned
+	Created       string        `xml:"created,attr,omitempty"`
+	Codespaces    []codespace   `xml:"codespaces>Codespace"`
+	FrameDefaults frameDefaults `xml:"FrameDefaults"`
+	Frames        frames        `xml:"frames"`
+}
+
+type codespace struct {
+	ID       string `xml:"id,attr"`
+	Xmlns    string `xml:"Xmlns"`
+	XmlnsUrl string `xml:"XmlnsUrl"`
+}
+
+type frameDefaults struct {
+	DefaultLocale defaultLocale `xml:"DefaultLocale"`
+}
+
+type defaultLocale struct {
+	TimeZone        string `xml:"TimeZone,omitempty"`
+	DefaultLanguage string `xml:"DefaultLanguage,omitempty"`
+}
+
+type frames struct {
+	ResourceFrame        *resourceFrame        `xml:"ResourceFrame,omitempty"`
+	SiteFrame            *siteFrame            `xml:"SiteFrame,omitempty"`
+	ServiceFrame         *serviceFrame         `xml:"ServiceFrame,omitempty"`
+	ServiceCalendarFrame *serviceCalendarFrame `xml:"ServiceCalendarFrame,omitempty"`
+	TimetableFrame       *timetableFrame       `xml:"TimetableFrame,omitempty"`
+}
+
+// Resource frame
+
+type resourceFrame struct {
+	versioned
+	Authorities *[]organisation `xml:"organisations>Authority"`
+	Operators   *[]organisation `xml:"organisations>Operator"`
+}
+
+type organisation struct {
+	versioned
+	CompanyNumber    string          `xml:"CompanyNumber,omitempty"`
+	Name             string          `xml:"Name"`
+	LegalName        string          `xml:"LegalName,omitempty"`
+	ContactDetails   *contactDetails `xml:"ContactDetails,omitempty"`
+	OrganisationType string          `xml:"OrganisationType"`
+}
+
+type contactDetails struct {
+	Email string `xml:"Email,omitempty"`
+	Phone string `xml:"Phone,omitempty"`
+	Url   string `xml:"Url,omitempty"`
+}
+
+// Site frame
+
+type siteFrame struct {
+	versioned
+	StopPlaces *[]stopPlace `xml:"stopPlaces>StopPlace"`
+}
+
+type centroid struct {
+	Longitude float64 `xml:"Location>Longitude"`
+	Latitude  float64 `xml:"Location>Latitude"`
+}
+
+type accessibilityAssessment struct {
+	versioned
+	MobilityImpairedAccess string `xml:"MobilityImpairedAccess"`
+	WheelchairAccess       string `xml:"limitations>AccessibilityLimitation>WheelchairAccess"`
+}
+
+type stopPlace struct {
+	versioned
+	Name                    string                   `xml:"Name"`
+	Description             string                   `xml:"Description,omitempty"`
+	Centroid                *centroid                `xml:"Centroid,omitempty"`
+	Url                     string                   `xml:"Url,omitempty"`
+	AccessibilityAssessment *accessibilityAssessment `xml:"AccessibilityAssessment,omitempty"`
+	Levels                  *[]level                 `xml:"levels>Level"`
+	Entrances               *[]stopPlaceEntrance     `xml:"entrances>StopPlaceEntrance"`
+	PublicCode              string                   `xml:"PublicCode,omitempty"`
+	TransportMode           string                   `xml:"TransportMode"`
+	StopPlaceType           string                   `xml:"StopPlaceType,omitempty"`
+	Quays                   *[]quay                  `xml:"quays>Quay"`
+}
+
+type level struct {
+	versioned
+	Name       string `xml:"Name,omitempty"`
+	PublicCode string `xml:"PublicCode,omitempty"`
+}
+
+type stopPlaceEntrance struct {
+	versioned
+	Name     string    `xml:"Name,omitempty"`
+	Centroid *centroid `xml:"Centroid,omitempty"`
+	LevelRef *ref      `xml:"LevelRef,omitempty"`
+}
+
+type quay struct {
+	versioned
+	Name                    string                   `xml:"Name,omitempty"`
+	Description             string                   `xml:"Description,omitempty"`
+	Centroid                *centroid                `xml:"Centroid,omitempty"`
+	AccessibilityAssessment *accessibilityAssessment `xml:"AccessibilityAssessment,omitempty"`
+	LevelRef                *ref                     `xml:"LevelRef,omitempty"`
+	PublicCode              string                   `xml:"PublicCode,omitempty"`
+}
+
+// Service frame
+
+type serviceFrame struct {
+	versioned
+	Networks               *[]network                 `xml:"additionalNetworks>Network"`
+	RoutePoints            *[]routePoint              `xml:"routePoints>RoutePoint"`
+	Routes                 *[]route                   `xml:"routes>Route"`
+	Lines                  *[]line                    `xml:"lines>Line"`
+	DestinationDisplays    *[]destinationDisplay      `xml:"destinationDisplays>DestinationDisplay"`
+	ScheduledStopPoints    *[]scheduledStopPoint      `xml:"scheduledStopPoints>ScheduledStopPoint"`
+	PassengerStopAssigment *[]passengerStopAssignment `xml:"stopAssignments>PassengerStopAssignment"`
+	JourneyPatterns        *[]serviceJourneyPattern   `xml:"journeyPatterns>ServiceJourneyPattern"`
+}
+
+type network struct {
+	versioned
+	Name         string `xml:"Name"`
+	AuthorityRef ref    `xml:"AuthorityRef"`
+}
+
+type routePoint struct {
+	versioned
+	Projection pointProjection `xml:"projections>PointProjection"`
+}
+
+type pointProjection struct {
+	versioned
+	ProjectToPointRef ref `xml:"ProjectToPointRef"`
+}
+
+type route struct {
+	versioned
+	Name             string         `xml:"Name,omitempty"`
+	LineRef          ref            `xml:"LineRef"`
+	DirectionType    string         `xml:"DirectionType,omitempty"`
+	PointsInSequence []pointOnRoute `xml:"pointsInSequence>PointOnRoute"`
+}
+
+type pointOnRoute struct {
+	versioned
+	Order         int `xml:"order,attr"`
+	RoutePointRef ref `xml:"RoutePointRef"`
+}
+
+type line struct {
+	versioned
+	Name                  string        `xml:"Name"`
+	Description           string        `xml:"Description,omitempty"`
+	TransportMode         string        `xml:"TransportMode"`
+	Url                   string        `xml:"Url,omitempty"`
+	PublicCode            string        `xml:"PublicCode,omitempty"`
+	PrivateCode           string        `xml:"PrivateCode,omitempty"`
+	OperatorRef           *ref          `xml:"OperatorRef,omitempty"`
+	RepresentedByGroupRef *ref          `xml:"RepresentedByGroupRef,omitempty"`
+	Presentation          *presentation `xml:"Presentation,omitempty"`
+}
+
+type presentation struct {
+	Colour     string `xml:"Colour,omitempty"`
+	TextColour string `xml:"TextColour,omitempty"`
+}
+
+type destinationDisplay struct {
+	versioned
+	FrontText string `xml:"FrontText"`
+}
+
+type scheduledStopPoint struct {
+	versioned
+	Name string `xml:"Name,omitempty"`
+}
+
+type passengerStopAssignment struct {
+	versioned
+	Order                 int `xml:"order,attr"`
+	ScheduledStopPointRef ref `xml:"ScheduledStopPointRef"`
+	StopPlaceRef          ref `xml:"StopPlaceRef"`
+	QuayRef               ref `xml:"QuayRef"`
+}
+
+type serviceJourneyPattern struct {
+	versioned
+	Name             string                      `xml:"Name,omitempty"`
+	RouteRef         ref                         `xml:"RouteRef"`
+	PointsInSequence []stopPointInJourneyPattern `xml:"pointsInSequence>StopPointInJourneyPattern"`
+}
+
+type stopPointInJourneyPattern struct {
+	versioned
+	Order                 int   `xml:"order,attr"`
+	ScheduledStopPointRef ref   `xml:"ScheduledStopPointRef"`
+	ForAlighting          *bool `xml:"ForAlighting,omitempty"`
+	ForBoarding           *bool `xml:"ForBoarding,omitempty"`
+	DestinationDisplayRef *ref  `xml:"DestinationDisplayRef,omitempty"`
+}
+
+// Service calendar frame
+
+type serviceCalendarFrame struct {
+	versioned
+	DayTypes           *[]dayType           `xml:"dayTypes>DayType"`
+	OperatingPeriods   *[]operatingPeriod   `xml:"operatingPeriods>OperatingPeriod"`
+	DayTypeAssignments *[]dayTypeAssignment `xml:"dayTypeAssignments>DayTypeAssignment"`
+}
+
+type dayType struct {
+	versioned
+	DaysOfWeek *string `xml:"properties>PropertyOfDay>DaysOfWeek"`
+}
+
+type operatingPeriod struct {
+	versioned
+	FromDate string `xml:"FromDate"`
+	ToDate   string `xml:"ToDate"`
+}
+
+type dayTypeAssignment struct {
+	versioned
+	Order              int    `xml:"order,attr"`
+	OperatingPeriodRef *ref   `xml:"OperatingPeriodRef,omitempty"`
+	Date               string `xml:"Date,omitempty"`
+	DayTypeRef         ref    `xml:"DayTypeRef"`
+	IsAvailable        *bool  `xml:"isAvailable,omitempty"`
+}
+
+// Timetable frame
+
+type timetableFrame struct {
+	versioned
+	ServiceJourneys *[]serviceJourney `xml:"vehicleJourneys>ServiceJourney"`
+}
+
+type serviceJourney struct {
+	versioned
+	Name              string                  `xml:"Name,omitempty"`
+	PrivateCode       string                  `xml:"PrivateCode,omitempty"`
+	DayTypes          []ref                   `xml:"dayTypes>DayTypeRef"`
+	JourneyPatternRef ref                     `xml:"JourneyPatternRef"`
+	OperatorRef       *ref                    `xml:"OperatorRef,omitempty"`
+	PassingTimes      []timetabledPassingTime `xml:"passingTimes>TimetabledPassingTime"`
+}
+
+type timetabledPassingTime struct {
+	versioned
+	StopPointInJourneyPatternRef ref    `xml:"StopPointInJourneyPatternRef"`
+	ArrivalTime                  string `xml:"ArrivalTime,omitempty"`
+	ArrivalDayOffset             int    `xml:"ArrivalDayOffset,omitempty"`
+	DepartureTime                string `xml:"DepartureTime,omitempty"`
+	DepartureDayOffset           int    `xml:"DepartureDayOffset,omitempty"`
+}
+
+// listPtr returns nil for an empty list.
+func listPtr[T any](v []T) *[]T {
+	if len(v) == 0 {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,15 @@
+// Package netex provides a Writer that exports GTFS as a NeTEx (Nordic/EPIP profile) PublicationDelivery.
+package netex
+
+import (
+	"github.com/interline-io/transitland-lib/adapters"
+	"github.com/interline-io/transitland-lib/ext"
+)
+
+func init() {
+	// Register writers
+	w := func(url string) (adapters.Writer, error) { return NewWriter(url) }
+	ext.RegisterWriter("netex", w)
+	// Only claim a NeTEx specific suffix; plain .xml requires the netex:// scheme
+	ext.RegisterWriterSuffix(".netex.xml", "netex")
+}
@@ -0,0 +1,807 @@
+package netex
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/interline-io/transitland-lib/adapters"
+	"github.com/interline-io/transitland-lib/copier"
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+type hasEntityKey interface {
+	EntityKey() string
+}
+
+// Writer writes a NeTEx PublicationDelivery document using the Nordic/EPIP profile.
+// Entities are collected in memory and the document is written when the Writer is closed.
+// Shapes, frequencies, transfers, pathways and fares are not exported.
+type Writer struct {
+	Codespace string
+	path      string
+	agencies  []gtfs.Agency
+	routes    []gtfs.Route
+	levels    []gtfs.Level
+	stops     []gtfs.Stop
+	calendars []gtfs.Calendar
+	calDates  map[string][]gtfs.CalendarDate
+	trips     []gtfs.Trip
+	stopTimes map[string][]gtfs.StopTime
+}
+
+// NewWriter returns a new Writer.
+// The path may include a "netex://" prefix and a "codespace" query parameter, e.g. "netex://output.xml?codespace=ABC".
+func NewWriter(path string) (*Writer, error) {
+	path = strings.TrimPrefix(path, "netex://")
+	codespace := "TL"
+	if i := strings.Index(path, "?"); i >= 0 {
+		q, err := url.ParseQuery(path[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		if v := q.Get("codespace"); v != "" {
+			codespace = strings.ToUpper(v)
+		}
+		path = path[:i]
+	}
+	if path == "" {
+		return nil, errors.New("no output path")
+	}
+	return &Writer{
+		Codespace: codespace,
+		path:      path,
+		calDates:  map[string][]gtfs.CalendarDate{},
+		stopTimes: map[string][]gtfs.StopTime{},
+	}, nil
+}
+
+func (writer *Writer) String() string {
+	return writer.path
+}
+
+// Open the Writer.
+func (writer *Writer) Open() error {
+	return nil
+}
+
+// Create the necessary files for the Writer.
+func (writer *Writer) Create() error {
+	return nil
+}
+
+// Delete the Writer.
+func (writer *Writer) Delete() error {
+	return nil
+}
+
+// NewReader is not supported; NeTEx can not be read back as GTFS.
+func (writer *Writer) NewReader() (adapters.Reader, error) {
+	return nil, errors.New("netex writer does not support reading")
+}
+
+// AddEntity adds an entity to the output.
+func (writer *Writer) AddEntity(ent tt.Entity) (string, error) {
+	eids, err := writer.AddEntities([]tt.Entity{ent})
+	if err != nil {
+		return "", err
+	}
+	if len(eids) == 0 {
+		return "", errors.New("did not write expected number of entities")
+	}
+	return eids[0], nil
+}
+
+// AddEntities adds entities to the output.
+func (writer *Writer) AddEntities(ents []tt.Entity) ([]string, error) {
+	eids := make([]string, 0, len(ents))
+	for _, ent := range ents {
+		switch v := ent.(type) {
+		case *gtfs.Agency:
+			writer.agencies = append(writer.agencies, *v)
+		case *gtfs.Route:
+			writer.routes = append(writer.routes, *v)
+		case *gtfs.Level:
+			writer.levels = append(writer.levels, *v)
+		case *gtfs.Stop:
+			writer.stops = append(writer.stops, *v)
+		case *gtfs.Calendar:
+			writer.calendars = append(writer.calendars, *v)
+		case *gtfs.CalendarDate:
+			writer.calDates[v.ServiceID.Val] = append(writer.calDates[v.ServiceID.Val], *v)
+		case *gtfs.Trip:
+			writer.trips = append(writer.trips, *v)
+		case *gtfs.StopTime:
+			writer.stopTimes[v.TripID.Val] = append(writer.stopTimes[v.TripID.Val], *v)
+		}
+		eid := ent.EntityID()
+		if v, ok := ent.(hasEntityKey); ok {
+			eid = v.EntityKey()
+		}
+		eids = append(eids, eid)
+	}
+	return eids, nil
+}
+
+// Close writes the NeTEx document.
+func (writer *Writer) Close() error {
+	doc := writer.buildDocument(time.Now().UTC())
+	out, err := os.Create(writer.path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := out.WriteString(xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "  ")
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+/////////
+
+// escapeID escapes characters that are not allowed in NeTEx identifiers as _xHH.
+// Escapes can not be confused with the numeric suffixes that follow "_", so distinct values keep distinct identifiers.
+func escapeID(value string) string {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || c == '-' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "_x%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// id returns a NeTEx identifier in the form CODESPACE:Type:value, with optional numeric suffixes.
+func (writer *Writer) id(typ string, value string, suffix ...int) string {
+	var sb strings.Builder
+	sb.WriteString(escapeID(value))
+	for _, n := range suffix {
+		sb.WriteString("_")
+		sb.WriteString(strconv.Itoa(n))
+	}
+	return fmt.Sprintf("%s:%s:%s", writer.Codespace, typ, sb.String())
+}
+
+func (writer *Writer) versioned(typ string, value string, suffix ...int) versioned {
+	return versioned{ID: writer.id(typ, value, suffix...), Version: "1"}
+}
+
+func (writer *Writer) ref(typ string, value string, suffix ...int) ref {
+	return ref{Ref: writer.id(typ, value, suffix...), Version: "1"}
+}
+
+func (writer *Writer) refPtr(typ string, value string, suffix ...int) *ref {
+	r := writer.ref(typ, value, suffix...)
+	return &r
+}
+
+func (writer *Writer) buildDocument(now time.Time) publicationDelivery {
+	timezone := ""
+	lang := ""
+	if len(writer.agencies) > 0 {
+		timezone = writer.agencies[0].AgencyTimezone.Val
+		lang = writer.agencies[0].AgencyLang.Val
+	}
+	frame := compositeFrame{
+		versioned: writer.versioned("CompositeFrame", "1"),
+		Created:   now.Format("2006-01-02T15:04:05"),
+		Codespaces: []codespace{{
+			ID:       strings.ToLower(writer.Codespace),
+			Xmlns:    writer.Codespace,
+			XmlnsUrl: "http://www.rutebanken.org/ns/" + strings.ToLower(writer.Codespace),
+		}},
+		FrameDefaults: frameDefaults{DefaultLocale: defaultLocale{TimeZone: timezone, DefaultLanguage: lang}},
+	}
+	routeModes := writer.routeModes()
+	stopModes := writer.stopModes(routeModes)
+	frame.Frames.ResourceFrame = writer.buildResourceFrame()
+	frame.Frames.SiteFrame = writer.buildSiteFrame(stopModes)
+	serviceFrame, patternIDs := writer.buildServiceFrame(routeModes)
+	frame.Frames.ServiceFrame = serviceFrame
+	frame.Frames.ServiceCalendarFrame = writer.buildServiceCalendarFrame()
+	frame.Frames.TimetableFrame = writer.buildTimetableFrame(patternIDs)
+	return publicationDelivery{
+		Xmlns:                netexNamespace,
+		XmlnsGml:             "http://www.opengis.net/gml/3.2",
+		Version:              netexVersion,
+		PublicationTimestamp: now.Format("2006-01-02T15:04:05"),
+		ParticipantRef:       writer.Codespace,
+		DataObjects:          dataObjects{CompositeFrame: frame},
+	}
+}
+
+func (writer *Writer) buildResourceFrame() *resourceFrame {
+	frame := resourceFrame{versioned: writer.versioned("ResourceFrame", "1")}
+	var authorities, operators []organisation
+	for _, agency := range writer.agencies {
+		aid := agencyKey(agency)
+		contact := &contactDetails{
+			Email: agency.AgencyEmail.Val,
+			Phone: agency.AgencyPhone.Val,
+			Url:   agency.AgencyURL.Val,
+		}
+		authorities = append(authorities, organisation{
+			versioned:        writer.versioned("Authority", aid),
+			CompanyNumber:    aid,
+			Name:             agency.AgencyName.Val,
+			LegalName:        agency.AgencyName.Val,
+			ContactDetails:   contact,
+			OrganisationType: "authority",
+		})
+		operators = append(operators, organisation{
+			versioned:        writer.versioned("Operator", aid),
+			CompanyNumber:    aid,
+			Name:             agency.AgencyName.Val,
+			LegalName:        agency.AgencyName.Val,
+			ContactDetails:   contact,
+			OrganisationType: "operator",
+		})
+	}
+	frame.Authorities = listPtr(authorities)
+	frame.Operators = listPtr(operators)
+	return &frame
+}
+
+func (writer *Writer) buildSiteFrame(stopModes map[string]string) *siteFrame {
+	frame := siteFrame{versioned: writer.versioned("SiteFrame", "1")}
+	levels := map[string]gtfs.Level{}
+	for _, lvl := range writer.levels {
+		levels[lvl.LevelID.Val] = lvl
+	}
+	var places []stopPlace
+	var placeLevels [][]level
+	var placeEntrances [][]stopPlaceEntrance
+	var placeQuays [][]quay
+	addPlace := func(sp stopPlace) int {
+		places = append(places, sp)
+		placeLevels = append(placeLevels, nil)
+		placeEntrances = append(placeEntrances, nil)
+		placeQuays = append(placeQuays, nil)
+		return len(places) - 1
+	}
+	stations := map[string]int{}
+	for _, stop := range writer.stops {
+		if stop.LocationType.Val != 1 {
+			continue
+		}
+		stations[stop.StopID.Val] = addPlace(stopPlace{
+			versioned:               writer.versioned("StopPlace", stop.StopID.Val),
+			Name:                    stop.StopName.Val,
+			Description:             stop.StopDesc.Val,
+			Centroid:                stopCentroid(stop),
+			Url:                     stop.StopURL.Val,
+			AccessibilityAssessment: writer.accessibility(stop),
+			PublicCode:              stop.StopCode.Val,
+		})
+	}
+	for _, stop := range writer.stops {
+		lt := stop.LocationType.Val
+		if lt != 0 && lt != 2 {
+			// Generic nodes and boarding areas are not exported
+			continue
+		}
+		idx, ok := stations[stop.ParentStation.Val]
+		if !ok {
+			if lt != 0 {
+				continue
+			}
+			// Stops without a parent station become a StopPlace with a single Quay
+			idx = addPlace(stopPlace{
+				versioned:   writer.versioned("StopPlace", stop.StopID.Val),
+				Name:        stop.StopName.Val,
+				Description: stop.StopDesc.Val,
+				Centroid:    stopCentroid(stop),
+				Url:         stop.StopURL.Val,
+			})
+		}
+		sp := &places[idx]
+		var levelRef *ref
+		if lvl, ok := levels[stop.LevelID.Val]; ok {
+			levelRef = writer.refPtr("Level", lvl.LevelID.Val)
+			found := false
+			for _, l := range placeLevels[idx] {
+				if l.ID == levelRef.Ref {
+					found = true
+				}
+			}
+			if !found {
+				placeLevels[idx] = append(placeLevels[idx], level{
+					versioned:  writer.versioned("Level", lvl.LevelID.Val),
+					Name:       lvl.LevelName.Val,
+					PublicCode: strconv.FormatFloat(lvl.LevelIndex.Val, 'f', -1, 64),
+				})
+			}
+		}
+		if lt == 2 {
+			placeEntrances[idx] = append(placeEntrances[idx], stopPlaceEntrance{
+				versioned: writer.versioned("StopPlaceEntrance", stop.StopID.Val),
+				Name:      stop.StopName.Val,
+				Centroid:  stopCentroid(stop),
+				LevelRef:  levelRef,
+			})
+			continue
+		}
+		if mode, ok := stopModes[stop.StopID.Val]; ok && sp.TransportMode == "" {
+			sp.TransportMode = mode
+		}
+		placeQuays[idx] = append(placeQuays[idx], quay{
+			versioned:               writer.versioned("Quay", stop.StopID.Val),
+			Name:                    stop.StopName.Val,
+			Description:             stop.StopDesc.Val,
+			Centroid:                stopCentroid(stop),
+			AccessibilityAssessment: writer.accessibility(stop),
+			LevelRef:                levelRef,
+			PublicCode:              stop.PlatformCode.Val,
+		})
+	}
+	for i := range places {
+		sp := &places[i]
+		sp.Levels = listPtr(placeLevels[i])
+		sp.Entrances = listPtr(placeEntrances[i])
+		sp.Quays = listPtr(placeQuays[i])
+		if sp.TransportMode == "" {
+			sp.TransportMode = "bus"
+		}
+		sp.StopPlaceType = stopPlaceType(sp.TransportMode)
+	}
+	frame.StopPlaces = listPtr(places)
+	return &frame
+}
+
+func (writer *Writer) accessibility(stop gtfs.Stop) *accessibilityAssessment {
+	if !stop.WheelchairBoarding.Valid || stop.WheelchairBoarding.Val == 0 {
+		return nil
+	}
+	access := "false"
+	if stop.WheelchairBoarding.Val == 1 {
+		access = "true"
+	}
+	return &accessibilityAssessment{
+		versioned:              writer.versioned("AccessibilityAssessment", stop.StopID.Val),
+		MobilityImpairedAccess: access,
+		WheelchairAccess:       access,
+	}
+}
+
+type journeyPatternInfo struct {
+	num     int
+	routeID string
+	stops   []gtfs.StopTime
+}
+
+func (writer *Writer) buildServiceFrame(routeModes map[string]string) (*serviceFrame, map[string]journeyPatternInfo) {
+	frame := serviceFrame{versioned: writer.versioned("ServiceFrame", "1")}
+	var networks []network
+	var lines []line
+	var ssps []scheduledStopPoint
+	var assignments []passengerStopAssignment
+	var displays []destinationDisplay
+	var points []routePoint
+	var routes []route
+	var jps []serviceJourneyPattern
+	agencies := map[string]bool{}
+	for _, agency := range writer.agencies {
+		aid := agencyKey(agency)
+		agencies[aid] = true
+		networks = append(networks, network{
+			versioned:    writer.versioned("Network", aid),
+			Name:         agency.AgencyName.Val,
+			AuthorityRef: writer.ref("Authority", aid),
+		})
+	}
+
+	// Lines
+	for _, rt := range writer.routes {
+		aid := rt.AgencyID.Val
+		if !agencies[aid] && len(writer.agencies) == 1 {
+			aid = agencyKey(writer.agencies[0])
+		}
+		ln := line{
+			versioned:     writer.versioned("Line", rt.RouteID.Val),
+			Name:          routeName(rt),
+			Description:   rt.RouteDesc.Val,
+			TransportMode: routeModes[rt.RouteID.Val],
+			Url:           rt.RouteURL.Val,
+			PublicCode:    rt.RouteShortName.Val,
+			PrivateCode:   rt.RouteID.Val,
+		}
+		if agencies[aid] {
+			ln.OperatorRef = writer.refPtr("Operator", aid)
+			ln.RepresentedByGroupRef = writer.refPtr("Network", aid)
+		}
+		if rt.RouteColor.Val != "" || rt.RouteTextColor.Val != "" {
+			ln.Presentation = &presentation{
+				Colour:     strings.ToUpper(rt.RouteColor.Val),
+				TextColour: strings.ToUpper(rt.RouteTextColor.Val),
+			}
+		}
+		lines = append(lines, ln)
+	}
+
+	// Scheduled stop points and assignments for each Quay
+	stops := map[string]gtfs.Stop{}
+	for _, stop := range writer.stops {
+		stops[stop.StopID.Val] = stop
+	}
+	for _, stop := range writer.stops {
+		if stop.LocationType.Val != 0 {
+			continue
+		}
+		stopPlaceID := stop.StopID.Val
+		if parent, ok := stops[stop.ParentStation.Val]; ok && parent.LocationType.Val == 1 {
+			stopPlaceID = parent.StopID.Val
+		}
+		ssps = append(ssps, scheduledStopPoint{
+			versioned: writer.versioned("ScheduledStopPoint", stop.StopID.Val),
+			Name:      stop.StopName.Val,
+		})
+		assignments = append(assignments, passengerStopAssignment{
+			versioned:             writer.versioned("PassengerStopAssignment", stop.StopID.Val),
+			Order:                 len(assignments) + 1,
+			ScheduledStopPointRef: writer.ref("ScheduledStopPoint", stop.StopID.Val),
+			StopPlaceRef:          writer.ref("StopPlace", stopPlaceID),
+			QuayRef:               writer.ref("Quay", stop.StopID.Val),
+		})
+	}
+
+	// Journey patterns, grouped by route, direction, headsign and stop pattern
+	patterns := map[string]journeyPatternInfo{}
+	tripPatterns := map[string]journeyPatternInfo{}
+	destinationDisplays := map[string]string{}
+	routePoints := map[string]bool{}
+	routePatternCount := map[string]int{}
+	for _, trip := range writer.trips {
+		sts := writer.tripStopTimes(trip.TripID.Val)
+		if len(sts) == 0 {
+			continue
+		}
+		headsign := trip.TripHeadsign.Val
+		if headsign == "" {
+			headsign = stops[sts[len(sts)-1].StopID.Val].StopName.Val
+		}
+		pkey := strings.Join([]string{
+			trip.RouteID.Val,
+			strconv.Itoa(int(trip.DirectionID.Val)),
+			headsign,
+			copier.StopPatternKey(sts),
+			stopTimeBoardingKey(sts),
+		}, string(byte(0)))
+		if pat, ok := patterns[pkey]; ok {
+			tripPatterns[trip.TripID.Val] = pat
+			continue
+		}
+		routePatternCount[trip.RouteID.Val]++
+		patNum := routePatternCount[trip.RouteID.Val]
+		pat := journeyPatternInfo{num: patNum, routeID: trip.RouteID.Val, stops: sts}
+		patterns[pkey] = pat
+		tripPatterns[trip.TripID.Val] = pat
+
+		// Destination display
+		ddID, ok := destinationDisplays[headsign]
+		if !ok {
+			ddID = strconv.Itoa(len(destinationDisplays) + 1)
+			destinationDisplays[headsign] = ddID
+			displays = append(displays, destinationDisplay{
+				versioned: writer.versioned("DestinationDisplay", ddID),
+				FrontText: headsign,
+			})
+		}
+
+		// Route
+		directionType := "outbound"
+		if trip.DirectionID.Val == 1 {
+			directionType = "inbound"
+		}
+		rt := route{
+			versioned:     writer.versioned("Route", trip.RouteID.Val, patNum),
+			Name:          headsign,
+			LineRef:       writer.ref("Line", trip.RouteID.Val),
+			DirectionType: directionType,
+		}
+		jp := serviceJourneyPattern{
+			versioned: writer.versioned("ServiceJourneyPattern", trip.RouteID.Val, patNum),
+			Name:      headsign,
+			RouteRef:  writer.ref("Route", trip.RouteID.Val, patNum),
+		}
+		for i, st := range sts {
+			stopID := st.StopID.Val
+			if !routePoints[stopID] {
+				routePoints[stopID] = true
+				points = append(points, routePoint{
+					versioned: writer.versioned("RoutePoint", stopID),
+					Projection: pointProjection{
+						versioned:         writer.versioned("PointProjection", stopID),
+						ProjectToPointRef: writer.ref("ScheduledStopPoint", stopID),
+					},
+				})
+			}
+			rt.PointsInSequence = append(rt.PointsInSequence, pointOnRoute{
+				versioned:     writer.versioned("PointOnRoute", trip.RouteID.Val, patNum, i+1),
+				Order:         i + 1,
+				RoutePointRef: writer.ref("RoutePoint", stopID),
+			})
+			sp := stopPointInJourneyPattern{
+				versioned:             writer.versioned("StopPointInJourneyPattern", trip.RouteID.Val, patNum, i+1),
+				Order:                 i + 1,
+				ScheduledStopPointRef: writer.ref("ScheduledStopPoint", stopID),
+			}
+			if st.DropOffType.Val == 1 || i == 0 {
+				sp.ForAlighting = boolPtr(false)
+			}
+			if st.PickupType.Val == 1 || i == len(sts)-1 {
+				sp.ForBoarding = boolPtr(false)
+			}
+			if i == 0 {
+				sp.DestinationDisplayRef = writer.refPtr("DestinationDisplay", ddID)
+			}
+			jp.PointsInSequence = append(jp.PointsInSequence, sp)
+		}
+		routes = append(routes, rt)
+		jps = append(jps, jp)
+	}
+	frame.Networks = listPtr(networks)
+	frame.RoutePoints = listPtr(points)
+	frame.Routes = listPtr(routes)
+	frame.Lines = listPtr(lines)
+	frame.DestinationDisplays = listPtr(displays)
+	frame.ScheduledStopPoints = listPtr(ssps)
+	frame.PassengerStopAssigment = listPtr(assignments)
+	frame.JourneyPatterns = listPtr(jps)
+	return &frame, tripPatterns
+}
+
+func (writer *Writer) buildServiceCalendarFrame() *serviceCalendarFrame {
+	frame := serviceCalendarFrame{versioned: writer.versioned("ServiceCalendarFrame", "1")}
+	var dayTypes []dayType
+	var periods []operatingPeriod
+	var assignments []dayTypeAssignment
+	serviceIDs := map[string]bool{}
+	addDates := func(serviceID string) {
+		cds := writer.calDates[serviceID]
+		sort.Slice(cds, func(i, j int) bool { return cds[i].Date.Val.Before(cds[j].Date.Val) })
+		for _, cd := range cds {
+			assignments = append(assignments, dayTypeAssignment{
+				versioned:   writer.versioned("DayTypeAssignment", serviceID, dateNum(cd.Date.Val)),
+				Order:       len(assignments) + 1,
+				Date:        cd.Date.Val.Format("2006-01-02"),
+				DayTypeRef:  writer.ref("DayType", serviceID),
+				IsAvailable: boolPtr(cd.ExceptionType.Val == 1),
+			})
+		}
+	}
+	for _, cal := range writer.calendars {
+		serviceID := cal.ServiceID.Val
+		serviceIDs[serviceID] = true
+		dt := dayType{versioned: writer.versioned("DayType", serviceID)}
+		if days := daysOfWeek(cal); days != "" {
+			dt.DaysOfWeek = &days
+			periods = append(periods, operatingPeriod{
+				versioned: writer.versioned("OperatingPeriod", serviceID),
+				FromDate:  cal.StartDate.Val.Format("2006-01-02T15:04:05"),
+				ToDate:    cal.EndDate.Val.Format("2006-01-02T15:04:05"),
+			})
+			assignments = append(assignments, dayTypeAssignment{
+				versioned:          writer.versioned("DayTypeAssignment", serviceID),
+				Order:              len(assignments) + 1,
+				OperatingPeriodRef: writer.refPtr("OperatingPeriod", serviceID),
+				DayTypeRef:         writer.ref("DayType", serviceID),
+			})
+		}
+		dayTypes = append(dayTypes, dt)
+		addDates(serviceID)
+	}
+	// Services defined only in calendar_dates.txt
+	var dateOnly []string
+	for serviceID := range writer.calDates {
+		if !serviceIDs[serviceID] {
+			dateOnly = append(dateOnly, serviceID)
+		}
+	}
+	sort.Strings(dateOnly)
+	for _, serviceID := range dateOnly {
+		dayTypes = append(dayTypes, dayType{versioned: writer.versioned("DayType", serviceID)})
+		addDates(serviceID)
+	}
+	frame.DayTypes = listPtr(dayTypes)
+	frame.OperatingPeriods = listPtr(periods)
+	frame.DayTypeAssignments = listPtr(assignments)
+	return &frame
+}
+
+func (writer *Writer) buildTimetableFrame(tripPatterns map[string]journeyPatternInfo) *timetableFrame {
+	frame := timetableFrame{versioned: writer.versioned("TimetableFrame", "1")}
+	var journeys []serviceJourney
+	routeAgencies := map[string]string{}
+	for _, rt := range writer.routes {
+		routeAgencies[rt.RouteID.Val] = rt.AgencyID.Val
+	}
+	agencies := map[string]bool{}
+	for _, agency := range writer.agencies {
+		agencies[agencyKey(agency)] = true
+	}
+	for _, trip := range writer.trips {
+		pat, ok := tripPatterns[trip.TripID.Val]
+		if !ok {
+			continue
+		}
+		sj := serviceJourney{
+			versioned:         writer.versioned("ServiceJourney", trip.TripID.Val),
+			Name:              trip.TripHeadsign.Val,
+			PrivateCode:       trip.TripShortName.Val,
+			DayTypes:          []ref{writer.ref("DayType", trip.ServiceID.Val)},
+			JourneyPatternRef: writer.ref("ServiceJourneyPattern", pat.routeID, pat.num),
+		}
+		aid := routeAgencies[trip.RouteID.Val]
+		if !agencies[aid] && len(writer.agencies) == 1 {
+			aid = agencyKey(writer.agencies[0])
+		}
+		if agencies[aid] {
+			sj.OperatorRef = writer.refPtr("Operator", aid)
+		}
+		for i, st := range writer.tripStopTimes(trip.TripID.Val) {
+			pt := timetabledPassingTime{
+				versioned:                    writer.versioned("TimetabledPassingTime", trip.TripID.Val, i+1),
+				StopPointInJourneyPatternRef: writer.ref("StopPointInJourneyPattern", pat.routeID, pat.num, i+1),
+			}
+			if st.ArrivalTime.Valid {
+				pt.ArrivalTime, pt.ArrivalDayOffset = passingTime(st.ArrivalTime)
+			}
+			if st.DepartureTime.Valid {
+				pt.DepartureTime, pt.DepartureDayOffset = passingTime(st.DepartureTime)
+			}
+			sj.PassingTimes = append(sj.PassingTimes, pt)
+		}
+		journeys = append(journeys, sj)
+	}
+	frame.ServiceJourneys = listPtr(journeys)
+	return &frame
+}
+
+// tripStopTimes returns the stop times for a trip, sorted by stop_sequence.
+func (writer *Writer) tripStopTimes(tripID string) []gtfs.StopTime {
+	sts := writer.stopTimes[tripID]
+	sort.SliceStable(sts, func(i, j int) bool { return sts[i].StopSequence.Val < sts[j].StopSequence.Val })
+	return sts
+}
+
+// routeModes returns the NeTEx TransportMode for each route.
+func (writer *Writer) routeModes() map[string]string {
+	ret := map[string]string{}
+	for _, rt := range writer.routes {
+		ret[rt.RouteID.Val] = transportMode(rt.RouteType.Int())
+	}
+	return ret
+}
+
+// stopModes returns the TransportMode of the first route serving each stop.
+func (writer *Writer) stopModes(routeModes map[string]string) map[string]string {
+	ret := map[string]string{}
+	for _, trip := range writer.trips {
+		mode, ok := routeModes[trip.RouteID.Val]
+		if !ok {
+			continue
+		}
+		for _, st := range writer.stopTimes[trip.TripID.Val] {
+			if _, ok := ret[st.StopID.Val]; !ok {
+				ret[st.StopID.Val] = mode
+			}
+		}
+	}
+	return ret
+}
+
+/////////
+
+func agencyKey(agency gtfs.Agency) string {
+	if agency.AgencyID.Val != "" {
+		return agency.AgencyID.Val
+	}
+	return agency.EntityID()
+}
+
+func routeName(rt gtfs.Route) string {
+	if rt.RouteLongName.Val != "" {
+		return rt.RouteLongName.Val
+	}
+	return rt.RouteShortName.Val
+}
+
+func stopCentroid(stop gtfs.Stop) *centroid {
+	if !stop.Geometry.Valid {
+		return nil
+	}
+	c := stop.Coordinates()
+	return &centroid{Longitude: c[0], Latitude: c[1]}
+}
+
+func stopTimeBoardingKey(sts []gtfs.StopTime) string {
+	var sb strings.Builder
+	for _, st := range sts {
+		sb.WriteString(strconv.Itoa(int(st.PickupType.Val)))
+		sb.WriteString(strconv.Itoa(int(st.DropOffType.Val)))
+	}
+	return sb.String()
+}
+
+func daysOfWeek(cal gtfs.Calendar) string {
+	var days []string
+	for i, v := range []tt.Int{cal.Monday, cal.Tuesday, cal.Wednesday, cal.Thursday, cal.Friday, cal.Saturday, cal.Sunday} {
+		if v.Val == 1 {
+			days = append(days, time.Weekday((i+1)%7).String())
+		}
+	}
+	return strings.Join(days, " ")
+}
+
+// passingTime returns a time of day and the number of days after the operating day.
+func passingTime(s tt.Seconds) (string, int) {
+	v := int(s.Val)
+	days := v / 86400
+	v = v % 86400
+	return fmt.Sprintf("%02d:%02d:%02d", v/3600, (v%3600)/60, v%60), days
+}
+
+// dateNum returns a date as a YYYYMMDD identifier suffix.
+func dateNum(t time.Time) int {
+	return t.Year()*10000 + int(t.Month())*100 + t.Day()
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+// transportMode maps a GTFS route_type, including extended route types, to a NeTEx TransportMode.
+func transportMode(routeType int) string {
+	if rt, ok := tt.GetBasicRouteType(routeType); ok {
+		routeType = rt.Code
+	}
+	switch routeType {
+	case 0, 5:
+		return "tram"
+	case 1, 12:
+		return "metro"
+	case 2:
+		return "rail"
+	case 4:
+		return "water"
+	case 6:
+		return "cableway"
+	case 7:
+		return "funicular"
+	case 11:
+		return "trolleyBus"
+	}
+	return "bus"
+}
+
+// stopPlaceType returns the StopPlaceType for a TransportMode.
+func stopPlaceType(mode string) string {
+	switch mode {
+	case "tram":
+		return "onstreetTram"
+	case "metro":
+		return "metroStation"
+	case "rail", "funicular":
+		return "railStation"
+	case "water":
+		return "ferryStop"
+	case "cableway":
+		return "liftStation"
+	}
+	return "onstreetBus"
+}
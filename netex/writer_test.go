@@ -0,0 +1,133 @@
+package netex
+
+import (
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/interline-io/transitland-lib/copier"
+	"github.com/interline-io/transitland-lib/ext"
+	"github.com/interline-io/transitland-lib/internal/testutil"
+	"github.com/interline-io/transitland-lib/tlcsv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWriter(t *testing.T) {
+	tcs := []struct {
+		name          string
+		path          string
+		expectPath    string
+		expectCodespc string
+	}{
+		{"suffix", "output.netex.xml", "output.netex.xml", "TL"},
+		{"scheme", "netex://output.xml", "output.xml", "TL"},
+		{"codespace", "netex://output.xml?codespace=abc", "output.xml", "ABC"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := ext.NewWriter(tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writer, ok := w.(*Writer)
+			if !ok {
+				t.Fatalf("got writer %T, expected netex writer", w)
+			}
+			assert.Equal(t, tc.expectPath, writer.String())
+			assert.Equal(t, tc.expectCodespc, writer.Codespace)
+		})
+	}
+	t.Run("plain xml is not netex", func(t *testing.T) {
+		w, err := ext.NewWriter(filepath.Join(t.TempDir(), "output.xml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := w.(*Writer); ok {
+			t.Errorf("got netex writer for plain .xml path")
+		}
+	})
+}
+
+func TestWriter(t *testing.T) {
+	reader, err := tlcsv.NewReader(testutil.ExampleDir.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outpath := filepath.Join(t.TempDir(), "netex.xml")
+	writer, err := NewWriter(outpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := copier.QuietCopy(context.Background(), reader, writer); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(outpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := publicationDelivery{}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	frames := doc.DataObjects.CompositeFrame.Frames
+	assert.Equal(t, "America/Los_Angeles", doc.DataObjects.CompositeFrame.FrameDefaults.DefaultLocale.TimeZone)
+	assert.Equal(t, 1, len(*frames.ResourceFrame.Authorities))
+	assert.Equal(t, 1, len(*frames.ResourceFrame.Operators))
+	assert.Equal(t, 9, len(*frames.SiteFrame.StopPlaces))
+	assert.Equal(t, 5, len(*frames.ServiceFrame.Lines))
+	assert.Equal(t, 9, len(*frames.ServiceFrame.ScheduledStopPoints))
+	assert.Equal(t, 11, len(*frames.TimetableFrame.ServiceJourneys))
+	assert.Equal(t, 3, len(*frames.ServiceCalendarFrame.DayTypes))
+	// Check references
+	patterns := map[string]serviceJourneyPattern{}
+	for _, jp := range *frames.ServiceFrame.JourneyPatterns {
+		patterns[jp.ID] = jp
+	}
+	for _, sj := range *frames.TimetableFrame.ServiceJourneys {
+		jp, ok := patterns[sj.JourneyPatternRef.Ref]
+		if !ok {
+			t.Errorf("service journey '%s' references unknown journey pattern '%s'", sj.ID, sj.JourneyPatternRef.Ref)
+			continue
+		}
+		assert.Equal(t, len(jp.PointsInSequence), len(sj.PassingTimes))
+	}
+	for _, sj := range *frames.TimetableFrame.ServiceJourneys {
+		if sj.ID == "TL:ServiceJourney:AB1" {
+			assert.Equal(t, "TL:DayType:FULLW", sj.DayTypes[0].Ref)
+			assert.Equal(t, "08:00:00", sj.PassingTimes[0].DepartureTime)
+		}
+	}
+}
+
+func TestWriter_id(t *testing.T) {
+	writer := &Writer{Codespace: "TL"}
+	tcs := []struct {
+		name   string
+		value  string
+		suffix []int
+		expect string
+	}{
+		{"plain", "AB-1", nil, "TL:Line:AB-1"},
+		{"escaped", "a.b", nil, "TL:Line:a_x2Eb"},
+		{"underscore", "a_b", nil, "TL:Line:a_x5Fb"},
+		{"suffix", "a_b", []int{1, 2}, "TL:Line:a_x5Fb_1_2"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, writer.id("Line", tc.value, tc.suffix...))
+		})
+	}
+	t.Run("distinct", func(t *testing.T) {
+		ids := map[string]bool{}
+		for _, v := range []string{"a.b", "a_b", "a b", "a_x2Eb", "a_1"} {
+			ids[writer.id("Line", v)] = true
+		}
+		ids[writer.id("Line", "a", 1)] = true
+		assert.Equal(t, 6, len(ids))
+	})
+}
@@ -20,6 +20,7 @@ import (
 func init() {
 	var _ Store = &Az{}
 	var _ Presigner = &Az{}
+	var _ Exister = &Az{}
 }
 
 type Az struct {
@@ -59,6 +60,18 @@ func (r Az) Download(ctx context.Context, key string) (io.ReadCloser, int, error
 	return rs.Body, 0, err
 }
 
+func (r Az) Exists(ctx context.Context, key string) bool {
+	if key == "" {
+		return false
+	}
+	_, client, err := getAzBlobClient(r.Account)
+	if err != nil {
+		return false
+	}
+	_, err = client.ServiceClient().NewContainerClient(r.Container).NewBlobClient(r.getFullKey(key)).GetProperties(ctx, nil)
+	return err == nil
+}
+
 func (r Az) DownloadAuth(ctx context.Context, key string, auth dmfr.FeedAuthorization) (io.ReadCloser, int, error) {
 	return r.Download(ctx, key)
 }
@@ -0,0 +1,309 @@
+package request
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/dmfr"
+)
+
+func init() {
+	var _ Store = &ContentStore{}
+	var _ Exister = &ContentStore{}
+}
+
+// ContentStorePrefix selects the content-addressed layout when used as a storage url prefix, e.g. cas+s3://bucket/prefix
+const ContentStorePrefix = "cas+"
+
+// ContentStore wraps a Store to save each file inside a zip archive by content hash,
+// along with a manifest for each key that is used to reassemble the archive on download.
+// Entries are stored compressed, exactly as they appear in the archive, and the remaining
+// bytes (local headers and the central directory) are stored as a separate blob,
+// so reassembled archives are byte-for-byte identical to the original and have the same SHA1.
+// Files that are unchanged between archives created with the same compression settings,
+// such as a large stop_times.txt, are stored once.
+// Keys that are not zip archives are stored as a single content-addressed blob.
+type ContentStore struct {
+	Store Store
+}
+
+// ContentManifest describes how to reassemble the file stored for a key.
+type ContentManifest struct {
+	Key     string                `json:"key"`
+	SHA1    string                `json:"sha1"`
+	Size    int64                 `json:"size"`
+	Zip     bool                  `json:"zip"`
+	Blob    string                `json:"blob,omitempty"`
+	Headers string                `json:"headers,omitempty"`
+	Files   []ContentManifestFile `json:"files,omitempty"`
+	Created time.Time             `json:"created"`
+}
+
+// ContentManifestFile is the compressed data for a single entry in a zip archive.
+type ContentManifestFile struct {
+	Name   string `json:"name"`
+	SHA1   string `json:"sha1,omitempty"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+}
+
+func NewContentStore(store Store) *ContentStore {
+	return &ContentStore{Store: store}
+}
+
+func (r *ContentStore) SetSecret(secret dmfr.Secret) error {
+	return r.Store.SetSecret(secret)
+}
+
+func (r ContentStore) DownloadAuth(ctx context.Context, key string, auth dmfr.FeedAuthorization) (io.ReadCloser, int, error) {
+	return r.Download(ctx, key)
+}
+
+// Download returns the stored file, reassembling zip archives from the manifest.
+func (r ContentStore) Download(ctx context.Context, key string) (io.ReadCloser, int, error) {
+	m, err := r.Manifest(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !m.Zip {
+		return r.Store.Download(ctx, blobKey(m.Blob))
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		h := sha1.New()
+		err := r.writeZip(ctx, io.MultiWriter(pw, h), m)
+		if err == nil && fmt.Sprintf("%x", h.Sum(nil)) != m.SHA1 {
+			err = fmt.Errorf("reassembled file for key '%s' does not match sha1 '%s'", m.Key, m.SHA1)
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, 0, nil
+}
+
+// Exists checks if a manifest exists for a key.
+func (r ContentStore) Exists(ctx context.Context, key string) bool {
+	return r.exists(ctx, manifestKey(key))
+}
+
+// Manifest returns the manifest for a key.
+func (r ContentStore) Manifest(ctx context.Context, key string) (ContentManifest, error) {
+	m := ContentManifest{}
+	rdr, _, err := r.Store.Download(ctx, manifestKey(key))
+	if err != nil {
+		return m, err
+	}
+	defer rdr.Close()
+	if err := json.NewDecoder(rdr).Decode(&m); err != nil {
+		return m, fmt.Errorf("could not read manifest for key '%s': %w", key, err)
+	}
+	return m, nil
+}
+
+// ListKeys returns the keys that have manifests.
+func (r ContentStore) ListKeys(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := r.Store.ListKeys(ctx, "manifests/"+strings.TrimPrefix(prefix, "/"))
+	if err != nil {
+		return nil, err
+	}
+	var ret []string
+	for _, key := range keys {
+		key = strings.TrimPrefix(key, "/")
+		if !strings.HasPrefix(key, "manifests/") || !strings.HasSuffix(key, ".json") {
+			continue
+		}
+		ret = append(ret, strings.TrimSuffix(strings.TrimPrefix(key, "manifests/"), ".json"))
+	}
+	return ret, nil
+}
+
+// Upload saves each file in a zip archive, or the entire file, as content-addressed blobs and writes a manifest.
+func (r ContentStore) Upload(ctx context.Context, key string, uploadFile io.Reader) error {
+	key = trimSlash(key)
+	if key == "" {
+		return errors.New("key must not be empty")
+	}
+	// Archives require random access
+	tmpfile, err := os.CreateTemp("", "content-store")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpfile.Name())
+	defer tmpfile.Close()
+	h := sha1.New()
+	size, err := io.Copy(io.MultiWriter(tmpfile, h), uploadFile)
+	if err != nil {
+		return err
+	}
+	m := ContentManifest{
+		Key:     key,
+		SHA1:    fmt.Sprintf("%x", h.Sum(nil)),
+		Size:    size,
+		Created: time.Now().UTC(),
+	}
+	if files, ok := zipLayout(tmpfile, size); ok && strings.HasSuffix(key, ".zip") {
+		if err := r.uploadZip(ctx, &m, tmpfile, files); err != nil {
+			return err
+		}
+	} else {
+		if _, err := tmpfile.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if err := r.uploadBlob(ctx, m.SHA1, tmpfile); err != nil {
+			return err
+		}
+		m.Blob = m.SHA1
+	}
+	mb, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	log.Debug().Msgf("content store: uploading manifest for key '%s'", key)
+	return r.Store.Upload(ctx, manifestKey(key), bytes.NewReader(mb))
+}
+
+// zipLayout returns the location of the compressed data for each entry in a zip archive, ordered by offset.
+// Archives with overlapping or out of range entries are not split.
+func zipLayout(f io.ReaderAt, size int64) ([]ContentManifestFile, bool) {
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return nil, false
+	}
+	var files []ContentManifestFile
+	for _, zf := range zr.File {
+		offset, err := zf.DataOffset()
+		if err != nil {
+			return nil, false
+		}
+		files = append(files, ContentManifestFile{
+			Name:   zf.Name,
+			Offset: offset,
+			Size:   int64(zf.CompressedSize64),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Offset < files[j].Offset })
+	end := int64(0)
+	for _, mf := range files {
+		if mf.Offset < end || mf.Size < 0 || mf.Offset+mf.Size > size {
+			return nil, false
+		}
+		end = mf.Offset + mf.Size
+	}
+	return files, true
+}
+
+// uploadZip saves the compressed data for each entry, and the bytes between entries, as blobs.
+func (r ContentStore) uploadZip(ctx context.Context, m *ContentManifest, f *os.File, files []ContentManifestFile) error {
+	for i, mf := range files {
+		if mf.Size == 0 {
+			continue
+		}
+		hash, err := sha1Reader(io.NewSectionReader(f, mf.Offset, mf.Size))
+		if err != nil {
+			return err
+		}
+		if err := r.uploadBlob(ctx, hash, io.NewSectionReader(f, mf.Offset, mf.Size)); err != nil {
+			return err
+		}
+		files[i].SHA1 = hash
+	}
+	headers := func() io.Reader {
+		var rdrs []io.Reader
+		end := int64(0)
+		for _, mf := range files {
+			rdrs = append(rdrs, io.NewSectionReader(f, end, mf.Offset-end))
+			end = mf.Offset + mf.Size
+		}
+		rdrs = append(rdrs, io.NewSectionReader(f, end, m.Size-end))
+		return io.MultiReader(rdrs...)
+	}
+	hash, err := sha1Reader(headers())
+	if err != nil {
+		return err
+	}
+	if err := r.uploadBlob(ctx, hash, headers()); err != nil {
+		return err
+	}
+	m.Zip = true
+	m.Headers = hash
+	m.Files = files
+	return nil
+}
+
+func sha1Reader(rdr io.Reader) (string, error) {
+	h := sha1.New()
+	if _, err := io.Copy(h, rdr); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func (r ContentStore) uploadBlob(ctx context.Context, hash string, rdr io.Reader) error {
+	key := blobKey(hash)
+	if r.exists(ctx, key) {
+		log.Trace().Msgf("content store: blob '%s' already exists", key)
+		return nil
+	}
+	log.Debug().Msgf("content store: uploading blob '%s'", key)
+	return r.Store.Upload(ctx, key, rdr)
+}
+
+// exists checks if a key exists, if supported by the underlying store.
+// Otherwise the blob is uploaded again.
+func (r ContentStore) exists(ctx context.Context, key string) bool {
+	if v, ok := r.Store.(Exister); ok {
+		return v.Exists(ctx, key)
+	}
+	return false
+}
+
+// writeZip writes the original archive by interleaving the header bytes with the compressed data for each entry.
+func (r ContentStore) writeZip(ctx context.Context, w io.Writer, m ContentManifest) error {
+	hdr, _, err := r.Store.Download(ctx, blobKey(m.Headers))
+	if err != nil {
+		return fmt.Errorf("could not download headers for key '%s': %w", m.Key, err)
+	}
+	defer hdr.Close()
+	end := int64(0)
+	for _, mf := range m.Files {
+		if _, err := io.CopyN(w, hdr, mf.Offset-end); err != nil {
+			return err
+		}
+		end = mf.Offset + mf.Size
+		if mf.SHA1 == "" {
+			continue
+		}
+		rdr, _, err := r.Store.Download(ctx, blobKey(mf.SHA1))
+		if err != nil {
+			return fmt.Errorf("could not download blob for '%s': %w", mf.Name, err)
+		}
+		_, err = io.CopyN(w, rdr, mf.Size)
+		rdr.Close()
+		if err != nil {
+			return err
+		}
+	}
+	_, err = io.Copy(w, hdr)
+	return err
+}
+
+func blobKey(hash string) string {
+	if len(hash) < 2 {
+		return "blobs/" + hash
+	}
+	return "blobs/" + hash[0:2] + "/" + hash
+}
+
+func manifestKey(key string) string {
+	return "manifests/" + trimSlash(key) + ".json"
+}
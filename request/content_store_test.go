@@ -0,0 +1,166 @@
+package request
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/interline-io/transitland-lib/internal/testpath"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentStore(t *testing.T) {
+	b, err := GetStore(ContentStorePrefix + t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBucket(t, context.TODO(), b)
+}
+
+func TestContentStore_Dedup(t *testing.T) {
+	ctx := context.TODO()
+	dir := t.TempDir()
+	local := &Local{Directory: dir}
+	store := NewContentStore(local)
+
+	// Create two archives with the same compression settings that differ by one file
+	exampleFn := testpath.RelPath("testdata/gtfs-examples/example.zip")
+	exampleData, err := os.ReadFile(exampleFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(exampleData), int64(len(exampleData)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var original, modified bytes.Buffer
+	zwa := zip.NewWriter(&original)
+	zwb := zip.NewWriter(&modified)
+	for _, zf := range zr.File {
+		f, err := zf.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := zwa.Create(zf.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+		if zf.Name == "agency.txt" {
+			data = append(data, []byte("\n")...)
+		}
+		w, err = zwb.Create(zf.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	zwa.Close()
+	zwb.Close()
+
+	// Upload both, and the example archive created by another tool
+	uploads := map[string][]byte{
+		"a.zip":       original.Bytes(),
+		"b.zip":       modified.Bytes(),
+		"example.zip": exampleData,
+	}
+	for key, data := range uploads {
+		if err := store.Upload(ctx, key, bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys, err := store.ListKeys(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.ElementsMatch(t, []string{"a.zip", "b.zip", "example.zip"}, keys)
+	ma, err := store.Manifest(ctx, "a.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb, err := store.Manifest(ctx, "b.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, ma.Zip)
+	assert.Equal(t, int64(original.Len()), ma.Size)
+	assert.Equal(t, len(zr.File), len(ma.Files))
+	shared := 0
+	for i := range ma.Files {
+		if ma.Files[i].SHA1 == mb.Files[i].SHA1 {
+			shared++
+		}
+	}
+	assert.Equal(t, len(zr.File)-1, shared, "expected all but the changed file to be shared")
+
+	// Reassembled archives are identical to the original
+	for key, expect := range uploads {
+		rdr, _, err := store.Download(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rdr)
+		rdr.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, fmt.Sprintf("%x", sha1.Sum(expect)), fmt.Sprintf("%x", sha1.Sum(data)), "key %s", key)
+		m, err := store.Manifest(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, m.SHA1, fmt.Sprintf("%x", sha1.Sum(data)), "key %s", key)
+	}
+	assert.True(t, store.Exists(ctx, "a.zip"))
+	assert.False(t, store.Exists(ctx, "missing.zip"))
+
+	// Corrupt blobs are detected on download
+	for _, mf := range ma.Files {
+		if mf.Name == "stops.txt" {
+			fn := filepath.Join(dir, blobKey(mf.SHA1))
+			data, err := os.ReadFile(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data[0] ^= 0xff
+			if err := os.WriteFile(fn, data, 0666); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	rdr, _, err := store.Download(ctx, "a.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.ReadAll(rdr)
+	rdr.Close()
+	assert.Error(t, err)
+
+	// Non-zip files are stored whole
+	if err := store.Upload(ctx, "report.json", strings.NewReader(`{"ok":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	rdr, _, err = store.Download(ctx, "report.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rdr)
+	rdr.Close()
+	assert.Equal(t, `{"ok":true}`, string(data))
+
+	// Missing keys return an error
+	_, _, err = store.Download(ctx, "missing.zip")
+	assert.Error(t, err)
+}
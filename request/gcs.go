@@ -0,0 +1,172 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/dmfr"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+func init() {
+	var _ Store = &Gcs{}
+	var _ Presigner = &Gcs{}
+	var _ Exister = &Gcs{}
+}
+
+// Gcs is a Google Cloud Storage store, e.g. gs://bucket/prefix
+// Credentials are found using Application Default Credentials unless set through a secret.
+// Set STORAGE_EMULATOR_HOST to use a local emulator.
+type Gcs struct {
+	Bucket    string
+	KeyPrefix string
+	secret    dmfr.Secret
+}
+
+func NewGcsFromUrl(ustr string) (*Gcs, error) {
+	u, err := url.Parse(ustr)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, errors.New("bucket must not be empty")
+	}
+	s := Gcs{
+		Bucket:    u.Host,
+		KeyPrefix: trimSlash(u.Path),
+	}
+	return &s, nil
+}
+
+func (r *Gcs) SetSecret(secret dmfr.Secret) error {
+	r.secret = secret
+	return nil
+}
+
+func (r Gcs) Download(ctx context.Context, key string) (io.ReadCloser, int, error) {
+	if key == "" {
+		return nil, 0, errors.New("key must not be empty")
+	}
+	client, err := gcsClient(ctx, r.secret)
+	if err != nil {
+		return nil, 0, err
+	}
+	log.Debug().Msgf("gcs store: downloading key '%s', full key is '%s'", key, r.getFullKey(key))
+	rdr, err := client.Bucket(r.Bucket).Object(r.getFullKey(key)).NewReader(ctx)
+	if err != nil {
+		client.Close()
+		return nil, 0, err
+	}
+	return &gcsReader{Reader: rdr, client: client}, 0, nil
+}
+
+func (r Gcs) Exists(ctx context.Context, key string) bool {
+	if key == "" {
+		return false
+	}
+	client, err := gcsClient(ctx, r.secret)
+	if err != nil {
+		return false
+	}
+	defer client.Close()
+	_, err = client.Bucket(r.Bucket).Object(r.getFullKey(key)).Attrs(ctx)
+	return err == nil
+}
+
+func (r Gcs) DownloadAuth(ctx context.Context, key string, auth dmfr.FeedAuthorization) (io.ReadCloser, int, error) {
+	return r.Download(ctx, key)
+}
+
+func (r Gcs) ListKeys(ctx context.Context, prefix string) ([]string, error) {
+	client, err := gcsClient(ctx, r.secret)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	var ret []string
+	it := client.Bucket(r.Bucket).Objects(ctx, &storage.Query{Prefix: r.getFullKey(prefix)})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		ret = append(ret, stripDir(r.KeyPrefix, attrs.Name))
+	}
+	return ret, nil
+}
+
+func (r Gcs) Upload(ctx context.Context, key string, uploadFile io.Reader) error {
+	if key == "" {
+		return errors.New("key must not be empty")
+	}
+	client, err := gcsClient(ctx, r.secret)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	log.Debug().Msgf("gcs store: uploading to key '%s', full key is '%s'", key, r.getFullKey(key))
+	w := client.Bucket(r.Bucket).Object(r.getFullKey(key)).NewWriter(ctx)
+	if _, err := io.Copy(w, uploadFile); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (r Gcs) CreateSignedUrl(ctx context.Context, key string, contentDisposition string) (string, error) {
+	if key == "" {
+		return "", errors.New("key must not be empty")
+	}
+	client, err := gcsClient(ctx, r.secret)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	opts := storage.SignedURLOptions{
+		Method:  "GET",
+		Scheme:  storage.SigningSchemeV4,
+		Expires: time.Now().Add(1 * time.Hour),
+	}
+	if contentDisposition != "" {
+		opts.QueryParameters = url.Values{
+			"response-content-disposition": []string{fmt.Sprintf(`attachment; filename="%s"`, url.PathEscape(contentDisposition))},
+		}
+	}
+	return client.Bucket(r.Bucket).SignedURL(r.getFullKey(key), &opts)
+}
+
+func (r Gcs) getFullKey(key string) string {
+	if r.KeyPrefix != "" {
+		return r.KeyPrefix + "/" + trimSlash(key)
+	}
+	return trimSlash(key)
+}
+
+func gcsClient(ctx context.Context, secret dmfr.Secret) (*storage.Client, error) {
+	var opts []option.ClientOption
+	if secret.GCPCredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(secret.GCPCredentialsFile))
+	}
+	return storage.NewClient(ctx, opts...)
+}
+
+// gcsReader closes the client along with the object reader.
+type gcsReader struct {
+	*storage.Reader
+	client *storage.Client
+}
+
+func (r *gcsReader) Close() error {
+	err := r.Reader.Close()
+	r.client.Close()
+	return err
+}
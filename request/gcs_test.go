@@ -0,0 +1,22 @@
+package request
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// Use with a local emulator, e.g. fake-gcs-server and STORAGE_EMULATOR_HOST=localhost:4443
+func TestGcs(t *testing.T) {
+	ctx := context.TODO()
+	gcsUri := os.Getenv("TL_TEST_GCS_STORAGE")
+	if gcsUri == "" {
+		t.Skip("Set TL_TEST_GCS_STORAGE for this test")
+		return
+	}
+	b, err := NewGcsFromUrl(gcsUri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBucket(t, ctx, b)
+}
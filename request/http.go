@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	tl "github.com/interline-io/transitland-lib"
 	"github.com/interline-io/transitland-lib/dmfr"
@@ -15,10 +16,12 @@ import (
 
 func init() {
 	var _ Downloader = &Http{}
+	var _ CanDownloadConditional = &Http{}
 }
 
 type Http struct {
-	secret dmfr.Secret
+	secret  dmfr.Secret
+	headers map[string]string
 }
 
 func (r *Http) SetSecret(secret dmfr.Secret) error {
@@ -38,15 +41,22 @@ func (r Http) Download(ctx context.Context, ustr string) (io.ReadCloser, int, er
 }
 
 func (r Http) DownloadAuth(ctx context.Context, ustr string, auth dmfr.FeedAuthorization) (io.ReadCloser, int, error) {
+	body, code, _, err := r.DownloadConditional(ctx, ustr, auth, Validators{})
+	return body, code, err
+}
+
+// DownloadConditional sends If-None-Match and If-Modified-Since headers when validators are provided.
+// A 304 Not Modified response is returned with a nil body and no error.
+func (r Http) DownloadConditional(ctx context.Context, ustr string, auth dmfr.FeedAuthorization, validators Validators) (io.ReadCloser, int, Validators, error) {
 	u, err := url.Parse(ustr)
 	if err != nil {
-		return nil, 0, errors.New("could not parse url")
+		return nil, 0, Validators{}, errors.New("could not parse url")
 	}
 	switch auth.Type {
 	case "query_param":
 		v, err := url.ParseQuery(u.RawQuery)
 		if err != nil {
-			return nil, 0, errors.New("could not parse query string")
+			return nil, 0, Validators{}, errors.New("could not parse query string")
 		}
 		v.Set(auth.ParamName, r.secret.Key)
 		u.RawQuery = v.Encode()
@@ -55,7 +65,7 @@ func (r Http) DownloadAuth(ctx context.Context, ustr string, auth dmfr.FeedAutho
 	case "replace_url":
 		u, err = url.Parse(r.secret.ReplaceUrl)
 		if err != nil {
-			return nil, 0, errors.New("could not parse replacement query string")
+			return nil, 0, Validators{}, errors.New("could not parse replacement query string")
 		}
 	}
 	ustr = u.String()
@@ -63,7 +73,7 @@ func (r Http) DownloadAuth(ctx context.Context, ustr string, auth dmfr.FeedAutho
 	// Prepare HTTP request
 	req, err := http.NewRequestWithContext(ctx, "GET", ustr, nil)
 	if err != nil {
-		return nil, 0, errors.New("invalid request")
+		return nil, 0, Validators{}, errors.New("invalid request")
 	}
 
 	// Set basic auth, if used
@@ -72,6 +82,20 @@ func (r Http) DownloadAuth(ctx context.Context, ustr string, auth dmfr.FeedAutho
 		req.SetBasicAuth(r.secret.Username, r.secret.Password)
 	case "header":
 		req.Header.Add(auth.ParamName, r.secret.Key)
+	case "oauth2_client_credentials":
+		tok, err := oauth2Token(r.secret)
+		if err != nil {
+			return nil, 0, Validators{}, err
+		}
+		tok.SetAuthHeader(req)
+	}
+
+	// Set conditional request headers, if we have validators from a previous response
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
 	}
 
 	// Make HTTP request
@@ -79,6 +103,9 @@ func (r Http) DownloadAuth(ctx context.Context, ustr string, auth dmfr.FeedAutho
 	// If the following headers are not set, some CDNs may block the request as coming from a bot rather than a browser
 	req.Header.Set("Accept", "application/zip,application/x-zip-compressed,application/octet-stream;q=0.9,*/*;q=0.8")
 	req.Header.Set("Accept-Language", "")
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
 
 	// Remove default ports from host header if explicitly specified as it
 	// may break pre-signed S3 URLs or other systems that rely on the host header
@@ -93,11 +120,26 @@ func (r Http) DownloadAuth(ctx context.Context, ustr string, auth dmfr.FeedAutho
 	resp, err := client.Do(req)
 	if err != nil {
 		// return error directly
-		return nil, 0, err
+		return nil, 0, Validators{}, err
+	}
+	respValidators := Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
 	}
 	if resp.StatusCode >= 400 {
 		resp.Body.Close()
-		return nil, resp.StatusCode, fmt.Errorf("response status code: %d", resp.StatusCode)
+		if resp.StatusCode == http.StatusUnauthorized && auth.Type == "oauth2_client_credentials" {
+			// Token may have been revoked; request a new token next time
+			resetOAuth2Token(r.secret)
+		}
+		return nil, resp.StatusCode, respValidators, &ResponseError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, resp.StatusCode, respValidators, nil
 	}
-	return resp.Body, resp.StatusCode, nil
+	return resp.Body, resp.StatusCode, respValidators, nil
 }
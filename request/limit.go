@@ -0,0 +1,202 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// HostLimit sets per-host request limits. Zero values are unlimited.
+type HostLimit struct {
+	MaxConcurrency    int
+	RequestsPerMinute int
+}
+
+// Merge returns a limit using any non-zero values in b, falling back to r.
+func (r HostLimit) Merge(b HostLimit) HostLimit {
+	if b.MaxConcurrency > 0 {
+		r.MaxConcurrency = b.MaxConcurrency
+	}
+	if b.RequestsPerMinute > 0 {
+		r.RequestsPerMinute = b.RequestsPerMinute
+	}
+	return r
+}
+
+func (r HostLimit) interval() time.Duration {
+	if r.RequestsPerMinute <= 0 {
+		return 0
+	}
+	return time.Minute / time.Duration(r.RequestsPerMinute)
+}
+
+// HostLimiter coordinates requests to the same host across goroutines.
+// A single HostLimiter should be shared by all requests that need to be limited together.
+type HostLimiter struct {
+	Default    HostLimit
+	MaxRetries int           // retries after a 429 or 503 response
+	MaxBackoff time.Duration // maximum wait before a retry
+	lock       sync.Mutex
+	hosts      map[string]*hostState
+}
+
+type hostState struct {
+	active       int
+	nextStart    time.Time
+	blockedUntil time.Time
+	changed      chan struct{}
+}
+
+// NewHostLimiter returns a HostLimiter using the provided default limits.
+func NewHostLimiter(defaultLimit HostLimit) *HostLimiter {
+	return &HostLimiter{
+		Default:    defaultLimit,
+		MaxRetries: 3,
+		MaxBackoff: 5 * time.Minute,
+		hosts:      map[string]*hostState{},
+	}
+}
+
+// Acquire waits until a request to host is permitted by limit, merged over the default limit.
+// The returned function must be called when the request is complete.
+func (h *HostLimiter) Acquire(ctx context.Context, host string, limit HostLimit) (func(), error) {
+	limit = h.Default.Merge(limit)
+	for {
+		h.lock.Lock()
+		hs := h.getHost(host)
+		now := time.Now()
+		var wait time.Duration
+		if now.Before(hs.blockedUntil) {
+			wait = hs.blockedUntil.Sub(now)
+		} else if now.Before(hs.nextStart) {
+			wait = hs.nextStart.Sub(now)
+		} else if limit.MaxConcurrency <= 0 || hs.active < limit.MaxConcurrency {
+			hs.active++
+			hs.nextStart = now.Add(limit.interval())
+			h.lock.Unlock()
+			var once sync.Once
+			return func() { once.Do(func() { h.release(host) }) }, nil
+		}
+		changed := hs.changed
+		h.lock.Unlock()
+
+		// Wait for a slot, the rate interval, or a backoff to elapse
+		var timer *time.Timer
+		var timerC <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timerC = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil, ctx.Err()
+		case <-changed:
+		case <-timerC:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// Backoff blocks new requests to host for the given duration.
+func (h *HostLimiter) Backoff(host string, d time.Duration) {
+	if h.MaxBackoff > 0 && d > h.MaxBackoff {
+		d = h.MaxBackoff
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	hs := h.getHost(host)
+	if t := time.Now().Add(d); t.After(hs.blockedUntil) {
+		hs.blockedUntil = t
+	}
+	hs.notify()
+}
+
+func (h *HostLimiter) release(host string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	hs := h.getHost(host)
+	hs.active--
+	hs.notify()
+}
+
+func (h *HostLimiter) getHost(host string) *hostState {
+	if h.hosts == nil {
+		h.hosts = map[string]*hostState{}
+	}
+	hs, ok := h.hosts[host]
+	if !ok {
+		hs = &hostState{changed: make(chan struct{})}
+		h.hosts[host] = hs
+	}
+	return hs
+}
+
+func (hs *hostState) notify() {
+	close(hs.changed)
+	hs.changed = make(chan struct{})
+}
+
+// ResponseError is returned for unsuccessful HTTP responses.
+type ResponseError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("response status code: %d", e.StatusCode)
+}
+
+// Retryable is true for responses that indicate the server is temporarily unavailable or rate limiting requests.
+func (e *ResponseError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusServiceUnavailable
+}
+
+// parseRetryAfter parses a Retry-After header value in either delay-seconds or HTTP-date format.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(v); err == nil {
+		if s < 0 {
+			return 0
+		}
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// retryBackoff returns the wait before the next attempt, preferring the server provided Retry-After.
+func retryBackoff(err error, attempt int) (time.Duration, bool) {
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) || !respErr.Retryable() {
+		return 0, false
+	}
+	if respErr.RetryAfter > 0 {
+		return respErr.RetryAfter, true
+	}
+	return time.Duration(1<<attempt) * time.Second, true
+}
+
+// limiterHost returns the key used for limiting requests to a url.
+func limiterHost(address string) string {
+	u, err := url.Parse(address)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
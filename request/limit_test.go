@@ -0,0 +1,137 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostLimiter_MaxConcurrency(t *testing.T) {
+	var active, maxActive int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			m := atomic.LoadInt32(&maxActive)
+			if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	limiter := NewHostLimiter(HostLimit{MaxConcurrency: 4})
+	ctx := context.TODO()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var out bytes.Buffer
+			fr, err := AuthenticatedRequest(ctx, &out, ts.URL, WithHostLimiter(limiter), WithHostLimit(HostLimit{MaxConcurrency: 2}))
+			if err != nil || fr.FetchError != nil {
+				t.Error(err, fr.FetchError)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), maxActive)
+}
+
+func TestHostLimiter_RequestsPerMinute(t *testing.T) {
+	limiter := NewHostLimiter(HostLimit{RequestsPerMinute: 1200}) // 50ms interval
+	ctx := context.TODO()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := limiter.Acquire(ctx, "example.com", HostLimit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	// Other hosts are not affected
+	start = time.Now()
+	release, err := limiter.Acquire(ctx, "example.org", HostLimit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestHostLimiter_Canceled(t *testing.T) {
+	limiter := NewHostLimiter(HostLimit{MaxConcurrency: 1})
+	release, err := limiter.Acquire(context.TODO(), "example.com", HostLimit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Acquire(ctx, "example.com", HostLimit{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestHostLimiter_RetryAfter(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	limiter := NewHostLimiter(HostLimit{})
+	ctx := context.TODO()
+	start := time.Now()
+	var out bytes.Buffer
+	fr, err := AuthenticatedRequest(ctx, &out, ts.URL, WithHostLimiter(limiter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, fr.FetchError)
+	assert.Equal(t, 200, fr.ResponseCode)
+	assert.Equal(t, int32(2), requests)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestHostLimiter_MaxRetries(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "0")
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	limiter := NewHostLimiter(HostLimit{})
+	limiter.MaxRetries = 2
+	limiter.MaxBackoff = time.Millisecond
+	var out bytes.Buffer
+	fr, err := AuthenticatedRequest(context.TODO(), &out, ts.URL, WithHostLimiter(limiter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, fr.FetchError)
+	assert.Equal(t, 503, fr.ResponseCode)
+	assert.Equal(t, int32(3), requests)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 120*time.Second, parseRetryAfter("120", now))
+	assert.Equal(t, 30*time.Second, parseRetryAfter("Sun, 18 Oct 2026 12:00:30 GMT", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("Sun, 18 Oct 2026 11:00:00 GMT", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("invalid", now))
+}
@@ -13,6 +13,7 @@ import (
 
 func init() {
 	var _ Store = &Local{}
+	var _ Exister = &Local{}
 }
 
 type Local struct {
@@ -0,0 +1,66 @@
+package request
+
+import (
+	"context"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/interline-io/transitland-lib/dmfr"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Token sources are shared between requests so that tokens are reused until they expire.
+var oauth2Sources = struct {
+	lock    sync.Mutex
+	sources map[string]oauth2.TokenSource
+}{sources: map[string]oauth2.TokenSource{}}
+
+// oauth2Token returns a cached or newly requested bearer token using the client credentials grant.
+func oauth2Token(secret dmfr.Secret) (*oauth2.Token, error) {
+	if secret.TokenURL == "" {
+		return nil, errors.New("oauth2_client_credentials requires a secret with token_url")
+	}
+	key := oauth2SourceKey(secret)
+	oauth2Sources.lock.Lock()
+	src, ok := oauth2Sources.sources[key]
+	if !ok {
+		cfg := clientcredentials.Config{
+			ClientID:     secret.ClientID,
+			ClientSecret: secret.ClientSecret,
+			TokenURL:     secret.TokenURL,
+			Scopes:       strings.Fields(secret.Scope),
+		}
+		// Token requests outlive any single fetch, so do not use the request context
+		tokenCtx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Timeout: 60 * time.Second})
+		src = cfg.TokenSource(tokenCtx)
+		oauth2Sources.sources[key] = src
+	}
+	oauth2Sources.lock.Unlock()
+	tok, err := src.Token()
+	if err != nil {
+		return nil, fmt.Errorf("could not get oauth2 token: %w", err)
+	}
+	return tok, nil
+}
+
+// resetOAuth2Token discards any cached token, e.g. after the token is rejected.
+func resetOAuth2Token(secret dmfr.Secret) {
+	oauth2Sources.lock.Lock()
+	delete(oauth2Sources.sources, oauth2SourceKey(secret))
+	oauth2Sources.lock.Unlock()
+}
+
+func oauth2SourceKey(secret dmfr.Secret) string {
+	h := sha1.New()
+	for _, v := range []string{secret.TokenURL, secret.ClientID, secret.ClientSecret, secret.Scope} {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
@@ -0,0 +1,84 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/interline-io/transitland-lib/dmfr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticatedRequest_OAuth2ClientCredentials(t *testing.T) {
+	var tokenRequests int32
+	expiresIn := 3600
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			id, secret, _ := r.BasicAuth()
+			if id != "client" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" {
+				http.Error(w, "invalid_client", http.StatusUnauthorized)
+				return
+			}
+			n := atomic.AddInt32(&tokenRequests, 1)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"token%d","token_type":"bearer","expires_in":%d,"scope":%q}`, n, expiresIn, r.FormValue("scope"))
+		case "/get":
+			w.Write([]byte(r.Header.Get("Authorization")))
+		}
+	}))
+	defer ts.Close()
+	auth := dmfr.FeedAuthorization{Type: "oauth2_client_credentials"}
+	ctx := context.TODO()
+	get := func(secret dmfr.Secret) (string, error) {
+		var out bytes.Buffer
+		fr, err := AuthenticatedRequest(ctx, &out, ts.URL+"/get", WithAuth(secret, auth))
+		if err != nil {
+			return "", err
+		}
+		if fr.FetchError != nil {
+			return "", fr.FetchError
+		}
+		return out.String(), nil
+	}
+	t.Run("cached token", func(t *testing.T) {
+		secret := dmfr.Secret{TokenURL: ts.URL + "/token", ClientID: "client", ClientSecret: "secret", Scope: "read"}
+		defer resetOAuth2Token(secret)
+		atomic.StoreInt32(&tokenRequests, 0)
+		for i := 0; i < 3; i++ {
+			v, err := get(secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, "Bearer token1", v)
+		}
+		assert.Equal(t, int32(1), tokenRequests)
+	})
+	t.Run("expired token", func(t *testing.T) {
+		expiresIn = 1
+		defer func() { expiresIn = 3600 }()
+		secret := dmfr.Secret{TokenURL: ts.URL + "/token", ClientID: "client", ClientSecret: "secret", Scope: "write"}
+		defer resetOAuth2Token(secret)
+		atomic.StoreInt32(&tokenRequests, 0)
+		for i := 0; i < 2; i++ {
+			if _, err := get(secret); err != nil {
+				t.Fatal(err)
+			}
+		}
+		assert.Equal(t, int32(2), tokenRequests)
+	})
+	t.Run("invalid client", func(t *testing.T) {
+		secret := dmfr.Secret{TokenURL: ts.URL + "/token", ClientID: "client", ClientSecret: "wrong"}
+		defer resetOAuth2Token(secret)
+		_, err := get(secret)
+		assert.ErrorContains(t, err, "could not get oauth2 token")
+	})
+	t.Run("missing token url", func(t *testing.T) {
+		_, err := get(dmfr.Secret{ClientID: "client", ClientSecret: "secret"})
+		assert.ErrorContains(t, err, "token_url")
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -25,13 +26,28 @@ type Uploader interface {
 	Upload(context.Context, string, io.Reader) error
 }
 
+// CanDownloadConditional is implemented by downloaders that support
+// conditional requests using cache validators from a previous response.
+type CanDownloadConditional interface {
+	DownloadConditional(context.Context, string, dmfr.FeedAuthorization, Validators) (io.ReadCloser, int, Validators, error)
+}
+
+// Validators are the cache validators sent with and returned from a conditional request.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
 type FetchResponse struct {
-	ResponseSize   int
-	ResponseCode   int
-	ResponseTimeMs int
-	ResponseTtfbMs int
-	ResponseSHA1   string
-	FetchError     error
+	ResponseSize         int
+	ResponseCode         int
+	ResponseTimeMs       int
+	ResponseTtfbMs       int
+	ResponseSHA1         string
+	ResponseETag         string
+	ResponseLastModified string
+	NotModified          bool
+	FetchError           error
 }
 
 type Request struct {
@@ -42,19 +58,34 @@ type Request struct {
 	MaxSize    uint64
 	Secret     dmfr.Secret
 	Auth       dmfr.FeedAuthorization
+	Validators Validators
+	Limiter    *HostLimiter
+	HostLimit  HostLimit
+	Headers    map[string]string
 }
 
 func (req *Request) Request(ctx context.Context) (io.ReadCloser, int, error) {
+	r, code, _, err := req.conditionalRequest(ctx)
+	return r, code, err
+}
+
+// conditionalRequest sends the request with any configured validators.
+// A nil reader with a 304 response code indicates the resource was not modified.
+func (req *Request) conditionalRequest(ctx context.Context) (io.ReadCloser, int, Validators, error) {
 	// Download
 	log.For(ctx).Debug().Str("url", req.URL).Str("auth_type", req.Auth.Type).Msg("download")
 	downloader, key, err := req.newDownloader(req.URL)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, Validators{}, err
 	}
 	if a, ok := downloader.(CanSetSecret); ok {
 		a.SetSecret(req.Secret)
 	}
-	return downloader.DownloadAuth(ctx, key, req.Auth)
+	if a, ok := downloader.(CanDownloadConditional); ok {
+		return a.DownloadConditional(ctx, key, req.Auth, req.Validators)
+	}
+	r, code, err := downloader.DownloadAuth(ctx, key, req.Auth)
+	return r, code, Validators{}, err
 }
 
 func (req *Request) newDownloader(ustr string) (Downloader, string, error) {
@@ -67,9 +98,9 @@ func (req *Request) newDownloader(ustr string) (Downloader, string, error) {
 	reqUrl := req.URL
 	switch u.Scheme {
 	case "http":
-		downloader = &Http{}
+		downloader = &Http{headers: req.Headers}
 	case "https":
-		downloader = &Http{}
+		downloader = &Http{headers: req.Headers}
 	case "ftp":
 		if req.AllowFTP {
 			downloader = &Ftp{}
@@ -134,6 +165,38 @@ func WithAuth(secret dmfr.Secret, auth dmfr.FeedAuthorization) func(req *Request
 	}
 }
 
+// WithValidators sends If-None-Match and If-Modified-Since headers using validators from a previous response.
+func WithValidators(etag string, lastModified string) RequestOption {
+	return func(req *Request) {
+		req.Validators = Validators{ETag: etag, LastModified: lastModified}
+	}
+}
+
+// WithHostLimiter waits for permission from a shared HostLimiter before each request,
+// and retries requests that receive a 429 or 503 response.
+func WithHostLimiter(limiter *HostLimiter) RequestOption {
+	return func(req *Request) {
+		req.Limiter = limiter
+	}
+}
+
+// WithHostLimit overrides the HostLimiter default limits for this request.
+func WithHostLimit(limit HostLimit) RequestOption {
+	return func(req *Request) {
+		req.HostLimit = limit
+	}
+}
+
+// WithHeader sets an additional header on HTTP requests, replacing any default value.
+func WithHeader(key string, value string) RequestOption {
+	return func(req *Request) {
+		if req.Headers == nil {
+			req.Headers = map[string]string{}
+		}
+		req.Headers[key] = value
+	}
+}
+
 // AuthenticatedRequestDownload is similar to AuthenticatedRequest but writes to a temporary file.
 // Fatal errors will be returned as the error; non-fatal errors as FetchResponse.FetchError
 func AuthenticatedRequestDownload(ctx context.Context, address string, opts ...RequestOption) (string, FetchResponse, error) {
@@ -149,6 +212,11 @@ func AuthenticatedRequestDownload(ctx context.Context, address string, opts ...R
 	if err != nil {
 		return "", fr, err
 	}
+	if fr.NotModified {
+		// Nothing was written
+		os.Remove(tmpfile.Name())
+		return "", fr, nil
+	}
 
 	// Collect data
 	return tmpfile.Name(), fr, nil
@@ -165,10 +233,21 @@ func AuthenticatedRequest(ctx context.Context, out io.Writer, address string, op
 	fr := FetchResponse{}
 	req := NewRequest(address, opts...)
 	var r io.ReadCloser
-	r, fr.ResponseCode, fr.FetchError = req.Request(ctx)
+	var v Validators
+	var release func()
+	r, fr.ResponseCode, v, release, fr.FetchError = req.limitedRequest(ctx)
+	defer release()
+	fr.ResponseETag = v.ETag
+	fr.ResponseLastModified = v.LastModified
 	if fr.FetchError != nil {
 		return fr, nil
 	}
+	if fr.ResponseCode == http.StatusNotModified {
+		fr.NotModified = true
+		fr.ResponseTtfbMs = int(time.Since(t) / time.Millisecond)
+		fr.ResponseTimeMs = fr.ResponseTtfbMs
+		return fr, nil
+	}
 	defer r.Close()
 
 	// Write response
@@ -190,6 +269,31 @@ func AuthenticatedRequest(ctx context.Context, out io.Writer, address string, op
 	return fr, nil
 }
 
+// limitedRequest waits for the configured HostLimiter, if any, and makes the request.
+// Requests that receive a 429 or 503 response are retried after backing off.
+// The returned function releases the HostLimiter slot and must be called after the response is read.
+func (req *Request) limitedRequest(ctx context.Context) (io.ReadCloser, int, Validators, func(), error) {
+	host := limiterHost(req.URL)
+	if req.Limiter == nil || host == "" {
+		r, code, v, err := req.conditionalRequest(ctx)
+		return r, code, v, func() {}, err
+	}
+	for attempt := 0; ; attempt++ {
+		release, err := req.Limiter.Acquire(ctx, host, req.HostLimit)
+		if err != nil {
+			return nil, 0, Validators{}, func() {}, err
+		}
+		r, code, v, err := req.conditionalRequest(ctx)
+		backoff, ok := retryBackoff(err, attempt)
+		if !ok || attempt >= req.Limiter.MaxRetries {
+			return r, code, v, release, err
+		}
+		log.For(ctx).Info().Str("url", req.URL).Int("response_code", code).Dur("backoff", backoff).Msg("request: backing off")
+		req.Limiter.Backoff(host, backoff)
+		release()
+	}
+}
+
 func copyTo(dst io.Writer, src io.Reader, maxSize uint64) (int, string, error) {
 	size := 0
 	h := sha1.New()
@@ -160,3 +160,49 @@ func TestAuthorizedRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthenticatedRequest_Conditional(t *testing.T) {
+	etag := `"abc123"`
+	lastModified := "Wed, 21 Oct 2026 07:28:00 GMT"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		if r.Header.Get("If-None-Match") == etag || r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	testcases := []struct {
+		name              string
+		etag              string
+		lastModified      string
+		expectCode        int
+		expectSize        int
+		expectNotModified bool
+	}{
+		{name: "no validators", expectCode: 200, expectSize: 2},
+		{name: "etag match", etag: etag, expectCode: 304, expectNotModified: true},
+		{name: "last-modified match", lastModified: lastModified, expectCode: 304, expectNotModified: true},
+		{name: "etag mismatch", etag: `"other"`, expectCode: 200, expectSize: 2},
+	}
+	ctx := context.TODO()
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			fr, err := AuthenticatedRequest(ctx, &out, ts.URL, WithValidators(tc.etag, tc.lastModified))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fr.FetchError != nil {
+				t.Fatal(fr.FetchError)
+			}
+			assert.Equal(t, tc.expectCode, fr.ResponseCode)
+			assert.Equal(t, tc.expectSize, fr.ResponseSize)
+			assert.Equal(t, tc.expectNotModified, fr.NotModified)
+			assert.Equal(t, etag, fr.ResponseETag)
+			assert.Equal(t, lastModified, fr.ResponseLastModified)
+		})
+	}
+}
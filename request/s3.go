@@ -20,6 +20,7 @@ import (
 func init() {
 	var _ Store = &S3{}
 	var _ Presigner = &S3{}
+	var _ Exister = &S3{}
 }
 
 func trimSlash(v string) string {
@@ -84,6 +85,18 @@ func (r S3) Download(ctx context.Context, key string) (io.ReadCloser, int, error
 	return s3obj.Body, 0, nil
 }
 
+func (r S3) Exists(ctx context.Context, key string) bool {
+	client, err := awsConfig(ctx, r.secret)
+	if err != nil {
+		return false
+	}
+	_, err = client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(r.Bucket),
+		Key:    aws.String(r.getFullKey(key)),
+	})
+	return err == nil
+}
+
 func (r S3) DownloadAuth(ctx context.Context, key string, auth dmfr.FeedAuthorization) (io.ReadCloser, int, error) {
 	return r.Download(ctx, key)
 }
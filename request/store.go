@@ -29,8 +29,20 @@ type Presigner interface {
 	CreateSignedUrl(context.Context, string, string) (string, error)
 }
 
+// Exister checks if a key exists without downloading it.
+type Exister interface {
+	Exists(context.Context, string) bool
+}
+
 // Store returns a configured store based on the provided url.
 func GetStore(ustr string) (Store, error) {
+	if strings.HasPrefix(ustr, ContentStorePrefix) {
+		s, err := GetStore(strings.TrimPrefix(ustr, ContentStorePrefix))
+		if err != nil {
+			return nil, err
+		}
+		return NewContentStore(s), nil
+	}
 	u, err := url.Parse(ustr)
 	if err != nil {
 		return nil, err
@@ -42,6 +54,10 @@ func GetStore(ustr string) (Store, error) {
 		s, storeErr = NewS3FromUrl(ustr)
 	case "az":
 		s, storeErr = NewAzFromUrl(ustr)
+	case "gs":
+		s, storeErr = NewGcsFromUrl(ustr)
+	case "dav", "davs":
+		s, storeErr = NewWebDavFromUrl(ustr)
 	case "file":
 		s = &Local{Directory: ustr}
 	default:
@@ -0,0 +1,35 @@
+package rules
+
+import (
+	"github.com/interline-io/transitland-lib/adapters"
+	"github.com/interline-io/transitland-lib/causes"
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+// AreaWithoutStopsCheck checks for AreaWithoutStopsErrors.
+// Areas are copied before stop_areas.txt, so stop areas are read from the source feed in advance.
+type AreaWithoutStopsCheck struct {
+	areaStops map[string]bool
+}
+
+// Prepare reads the areas referenced in stop_areas.txt.
+func (e *AreaWithoutStopsCheck) Prepare(reader adapters.Reader, emap *tt.EntityMap) error {
+	e.areaStops = map[string]bool{}
+	for ent := range reader.StopAreas() {
+		e.areaStops[ent.AreaID.Val] = true
+	}
+	return nil
+}
+
+// Validate .
+func (e *AreaWithoutStopsCheck) Validate(ent tt.Entity) []error {
+	v, ok := ent.(*gtfs.Area)
+	if !ok || e.areaStops == nil {
+		return nil
+	}
+	if !e.areaStops[v.AreaID.Val] {
+		return []error{causes.NewAreaWithoutStopsError(v.AreaID.Val)}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package rules
+
+import (
+	"github.com/interline-io/transitland-lib/causes"
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+type fareLegRuleMatch struct {
+	legGroupID string
+	fields     [5]string // network, from area, to area, from timeframe group, to timeframe group
+}
+
+func newFareLegRuleMatch(v *gtfs.FareLegRule) fareLegRuleMatch {
+	return fareLegRuleMatch{
+		legGroupID: v.LegGroupID.Val,
+		fields: [5]string{
+			v.NetworkID.Val,
+			v.FromAreaID.Val,
+			v.ToAreaID.Val,
+			v.FromTimeframeGroupID.Val,
+			v.ToTimeframeGroupID.Val,
+		},
+	}
+}
+
+// overlaps returns true if both rules can match the same leg.
+// When rule_priority is used, empty fields match any value.
+func (m fareLegRuleMatch) overlaps(other fareLegRuleMatch) bool {
+	for i := range m.fields {
+		a, b := m.fields[i], other.fields[i]
+		if a != b && a != "" && b != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// FareLegRuleAmbiguousCheck checks for AmbiguousFareLegRuleErrors.
+type FareLegRuleAmbiguousCheck struct {
+	exact       map[fareLegRuleMatch]string
+	prioritized map[int64][]fareLegRuleMatch
+}
+
+// Validate .
+func (e *FareLegRuleAmbiguousCheck) Validate(ent tt.Entity) []error {
+	v, ok := ent.(*gtfs.FareLegRule)
+	if !ok {
+		return nil
+	}
+	if e.exact == nil {
+		e.exact = map[fareLegRuleMatch]string{}
+		e.prioritized = map[int64][]fareLegRuleMatch{}
+	}
+	m := newFareLegRuleMatch(v)
+	if !v.RulePriority.Valid {
+		// Without rule_priority, empty fields exclude listed values, so only identical rules match the same legs
+		key := fareLegRuleMatch{fields: m.fields}
+		if other, ok := e.exact[key]; !ok {
+			e.exact[key] = m.legGroupID
+		} else if other != m.legGroupID {
+			return []error{causes.NewAmbiguousFareLegRuleError(m.legGroupID, other, 0)}
+		}
+		return nil
+	}
+	var errs []error
+	for _, other := range e.prioritized[v.RulePriority.Val] {
+		if other.legGroupID != m.legGroupID && m.overlaps(other) {
+			errs = append(errs, causes.NewAmbiguousFareLegRuleError(m.legGroupID, other.legGroupID, v.RulePriority.Val))
+			break
+		}
+	}
+	e.prioritized[v.RulePriority.Val] = append(e.prioritized[v.RulePriority.Val], m)
+	return errs
+}
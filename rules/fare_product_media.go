@@ -0,0 +1,25 @@
+package rules
+
+import (
+	"github.com/interline-io/transitland-lib/causes"
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+// FareProductMediaCheck checks for FareProductWithoutMediaErrors.
+type FareProductMediaCheck struct {
+	hasFareMedia bool
+}
+
+// Validate .
+func (e *FareProductMediaCheck) Validate(ent tt.Entity) []error {
+	switch v := ent.(type) {
+	case *gtfs.FareMedia:
+		e.hasFareMedia = true
+	case *gtfs.FareProduct:
+		if e.hasFareMedia && !v.FareMediaID.Valid {
+			return []error{causes.NewFareProductWithoutMediaError(v.FareProductID.Val)}
+		}
+	}
+	return nil
+}
@@ -63,6 +63,7 @@ var maxSpeeds = map[int]float64{
 
 // StopTimeFastTravelCheck checks for FastTravelErrors.
 type StopTimeFastTravelCheck struct {
+	MaxSpeeds  map[int]float64    // override max speed (km/h) by route_type
 	routeTypes map[string]int     // keep track of route_types
 	stopDist   map[string]float64 // cache stop-to-stop distances
 	geomCache  tlxy.GeomCache     // share with copier
@@ -91,7 +92,9 @@ func (e *StopTimeFastTravelCheck) Validate(ent tt.Entity) []error {
 	}
 	maxspeed := 200.0 // default max speed
 	if rtype, ok := e.routeTypes[trip.RouteID.Val]; ok {
-		if m, ok := maxSpeeds[rtype]; ok {
+		if m, ok := e.MaxSpeeds[rtype]; ok {
+			maxspeed = m
+		} else if m, ok := maxSpeeds[rtype]; ok {
 			maxspeed = m
 		}
 	}
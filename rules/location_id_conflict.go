@@ -0,0 +1,65 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+// LocationIDConflictError reports when a GTFS-Flex location or location group uses an ID that is already used by another location type.
+type LocationIDConflictError struct {
+	OtherFilename string
+	bc
+}
+
+func (e *LocationIDConflictError) Error() string {
+	return fmt.Sprintf(
+		"id '%s' in '%s' conflicts with an id in '%s'",
+		e.EntityID,
+		e.Filename,
+		e.OtherFilename,
+	)
+}
+
+// LocationIDConflictCheck checks that stop_id, location_id, and location_group_id values are unique across stops.txt, locations.geojson, and location_groups.txt.
+type LocationIDConflictCheck struct {
+	seen map[string]string
+}
+
+// Validate .
+func (e *LocationIDConflictCheck) Validate(ent tt.Entity) []error {
+	if e.seen == nil {
+		e.seen = map[string]string{}
+	}
+	eid := ""
+	field := ""
+	switch v := ent.(type) {
+	case *gtfs.Stop:
+		eid = v.StopID.Val
+		field = "stop_id"
+	case *gtfs.Location:
+		eid = v.LocationID.Val
+		field = "id"
+	case *gtfs.LocationGroup:
+		eid = v.LocationGroupID.Val
+		field = "location_group_id"
+	default:
+		return nil
+	}
+	if eid == "" {
+		return nil
+	}
+	efn := ent.Filename()
+	if otherFn, ok := e.seen[eid]; ok && otherFn != efn {
+		err := &LocationIDConflictError{OtherFilename: otherFn}
+		err.Filename = efn
+		err.EntityID = eid
+		err.Field = field
+		err.Value = eid
+		return []error{err}
+	} else if !ok {
+		e.seen[eid] = efn
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package rules
+
+import (
+	"github.com/interline-io/transitland-lib/causes"
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+// RouteNetworkConflictCheck checks for ConflictingRouteNetworkErrors.
+// route_networks.txt is forbidden when routes.txt assigns networks using network_id.
+type RouteNetworkConflictCheck struct {
+	routeNetworkIDs bool
+}
+
+// Validate .
+func (e *RouteNetworkConflictCheck) Validate(ent tt.Entity) []error {
+	switch v := ent.(type) {
+	case *gtfs.Route:
+		if v.NetworkID.Valid {
+			e.routeNetworkIDs = true
+		}
+	case *gtfs.RouteNetwork:
+		if e.routeNetworkIDs {
+			return []error{causes.NewConflictingRouteNetworkError(v.RouteID.Val, v.NetworkID.Val)}
+		}
+	}
+	return nil
+}
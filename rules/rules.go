@@ -3,8 +3,18 @@ package rules
 
 import (
 	"github.com/interline-io/transitland-lib/causes"
+	"github.com/interline-io/transitland-lib/tlxy"
+	"github.com/interline-io/transitland-lib/tt"
+	geom "github.com/twpayne/go-geom"
 )
 
 ///////////////
 
 type bc = causes.Context
+
+// lineGeometry returns a line between two points, used to locate errors on a map.
+func lineGeometry(a tlxy.Point, b tlxy.Point) tt.Geometry {
+	g := geom.NewLineStringFlat(geom.XY, []float64{a.Lon, a.Lat, b.Lon, b.Lat})
+	g.SetSRID(4326)
+	return tt.NewGeometry(g)
+}
@@ -31,7 +31,8 @@ func ValidateStopTimes(stoptimes []gtfs.StopTime) []error {
 	if len(stoptimes) < 2 {
 		errs = append(errs, causes.NewEmptyTripError(len(stoptimes)))
 	}
-	if lastSt := stoptimes[len(stoptimes)-1]; lastSt.ArrivalTime.Int() <= 0 {
+	// GTFS-Flex stop times with pickup/drop off windows do not have arrival times
+	if lastSt := stoptimes[len(stoptimes)-1]; lastSt.ArrivalTime.Int() <= 0 && !lastSt.EndPickupDropOffWindow.Valid {
 		errs = append(errs, causes.NewSequenceError("arrival_time", lastSt.ArrivalTime.String()))
 	}
 	lastDist := stoptimes[0].ShapeDistTraveled
@@ -17,6 +17,12 @@ type StopTooCloseError struct {
 	OtherStopID string
 	Distance    float64
 	bc
+	geom tt.Geometry
+}
+
+// Geometry returns a line between the two stops.
+func (e *StopTooCloseError) Geometry() tt.Geometry {
+	return e.geom
 }
 
 func (e *StopTooCloseError) Error() string {
@@ -72,6 +78,7 @@ func (e *StopTooCloseCheck) Validate(ent tt.Entity) []error {
 						StopID:      v.StopID.Val,
 						OtherStopID: hit.id,
 						Distance:    d,
+						geom:        lineGeometry(g.pt, hit.pt),
 					})
 				}
 			}
@@ -8,12 +8,18 @@ import (
 	"github.com/interline-io/transitland-lib/tt"
 )
 
-// StopTooFarError reports when two related stops are >1km away.
+// StopTooFarError reports when two related stops are too far apart, by default >1km.
 type StopTooFarError struct {
 	StopID        string
 	ParentStation string
 	Distance      float64
 	bc
+	geom tt.Geometry
+}
+
+// Geometry returns a line between the stop and the parent stop.
+func (e *StopTooFarError) Geometry() tt.Geometry {
+	return e.geom
 }
 
 func (e *StopTooFarError) Error() string {
@@ -27,13 +33,15 @@ func (e *StopTooFarError) Error() string {
 
 // StopTooFarCheck checks for StopTooFarErrors.
 type StopTooFarCheck struct {
-	geoms   map[string]tlxy.Point // use shared geom cache?
-	maxdist float64
+	MaxDistance float64               // in meters; default 1km
+	geoms       map[string]tlxy.Point // use shared geom cache?
 }
 
 // Validate .
 func (e *StopTooFarCheck) Validate(ent tt.Entity) []error {
-	e.maxdist = 1000.0
+	if e.MaxDistance <= 0 {
+		e.MaxDistance = 1000.0
+	}
 	if e.geoms == nil {
 		e.geoms = map[string]tlxy.Point{}
 	}
@@ -50,15 +58,16 @@ func (e *StopTooFarCheck) Validate(ent tt.Entity) []error {
 	if !v.ParentStation.Valid {
 		return nil
 	}
-	// Check if parent stop is >1km
+	// Check if parent stop is too far
 	if pgeom, ok := e.geoms[v.ParentStation.Val]; ok {
 		// if not ok, then it's a parent error and out of scope for this check
 		d := tlxy.DistanceHaversine(spoint, pgeom)
-		if d > e.maxdist {
+		if d > e.MaxDistance {
 			errs = append(errs, &StopTooFarError{
 				StopID:        v.StopID.Val,
 				ParentStation: v.ParentStation.Val,
 				Distance:      d,
+				geom:          lineGeometry(spoint, pgeom),
 			})
 		}
 	}
@@ -0,0 +1,52 @@
+package rules
+
+import (
+	"github.com/interline-io/transitland-lib/causes"
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+type timeframeKey struct {
+	timeframeGroupID string
+	serviceID        string
+}
+
+// TimeframeOverlapCheck checks for TimeframeOverlapErrors.
+type TimeframeOverlapCheck struct {
+	timeframes map[timeframeKey][]*freqValue
+}
+
+// Validate .
+func (e *TimeframeOverlapCheck) Validate(ent tt.Entity) []error {
+	v, ok := ent.(*gtfs.Timeframe)
+	if !ok {
+		return nil
+	}
+	if e.timeframes == nil {
+		e.timeframes = map[timeframeKey][]*freqValue{}
+	}
+	// Empty start_time and end_time cover the entire day
+	tf := freqValue{start: 0, end: 24 * 3600}
+	if v.StartTime.Valid {
+		tf.start = v.StartTime.Int()
+	}
+	if v.EndTime.Valid {
+		tf.end = v.EndTime.Int()
+	}
+	key := timeframeKey{timeframeGroupID: v.TimeframeGroupID.Val, serviceID: v.ServiceID.Val}
+	var errs []error
+	for _, hit := range e.timeframes[key] {
+		if !(tf.start >= hit.end || tf.end <= hit.start) {
+			errs = append(errs, &causes.TimeframeOverlapError{
+				TimeframeGroupID: v.TimeframeGroupID.Val,
+				ServiceID:        v.ServiceID.Val,
+				StartTime:        tt.NewSeconds(tf.start).String(),
+				EndTime:          tt.NewSeconds(tf.end).String(),
+				OtherStartTime:   tt.NewSeconds(hit.start).String(),
+				OtherEndTime:     tt.NewSeconds(hit.end).String(),
+			})
+		}
+	}
+	e.timeframes[key] = append(e.timeframes[key], &tf)
+	return errs
+}
@@ -15,6 +15,12 @@ type StopTooFarFromShapeError struct {
 	ShapeID  string
 	Distance float64
 	bc
+	geom tt.Geometry
+}
+
+// Geometry returns a line between the stop and the nearest point on the shape.
+func (e *StopTooFarFromShapeError) Geometry() tt.Geometry {
+	return e.geom
 }
 
 func (e *StopTooFarFromShapeError) Error() string {
@@ -71,6 +77,7 @@ func (e *StopTooFarFromShapeCheck) Validate(ent tt.Entity) []error {
 				StopID:   st.StopID.Val,
 				ShapeID:  shapeid,
 				Distance: distance,
+				geom:     lineGeometry(g, nearest),
 			})
 		}
 	}
@@ -186,6 +186,22 @@ CREATE TABLE IF NOT EXISTS "feed_version_service_windows" (
   foreign key(feed_version_id) REFERENCES feed_versions(id)
 );
 CREATE INDEX feed_version_service_windows_feed_version_id ON "feed_version_service_windows"(feed_version_id);
+CREATE TABLE IF NOT EXISTS "feed_version_changes" (
+  "id" integer primary key autoincrement,
+  "feed_version_id" integer not null,
+  "previous_feed_version_id" integer not null,
+  "created_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  "added_count" blob,
+  "removed_count" blob,
+  "changed_count" blob,
+  "routes_added" blob,
+  "routes_removed" blob,
+  "stops_added" blob,
+  "stops_removed" blob,
+  foreign key(feed_version_id) REFERENCES feed_versions(id)
+);
+CREATE INDEX feed_version_changes_feed_version_id ON "feed_version_changes"(feed_version_id);
 CREATE TABLE IF NOT EXISTS "feed_version_service_levels" (
   "id" integer primary key autoincrement,
   "feed_version_id" integer not null,
@@ -384,15 +400,21 @@ CREATE TABLE IF NOT EXISTS "gtfs_stop_times" (
   "trip_id" integer NOT NULL,
   "arrival_time" int,
   "departure_time" int,
-  "stop_id" integer NOT NULL,
+  "stop_id" integer,
+  "location_group_id" integer,
+  "location_id" integer,
   "stop_sequence" integer,
   "stop_headsign" varchar(255),
+  "start_pickup_drop_off_window" int,
+  "end_pickup_drop_off_window" int,
   "pickup_type" integer,
   "drop_off_type" integer,
   "shape_dist_traveled" real,
   "timepoint" integer,
   "continuous_pickup" integer,
   "continuous_drop_off" integer,
+  "pickup_booking_rule_id" integer,
+  "drop_off_booking_rule_id" integer,
   "interpolated" integer,
   "feed_version_id" integer NOT NULL,
   foreign key(feed_version_id) REFERENCES feed_versions(id),
@@ -590,6 +612,9 @@ CREATE TABLE feed_fetches (
   "response_time_ms" int,
   "response_ttfb_ms" int,
   "response_sha1" varchar(255),
+  "response_etag" varchar(255),
+  "response_last_modified" varchar(255),
+  "not_modified" bool NOT NULL DEFAULT false,
   "validation_duration_ms" int,
   "upload_duration_ms" int,
   "feed_version_id" int,
@@ -631,6 +656,9 @@ CREATE TABLE gtfs_fare_leg_rules (
   "from_area_id" varchar(255),
   "to_area_id" varchar(255),
   "fare_product_id" varchar(255),
+  "from_timeframe_group_id" varchar(255),
+  "to_timeframe_group_id" varchar(255),
+  "rule_priority" integer,
   "transfer_only" integer,
   foreign key(feed_version_id) REFERENCES feed_versions(id)
 );
@@ -646,6 +674,7 @@ CREATE TABLE gtfs_fare_transfer_rules (
   duration_limit_type int,
   fare_transfer_type int,
   fare_product_id varchar(255),
+  filter_fare_product_id varchar(255),
   foreign key(feed_version_id) REFERENCES feed_versions(id)
 );
 CREATE TABLE gtfs_fare_products (
@@ -727,6 +756,66 @@ CREATE TABLE gtfs_route_networks (
   foreign key(route_id) REFERENCES gtfs_routes(id)
 );
 
+CREATE TABLE gtfs_locations (
+  "id" integer primary key autoincrement,
+  "feed_version_id" int not null,
+  "created_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  "location_id" varchar(255) not null,
+  "stop_name" varchar(255),
+  "stop_desc" varchar(255),
+  "zone_id" varchar(255),
+  "stop_url" varchar(255),
+  "geometry" blob,
+  foreign key(feed_version_id) REFERENCES feed_versions(id)
+);
+
+CREATE TABLE gtfs_location_groups (
+  "id" integer primary key autoincrement,
+  "feed_version_id" int not null,
+  "created_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  "location_group_id" varchar(255) not null,
+  "location_group_name" varchar(255),
+  foreign key(feed_version_id) REFERENCES feed_versions(id)
+);
+
+CREATE TABLE gtfs_location_group_stops (
+  "id" integer primary key autoincrement,
+  "feed_version_id" int not null,
+  "created_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  "location_group_id" integer not null,
+  "stop_id" integer not null,
+  foreign key(feed_version_id) REFERENCES feed_versions(id),
+  foreign key(location_group_id) REFERENCES gtfs_location_groups(id),
+  foreign key(stop_id) REFERENCES gtfs_stops(id)
+);
+
+CREATE TABLE gtfs_booking_rules (
+  "id" integer primary key autoincrement,
+  "feed_version_id" int not null,
+  "created_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  "booking_rule_id" varchar(255) not null,
+  "booking_type" integer not null,
+  "prior_notice_duration_min" integer,
+  "prior_notice_duration_max" integer,
+  "prior_notice_last_day" integer,
+  "prior_notice_last_time" integer,
+  "prior_notice_start_day" integer,
+  "prior_notice_start_time" integer,
+  "prior_notice_service_id" integer,
+  "message" varchar(255),
+  "pickup_message" varchar(255),
+  "drop_off_message" varchar(255),
+  "phone_number" varchar(255),
+  "info_url" varchar(255),
+  "booking_url" varchar(255),
+  foreign key(feed_version_id) REFERENCES feed_versions(id),
+  foreign key(prior_notice_service_id) REFERENCES gtfs_calendars(id)
+);
+
 CREATE TABLE tl_validation_reports (
   "id" integer primary key autoincrement,
   "feed_version_id" int not null,
@@ -822,4 +911,62 @@ CREATE TABLE feed_version_stop_onestop_ids (
   "entity_id" varchar(255) not null,
   "onestop_id" varchar(255) not null,
   foreign key(feed_version_id) REFERENCES feed_versions(id)
-);
\ No newline at end of file
+);
+CREATE TABLE tl_mds_trips (
+  "id" integer primary key autoincrement,
+  "feed_id" integer not null,
+  "provider_id" varchar(255),
+  "device_id" varchar(255),
+  "vehicle_id" varchar(255),
+  "vehicle_type" varchar(255),
+  "trip_id" varchar(255) not null,
+  "trip_duration" integer,
+  "trip_distance" integer,
+  "start_time" datetime,
+  "end_time" datetime,
+  "start_geometry" blob,
+  "end_geometry" blob,
+  "start_zone" varchar(255),
+  "end_zone" varchar(255),
+  "created_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  foreign key(feed_id) REFERENCES current_feeds(id)
+);
+CREATE INDEX idx_tl_mds_trips_feed_id_start_time ON "tl_mds_trips"(feed_id, start_time);
+CREATE TABLE tl_mds_status_changes (
+  "id" integer primary key autoincrement,
+  "feed_id" integer not null,
+  "provider_id" varchar(255),
+  "device_id" varchar(255),
+  "vehicle_id" varchar(255),
+  "vehicle_type" varchar(255),
+  "vehicle_state" varchar(255),
+  "event_types" blob,
+  "event_time" datetime,
+  "geometry" blob,
+  "zone" varchar(255),
+  "battery_pct" real,
+  "trip_id" varchar(255),
+  "created_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  foreign key(feed_id) REFERENCES current_feeds(id)
+);
+CREATE INDEX idx_tl_mds_status_changes_feed_id_event_time ON "tl_mds_status_changes"(feed_id, event_time);
+CREATE TABLE tl_mds_vehicles (
+  "id" integer primary key autoincrement,
+  "feed_id" integer not null,
+  "provider_id" varchar(255),
+  "device_id" varchar(255),
+  "vehicle_id" varchar(255),
+  "vehicle_type" varchar(255),
+  "last_state" varchar(255),
+  "last_event_types" blob,
+  "last_event_time" datetime,
+  "geometry" blob,
+  "zone" varchar(255),
+  "battery_pct" real,
+  "created_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" datetime DEFAULT CURRENT_TIMESTAMP,
+  foreign key(feed_id) REFERENCES current_feeds(id)
+);
+CREATE INDEX idx_tl_mds_vehicles_feed_id ON "tl_mds_vehicles"(feed_id);
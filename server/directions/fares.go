@@ -0,0 +1,171 @@
+package directions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/fares"
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/interline-io/transitland-lib/tldb"
+	"github.com/interline-io/transitland-lib/tldb/postgres"
+	"github.com/tidwall/tinylru"
+	"golang.org/x/sync/singleflight"
+)
+
+// maxFareCalculators is the maximum number of cached fare calculators.
+const maxFareCalculators = 64
+
+// fareCalculators holds the most recently used fare calculators, keyed by feed version ID.
+var fareCalculators = func() *tinylru.LRU {
+	c := tinylru.LRU{}
+	c.Resize(maxFareCalculators)
+	return &c
+}()
+
+// fareAdapter returns the adapter used to read fare rules from the database.
+var fareAdapter = func(db tldb.Ext) tldb.Adapter {
+	return postgres.NewPostgresAdapterFromDBX(db)
+}
+
+// fareCalculatorsLoading ensures each feed version is loaded once by concurrent requests.
+var fareCalculatorsLoading singleflight.Group
+
+// ItineraryFares calculates candidate fares for the transit legs of an itinerary.
+// Fares are only calculated when all transit legs use the same feed version.
+func ItineraryFares(ctx context.Context, itin *model.Itinerary, riderCategoryID *string, fareMediaID *string) ([]*model.ItineraryFare, error) {
+	req, legIndex, fvsha1 := itineraryFareRequest(itin)
+	if len(req.Legs) == 0 {
+		return []*model.ItineraryFare{}, nil
+	}
+	if riderCategoryID != nil {
+		req.RiderCategoryID = *riderCategoryID
+	}
+	if fareMediaID != nil {
+		req.FareMediaID = *fareMediaID
+	}
+	calc, err := fareCalculatorForFeedVersion(ctx, fvsha1)
+	if err != nil {
+		return nil, err
+	} else if calc == nil {
+		return []*model.ItineraryFare{}, nil
+	}
+	cands, err := calc.Calculate(req)
+	if errors.Is(err, fares.ErrTooManyCandidates) {
+		log.For(ctx).Warn().Str("feed_version_sha1", fvsha1).Msg("reached maximum fare candidates, results are incomplete")
+	} else if err != nil {
+		return nil, err
+	}
+	return itineraryFaresFromCandidates(cands, legIndex), nil
+}
+
+// itineraryFareRequest converts the transit legs of an itinerary to a fare request.
+// Returns the itinerary index of each fare leg and the feed version of the transit legs.
+func itineraryFareRequest(itin *model.Itinerary) (fares.Request, []int, string) {
+	req := fares.Request{}
+	var legIndex []int
+	fvsha1 := ""
+	for i, leg := range itin.Legs {
+		if leg == nil || leg.Trip == nil || leg.Trip.Route == nil {
+			continue
+		}
+		if fvsha1 != "" && leg.Trip.FeedVersionSha1 != fvsha1 {
+			return fares.Request{}, nil, ""
+		}
+		fvsha1 = leg.Trip.FeedVersionSha1
+		fareLeg := fares.Leg{
+			RouteID:   leg.Trip.Route.RouteID,
+			Departure: leg.StartTime,
+			Arrival:   leg.EndTime,
+		}
+		if leg.From != nil && leg.From.Stop != nil {
+			fareLeg.FromStopID = leg.From.Stop.StopID
+		}
+		if leg.To != nil && leg.To.Stop != nil {
+			fareLeg.ToStopID = leg.To.Stop.StopID
+		}
+		req.Legs = append(req.Legs, fareLeg)
+		legIndex = append(legIndex, i)
+	}
+	return req, legIndex, fvsha1
+}
+
+func itineraryFaresFromCandidates(cands []fares.Candidate, legIndex []int) []*model.ItineraryFare {
+	mapLegs := func(v []int) []int {
+		ret := make([]int, len(v))
+		for i, idx := range v {
+			ret[i] = legIndex[idx]
+		}
+		return ret
+	}
+	ret := []*model.ItineraryFare{}
+	for _, cand := range cands {
+		fare := &model.ItineraryFare{
+			Total:         cand.Total,
+			Currency:      cand.Currency,
+			Products:      []*model.ItineraryFareProduct{},
+			UnmatchedLegs: mapLegs(cand.UnmatchedLegs),
+		}
+		for _, use := range cand.Products {
+			fare.Products = append(fare.Products, &model.ItineraryFareProduct{
+				FareProductID:   use.FareProductID,
+				FareProductName: optString(use.FareProductName),
+				RiderCategoryID: optString(use.RiderCategoryID),
+				FareMediaID:     optString(use.FareMediaID),
+				Amount:          use.Amount,
+				Currency:        use.Currency,
+				Legs:            mapLegs(use.Legs),
+				Transfer:        use.Transfer,
+			})
+		}
+		ret = append(ret, fare)
+	}
+	return ret
+}
+
+// fareCalculatorForFeedVersion returns a cached fare calculator for the feed version,
+// loading fare rules from the database if necessary.
+func fareCalculatorForFeedVersion(ctx context.Context, fvsha1 string) (*fares.Calculator, error) {
+	cfg := model.ForContext(ctx)
+	fvs, err := cfg.Finder.FindFeedVersions(ctx, nil, nil, nil, &model.FeedVersionFilter{Sha1: &fvsha1})
+	if err != nil {
+		return nil, err
+	}
+	if len(fvs) == 0 {
+		return nil, nil
+	}
+	fvid := fvs[0].ID
+	if calc, ok := fareCalculators.Get(fvid); ok {
+		return calc.(*fares.Calculator), nil
+	}
+	// Load outside of any lock; concurrent requests for the same feed version share the result
+	calc, err, _ := fareCalculatorsLoading.Do(strconv.Itoa(fvid), func() (any, error) {
+		if calc, ok := fareCalculators.Get(fvid); ok {
+			return calc, nil
+		}
+		reader := &tldb.Reader{
+			Adapter:        fareAdapter(cfg.Finder.DBX()),
+			PageSize:       1_000,
+			FeedVersionIDs: []int{fvid},
+		}
+		calc, err := fares.NewCalculatorFromReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load fares for feed version %d: %w", fvid, err)
+		}
+		fareCalculators.Set(fvid, calc)
+		return calc, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return calc.(*fares.Calculator), nil
+}
+
+func optString(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,173 @@
+package directions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/interline-io/transitland-lib/dmfr"
+	"github.com/interline-io/transitland-lib/importer"
+	"github.com/interline-io/transitland-lib/internal/testdb"
+	"github.com/interline-io/transitland-lib/server/finders/dbfinder"
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/interline-io/transitland-lib/testdata"
+	"github.com/interline-io/transitland-lib/tldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFaresSha1 is the server test fares example feed, which includes Fares v2 files
+const testFaresSha1 = "376f03fe1e472dda6594fd1e6717faf9de4750f5"
+
+func testFareLeg(fvsha1 string, route string, from string, to string, dep time.Time) *model.Leg {
+	return &model.Leg{
+		StartTime: dep,
+		EndTime:   dep.Add(20 * time.Minute),
+		From:      &model.Waypoint{Stop: &model.WaypointStop{StopID: from}},
+		To:        &model.Waypoint{Stop: &model.WaypointStop{StopID: to}},
+		Trip: &model.LegTrip{
+			FeedVersionSha1: fvsha1,
+			Route:           &model.LegRoute{RouteID: route},
+		},
+	}
+}
+
+func testWalkLeg(dep time.Time) *model.Leg {
+	return &model.Leg{StartTime: dep, EndTime: dep.Add(5 * time.Minute)}
+}
+
+func TestItineraryFareRequest(t *testing.T) {
+	dep := time.Date(2008, 1, 5, 16, 0, 0, 0, time.UTC)
+	t.Run("transit legs", func(t *testing.T) {
+		itin := &model.Itinerary{Legs: []*model.Leg{
+			testWalkLeg(dep),
+			testFareLeg("a", "STBA", "STAGECOACH", "BEATTY_AIRPORT", dep.Add(5*time.Minute)),
+			testWalkLeg(dep.Add(25 * time.Minute)),
+			testFareLeg("a", "AB", "BEATTY_AIRPORT", "BULLFROG", dep.Add(30*time.Minute)),
+		}}
+		req, legIndex, fvsha1 := itineraryFareRequest(itin)
+		assert.Equal(t, "a", fvsha1)
+		assert.Equal(t, []int{1, 3}, legIndex)
+		if assert.Equal(t, 2, len(req.Legs)) {
+			assert.Equal(t, "STBA", req.Legs[0].RouteID)
+			assert.Equal(t, "STAGECOACH", req.Legs[0].FromStopID)
+			assert.Equal(t, "BEATTY_AIRPORT", req.Legs[0].ToStopID)
+			assert.Equal(t, dep.Add(5*time.Minute), req.Legs[0].Departure)
+			assert.Equal(t, dep.Add(25*time.Minute), req.Legs[0].Arrival)
+			assert.Equal(t, "AB", req.Legs[1].RouteID)
+		}
+	})
+	t.Run("walk only", func(t *testing.T) {
+		req, legIndex, fvsha1 := itineraryFareRequest(&model.Itinerary{Legs: []*model.Leg{testWalkLeg(dep)}})
+		assert.Equal(t, 0, len(req.Legs))
+		assert.Equal(t, 0, len(legIndex))
+		assert.Equal(t, "", fvsha1)
+	})
+	t.Run("multiple feed versions", func(t *testing.T) {
+		req, _, fvsha1 := itineraryFareRequest(&model.Itinerary{Legs: []*model.Leg{
+			testFareLeg("a", "STBA", "STAGECOACH", "BEATTY_AIRPORT", dep),
+			testFareLeg("b", "AB", "BEATTY_AIRPORT", "BULLFROG", dep.Add(30*time.Minute)),
+		}})
+		assert.Equal(t, 0, len(req.Legs))
+		assert.Equal(t, "", fvsha1)
+	})
+}
+
+func TestItineraryFares(t *testing.T) {
+	// Timeframes are evaluated in the feed's local time
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	dep := time.Date(2008, 1, 5, 8, 0, 0, 0, loc)
+	testFares(t, func(ctx context.Context) {
+		t.Run("transfer", func(t *testing.T) {
+			itin := &model.Itinerary{Legs: []*model.Leg{
+				testWalkLeg(dep),
+				testFareLeg(testFaresSha1, "STBA", "STAGECOACH", "BEATTY_AIRPORT", dep.Add(5*time.Minute)),
+				testFareLeg(testFaresSha1, "AB", "BEATTY_AIRPORT", "BULLFROG", dep.Add(30*time.Minute)),
+			}}
+			card := "card"
+			ret, err := ItineraryFares(ctx, itin, nil, &card)
+			require.NoError(t, err)
+			require.Equal(t, 2, len(ret))
+			// Local fare with an upgrade, or local and regional fares paid separately
+			assert.InDelta(t, 3.25, ret[0].Total, 0.001)
+			assert.InDelta(t, 6.75, ret[1].Total, 0.001)
+			assert.Equal(t, "USD", ret[0].Currency)
+			assert.Equal(t, []int{}, ret[0].UnmatchedLegs)
+			if assert.Equal(t, 2, len(ret[0].Products)) {
+				assert.Equal(t, "local_fare", ret[0].Products[0].FareProductID)
+				assert.Equal(t, []int{1}, ret[0].Products[0].Legs)
+				assert.False(t, ret[0].Products[0].Transfer)
+				if assert.NotNil(t, ret[0].Products[0].FareMediaID) {
+					assert.Equal(t, "card", *ret[0].Products[0].FareMediaID)
+				}
+				assert.Equal(t, "upgrade", ret[0].Products[1].FareProductID)
+				assert.Equal(t, []int{1, 2}, ret[0].Products[1].Legs)
+				assert.True(t, ret[0].Products[1].Transfer)
+			}
+		})
+		t.Run("rider category", func(t *testing.T) {
+			itin := &model.Itinerary{Legs: []*model.Leg{
+				testFareLeg(testFaresSha1, "CITY", "STAGECOACH", "NANAA", dep),
+			}}
+			senior := "senior"
+			ret, err := ItineraryFares(ctx, itin, &senior, nil)
+			require.NoError(t, err)
+			if assert.Equal(t, 1, len(ret)) {
+				assert.InDelta(t, 1.00, ret[0].Total, 0.001)
+			}
+		})
+		t.Run("unmatched leg", func(t *testing.T) {
+			itin := &model.Itinerary{Legs: []*model.Leg{
+				testFareLeg(testFaresSha1, "CITY", "STAGECOACH", "NANAA", dep),
+				testFareLeg(testFaresSha1, "AB", "BEATTY_AIRPORT", "BULLFROG", time.Date(2007, 6, 4, 8, 0, 0, 0, loc)),
+			}}
+			cash := "cash"
+			ret, err := ItineraryFares(ctx, itin, nil, &cash)
+			require.NoError(t, err)
+			if assert.Equal(t, 1, len(ret)) {
+				assert.InDelta(t, 2.00, ret[0].Total, 0.001)
+				assert.Equal(t, []int{1}, ret[0].UnmatchedLegs)
+			}
+		})
+		t.Run("unknown feed version", func(t *testing.T) {
+			itin := &model.Itinerary{Legs: []*model.Leg{
+				testFareLeg("unknown", "CITY", "STAGECOACH", "NANAA", dep),
+			}}
+			ret, err := ItineraryFares(ctx, itin, nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, 0, len(ret))
+		})
+		t.Run("cached", func(t *testing.T) {
+			a, err := fareCalculatorForFeedVersion(ctx, testFaresSha1)
+			require.NoError(t, err)
+			b, err := fareCalculatorForFeedVersion(ctx, testFaresSha1)
+			require.NoError(t, err)
+			assert.NotNil(t, a)
+			assert.Same(t, a, b)
+		})
+	})
+}
+
+// testFares imports the server test fares example feed into a temporary database.
+func testFares(t *testing.T, cb func(context.Context)) {
+	testdb.TempSqlite(func(atx tldb.Adapter) error {
+		ctx := context.Background()
+		feed := dmfr.Feed{FeedID: "EX-fares"}
+		feed.ID = testdb.ShouldInsert(t, atx, &feed)
+		fv := dmfr.FeedVersion{FeedID: feed.ID, File: testdata.Path("server/gtfs/example-fares.zip"), SHA1: testFaresSha1}
+		fvid := testdb.ShouldInsert(t, atx, &fv)
+		if _, err := importer.ImportFeedVersion(ctx, &testdb.AdapterIgnoreTx{Adapter: atx}, importer.Options{Activate: true, FeedVersionID: fvid, Storage: "/"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := atx.DBX().ExecContext(ctx, "UPDATE feed_states SET public = true"); err != nil {
+			t.Fatal(err)
+		}
+		// The default adapter reads from postgres
+		defaultAdapter := fareAdapter
+		fareAdapter = func(tldb.Ext) tldb.Adapter { return atx }
+		defer func() { fareAdapter = defaultAdapter }()
+		cb(model.WithConfig(ctx, model.Config{Finder: dbfinder.NewFinder(atx.DBX())}))
+		return nil
+	})
+}
@@ -0,0 +1,392 @@
+package raptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/internal/clock"
+	"github.com/interline-io/transitland-lib/server/directions"
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/interline-io/transitland-lib/tldb"
+	"github.com/interline-io/transitland-lib/tlxy"
+	"github.com/interline-io/transitland-lib/tt"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultRouter is shared between requests to reuse the timetable.
+var DefaultRouter = NewRouter()
+
+func init() {
+	if err := directions.RegisterRouter("raptor", func() directions.Handler {
+		return DefaultRouter
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// Options configures the router.
+type Options struct {
+	WalkSpeed           float64 // meters per second
+	MaxWalkDistance     float64 // meters, to or from the first or last stop
+	MaxTransferDistance float64 // meters, between stops
+	MaxTransfers        int
+	MaxDuration         int // seconds
+}
+
+// DefaultOptions returns the default router options.
+func DefaultOptions() Options {
+	return Options{
+		WalkSpeed:           1.2,
+		MaxWalkDistance:     1000,
+		MaxTransferDistance: 400,
+		MaxTransfers:        4,
+		MaxDuration:         6 * 3600,
+	}
+}
+
+// Router is an in-process transit router using the RAPTOR algorithm.
+// The timetable is built from the active feed versions in the database.
+// The active feed versions are checked every RefreshInterval, and the timetable is rebuilt
+// in the background when they change; requests continue to use the previous timetable until then.
+type Router struct {
+	Clock           clock.Clock
+	Options         Options
+	RefreshInterval time.Duration
+	lock            sync.RWMutex
+	key             string
+	checkedAt       time.Time
+	net             *network
+	loading         singleflight.Group
+}
+
+func NewRouter() *Router {
+	return &Router{Options: DefaultOptions(), RefreshInterval: time.Minute}
+}
+
+func (h *Router) Request(ctx context.Context, req model.DirectionRequest) (*model.Directions, error) {
+	// Prepare response
+	ret := model.Directions{
+		Origin:      wpiWaypoint(req.From),
+		Destination: wpiWaypoint(req.To),
+		Success:     true,
+		Exception:   nil,
+	}
+	if err := directions.ValidateDirectionRequest(req); err != nil {
+		ret.Success = false
+		ret.Exception = aws.String("invalid input")
+		return &ret, nil
+	}
+	if req.Mode != model.StepModeTransit {
+		ret.Success = false
+		ret.Exception = aws.String("unsupported travel mode")
+		return &ret, nil
+	}
+
+	departAt := time.Now().In(time.UTC)
+	if h.Clock != nil {
+		departAt = h.Clock.Now()
+	}
+	if req.DepartAt != nil {
+		departAt = *req.DepartAt
+	}
+	// Ensure we are in UTC
+	departAt = departAt.In(time.UTC)
+
+	// Get timetable
+	net, err := h.network(ctx)
+	if err == errNoDatabase {
+		ret.Success = false
+		ret.Exception = aws.String("no database available")
+		return &ret, nil
+	} else if err != nil {
+		log.For(ctx).Error().Err(err).Msg("raptor: failed to load timetable")
+		ret.Success = false
+		ret.Exception = aws.String("could not calculate route")
+		return &ret, nil
+	}
+
+	// Search
+	from := tlxy.Point{Lon: req.From.Lon, Lat: req.From.Lat}
+	to := tlxy.Point{Lon: req.To.Lon, Lat: req.To.Lat}
+	opts := h.Options
+	allowed := net.permitted(model.PermsForContext(ctx))
+	s := newSearch(net, opts, from, departAt.Unix(), allowed)
+	s.egress = net.nearbyStops(to, opts, allowed)
+	for _, j := range s.solve() {
+		ret.Itineraries = append(ret.Itineraries, makeItinerary(net, j, ret.Origin, ret.Destination))
+	}
+	// Walking may be faster than transit for short distances
+	if distance := tlxy.DistanceHaversine(from, to); distance <= opts.MaxWalkDistance {
+		duration := int64(walkSeconds(distance, opts.WalkSpeed))
+		leg := makeWalkLeg(ret.Origin, ret.Destination, departAt.Unix(), departAt.Unix()+duration)
+		ret.Itineraries = append(ret.Itineraries, makeItineraryFromLegs([]*model.Leg{leg}, ret.Origin, ret.Destination))
+	}
+	if len(ret.Itineraries) == 0 {
+		ret.Success = false
+		ret.Exception = aws.String("no route found")
+		return &ret, nil
+	}
+	sort.SliceStable(ret.Itineraries, func(i, j int) bool {
+		return ret.Itineraries[i].EndTime.Before(ret.Itineraries[j].EndTime)
+	})
+	r0 := ret.Itineraries[0]
+	ret.Duration = r0.Duration
+	ret.Distance = r0.Distance
+	ret.StartTime = &r0.StartTime
+	ret.EndTime = &r0.EndTime
+	ret.DataSource = aws.String("Transitland")
+	return &ret, nil
+}
+
+var errNoDatabase = errors.New("no database available")
+
+// network returns the timetable for the active feed versions.
+// The first request waits for the timetable to be built; afterwards, a stale timetable
+// is returned immediately while it is refreshed in the background.
+func (h *Router) network(ctx context.Context) (*network, error) {
+	finder := model.ForContext(ctx).Finder
+	if finder == nil {
+		return nil, errNoDatabase
+	}
+	db := finder.DBX()
+	h.lock.RLock()
+	net, checkedAt := h.net, h.checkedAt
+	h.lock.RUnlock()
+	// Loading is not tied to the lifetime of any single request
+	loadCtx := context.WithoutCancel(ctx)
+	if net == nil {
+		return h.refresh(loadCtx, db)
+	}
+	if time.Since(checkedAt) > h.RefreshInterval {
+		go func() {
+			if _, err := h.refresh(loadCtx, db); err != nil {
+				log.For(loadCtx).Error().Err(err).Msg("raptor: failed to refresh timetable")
+			}
+		}()
+	}
+	return net, nil
+}
+
+// refresh checks the active feed versions and rebuilds the timetable if they have changed.
+// Concurrent calls share a single load, and the lock is only held to swap in the result.
+func (h *Router) refresh(ctx context.Context, db tldb.Ext) (*network, error) {
+	ret, err, _ := h.loading.Do("network", func() (any, error) {
+		afvs, err := activeFeedVersions(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		key := fmt.Sprintf("%v", afvs)
+		h.lock.RLock()
+		net, current := h.net, h.key
+		h.lock.RUnlock()
+		if net == nil || current != key {
+			t := time.Now()
+			net, err = loadNetwork(ctx, db, afvs, h.Options)
+			if err != nil {
+				return nil, err
+			}
+			var fvids []int
+			for _, afv := range afvs {
+				fvids = append(fvids, afv.ID)
+			}
+			log.For(ctx).Info().
+				Ints("feed_version_ids", fvids).
+				Int("stops", len(net.stops)).
+				Int("patterns", len(net.patterns)).
+				Float64("time", time.Since(t).Seconds()).
+				Msg("raptor: loaded timetable")
+		}
+		h.lock.Lock()
+		h.key = key
+		h.net = net
+		h.checkedAt = time.Now()
+		h.lock.Unlock()
+		return net, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*network), nil
+}
+
+func makeItinerary(net *network, j journey, origin *model.Waypoint, destination *model.Waypoint) *model.Itinerary {
+	var legs []*model.Leg
+	for _, jl := range j.legs {
+		from := origin
+		if jl.from >= 0 {
+			from = stopWaypoint(net.stops[jl.from], jl.start)
+		}
+		to := destination
+		if jl.to >= 0 {
+			to = stopWaypoint(net.stops[jl.to], jl.end)
+		}
+		if jl.kind == labelTransit {
+			legs = append(legs, makeTransitLeg(net, jl, from, to))
+		} else {
+			legs = append(legs, makeWalkLeg(from, to, jl.start, jl.end))
+		}
+	}
+	return makeItineraryFromLegs(legs, origin, destination)
+}
+
+func makeItineraryFromLegs(legs []*model.Leg, origin *model.Waypoint, destination *model.Waypoint) *model.Itinerary {
+	itin := model.Itinerary{
+		From: origin,
+		To:   destination,
+		Legs: legs,
+	}
+	distance := 0.0
+	for _, leg := range legs {
+		distance += leg.Distance.Distance
+	}
+	itin.StartTime = legs[0].StartTime
+	itin.EndTime = legs[len(legs)-1].EndTime
+	itin.Duration = makeDuration(itin.EndTime.Sub(itin.StartTime).Seconds())
+	itin.Distance = makeDistance(distance)
+	return &itin
+}
+
+func makeWalkLeg(from *model.Waypoint, to *model.Waypoint, start int64, end int64) *model.Leg {
+	mode := model.StepModeWalk
+	distance := tlxy.DistanceHaversine(tlxy.Point{Lon: from.Lon, Lat: from.Lat}, tlxy.Point{Lon: to.Lon, Lat: to.Lat}) / 1000.0
+	leg := model.Leg{
+		Mode:      &mode,
+		From:      from,
+		To:        to,
+		StartTime: unixTime(start),
+		EndTime:   unixTime(end),
+		Duration:  makeDuration(float64(end - start)),
+		Distance:  makeDistance(distance),
+		Geometry: tt.NewLineStringFromFlatCoords([]float64{
+			from.Lon, from.Lat, 0.0,
+			to.Lon, to.Lat, 0.0,
+		}),
+	}
+	step := model.Step{
+		Mode:      mode,
+		To:        to,
+		StartTime: leg.StartTime,
+		EndTime:   leg.EndTime,
+		Duration:  leg.Duration,
+		Distance:  leg.Distance,
+	}
+	leg.Steps = append(leg.Steps, &step)
+	return &leg
+}
+
+func makeTransitLeg(net *network, jl journeyLeg, from *model.Waypoint, to *model.Waypoint) *model.Leg {
+	mode := model.StepModeTransit
+	pat := net.patterns[jl.pattern]
+	t := jl.run.trip
+	leg := model.Leg{
+		Mode:      &mode,
+		From:      from,
+		To:        to,
+		StartTime: unixTime(jl.start),
+		EndTime:   unixTime(jl.end),
+		Duration:  makeDuration(float64(jl.end - jl.start)),
+		Trip: &model.LegTrip{
+			TripID:          t.tripID,
+			TripShortName:   t.shortName,
+			Headsign:        t.headsign,
+			FeedID:          t.fv.feedID,
+			FeedVersionSha1: t.fv.sha1,
+			Route: &model.LegRoute{
+				RouteID:        t.route.routeID,
+				RouteShortName: t.route.shortName,
+				RouteLongName:  t.route.longName,
+				RouteType:      t.route.routeType,
+				RouteColor:     aws.String(t.route.color),
+				RouteTextColor: aws.String(t.route.textColor),
+				Agency:         &model.LegRouteAgency{},
+			},
+		},
+	}
+	if a := t.route.agency; a != nil {
+		leg.Trip.Route.Agency.AgencyID = a.agencyID
+		leg.Trip.Route.Agency.AgencyName = a.name
+	}
+	var coords []tlxy.Point
+	var flatCoords []float64
+	for pos := jl.boardPos; pos <= jl.alightPos; pos++ {
+		s := net.stops[pat.stops[pos]]
+		wp := model.WaypointDeparture{
+			Lon:          s.pt.Lon,
+			Lat:          s.pt.Lat,
+			Departure:    unixTime(jl.run.start + int64(pat.departures[pos])),
+			StopID:       s.stopID,
+			StopName:     s.name,
+			StopCode:     s.code,
+			StopIndex:    aws.Int(pos),
+			StopSequence: aws.Int(pat.stopSequences[pos]),
+		}
+		leg.Stops = append(leg.Stops, &wp)
+		coords = append(coords, s.pt)
+		flatCoords = append(flatCoords, s.pt.Lon, s.pt.Lat, 0.0)
+	}
+	leg.Distance = makeDistance(tlxy.LengthHaversine(coords) / 1000.0)
+	leg.Geometry = tt.NewLineStringFromFlatCoords(flatCoords)
+	step := model.Step{
+		Mode:        mode,
+		To:          to,
+		StartTime:   leg.StartTime,
+		EndTime:     leg.EndTime,
+		Duration:    leg.Duration,
+		Distance:    leg.Distance,
+		Instruction: fmt.Sprintf("Take %s to %s", routeName(t.route), to.Stop.StopName),
+	}
+	leg.Steps = append(leg.Steps, &step)
+	return &leg
+}
+
+func stopWaypoint(s *stop, departure int64) *model.Waypoint {
+	return &model.Waypoint{
+		Lon:  s.pt.Lon,
+		Lat:  s.pt.Lat,
+		Name: aws.String(s.name),
+		Stop: &model.WaypointStop{
+			Lon:       s.pt.Lon,
+			Lat:       s.pt.Lat,
+			Departure: unixTime(departure),
+			StopID:    s.stopID,
+			StopName:  s.name,
+			StopCode:  s.code,
+		},
+	}
+}
+
+func routeName(r *route) string {
+	if r.shortName != "" {
+		return r.shortName
+	}
+	return r.longName
+}
+
+func unixTime(t int64) time.Time {
+	return time.Unix(t, 0).In(time.UTC)
+}
+
+func wpiWaypoint(w *model.WaypointInput) *model.Waypoint {
+	if w == nil {
+		return nil
+	}
+	return &model.Waypoint{
+		Lon:  w.Lon,
+		Lat:  w.Lat,
+		Name: w.Name,
+	}
+}
+
+func makeDuration(t float64) *model.Duration {
+	return &model.Duration{Duration: float64(t), Units: model.DurationUnitSeconds}
+}
+
+func makeDistance(v float64) *model.Distance {
+	return &model.Distance{Distance: v, Units: model.DistanceUnitKilometers}
+}
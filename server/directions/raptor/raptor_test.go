@@ -0,0 +1,293 @@
+package raptor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/interline-io/transitland-lib/dmfr"
+	"github.com/interline-io/transitland-lib/importer"
+	"github.com/interline-io/transitland-lib/internal/testdb"
+	"github.com/interline-io/transitland-lib/internal/testutil"
+	"github.com/interline-io/transitland-lib/server/auth/authz"
+	"github.com/interline-io/transitland-lib/server/finders/dbfinder"
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/interline-io/transitland-lib/tldb"
+	"github.com/interline-io/transitland-lib/tlxy"
+	sf "github.com/peterstace/simplefeatures/geom"
+	"github.com/stretchr/testify/assert"
+	"github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/encoding/wkb"
+)
+
+func TestRouter(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stagecoach := model.WaypointInput{Lon: -116.751677, Lat: 36.916682}
+	beattyAirport := model.WaypointInput{Lon: -116.784582, Lat: 36.867446}
+	furnaceCreek := model.WaypointInput{Lon: -117.133162, Lat: 36.424288}
+	amargosaValley := model.WaypointInput{Lon: -116.40094, Lat: 36.640496}
+	weekday := time.Date(2008, 1, 2, 6, 45, 0, 0, loc)
+	weekend := time.Date(2008, 1, 5, 7, 45, 0, 0, loc)
+	tcs := []struct {
+		name      string
+		req       model.DirectionRequest
+		success   bool
+		routes    []string
+		startTime time.Time
+		endTime   time.Time
+	}{
+		{
+			name:      "transfers",
+			req:       model.DirectionRequest{Mode: model.StepModeTransit, From: &stagecoach, To: &furnaceCreek, DepartAt: &weekday},
+			success:   true,
+			routes:    []string{"", "STBA", "AB", "BFC", ""},
+			startTime: time.Date(2008, 1, 2, 6, 58, 27, 0, loc),
+			endTime:   time.Date(2008, 1, 2, 9, 21, 33, 0, loc),
+		},
+		{
+			name:      "weekend",
+			req:       model.DirectionRequest{Mode: model.StepModeTransit, From: &beattyAirport, To: &amargosaValley, DepartAt: &weekend},
+			success:   true,
+			routes:    []string{"", "AAMV", ""},
+			startTime: time.Date(2008, 1, 5, 7, 58, 27, 0, loc),
+			endTime:   time.Date(2008, 1, 5, 9, 1, 33, 0, loc),
+		},
+		{
+			name:    "no service",
+			req:     model.DirectionRequest{Mode: model.StepModeTransit, From: &beattyAirport, To: &amargosaValley, DepartAt: &weekday},
+			success: false,
+		},
+		{
+			name:      "walk",
+			req:       model.DirectionRequest{Mode: model.StepModeTransit, From: &stagecoach, To: &model.WaypointInput{Lon: -116.751677, Lat: 36.920682}, DepartAt: &weekday},
+			success:   true,
+			routes:    []string{""},
+			startTime: weekday,
+			endTime:   weekday.Add(371 * time.Second),
+		},
+		{
+			name:    "unsupported mode",
+			req:     model.DirectionRequest{Mode: model.StepModeWalk, From: &stagecoach, To: &furnaceCreek, DepartAt: &weekday},
+			success: false,
+		},
+		{
+			name:    "no dest",
+			req:     model.DirectionRequest{Mode: model.StepModeTransit, From: &stagecoach, DepartAt: &weekday},
+			success: false,
+		},
+	}
+	testRouter(t, func(ctx context.Context, atx tldb.Adapter, h *Router) {
+		for _, tc := range tcs {
+			t.Run(tc.name, func(t *testing.T) {
+				ret, err := h.Request(ctx, tc.req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !assert.Equal(t, tc.success, ret.Success) || !ret.Success {
+					return
+				}
+				itin := ret.Itineraries[0]
+				var routes []string
+				for _, leg := range itin.Legs {
+					routeId := ""
+					if leg.Trip != nil {
+						routeId = leg.Trip.Route.RouteID
+						assert.Equal(t, testutil.ExampleZip.SHA1, leg.Trip.FeedVersionSha1)
+					}
+					routes = append(routes, routeId)
+				}
+				assert.Equal(t, tc.routes, routes)
+				assert.Equal(t, tc.startTime.UTC(), itin.StartTime)
+				assert.Equal(t, tc.endTime.UTC(), itin.EndTime)
+				assert.Equal(t, itin.EndTime.Sub(itin.StartTime).Seconds(), ret.Duration.Duration)
+				// Legs are continuous
+				for i := 1; i < len(itin.Legs); i++ {
+					assert.False(t, itin.Legs[i].StartTime.Before(itin.Legs[i-1].EndTime), "leg starts before previous leg ends")
+				}
+			})
+		}
+	})
+}
+
+func TestRouter_Reachable(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := tlxy.Point{Lon: -116.751677, Lat: 36.916682}
+	departAt := time.Date(2008, 1, 2, 6, 45, 0, 0, loc)
+	testRouter(t, func(ctx context.Context, atx tldb.Adapter, h *Router) {
+		stops, err := h.Reachable(ctx, from, departAt, 3600, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		type reached struct {
+			arrival   time.Time
+			transfers int
+		}
+		got := map[string]reached{}
+		for _, s := range stops {
+			stopId := ""
+			testdb.ShouldGet(t, atx, &stopId, "SELECT stop_id FROM gtfs_stops WHERE id = ?", s.StopID)
+			got[stopId] = reached{arrival: s.Arrival, transfers: s.Transfers}
+			assert.Equal(t, int(s.Arrival.Sub(departAt).Seconds()), s.Duration)
+		}
+		expect := map[string]reached{
+			"STAGECOACH":     {arrival: time.Date(2008, 1, 2, 6, 46, 33, 0, loc).UTC()},
+			"NANAA":          {arrival: time.Date(2008, 1, 2, 6, 57, 24, 0, loc).UTC()},
+			"NADAV":          {arrival: time.Date(2008, 1, 2, 7, 12, 0, 0, loc).UTC()},
+			"DADAN":          {arrival: time.Date(2008, 1, 2, 7, 19, 0, 0, loc).UTC()},
+			"EMSI":           {arrival: time.Date(2008, 1, 2, 7, 26, 0, 0, loc).UTC()},
+			"BEATTY_AIRPORT": {arrival: time.Date(2008, 1, 2, 7, 20, 0, 0, loc).UTC()},
+		}
+		assert.Equal(t, expect, got)
+
+		// Isochrone buffers are merged into non-overlapping polygons
+		isochrone, err := h.Isochrone(from, stops, 3600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Greater(t, isochrone.NumPolygons(), 0)
+		assert.LessOrEqual(t, isochrone.NumPolygons(), len(stops)+1)
+
+		// Transfers are required to reach Bullfrog
+		stops, err = h.Reachable(ctx, from, departAt, 4*3600, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range stops {
+			assert.Equal(t, 0, s.Transfers)
+		}
+		transferStops, err := h.Reachable(ctx, from, departAt, 4*3600, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Greater(t, len(transferStops), len(stops))
+	})
+}
+
+func TestRouter_Isochrone(t *testing.T) {
+	h := &Router{Options: DefaultOptions()}
+	from := tlxy.Point{Lon: -122.271604, Lat: 37.803664}
+	near := tlxy.Point{Lon: -122.268, Lat: 37.803664}
+	far := tlxy.Point{Lon: -122.0, Lat: 37.5}
+	tcs := []struct {
+		name  string
+		stops []ReachableStop
+		count int
+	}{
+		{"origin only", nil, 1},
+		{"overlapping buffers are merged", []ReachableStop{{StopID: 1, Point: near, Duration: 60}}, 1},
+		{"separate buffers", []ReachableStop{{StopID: 1, Point: near, Duration: 60}, {StopID: 2, Point: far, Duration: 600}}, 2},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			isochrone, err := h.Isochrone(from, tc.stops, 900)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.count, isochrone.NumPolygons())
+			// Members must not overlap
+			g, err := sf.UnmarshalWKB(mustMarshalWKB(t, isochrone))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.NoError(t, g.Validate())
+		})
+	}
+}
+
+func mustMarshalWKB(t *testing.T, g geom.T) []byte {
+	t.Helper()
+	b, err := wkb.Marshal(g, wkb.NDR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestRouter_Permissions(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	departAt := time.Date(2008, 1, 2, 6, 45, 0, 0, loc)
+	req := model.DirectionRequest{
+		Mode:     model.StepModeTransit,
+		From:     &model.WaypointInput{Lon: -116.751677, Lat: 36.916682},
+		To:       &model.WaypointInput{Lon: -117.133162, Lat: 36.424288},
+		DepartAt: &departAt,
+	}
+	testRouter(t, func(ctx context.Context, atx tldb.Adapter, h *Router) {
+		fvid := 0
+		testdb.ShouldGet(t, atx, &fvid, "SELECT id FROM feed_versions")
+		if _, err := atx.DBX().ExecContext(ctx, "UPDATE feed_states SET public = false"); err != nil {
+			t.Fatal(err)
+		}
+		t.Run("restricted", func(t *testing.T) {
+			ret, err := h.Request(ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.False(t, ret.Success)
+			stops, err := h.Reachable(ctx, tlxy.Point{Lon: req.From.Lon, Lat: req.From.Lat}, departAt, 3600, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Empty(t, stops)
+		})
+		t.Run("allowed feed version", func(t *testing.T) {
+			fvCtx := model.WithPerms(ctx, &testChecker{fvids: []int{fvid}})
+			ret, err := h.Request(fvCtx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.True(t, ret.Success)
+			stops, err := h.Reachable(fvCtx, tlxy.Point{Lon: req.From.Lon, Lat: req.From.Lat}, departAt, 3600, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.NotEmpty(t, stops)
+		})
+	})
+}
+
+// testChecker allows access to a fixed set of feed versions.
+type testChecker struct {
+	authz.UnimplementedCheckerServer
+	fvids []int
+}
+
+func (c *testChecker) FeedList(context.Context, *authz.FeedListRequest) (*authz.FeedListResponse, error) {
+	return &authz.FeedListResponse{}, nil
+}
+
+func (c *testChecker) FeedVersionList(context.Context, *authz.FeedVersionListRequest) (*authz.FeedVersionListResponse, error) {
+	ret := &authz.FeedVersionListResponse{}
+	for _, id := range c.fvids {
+		ret.FeedVersions = append(ret.FeedVersions, &authz.FeedVersion{Id: int64(id)})
+	}
+	return ret, nil
+}
+
+// testRouter imports the example feed into a temporary database.
+// The feed is public unless changed by the callback.
+func testRouter(t *testing.T, cb func(context.Context, tldb.Adapter, *Router)) {
+	testdb.TempSqlite(func(atx tldb.Adapter) error {
+		ctx := context.Background()
+		fv := dmfr.FeedVersion{File: testutil.ExampleZip.URL, SHA1: testutil.ExampleZip.SHA1}
+		fvid := testdb.ShouldInsert(t, atx, &fv)
+		if _, err := importer.ImportFeedVersion(ctx, &testdb.AdapterIgnoreTx{Adapter: atx}, importer.Options{Activate: true, FeedVersionID: fvid, Storage: "/"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := atx.DBX().ExecContext(ctx, "UPDATE feed_states SET public = true"); err != nil {
+			t.Fatal(err)
+		}
+		ctx = model.WithConfig(ctx, model.Config{Finder: dbfinder.NewFinder(atx.DBX())})
+		cb(ctx, atx, NewRouter())
+		return nil
+	})
+}
@@ -0,0 +1,115 @@
+package raptor
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/interline-io/transitland-lib/tlxy"
+	sf "github.com/peterstace/simplefeatures/geom"
+	"github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/encoding/wkb"
+)
+
+// ReachableStop is a stop that can be reached from an origin by transit and walking.
+type ReachableStop struct {
+	StopID    int // database ID
+	Point     tlxy.Point
+	Arrival   time.Time
+	Duration  int // seconds after departure
+	Transfers int
+}
+
+// Reachable returns the stops that can be reached from a point within the maximum duration, ordered by arrival time.
+func (h *Router) Reachable(ctx context.Context, from tlxy.Point, departAt time.Time, maxDuration int, maxTransfers int) ([]ReachableStop, error) {
+	net, err := h.network(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opts := h.Options
+	opts.MaxDuration = maxDuration
+	opts.MaxTransfers = maxTransfers
+	s := newSearch(net, opts, from, departAt.Unix(), net.permitted(model.PermsForContext(ctx)))
+	s.solve()
+	var ret []ReachableStop
+	for idx, t := range s.reached {
+		if t == infinity || t > s.endAt {
+			continue
+		}
+		transfers := 0
+		if k := s.reachRound[idx]; k > 1 {
+			transfers = k - 1
+		}
+		ret = append(ret, ReachableStop{
+			StopID:    net.stops[idx].id,
+			Point:     net.stops[idx].pt,
+			Arrival:   unixTime(t),
+			Duration:  int(t - s.departAt),
+			Transfers: transfers,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Duration == ret[j].Duration {
+			return ret[i].StopID < ret[j].StopID
+		}
+		return ret[i].Duration < ret[j].Duration
+	})
+	return ret, nil
+}
+
+// Number of sides used to approximate walking buffers
+const bufferSides = 24
+
+// Isochrone returns the area that can be reached within the maximum duration,
+// as the union of walking buffers around the origin and each reachable stop.
+// Each buffer extends as far as the remaining time allows, up to the maximum walking distance.
+func (h *Router) Isochrone(from tlxy.Point, stops []ReachableStop, maxDuration int) (*geom.MultiPolygon, error) {
+	opts := h.Options
+	var buffers []sf.Geometry
+	addBuffer := func(pt tlxy.Point, remaining int) {
+		radius := math.Min(float64(remaining)*opts.WalkSpeed, opts.MaxWalkDistance)
+		if radius <= 0 {
+			return
+		}
+		approx := tlxy.NewApprox(pt)
+		var coords []float64
+		for i := 0; i <= bufferSides; i++ {
+			// Counter-clockwise, closed ring
+			a := 2 * math.Pi * float64(i%bufferSides) / bufferSides
+			coords = append(coords,
+				pt.Lon+radius*math.Cos(a)/approx.LonMeters(),
+				pt.Lat+radius*math.Sin(a)/approx.LatMeters(),
+			)
+		}
+		ring := sf.NewLineString(sf.NewSequence(coords, sf.DimXY))
+		buffers = append(buffers, sf.NewPolygon([]sf.LineString{ring}).AsGeometry())
+	}
+	addBuffer(from, maxDuration)
+	for _, s := range stops {
+		addBuffer(s.Point, maxDuration-s.Duration)
+	}
+	ret := geom.NewMultiPolygon(geom.XY)
+	if len(buffers) == 0 {
+		return ret, nil
+	}
+	// Overlapping members are not valid in a MultiPolygon, so merge the buffers
+	union, err := sf.UnionMany(buffers)
+	if err != nil {
+		return nil, err
+	}
+	g, err := wkb.Unmarshal(union.ForceCCW().AsBinary())
+	if err != nil {
+		return nil, err
+	}
+	switch v := g.(type) {
+	case *geom.Polygon:
+		if err := ret.Push(v); err != nil {
+			return nil, err
+		}
+	case *geom.MultiPolygon:
+		ret = v
+	}
+	return ret, nil
+}
@@ -0,0 +1,323 @@
+package raptor
+
+import (
+	"math"
+	"sort"
+
+	"github.com/interline-io/transitland-lib/tlxy"
+)
+
+const infinity = math.MaxInt64
+
+type labelKind int
+
+const (
+	labelNone labelKind = iota
+	labelAccess
+	labelTransit
+	labelTransfer
+	labelFootpath
+)
+
+// label records how a stop was reached in a round.
+type label struct {
+	kind      labelKind
+	from      int // previous stop, for transit and footpath labels
+	fromRound int // round of the boarding stop, for transit labels
+	pattern   int
+	run       *run
+	boardPos  int
+	alightPos int
+}
+
+// journey is a reconstructed path from origin to destination.
+type journey struct {
+	arrival int64
+	legs    []journeyLeg
+}
+
+type journeyLeg struct {
+	kind      labelKind // labelAccess, labelTransit or labelFootpath; the egress leg is labelAccess with to = -1
+	from      int       // stop index, or -1 for the origin
+	to        int       // stop index, or -1 for the destination
+	start     int64
+	end       int64
+	pattern   int
+	run       *run
+	boardPos  int
+	alightPos int
+}
+
+// search is the state for a single RAPTOR query.
+// For each round k, arrive[k] holds arrival times by transit and ready[k] holds the earliest time a vehicle
+// can be boarded at each stop, after any transfer.
+type search struct {
+	net        *network
+	opts       Options
+	departAt   int64
+	endAt      int64
+	access     map[int]int // stop index to walking time from the origin
+	egress     map[int]int // stop index to walking time to the destination, if any
+	arrive     [][]int64
+	arriveLbl  [][]label
+	ready      [][]int64
+	readyLbl   [][]label
+	bestArrive []int64
+	bestReady  []int64
+	readyRound []int
+	reached    []int64 // earliest arrival at each stop, by transit or walking
+	reachRound []int
+	runs       map[int][]run
+	allowed    map[*feedVersion]bool // feed versions visible to the caller
+}
+
+func newSearch(net *network, opts Options, from tlxy.Point, departAt int64, allowed map[*feedVersion]bool) *search {
+	s := &search{
+		net:        net,
+		opts:       opts,
+		departAt:   departAt,
+		endAt:      departAt + int64(opts.MaxDuration),
+		access:     net.nearbyStops(from, opts, allowed),
+		bestArrive: make([]int64, len(net.stops)),
+		bestReady:  make([]int64, len(net.stops)),
+		readyRound: make([]int, len(net.stops)),
+		reached:    make([]int64, len(net.stops)),
+		reachRound: make([]int, len(net.stops)),
+		runs:       map[int][]run{},
+		allowed:    allowed,
+	}
+	for i := range net.stops {
+		s.bestArrive[i] = infinity
+		s.bestReady[i] = infinity
+		s.reached[i] = infinity
+	}
+	return s
+}
+
+// nearbyStops returns the allowed stops within walking distance of a point, with walking times.
+func (net *network) nearbyStops(pt tlxy.Point, opts Options, allowed map[*feedVersion]bool) map[int]int {
+	ret := map[int]int{}
+	for _, idx := range net.grid.within(pt, opts.MaxWalkDistance) {
+		if !allowed[net.stops[idx].fv] {
+			continue
+		}
+		d := tlxy.DistanceHaversine(pt, net.stops[idx].pt)
+		if d <= opts.MaxWalkDistance {
+			ret[idx] = walkSeconds(d, opts.WalkSpeed)
+		}
+	}
+	return ret
+}
+
+func (s *search) addRound() {
+	n := len(s.net.stops)
+	arrive := make([]int64, n)
+	ready := make([]int64, n)
+	for i := 0; i < n; i++ {
+		arrive[i] = infinity
+		ready[i] = infinity
+	}
+	s.arrive = append(s.arrive, arrive)
+	s.ready = append(s.ready, ready)
+	s.arriveLbl = append(s.arriveLbl, make([]label, n))
+	s.readyLbl = append(s.readyLbl, make([]label, n))
+}
+
+// solve performs the search and returns the fastest journey for each number of transfers,
+// where each journey arrives earlier than journeys with fewer transfers.
+func (s *search) solve() []journey {
+	// Round 0: walk to nearby stops
+	s.addRound()
+	var marked []int
+	for idx, dur := range s.access {
+		t := s.departAt + int64(dur)
+		s.ready[0][idx] = t
+		s.readyLbl[0][idx] = label{kind: labelAccess}
+		s.bestReady[idx] = t
+		s.readyRound[idx] = 0
+		s.reach(idx, t, 0)
+		marked = append(marked, idx)
+	}
+	sort.Ints(marked)
+
+	var ret []journey
+	bestTarget := int64(infinity)
+	for k := 1; k <= s.opts.MaxTransfers+1 && len(marked) > 0; k++ {
+		s.addRound()
+
+		// Collect patterns serving marked stops, from the earliest marked position
+		queue := map[int]int{}
+		for _, idx := range marked {
+			for _, sp := range s.net.stopPatterns[idx] {
+				if !s.allowed[s.net.patterns[sp.pattern].fv] {
+					continue
+				}
+				if pos, ok := queue[sp.pattern]; !ok || sp.pos < pos {
+					queue[sp.pattern] = sp.pos
+				}
+			}
+		}
+		patIdxs := make([]int, 0, len(queue))
+		for p := range queue {
+			patIdxs = append(patIdxs, p)
+		}
+		sort.Ints(patIdxs)
+
+		// Scan patterns
+		var improved []int
+		for _, patIdx := range patIdxs {
+			improved = s.scanPattern(k, patIdx, queue[patIdx], bestTarget, improved)
+		}
+
+		// Transfers
+		marked = marked[:0]
+		markedSet := map[int]bool{}
+		mark := func(idx int, t int64, lbl label) {
+			if !s.allowed[s.net.stops[idx].fv] {
+				return
+			}
+			if t >= s.bestReady[idx] || t >= bestTarget || t > s.endAt {
+				return
+			}
+			s.ready[k][idx] = t
+			s.readyLbl[k][idx] = lbl
+			s.bestReady[idx] = t
+			s.readyRound[idx] = k
+			if lbl.kind == labelFootpath {
+				s.reach(idx, t, k)
+			}
+			if !markedSet[idx] {
+				markedSet[idx] = true
+				marked = append(marked, idx)
+			}
+		}
+		for _, idx := range improved {
+			arr := s.arrive[k][idx]
+			mark(idx, arr+int64(s.net.minTransfer[idx]), label{kind: labelTransfer, from: idx})
+			for _, fp := range s.net.footpaths[idx] {
+				mark(fp.to, arr+int64(fp.duration), label{kind: labelFootpath, from: idx})
+			}
+		}
+		sort.Ints(marked)
+
+		// Check destination
+		bestStop := -1
+		for idx, dur := range s.egress {
+			if arr := s.arrive[k][idx]; arr < infinity && arr+int64(dur) < bestTarget {
+				bestTarget = arr + int64(dur)
+				bestStop = idx
+			}
+		}
+		if bestStop >= 0 {
+			ret = append(ret, s.journey(k, bestStop))
+		}
+	}
+	return ret
+}
+
+func (s *search) reach(idx int, t int64, k int) {
+	if t < s.reached[idx] {
+		s.reached[idx] = t
+		s.reachRound[idx] = k
+	}
+}
+
+// scanPattern traverses a pattern in round k, boarding the earliest possible run at each stop.
+func (s *search) scanPattern(k int, patIdx int, startPos int, bestTarget int64, improved []int) []int {
+	pat := s.net.patterns[patIdx]
+	var cur *run
+	boardPos := -1
+	for pos := startPos; pos < len(pat.stops); pos++ {
+		idx := pat.stops[pos]
+		// Alight
+		if cur != nil && pat.dropOff[pos] {
+			arr := cur.start + int64(pat.arrivals[pos])
+			if arr < s.bestArrive[idx] && arr < bestTarget && arr <= s.endAt {
+				s.reach(idx, arr, k)
+				if s.arrive[k][idx] == infinity {
+					improved = append(improved, idx)
+				}
+				s.arrive[k][idx] = arr
+				s.bestArrive[idx] = arr
+				s.arriveLbl[k][idx] = label{
+					kind:      labelTransit,
+					from:      pat.stops[boardPos],
+					fromRound: s.readyRound[pat.stops[boardPos]],
+					pattern:   patIdx,
+					run:       cur,
+					boardPos:  boardPos,
+					alightPos: pos,
+				}
+			}
+		}
+		// Board, using arrivals from previous rounds
+		if !pat.pickup[pos] {
+			continue
+		}
+		ready := s.bestReady[idx]
+		if ready == infinity {
+			continue
+		}
+		if cur != nil && ready > cur.start+int64(pat.departures[pos]) {
+			continue
+		}
+		if r := s.earliestRun(patIdx, pos, ready); r != nil && r != cur {
+			cur = r
+			boardPos = pos
+		}
+	}
+	return improved
+}
+
+// earliestRun returns the earliest run departing a pattern position at or after the given time.
+func (s *search) earliestRun(patIdx int, pos int, t int64) *run {
+	runs, ok := s.runs[patIdx]
+	if !ok {
+		runs = s.net.patterns[patIdx].runs(s.departAt, s.endAt)
+		s.runs[patIdx] = runs
+	}
+	dep := int64(s.net.patterns[patIdx].departures[pos])
+	i := sort.Search(len(runs), func(i int) bool { return runs[i].start+dep >= t })
+	if i < len(runs) {
+		return &runs[i]
+	}
+	return nil
+}
+
+// journey reconstructs the journey arriving at a stop in round k.
+func (s *search) journey(k int, idx int) journey {
+	arr := s.arrive[k][idx]
+	j := journey{arrival: arr + int64(s.egress[idx])}
+	legs := []journeyLeg{{kind: labelAccess, from: idx, to: -1, start: arr, end: j.arrival}}
+	for k > 0 {
+		lbl := s.arriveLbl[k][idx]
+		pat := s.net.patterns[lbl.pattern]
+		legs = append(legs, journeyLeg{
+			kind:      labelTransit,
+			from:      lbl.from,
+			to:        idx,
+			start:     lbl.run.start + int64(pat.departures[lbl.boardPos]),
+			end:       lbl.run.start + int64(pat.arrivals[lbl.alightPos]),
+			pattern:   lbl.pattern,
+			run:       lbl.run,
+			boardPos:  lbl.boardPos,
+			alightPos: lbl.alightPos,
+		})
+		board, round := lbl.from, lbl.fromRound
+		rl := s.readyLbl[round][board]
+		switch rl.kind {
+		case labelAccess:
+			// Leave the origin in time to board, instead of waiting at the stop
+			boardAt := lbl.run.start + int64(pat.departures[lbl.boardPos])
+			legs = append(legs, journeyLeg{kind: labelAccess, from: -1, to: board, start: boardAt - int64(s.access[board]), end: boardAt})
+		case labelFootpath:
+			legs = append(legs, journeyLeg{kind: labelFootpath, from: rl.from, to: board, start: s.arrive[round][rl.from], end: s.ready[round][board]})
+		}
+		idx, k = rl.from, round
+	}
+	for i, n := 0, len(legs); i < n/2; i++ {
+		legs[i], legs[n-1-i] = legs[n-1-i], legs[i]
+	}
+	j.legs = legs
+	return j
+}
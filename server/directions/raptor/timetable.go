@@ -0,0 +1,612 @@
+package raptor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	sq "github.com/irees/squirrel"
+
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/server/dbutil"
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/interline-io/transitland-lib/service"
+	"github.com/interline-io/transitland-lib/tldb"
+	"github.com/interline-io/transitland-lib/tlxy"
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+// network is the static transit network for a set of feed versions.
+// Trips are expanded into timed runs for each search, since the active services depend on the date.
+type network struct {
+	fvs          []*feedVersion
+	stops        []*stop
+	patterns     []*pattern
+	stopPatterns [][]stopPattern // patterns serving each stop
+	footpaths    [][]footpath    // walking transfers from each stop
+	minTransfer  []int           // minimum transfer time at each stop, in seconds
+	grid         *grid
+}
+
+type feedVersion struct {
+	id       int
+	sha1     string
+	feedID   string
+	feedDBID int  // current_feeds.id, for permission checks
+	public   bool // feed_states.public, for permission checks
+	loc      *time.Location
+	services map[int]*service.Service
+}
+
+type stop struct {
+	id     int
+	stopID string
+	name   string
+	code   string
+	pt     tlxy.Point
+	fv     *feedVersion
+}
+
+type agency struct {
+	agencyID string
+	name     string
+}
+
+type route struct {
+	routeID   string
+	shortName string
+	longName  string
+	routeType int
+	color     string
+	textColor string
+	agency    *agency
+}
+
+type trip struct {
+	tripID    string
+	headsign  string
+	shortName string
+	serviceID int
+	starts    []int // first departure of each run, in seconds after the start of the service day
+	route     *route
+	fv        *feedVersion
+}
+
+// pattern is a sequence of stops shared by trips with the same relative stop times.
+// Trips in a pattern never overtake one another.
+type pattern struct {
+	stops         []int
+	stopSequences []int
+	arrivals      []int // relative to the first departure
+	departures    []int // relative to the first departure
+	pickup        []bool
+	dropOff       []bool
+	trips         []*trip
+	fv            *feedVersion
+}
+
+type stopPattern struct {
+	pattern int
+	pos     int
+}
+
+type footpath struct {
+	to       int
+	duration int
+}
+
+// permitted returns the feed versions in the network that are visible with the permission filter.
+// Feed versions are visible if the feed is public, or if the feed or feed version is explicitly allowed.
+func (net *network) permitted(pf *model.PermFilter) map[*feedVersion]bool {
+	allowedFeeds := map[int]bool{}
+	for _, id := range pf.GetAllowedFeeds() {
+		allowedFeeds[id] = true
+	}
+	allowedFvs := map[int]bool{}
+	for _, id := range pf.GetAllowedFeedVersions() {
+		allowedFvs[id] = true
+	}
+	ret := map[*feedVersion]bool{}
+	for _, fv := range net.fvs {
+		if fv.public || allowedFeeds[fv.feedDBID] || allowedFvs[fv.id] {
+			ret[fv] = true
+		}
+	}
+	return ret
+}
+
+// Database rows
+
+type stopRow struct {
+	ID       int       `db:"id"`
+	StopID   string    `db:"stop_id"`
+	StopName tt.String `db:"stop_name"`
+	StopCode tt.String `db:"stop_code"`
+	Geometry tt.Point  `db:"geometry"`
+}
+
+type routeRow struct {
+	ID             int       `db:"id"`
+	RouteID        string    `db:"route_id"`
+	RouteShortName tt.String `db:"route_short_name"`
+	RouteLongName  tt.String `db:"route_long_name"`
+	RouteType      int       `db:"route_type"`
+	RouteColor     tt.String `db:"route_color"`
+	RouteTextColor tt.String `db:"route_text_color"`
+	AgencyID       int       `db:"agency_id"`
+}
+
+type agencyRow struct {
+	ID             int    `db:"id"`
+	AgencyID       string `db:"agency_id"`
+	AgencyName     string `db:"agency_name"`
+	AgencyTimezone string `db:"agency_timezone"`
+}
+
+type tripRow struct {
+	ID                   int       `db:"id"`
+	TripID               string    `db:"trip_id"`
+	RouteID              int       `db:"route_id"`
+	ServiceID            int       `db:"service_id"`
+	TripHeadsign         tt.String `db:"trip_headsign"`
+	TripShortName        tt.String `db:"trip_short_name"`
+	JourneyPatternID     tt.String `db:"journey_pattern_id"`
+	JourneyPatternOffset tt.Int    `db:"journey_pattern_offset"`
+}
+
+type stopTimeRow struct {
+	TripID        int        `db:"trip_id"`
+	StopID        int        `db:"stop_id"`
+	StopSequence  int        `db:"stop_sequence"`
+	ArrivalTime   tt.Seconds `db:"arrival_time"`
+	DepartureTime tt.Seconds `db:"departure_time"`
+	PickupType    tt.Int     `db:"pickup_type"`
+	DropOffType   tt.Int     `db:"drop_off_type"`
+}
+
+type frequencyRow struct {
+	TripID      int        `db:"trip_id"`
+	StartTime   tt.Seconds `db:"start_time"`
+	EndTime     tt.Seconds `db:"end_time"`
+	HeadwaySecs int        `db:"headway_secs"`
+}
+
+type transferRow struct {
+	FromStopID      tt.Int `db:"from_stop_id"`
+	ToStopID        tt.Int `db:"to_stop_id"`
+	FromRouteID     tt.Int `db:"from_route_id"`
+	ToRouteID       tt.Int `db:"to_route_id"`
+	FromTripID      tt.Int `db:"from_trip_id"`
+	ToTripID        tt.Int `db:"to_trip_id"`
+	TransferType    int    `db:"transfer_type"`
+	MinTransferTime tt.Int `db:"min_transfer_time"`
+}
+
+type activeFeedVersion struct {
+	ID     int  `db:"feed_version_id"`
+	FeedID int  `db:"feed_id"`
+	Public bool `db:"public"`
+}
+
+// activeFeedVersions returns the active feed versions, with the feed state used for permission checks.
+func activeFeedVersions(ctx context.Context, db tldb.Ext) ([]activeFeedVersion, error) {
+	var ret []activeFeedVersion
+	q := sq.StatementBuilder.
+		Select("feed_states.feed_version_id", "feed_states.feed_id", "feed_states.public").
+		From("feed_states").
+		LeftJoin("current_feeds ON current_feeds.id = feed_states.feed_id").
+		Where(sq.NotEq{"feed_states.feed_version_id": nil}).
+		Where(sq.Eq{"current_feeds.deleted_at": nil}).
+		OrderBy("feed_states.feed_version_id")
+	if err := dbutil.Select(ctx, db, q, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// loadNetwork builds the network for the specified feed versions.
+func loadNetwork(ctx context.Context, db tldb.Ext, afvs []activeFeedVersion, opts Options) (*network, error) {
+	b := &networkBuilder{
+		net:       &network{grid: newGrid()},
+		stopIndex: map[int]int{},
+	}
+	for _, afv := range afvs {
+		if err := b.loadFeedVersion(ctx, db, afv); err != nil {
+			return nil, fmt.Errorf("failed to load feed version %d: %w", afv.ID, err)
+		}
+	}
+	b.buildFootpaths(opts)
+	return b.net, nil
+}
+
+type networkBuilder struct {
+	net       *network
+	stopIndex map[int]int // stop database ID to stop index
+	transfers []transferRow
+}
+
+func (b *networkBuilder) loadFeedVersion(ctx context.Context, db tldb.Ext, afv activeFeedVersion) error {
+	fvid := afv.ID
+	fv := &feedVersion{id: fvid, feedDBID: afv.FeedID, public: afv.Public, loc: time.UTC, services: map[int]*service.Service{}}
+	b.net.fvs = append(b.net.fvs, fv)
+	fvq := sq.StatementBuilder.
+		Select("feed_versions.sha1", "coalesce(current_feeds.onestop_id, '') AS onestop_id").
+		From("feed_versions").
+		LeftJoin("current_feeds ON current_feeds.id = feed_versions.feed_id").
+		Where(sq.Eq{"feed_versions.id": fvid})
+	var fvRow struct {
+		SHA1      string `db:"sha1"`
+		OnestopID string `db:"onestop_id"`
+	}
+	if err := dbutil.Get(ctx, db, fvq, &fvRow); err != nil {
+		return err
+	}
+	fv.sha1 = fvRow.SHA1
+	fv.feedID = fvRow.OnestopID
+
+	// Agencies and routes
+	var agencyRows []agencyRow
+	if err := dbutil.Select(ctx, db, fvSelect("gtfs_agencies", fvid, "id", "agency_id", "agency_name", "agency_timezone"), &agencyRows); err != nil {
+		return err
+	}
+	agencies := map[int]*agency{}
+	for _, ent := range agencyRows {
+		agencies[ent.ID] = &agency{agencyID: ent.AgencyID, name: ent.AgencyName}
+		if loc, err := time.LoadLocation(ent.AgencyTimezone); err == nil {
+			// All agencies in a feed must have the same timezone
+			fv.loc = loc
+		}
+	}
+	var routeRows []routeRow
+	if err := dbutil.Select(ctx, db, fvSelect("gtfs_routes", fvid, "id", "route_id", "route_short_name", "route_long_name", "route_type", "route_color", "route_text_color", "agency_id"), &routeRows); err != nil {
+		return err
+	}
+	routes := map[int]*route{}
+	for _, ent := range routeRows {
+		routes[ent.ID] = &route{
+			routeID:   ent.RouteID,
+			shortName: ent.RouteShortName.Val,
+			longName:  ent.RouteLongName.Val,
+			routeType: ent.RouteType,
+			color:     ent.RouteColor.Val,
+			textColor: ent.RouteTextColor.Val,
+			agency:    agencies[ent.AgencyID],
+		}
+	}
+
+	// Services
+	var calendars []gtfs.Calendar
+	if err := dbutil.Select(ctx, db, fvSelect("gtfs_calendars", fvid, "id", "service_id", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday", "start_date", "end_date"), &calendars); err != nil {
+		return err
+	}
+	var calendarDates []gtfs.CalendarDate
+	if err := dbutil.Select(ctx, db, fvSelect("gtfs_calendar_dates", fvid, "service_id", "date", "exception_type"), &calendarDates); err != nil {
+		return err
+	}
+	for _, ent := range calendars {
+		fv.services[ent.ID] = service.NewService(ent)
+	}
+	for _, ent := range calendarDates {
+		if svc, ok := fv.services[ent.ServiceID.Int()]; ok {
+			svc.AddCalendarDate(ent)
+		}
+	}
+
+	// Stops
+	var stopRows []stopRow
+	if err := dbutil.Select(ctx, db, fvSelect("gtfs_stops", fvid, "id", "stop_id", "stop_name", "stop_code", "geometry"), &stopRows); err != nil {
+		return err
+	}
+	stops := map[int]stopRow{}
+	for _, ent := range stopRows {
+		stops[ent.ID] = ent
+	}
+
+	// Trips, stop times and frequencies
+	var tripRows []tripRow
+	if err := dbutil.Select(ctx, db, fvSelect("gtfs_trips", fvid, "id", "trip_id", "route_id", "service_id", "trip_headsign", "trip_short_name", "journey_pattern_id", "journey_pattern_offset"), &tripRows); err != nil {
+		return err
+	}
+	var stopTimeRows []stopTimeRow
+	stq := fvSelect("gtfs_stop_times", fvid, "trip_id", "stop_id", "stop_sequence", "arrival_time", "departure_time", "pickup_type", "drop_off_type").
+		Where(sq.NotEq{"stop_id": nil}).
+		OrderBy("trip_id", "stop_sequence")
+	if err := dbutil.Select(ctx, db, stq, &stopTimeRows); err != nil {
+		return err
+	}
+	var frequencyRows []frequencyRow
+	if err := dbutil.Select(ctx, db, fvSelect("gtfs_frequencies", fvid, "trip_id", "start_time", "end_time", "headway_secs"), &frequencyRows); err != nil {
+		return err
+	}
+	var transferRows []transferRow
+	if err := dbutil.Select(ctx, db, fvSelect("gtfs_transfers", fvid, "from_stop_id", "to_stop_id", "from_route_id", "to_route_id", "from_trip_id", "to_trip_id", "transfer_type", "min_transfer_time"), &transferRows); err != nil {
+		return err
+	}
+	b.transfers = append(b.transfers, transferRows...)
+
+	stopTimes := map[int][]stopTimeRow{}
+	for _, ent := range stopTimeRows {
+		stopTimes[ent.TripID] = append(stopTimes[ent.TripID], ent)
+	}
+	frequencies := map[int][]frequencyRow{}
+	for _, ent := range frequencyRows {
+		frequencies[ent.TripID] = append(frequencies[ent.TripID], ent)
+	}
+
+	// Stop times are only stored for the first trip in each journey pattern;
+	// other trips in the pattern are offset from the first trip.
+	baseTrips := map[string]int{}
+	for _, ent := range tripRows {
+		baseTrips[ent.TripID] = ent.ID
+	}
+	patterns := map[int]*pattern{}
+	for _, ent := range tripRows {
+		baseTrip := ent.ID
+		if v, ok := baseTrips[ent.JourneyPatternID.Val]; ok {
+			baseTrip = v
+		}
+		sts := stopTimes[baseTrip]
+		if len(sts) < 2 {
+			continue
+		}
+		pat, ok := patterns[baseTrip]
+		if !ok {
+			pat = b.addPattern(fv, sts, stops)
+			if pat == nil {
+				continue
+			}
+			patterns[baseTrip] = pat
+		}
+		firstDeparture := sts[0].DepartureTime.Int()
+		t := &trip{
+			tripID:    ent.TripID,
+			headsign:  ent.TripHeadsign.Val,
+			shortName: ent.TripShortName.Val,
+			serviceID: ent.ServiceID,
+			route:     routes[ent.RouteID],
+			fv:        fv,
+		}
+		if freqs := frequencies[ent.ID]; len(freqs) > 0 {
+			for _, freq := range freqs {
+				if freq.HeadwaySecs <= 0 {
+					continue
+				}
+				for s := freq.StartTime.Int(); s <= freq.EndTime.Int(); s += freq.HeadwaySecs {
+					t.starts = append(t.starts, s)
+				}
+			}
+		} else {
+			t.starts = append(t.starts, firstDeparture+ent.JourneyPatternOffset.Int())
+		}
+		if t.route == nil || len(t.starts) == 0 {
+			continue
+		}
+		pat.trips = append(pat.trips, t)
+	}
+	return nil
+}
+
+// addPattern creates a pattern from the stop times of a trip.
+func (b *networkBuilder) addPattern(fv *feedVersion, sts []stopTimeRow, stops map[int]stopRow) *pattern {
+	pat := &pattern{fv: fv}
+	firstDeparture := sts[0].DepartureTime.Int()
+	for _, st := range sts {
+		if !st.ArrivalTime.Valid || !st.DepartureTime.Valid {
+			return nil
+		}
+		s, ok := stops[st.StopID]
+		if !ok {
+			return nil
+		}
+		pat.stops = append(pat.stops, b.addStop(fv, s))
+		pat.stopSequences = append(pat.stopSequences, st.StopSequence)
+		pat.arrivals = append(pat.arrivals, st.ArrivalTime.Int()-firstDeparture)
+		pat.departures = append(pat.departures, st.DepartureTime.Int()-firstDeparture)
+		pat.pickup = append(pat.pickup, st.PickupType.Int() != 1)
+		pat.dropOff = append(pat.dropOff, st.DropOffType.Int() != 1)
+	}
+	patIdx := len(b.net.patterns)
+	b.net.patterns = append(b.net.patterns, pat)
+	for pos, s := range pat.stops {
+		b.net.stopPatterns[s] = append(b.net.stopPatterns[s], stopPattern{pattern: patIdx, pos: pos})
+	}
+	return pat
+}
+
+func (b *networkBuilder) addStop(fv *feedVersion, ent stopRow) int {
+	if idx, ok := b.stopIndex[ent.ID]; ok {
+		return idx
+	}
+	idx := len(b.net.stops)
+	s := &stop{
+		id:     ent.ID,
+		stopID: ent.StopID,
+		name:   ent.StopName.Val,
+		code:   ent.StopCode.Val,
+		pt:     ent.Geometry.ToPoint(),
+		fv:     fv,
+	}
+	b.stopIndex[ent.ID] = idx
+	b.net.stops = append(b.net.stops, s)
+	b.net.stopPatterns = append(b.net.stopPatterns, nil)
+	b.net.grid.add(idx, s.pt)
+	return idx
+}
+
+// buildFootpaths creates walking transfers between nearby stops and applies transfers.txt.
+func (b *networkBuilder) buildFootpaths(opts Options) {
+	net := b.net
+	durations := make([]map[int]int, len(net.stops))
+	for i, s := range net.stops {
+		durations[i] = map[int]int{}
+		for _, j := range net.grid.within(s.pt, opts.MaxTransferDistance) {
+			if i == j {
+				continue
+			}
+			d := tlxy.DistanceHaversine(s.pt, net.stops[j].pt)
+			if d <= opts.MaxTransferDistance {
+				durations[i][j] = walkSeconds(d, opts.WalkSpeed)
+			}
+		}
+	}
+	net.minTransfer = make([]int, len(net.stops))
+	for _, ent := range b.transfers {
+		// Route and trip specific transfers are not supported
+		if ent.FromRouteID.Valid || ent.ToRouteID.Valid || ent.FromTripID.Valid || ent.ToTripID.Valid {
+			continue
+		}
+		from, ok1 := b.stopIndex[ent.FromStopID.Int()]
+		to, ok2 := b.stopIndex[ent.ToStopID.Int()]
+		if !ok1 || !ok2 {
+			continue
+		}
+		switch ent.TransferType {
+		case 2:
+			if from == to {
+				net.minTransfer[from] = ent.MinTransferTime.Int()
+			} else {
+				durations[from][to] = ent.MinTransferTime.Int()
+			}
+		case 3:
+			delete(durations[from], to)
+		default:
+			if from != to {
+				if _, ok := durations[from][to]; !ok {
+					durations[from][to] = walkSeconds(tlxy.DistanceHaversine(net.stops[from].pt, net.stops[to].pt), opts.WalkSpeed)
+				}
+			}
+		}
+	}
+	net.footpaths = make([][]footpath, len(net.stops))
+	for i, m := range durations {
+		for j, d := range m {
+			net.footpaths[i] = append(net.footpaths[i], footpath{to: j, duration: d})
+		}
+		sort.Slice(net.footpaths[i], func(a, b int) bool { return net.footpaths[i][a].to < net.footpaths[i][b].to })
+	}
+}
+
+// run is a single timed trip on a service day.
+type run struct {
+	start       int64 // unix time of the first departure
+	trip        *trip
+	serviceDate time.Time
+}
+
+// runs returns the runs for a pattern that operate between the start and end times, ordered by departure.
+func (p *pattern) runs(start int64, end int64) []run {
+	if len(p.trips) == 0 {
+		return nil
+	}
+	lastArrival := int64(p.arrivals[len(p.arrivals)-1])
+	var ret []run
+	for _, d := range p.serviceDays(start, end) {
+		for _, t := range p.trips {
+			svc, ok := t.fv.services[t.serviceID]
+			if !ok || !svc.IsActive(d.date) {
+				continue
+			}
+			offset := d.offsets[t.fv]
+			for _, s := range t.starts {
+				st := offset + int64(s)
+				if st > end || st+lastArrival < start {
+					continue
+				}
+				ret = append(ret, run{start: st, trip: t, serviceDate: d.date})
+			}
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].start < ret[j].start })
+	return ret
+}
+
+type serviceDay struct {
+	date    time.Time
+	offsets map[*feedVersion]int64 // unix time of the start of the service day
+}
+
+// serviceDays returns the service days that may have trips between the start and end times.
+// The previous day is included for trips that continue past midnight.
+func (p *pattern) serviceDays(start int64, end int64) []serviceDay {
+	fvs := map[*feedVersion]bool{}
+	for _, t := range p.trips {
+		fvs[t.fv] = true
+	}
+	days := map[string]*serviceDay{}
+	var ret []*serviceDay
+	for fv := range fvs {
+		first := localDate(time.Unix(start, 0).In(fv.loc)).AddDate(0, 0, -1)
+		last := localDate(time.Unix(end, 0).In(fv.loc))
+		for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+			d := date
+			key := date.Format("20060102")
+			sd, ok := days[key]
+			if !ok {
+				sd = &serviceDay{date: date, offsets: map[*feedVersion]int64{}}
+				days[key] = sd
+				ret = append(ret, sd)
+			}
+			// GTFS times are measured from noon minus 12 hours
+			noon := time.Date(d.Year(), d.Month(), d.Day(), 12, 0, 0, 0, fv.loc)
+			sd.offsets[fv] = noon.Add(-12 * time.Hour).Unix()
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].date.Before(ret[j].date) })
+	var out []serviceDay
+	for _, sd := range ret {
+		out = append(out, *sd)
+	}
+	return out
+}
+
+// localDate returns the calendar date of a local time, as midnight UTC.
+func localDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func fvSelect(table string, fvid int, cols ...string) sq.SelectBuilder {
+	return sq.StatementBuilder.Select(cols...).From(table).Where(sq.Eq{"feed_version_id": fvid})
+}
+
+func walkSeconds(distance float64, speed float64) int {
+	return int(math.Ceil(distance / speed))
+}
+
+// grid is a simple spatial index for finding stops near a point.
+type grid struct {
+	cells map[[2]int][]int
+}
+
+// Grid cells are approximately 1km
+const gridSize = 0.01
+
+func newGrid() *grid {
+	return &grid{cells: map[[2]int][]int{}}
+}
+
+func (g *grid) key(pt tlxy.Point) [2]int {
+	return [2]int{int(math.Floor(pt.Lon / gridSize)), int(math.Floor(pt.Lat / gridSize))}
+}
+
+func (g *grid) add(idx int, pt tlxy.Point) {
+	k := g.key(pt)
+	g.cells[k] = append(g.cells[k], idx)
+}
+
+// within returns candidate stops within approximately the given radius, in meters.
+// Callers must check the actual distance.
+func (g *grid) within(pt tlxy.Point, radius float64) []int {
+	latDelta := radius / 111000.0
+	lonDelta := radius / tlxy.ApproxLonMeters(pt)
+	min := g.key(tlxy.Point{Lon: pt.Lon - lonDelta, Lat: pt.Lat - latDelta})
+	max := g.key(tlxy.Point{Lon: pt.Lon + lonDelta, Lat: pt.Lat + latDelta})
+	var ret []int
+	for x := min[0]; x <= max[0]; x++ {
+		for y := min[1]; y <= max[1]; y++ {
+			ret = append(ret, g.cells[[2]int{x, y}]...)
+		}
+	}
+	return ret
+}
@@ -46,11 +46,15 @@ func StaticFetch(ctx context.Context, feedId string, feedSrc io.Reader, feedUrl
 			Secrets:       cfg.Secrets,
 			FetchedAt:     time.Now().In(time.UTC),
 			AllowFTPFetch: true,
+			HostLimiter:   cfg.HostLimiter,
 		},
 	}
 	if user := authn.ForContext(ctx); user != nil {
 		fetchOpts.CreatedBy.Set(user.ID())
 	}
+	if cfg.Metrics != nil {
+		fetchOpts.FetchMetric = cfg.Metrics.NewFetchMetric()
+	}
 
 	// Allow a Reader
 	if feedSrc != nil {
@@ -102,18 +106,23 @@ func RTFetch(ctx context.Context, target string, feedId string, feedUrl string,
 	// Prepare
 	fetchOpts := fetch.RTFetchOptions{
 		Options: fetch.Options{
-			FeedID:    feed.ID,
-			URLType:   urlType,
-			FeedURL:   feedUrl,
-			Storage:   cfg.RTStorage,
-			Secrets:   cfg.Secrets,
-			FetchedAt: time.Now().In(time.UTC),
+			FeedID:      feed.ID,
+			URLType:     urlType,
+			FeedURL:     feedUrl,
+			Storage:     cfg.RTStorage,
+			Secrets:     cfg.Secrets,
+			FetchedAt:   time.Now().In(time.UTC),
+			HostLimiter: cfg.HostLimiter,
 		},
 	}
+	if cfg.Metrics != nil {
+		fetchOpts.FetchMetric = cfg.Metrics.NewFetchMetric()
+	}
 
 	// Make request
 	var rtMsg *pb.FeedMessage
 	var fetchErr error
+	notModified := false
 	if err := postgres.NewPostgresAdapterFromDBX(cfg.Finder.DBX()).Tx(func(atx tldb.Adapter) error {
 		fr, err := fetch.RTFetch(ctx, atx, fetchOpts)
 		if err != nil {
@@ -121,6 +130,7 @@ func RTFetch(ctx context.Context, target string, feedId string, feedUrl string,
 		}
 		rtMsg = fr.Message
 		fetchErr = fr.FetchError
+		notModified = fr.NotModified
 		return nil
 	}); err != nil {
 		return err
@@ -130,6 +140,10 @@ func RTFetch(ctx context.Context, target string, feedId string, feedUrl string,
 	if fetchErr != nil {
 		return fetchErr
 	}
+	if notModified || rtMsg == nil {
+		// Keep the previously cached message
+		return nil
+	}
 	rtdata, err := proto.Marshal(rtMsg)
 	if err != nil {
 		return errors.New("invalid rt data")
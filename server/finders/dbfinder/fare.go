@@ -0,0 +1,297 @@
+package dbfinder
+
+import (
+	"context"
+	"sort"
+
+	"github.com/interline-io/transitland-lib/server/dbutil"
+	"github.com/interline-io/transitland-lib/server/model"
+	sq "github.com/irees/squirrel"
+)
+
+// Fare leg rules, fare products, and stop areas reference areas, networks and fare media
+// by internal ID, stored as text in the referencing column.
+
+func (f *Finder) AreasByIDs(ctx context.Context, ids []int) ([]*model.Area, []error) {
+	var ents []*model.Area
+	err := dbutil.Select(ctx,
+		f.db,
+		quickSelect("gtfs_areas", nil, nil, ids),
+		&ents,
+	)
+	if err != nil {
+		return nil, logExtendErr(ctx, len(ids), err)
+	}
+	return arrangeBy(ids, ents, func(ent *model.Area) int { return ent.ID }), nil
+}
+
+func (f *Finder) AreasByFeedVersionIDs(ctx context.Context, limit *int, keys []int) ([][]*model.Area, error) {
+	var ents []*model.Area
+	err := dbutil.Select(ctx,
+		f.db,
+		lateralWrap(
+			quickSelect("gtfs_areas", limit, nil, nil),
+			"feed_versions",
+			"id",
+			"gtfs_areas",
+			"feed_version_id",
+			keys,
+		),
+		&ents,
+	)
+	return arrangeGroup(keys, ents, func(ent *model.Area) int { return ent.FeedVersionID }), err
+}
+
+func (f *Finder) AreasByStopIDs(ctx context.Context, limit *int, keys []int) ([][]*model.Area, error) {
+	var ents []*model.Area
+	q := sq.StatementBuilder.
+		Select("gtfs_areas.*", "gtfs_stop_areas.stop_id as with_stop_id").
+		From("gtfs_areas").
+		Join("gtfs_stop_areas on gtfs_stop_areas.area_id = gtfs_areas.id").
+		Limit(checkLimit(limit)).
+		OrderBy("gtfs_areas.id")
+	err := dbutil.Select(ctx,
+		f.db,
+		lateralWrap(q, "gtfs_stops", "id", "gtfs_stop_areas", "stop_id", keys),
+		&ents,
+	)
+	return arrangeGroup(keys, ents, func(ent *model.Area) int { return ent.WithStopID.Int() }), err
+}
+
+func (f *Finder) NetworksByIDs(ctx context.Context, ids []int) ([]*model.Network, []error) {
+	var ents []*model.Network
+	err := dbutil.Select(ctx,
+		f.db,
+		quickSelect("gtfs_networks", nil, nil, ids),
+		&ents,
+	)
+	if err != nil {
+		return nil, logExtendErr(ctx, len(ids), err)
+	}
+	return arrangeBy(ids, ents, func(ent *model.Network) int { return ent.ID }), nil
+}
+
+func (f *Finder) NetworksByFeedVersionIDs(ctx context.Context, limit *int, keys []int) ([][]*model.Network, error) {
+	var ents []*model.Network
+	err := dbutil.Select(ctx,
+		f.db,
+		lateralWrap(
+			quickSelect("gtfs_networks", limit, nil, nil),
+			"feed_versions",
+			"id",
+			"gtfs_networks",
+			"feed_version_id",
+			keys,
+		),
+		&ents,
+	)
+	return arrangeGroup(keys, ents, func(ent *model.Network) int { return ent.FeedVersionID }), err
+}
+
+func (f *Finder) NetworksByRouteIDs(ctx context.Context, limit *int, keys []int) ([][]*model.Network, error) {
+	// Routes may reference a network by routes.network_id or through route_networks.txt
+	var ents []*model.Network
+	q := sq.StatementBuilder.
+		Select("gtfs_networks.*", "gtfs_routes.id as with_route_id").
+		From("gtfs_routes").
+		Join("gtfs_networks on gtfs_networks.feed_version_id = gtfs_routes.feed_version_id").
+		Where(sq.Or{
+			sq.Expr("gtfs_networks.network_id = gtfs_routes.network_id"),
+			sq.Expr("gtfs_networks.id in (select gtfs_route_networks.network_id from gtfs_route_networks where gtfs_route_networks.route_id = gtfs_routes.id)"),
+		}).
+		Limit(checkLimit(limit)).
+		OrderBy("gtfs_networks.id")
+	err := dbutil.Select(ctx,
+		f.db,
+		lateralWrap(q, "gtfs_routes", "id", "gtfs_routes", "id", keys),
+		&ents,
+	)
+	return arrangeGroup(keys, ents, func(ent *model.Network) int { return ent.WithRouteID.Int() }), err
+}
+
+func (f *Finder) FareMediaByIDs(ctx context.Context, ids []int) ([]*model.FareMedia, []error) {
+	var ents []*model.FareMedia
+	err := dbutil.Select(ctx,
+		f.db,
+		quickSelect("gtfs_fare_media", nil, nil, ids),
+		&ents,
+	)
+	if err != nil {
+		return nil, logExtendErr(ctx, len(ids), err)
+	}
+	return arrangeBy(ids, ents, func(ent *model.FareMedia) int { return ent.ID }), nil
+}
+
+func (f *Finder) FareMediaByFeedVersionIDs(ctx context.Context, limit *int, keys []int) ([][]*model.FareMedia, error) {
+	var ents []*model.FareMedia
+	err := dbutil.Select(ctx,
+		f.db,
+		lateralWrap(
+			quickSelect("gtfs_fare_media", limit, nil, nil),
+			"feed_versions",
+			"id",
+			"gtfs_fare_media",
+			"feed_version_id",
+			keys,
+		),
+		&ents,
+	)
+	return arrangeGroup(keys, ents, func(ent *model.FareMedia) int { return ent.FeedVersionID }), err
+}
+
+func (f *Finder) FareProductsByFeedVersionIDs(ctx context.Context, limit *int, where *model.FareProductFilter, keys []int) ([][]*model.FareProduct, error) {
+	var ents []*model.FareProduct
+	q := quickSelect("gtfs_fare_products", limit, nil, nil)
+	if where != nil {
+		if where.FareProductID != nil {
+			q = q.Where(sq.Eq{"fare_product_id": *where.FareProductID})
+		}
+		if where.RiderCategoryID != nil {
+			q = q.Where(sq.Eq{"rider_category_id": *where.RiderCategoryID})
+		}
+	}
+	err := dbutil.Select(ctx,
+		f.db,
+		lateralWrap(q, "feed_versions", "id", "gtfs_fare_products", "feed_version_id", keys),
+		&ents,
+	)
+	return arrangeGroup(keys, ents, func(ent *model.FareProduct) int { return ent.FeedVersionID }), err
+}
+
+func (f *Finder) FareProductsByFareLegRuleIDs(ctx context.Context, limit *int, keys []int) ([][]*model.FareProduct, error) {
+	var ents []*model.FareProduct
+	q := sq.StatementBuilder.
+		Select("gtfs_fare_products.*", "gtfs_fare_leg_rules.id as with_fare_leg_rule_id").
+		From("gtfs_fare_leg_rules").
+		Join("gtfs_fare_products on gtfs_fare_products.feed_version_id = gtfs_fare_leg_rules.feed_version_id and gtfs_fare_products.fare_product_id = gtfs_fare_leg_rules.fare_product_id").
+		Limit(checkLimit(limit)).
+		OrderBy("gtfs_fare_products.id")
+	err := dbutil.Select(ctx,
+		f.db,
+		lateralWrap(q, "gtfs_fare_leg_rules", "id", "gtfs_fare_leg_rules", "id", keys),
+		&ents,
+	)
+	return arrangeGroup(keys, ents, func(ent *model.FareProduct) int { return ent.WithFareLegRuleID.Int() }), err
+}
+
+func (f *Finder) FareProductsByFareTransferRuleIDs(ctx context.Context, limit *int, keys []int) ([][]*model.FareProduct, error) {
+	var ents []*model.FareProduct
+	q := sq.StatementBuilder.
+		Select("gtfs_fare_products.*", "gtfs_fare_transfer_rules.id as with_fare_transfer_rule_id").
+		From("gtfs_fare_transfer_rules").
+		Join("gtfs_fare_products on gtfs_fare_products.feed_version_id = gtfs_fare_transfer_rules.feed_version_id and gtfs_fare_products.fare_product_id = gtfs_fare_transfer_rules.fare_product_id").
+		Limit(checkLimit(limit)).
+		OrderBy("gtfs_fare_products.id")
+	err := dbutil.Select(ctx,
+		f.db,
+		lateralWrap(q, "gtfs_fare_transfer_rules", "id", "gtfs_fare_transfer_rules", "id", keys),
+		&ents,
+	)
+	return arrangeGroup(keys, ents, func(ent *model.FareProduct) int { return ent.WithFareTransferRuleID.Int() }), err
+}
+
+func (f *Finder) FareLegRulesByFeedVersionIDs(ctx context.Context, limit *int, where *model.FareLegRuleFilter, keys []int) ([][]*model.FareLegRule, error) {
+	var ents []*model.FareLegRule
+	err := dbutil.Select(ctx,
+		f.db,
+		lateralWrap(
+			fareLegRuleSelect(limit, where),
+			"feed_versions",
+			"id",
+			"gtfs_fare_leg_rules",
+			"feed_version_id",
+			keys,
+		),
+		&ents,
+	)
+	return arrangeGroup(keys, ents, func(ent *model.FareLegRule) int { return ent.FeedVersionID }), err
+}
+
+func (f *Finder) FareLegRulesByNetworkIDs(ctx context.Context, limit *int, where *model.FareLegRuleFilter, keys []int) ([][]*model.FareLegRule, error) {
+	var ents []*model.FareLegRule
+	err := dbutil.Select(ctx,
+		f.db,
+		lateralWrapText(
+			fareLegRuleSelect(limit, where),
+			"gtfs_networks",
+			"id",
+			"gtfs_fare_leg_rules",
+			"network_id",
+			keys,
+		),
+		&ents,
+	)
+	return arrangeGroup(keys, ents, func(ent *model.FareLegRule) int { return ent.NetworkID.Int() }), err
+}
+
+func (f *Finder) FareLegRulesByAreaIDs(ctx context.Context, limit *int, where *model.FareLegRuleFilter, keys []int) ([][]*model.FareLegRule, error) {
+	// A rule may belong to both its from and to area
+	var fromEnts []*model.FareLegRule
+	if err := dbutil.Select(ctx,
+		f.db,
+		lateralWrapText(fareLegRuleSelect(limit, where), "gtfs_areas", "id", "gtfs_fare_leg_rules", "from_area_id", keys),
+		&fromEnts,
+	); err != nil {
+		return nil, err
+	}
+	var toEnts []*model.FareLegRule
+	if err := dbutil.Select(ctx,
+		f.db,
+		lateralWrapText(fareLegRuleSelect(limit, where), "gtfs_areas", "id", "gtfs_fare_leg_rules", "to_area_id", keys),
+		&toEnts,
+	); err != nil {
+		return nil, err
+	}
+	fromGroups := arrangeGroup(keys, fromEnts, func(ent *model.FareLegRule) int { return ent.FromAreaID.Int() })
+	toGroups := arrangeGroup(keys, toEnts, func(ent *model.FareLegRule) int { return ent.ToAreaID.Int() })
+	ret := make([][]*model.FareLegRule, len(keys))
+	for i := range keys {
+		seen := map[int]bool{}
+		for _, ent := range append(fromGroups[i], toGroups[i]...) {
+			if seen[ent.ID] {
+				continue
+			}
+			seen[ent.ID] = true
+			ret[i] = append(ret[i], ent)
+		}
+		sort.Slice(ret[i], func(a, b int) bool { return ret[i][a].ID < ret[i][b].ID })
+		if lim := int(checkLimit(limit)); len(ret[i]) > lim {
+			ret[i] = ret[i][:lim]
+		}
+	}
+	return ret, nil
+}
+
+func (f *Finder) FareTransferRulesByFeedVersionIDs(ctx context.Context, limit *int, keys []int) ([][]*model.FareTransferRule, error) {
+	var ents []*model.FareTransferRule
+	err := dbutil.Select(ctx,
+		f.db,
+		lateralWrap(
+			quickSelect("gtfs_fare_transfer_rules", limit, nil, nil),
+			"feed_versions",
+			"id",
+			"gtfs_fare_transfer_rules",
+			"feed_version_id",
+			keys,
+		),
+		&ents,
+	)
+	return arrangeGroup(keys, ents, func(ent *model.FareTransferRule) int { return ent.FeedVersionID }), err
+}
+
+func fareLegRuleSelect(limit *int, where *model.FareLegRuleFilter) sq.SelectBuilder {
+	q := sq.StatementBuilder.
+		Select("gtfs_fare_leg_rules.*").
+		From("gtfs_fare_leg_rules").
+		Limit(checkLimit(limit)).
+		OrderBy("gtfs_fare_leg_rules.id")
+	if where != nil {
+		if where.LegGroupID != nil {
+			q = q.Where(sq.Eq{"gtfs_fare_leg_rules.leg_group_id": *where.LegGroupID})
+		}
+		if where.FareProductID != nil {
+			q = q.Where(sq.Eq{"gtfs_fare_leg_rules.fare_product_id": *where.FareProductID})
+		}
+	}
+	return q
+}
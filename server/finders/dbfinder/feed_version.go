@@ -54,6 +54,19 @@ func (f *Finder) FeedVersionServiceWindowByFeedVersionIDs(ctx context.Context, i
 	return arrangeBy(ids, ents, func(ent *model.FeedVersionServiceWindow) int { return ent.FeedVersionID }), nil
 }
 
+func (f *Finder) FeedVersionChangesByFeedVersionIDs(ctx context.Context, ids []int) ([]*model.FeedVersionChanges, []error) {
+	var ents []*model.FeedVersionChanges
+	err := dbutil.Select(ctx,
+		f.db,
+		quickSelect("feed_version_changes", nil, nil, nil).Where(In("feed_version_id", ids)),
+		&ents,
+	)
+	if err != nil {
+		return nil, logExtendErr(ctx, len(ids), err)
+	}
+	return arrangeBy(ids, ents, func(ent *model.FeedVersionChanges) int { return ent.FeedVersionID }), nil
+}
+
 func (f *Finder) FeedVersionGeometryByIDs(ctx context.Context, ids []int) ([]*tt.Polygon, []error) {
 	if len(ids) == 0 {
 		return nil, nil
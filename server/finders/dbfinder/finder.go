@@ -244,7 +244,19 @@ func lateralWrap(q sq.SelectBuilder, outerTable string, outerKey string, innerTa
 	outerKey = az09(outerKey)
 	innerTable = az09(innerTable)
 	innerKey = az09(innerKey)
-	qInner := q.Where(fmt.Sprintf("%s.%s = out.%s", innerTable, innerKey, outerKey))
+	return lateralWrapWhere(q.Where(fmt.Sprintf("%s.%s = out.%s", innerTable, innerKey, outerKey)), outerTable, outerKey, outerIds)
+}
+
+// lateralWrapText is like lateralWrap, for inner keys that store the outer integer key as text.
+func lateralWrapText(q sq.SelectBuilder, outerTable string, outerKey string, innerTable string, innerKey string, outerIds []int) sq.SelectBuilder {
+	outerTable = az09(outerTable)
+	outerKey = az09(outerKey)
+	innerTable = az09(innerTable)
+	innerKey = az09(innerKey)
+	return lateralWrapWhere(q.Where(fmt.Sprintf("%s.%s = out.%s::text", innerTable, innerKey, outerKey)), outerTable, outerKey, outerIds)
+}
+
+func lateralWrapWhere(qInner sq.SelectBuilder, outerTable string, outerKey string, outerIds []int) sq.SelectBuilder {
 	q2 := sq.StatementBuilder.
 		Select("t.*").
 		From(outerTable + " out").
@@ -0,0 +1,52 @@
+package dbfinder
+
+import (
+	"context"
+
+	"github.com/interline-io/transitland-lib/internal/mds"
+	"github.com/interline-io/transitland-lib/server/dbutil"
+	"github.com/interline-io/transitland-lib/server/model"
+	sq "github.com/irees/squirrel"
+)
+
+func (f *Finder) MdsTripSummariesByFeedIDs(ctx context.Context, limit *int, where *model.MdsTripSummaryFilter, keys []int) ([][]*model.MdsTripSummary, error) {
+	// Zones are stored at full precision; group by a geohash prefix
+	precision := 6
+	if where != nil && where.ZonePrecision != nil {
+		precision = min(max(*where.ZonePrecision, 1), mds.ZonePrecision)
+	}
+	q := sq.StatementBuilder.
+		Select(
+			"tl_mds_trips.feed_id",
+			"date_trunc('hour', tl_mds_trips.start_time) as hour",
+		).
+		Column(sq.Expr("substr(tl_mds_trips.start_zone, 1, ?) as zone", precision)).
+		Columns(
+			"count(*) as trip_count",
+			"avg(tl_mds_trips.trip_duration) as avg_duration",
+			"avg(tl_mds_trips.trip_distance) as avg_distance",
+		).
+		From("tl_mds_trips").
+		Where(sq.NotEq{"tl_mds_trips.start_zone": nil}).
+		GroupBy("tl_mds_trips.feed_id", "hour", "zone").
+		OrderBy("hour", "zone").
+		Limit(checkLimit(limit))
+	if where != nil {
+		if where.StartTime != nil {
+			q = q.Where(sq.GtOrEq{"tl_mds_trips.start_time": *where.StartTime})
+		}
+		if where.EndTime != nil {
+			q = q.Where(sq.Lt{"tl_mds_trips.start_time": *where.EndTime})
+		}
+		if where.VehicleType != nil {
+			q = q.Where(sq.Eq{"tl_mds_trips.vehicle_type": *where.VehicleType})
+		}
+	}
+	var ents []*model.MdsTripSummary
+	err := dbutil.Select(ctx,
+		f.db,
+		lateralWrap(q, "current_feeds", "id", "tl_mds_trips", "feed_id", keys),
+		&ents,
+	)
+	return arrangeGroup(keys, ents, func(ent *model.MdsTripSummary) int { return ent.FeedID }), err
+}
@@ -75,6 +75,18 @@ func (f *Finder) StopsByRouteIDs(ctx context.Context, limit *int, where *model.S
 	return arrangeGroup(keys, ents, func(ent *model.Stop) int { return ent.WithRouteID.Int() }), err
 }
 
+func (f *Finder) StopsByAreaIDs(ctx context.Context, limit *int, where *model.StopFilter, keys []int) ([][]*model.Stop, error) {
+	var ents []*model.Stop
+	qso := stopSelect(limit, nil, nil, false, f.PermFilter(ctx), where)
+	qso = qso.Join("gtfs_stop_areas on gtfs_stop_areas.stop_id = gtfs_stops.id").Where(In("gtfs_stop_areas.area_id", keys)).Column("gtfs_stop_areas.area_id as with_area_id")
+	err := dbutil.Select(ctx,
+		f.db,
+		qso,
+		&ents,
+	)
+	return arrangeGroup(keys, ents, func(ent *model.Stop) int { return ent.WithAreaID.Int() }), err
+}
+
 func (f *Finder) StopsByParentStopIDs(ctx context.Context, limit *int, where *model.StopFilter, keys []int) ([][]*model.Stop, error) {
 	var ents []*model.Stop
 	err := dbutil.Select(ctx,
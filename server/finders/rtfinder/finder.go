@@ -19,6 +19,8 @@ type Cache interface {
 	AddFeedMessage(context.Context, string, *pb.FeedMessage) error
 	AddData(context.Context, string, []byte) error
 	GetSource(context.Context, string) (*Source, bool)
+	Topics(context.Context) []string
+	Subscribe(context.Context) <-chan *Update
 	Close() error
 }
 
@@ -42,6 +44,17 @@ func (f *Finder) AddData(ctx context.Context, topic string, data []byte) error {
 	return f.cache.AddData(ctx, topic, data)
 }
 
+// SourceTimestamps returns the header timestamp of the most recent message for each topic.
+func (f *Finder) SourceTimestamps(ctx context.Context) map[string]uint64 {
+	ret := map[string]uint64{}
+	for _, topic := range f.cache.Topics(ctx) {
+		if s, ok := f.cache.GetSource(ctx, topic); ok && s != nil {
+			ret[topic] = s.GetTimestamp()
+		}
+	}
+	return ret
+}
+
 func (f *Finder) GetGtfsTripID(ctx context.Context, id int) (string, bool) {
 	return f.lc.GetGtfsTripID(id)
 }
@@ -34,6 +34,15 @@ func testCache(t *testing.T, rtCache Cache) {
 			found = append(found, a.GetTimestamp())
 		}
 	}
+	cacheTopics := map[string]bool{}
+	for _, topic := range rtCache.Topics(ctx) {
+		cacheTopics[topic] = true
+	}
+	for _, topic := range topics {
+		if !cacheTopics[topic] {
+			t.Errorf("expected topic %s", topic)
+		}
+	}
 	rtCache.Close()
 	if len(found) != len(feeds) {
 		t.Errorf("got %d items, expected %d", len(found), len(feeds))
@@ -2,6 +2,7 @@ package rtfinder
 
 import (
 	"context"
+	"sort"
 	"sync"
 
 	"github.com/interline-io/transitland-lib/rt/pb"
@@ -10,11 +11,13 @@ import (
 type LocalCache struct {
 	lock    sync.Mutex
 	sources map[string]*Source
+	broker  *broker
 }
 
 func NewLocalCache() *LocalCache {
 	return &LocalCache{
 		sources: map[string]*Source{},
+		broker:  newBroker(),
 	}
 }
 
@@ -28,6 +31,17 @@ func (f *LocalCache) GetSource(ctx context.Context, topic string) (*Source, bool
 	return nil, false
 }
 
+func (f *LocalCache) Topics(ctx context.Context) []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var ret []string
+	for k := range f.sources {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
 func (f *LocalCache) AddFeedMessage(ctx context.Context, topic string, rtmsg *pb.FeedMessage) error {
 	return nil
 }
@@ -40,7 +54,22 @@ func (f *LocalCache) AddData(ctx context.Context, topic string, data []byte) err
 		s, _ = NewSource(topic)
 		f.sources[topic] = s
 	}
-	return s.process(ctx, data)
+	update, err := s.process(ctx, data)
+	if err != nil {
+		return err
+	}
+	f.broker.publish(update)
+	return nil
+}
+
+func (f *LocalCache) Subscribe(ctx context.Context) <-chan *Update {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var initial []*Update
+	for _, s := range f.sources {
+		initial = append(initial, s.snapshot())
+	}
+	return f.broker.subscribe(ctx, initial)
 }
 
 func (f *LocalCache) Close() error {
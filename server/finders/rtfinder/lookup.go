@@ -97,6 +97,35 @@ func (f *lookupCache) GetFeedVersionRTFeeds(id int) ([]string, bool) {
 	return eid, true
 }
 
+// feedPermission is the visibility of a feed and its active feed version.
+type feedPermission struct {
+	FeedID        int  `db:"feed_id"`
+	FeedVersionID int  `db:"feed_version_id"`
+	Public        bool `db:"public"`
+}
+
+// GetFeedPermission looks up the visibility of a feed by onestop ID.
+// Results are not cached, so changes are visible to new subscriptions.
+func (f *lookupCache) GetFeedPermission(onestopID string) (feedPermission, bool) {
+	ret := feedPermission{}
+	if f.db == nil {
+		return ret, false
+	}
+	q := `
+	select
+		cf.id as feed_id,
+		coalesce(fs.feed_version_id, 0) as feed_version_id,
+		coalesce(fs.public, false) as public
+	from current_feeds cf
+	left join feed_states fs on fs.feed_id = cf.id
+	where cf.onestop_id = $1 and cf.deleted_at is null
+	limit 1`
+	if err := sqlx.Get(f.db, &ret, q, onestopID); err != nil {
+		return ret, false
+	}
+	return ret, true
+}
+
 // StopTimezone looks up the timezone for a stop
 func (f *lookupCache) StopTimezone(ctx context.Context, id int, known string) (*time.Location, bool) {
 	// Need to lock while looking up or setting.
@@ -3,6 +3,8 @@ package rtfinder
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -31,6 +33,8 @@ type RedisCache struct {
 	lock      sync.Mutex
 	client    *redis.Client
 	listeners map[string]*listener
+	broker    *broker
+	watcher   context.CancelFunc
 }
 
 func NewRedisCache(client *redis.Client) *RedisCache {
@@ -38,6 +42,7 @@ func NewRedisCache(client *redis.Client) *RedisCache {
 	f := RedisCache{
 		client:    client,
 		listeners: map[string]*listener{},
+		broker:    newBroker(),
 		ctx:       ctx,
 	}
 	return &f
@@ -57,6 +62,18 @@ func (f *RedisCache) GetSource(ctx context.Context, topic string) (*Source, bool
 	return a.source, true
 }
 
+// Topics returns the topics with active listeners.
+func (f *RedisCache) Topics(ctx context.Context) []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var ret []string
+	for k := range f.listeners {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
 func (f *RedisCache) AddFeedMessage(ctx context.Context, topic string, rtmsg *pb.FeedMessage) error {
 	return nil
 }
@@ -76,9 +93,41 @@ func (f *RedisCache) AddData(ctx context.Context, topic string, data []byte) err
 	return nil
 }
 
+// Subscribe returns a channel that receives updates for all topics.
+// The first subscription starts listening for data published on any topic.
+func (f *RedisCache) Subscribe(ctx context.Context) <-chan *Update {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.watcher == nil {
+		wctx, cancel := context.WithCancel(f.ctx)
+		f.watcher = cancel
+		go f.watchTopics(wctx)
+	}
+	var initial []*Update
+	for _, ls := range f.listeners {
+		initial = append(initial, ls.source.snapshot())
+	}
+	return f.broker.subscribe(ctx, initial)
+}
+
+// watchTopics ensures a listener exists for every topic that receives data.
+func (f *RedisCache) watchTopics(ctx context.Context) {
+	sub := f.client.PSubscribe(ctx, subKey("*"))
+	defer sub.Close()
+	for rmsg := range sub.Channel() {
+		topic := strings.TrimPrefix(rmsg.Channel, subKey(""))
+		// New listeners process the last published data
+		f.GetSource(ctx, topic)
+	}
+}
+
 func (f *RedisCache) Close() error {
 	f.lock.Lock()
 	defer f.lock.Unlock()
+	if f.watcher != nil {
+		f.watcher()
+		f.watcher = nil
+	}
 	for k, ls := range f.listeners {
 		ls.cancel()
 		delete(f.listeners, k)
@@ -107,10 +156,11 @@ func (f *RedisCache) startListener(ctx context.Context, topic string) (*listener
 		defer sub.Close()
 		subch := sub.Channel()
 		for rmsg := range subch {
-			if err := s.process(ctx, []byte(rmsg.Payload)); err != nil {
+			if update, err := s.process(ctx, []byte(rmsg.Payload)); err != nil {
 				log.For(ctx).Error().Err(err).Str("topic", topic).Int("bytes", len(rmsg.Payload)).Msg("cache: error processing update")
 			} else {
 				log.For(ctx).Trace().Str("topic", topic).Int("bytes", len(rmsg.Payload)).Msg("cache: processed update")
+				f.broker.publish(update)
 			}
 		}
 	}(f.client, topic, ls)
@@ -126,7 +176,9 @@ func (f *RedisCache) startListener(ctx context.Context, topic string) (*listener
 		log.For(ctx).Error().Err(err).Str("topic", topic).Msg("cache: error getting last data for topic")
 	} else {
 		lb, _ := lastData.Bytes()
-		s.process(ctx, lb)
+		if update, err := s.process(ctx, lb); err == nil {
+			f.broker.publish(update)
+		}
 	}
 	return ls, nil
 }
@@ -2,6 +2,7 @@ package rtfinder
 
 import (
 	"context"
+	"hash/fnv"
 
 	"github.com/interline-io/log"
 	"github.com/interline-io/transitland-lib/rt/pb"
@@ -12,6 +13,8 @@ type Source struct {
 	feed         string
 	msg          *pb.FeedMessage
 	entityByTrip map[string]*pb.TripUpdate
+	vehicles     map[string]*pb.VehiclePosition
+	hashes       map[string]uint64 // entity content hashes, used to find changed entities
 	alerts       []*pb.Alert
 }
 
@@ -19,6 +22,8 @@ func NewSource(feed string) (*Source, error) {
 	f := Source{
 		feed:         feed,
 		entityByTrip: map[string]*pb.TripUpdate{},
+		vehicles:     map[string]*pb.VehiclePosition{},
+		hashes:       map[string]uint64{},
 	}
 	return &f, nil
 }
@@ -35,39 +40,97 @@ func (f *Source) GetTrip(tid string) (*pb.TripUpdate, bool) {
 	return nil, false
 }
 
-func (f *Source) processMessage(ctx context.Context, rtmsg *pb.FeedMessage) error {
+// snapshot returns an Update containing all current trip updates and vehicle positions.
+func (f *Source) snapshot() *Update {
+	update := &Update{Topic: f.feed}
+	for _, v := range f.entityByTrip {
+		update.TripUpdates = append(update.TripUpdates, v)
+	}
+	for _, v := range f.vehicles {
+		update.VehiclePositions = append(update.VehiclePositions, v)
+	}
+	return update
+}
+
+// processMessage replaces the current data and returns the trip updates and vehicle positions that changed.
+func (f *Source) processMessage(ctx context.Context, rtmsg *pb.FeedMessage) (*Update, error) {
 	f.msg = rtmsg
 	defaultTimestamp := rtmsg.GetHeader().GetTimestamp()
+	update := &Update{Topic: f.feed}
 	a := map[string]*pb.TripUpdate{}
+	vehicles := map[string]*pb.VehiclePosition{}
+	hashes := map[string]uint64{}
 	var alerts []*pb.Alert
 	for _, ent := range rtmsg.Entity {
 		if v := ent.TripUpdate; v != nil {
+			tid := v.GetTrip().GetTripId()
+			// Hash before setting default timestamp
+			key := "trip:" + tid
+			h := entityHash(v)
+			if prev, ok := f.hashes[key]; !ok || prev != h {
+				update.TripUpdates = append(update.TripUpdates, v)
+			}
+			hashes[key] = h
 			// Set default timestamp
 			if v.Timestamp == nil {
 				v.Timestamp = &defaultTimestamp
 			}
-			tid := v.GetTrip().GetTripId()
 			a[tid] = v
 		}
+		if v := ent.Vehicle; v != nil {
+			vid := vehicleKey(ent.GetId(), v)
+			key := "vehicle:" + vid
+			h := entityHash(v)
+			if prev, ok := f.hashes[key]; !ok || prev != h {
+				update.VehiclePositions = append(update.VehiclePositions, v)
+			}
+			hashes[key] = h
+			if v.Timestamp == nil {
+				v.Timestamp = &defaultTimestamp
+			}
+			vehicles[vid] = v
+		}
 		if v := ent.Alert; v != nil {
 			alerts = append(alerts, v)
 		}
-		// todo: vehicle positions...
 	}
-	log.For(ctx).Trace().Str("feed_id", f.feed).Int("trip_updates", len(a)).Int("alerts", len(alerts)).Msg("rtsource: processed data")
+	log.For(ctx).Trace().Str("feed_id", f.feed).Int("trip_updates", len(a)).Int("vehicle_positions", len(vehicles)).Int("alerts", len(alerts)).Msg("rtsource: processed data")
 	f.entityByTrip = a
+	f.vehicles = vehicles
+	f.hashes = hashes
 	f.alerts = alerts
-	return nil
+	return update, nil
 }
 
-func (f *Source) process(ctx context.Context, rtdata []byte) error {
+func (f *Source) process(ctx context.Context, rtdata []byte) (*Update, error) {
 	if len(rtdata) == 0 {
 		log.For(ctx).Trace().Str("feed_id", f.feed).Msg("rtsource: no data to process")
-		return nil
+		return nil, nil
 	}
 	rtmsg := pb.FeedMessage{}
 	if err := proto.Unmarshal(rtdata, &rtmsg); err != nil {
-		return err
+		return nil, err
 	}
 	return f.processMessage(ctx, &rtmsg)
 }
+
+// vehicleKey identifies a vehicle by vehicle ID, trip ID, or entity ID.
+func vehicleKey(entityId string, v *pb.VehiclePosition) string {
+	if vid := v.GetVehicle().GetId(); vid != "" {
+		return vid
+	}
+	if tid := v.GetTrip().GetTripId(); tid != "" {
+		return "trip:" + tid
+	}
+	return "entity:" + entityId
+}
+
+func entityHash(m proto.Message) uint64 {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write(data)
+	return h.Sum64()
+}
@@ -0,0 +1,355 @@
+package rtfinder
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/rt/pb"
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+// Update contains the trip updates and vehicle positions that changed when a message was processed for a topic.
+type Update struct {
+	Topic            string
+	TripUpdates      []*pb.TripUpdate
+	VehiclePositions []*pb.VehiclePosition
+}
+
+func (u *Update) empty() bool {
+	return u == nil || (len(u.TripUpdates) == 0 && len(u.VehiclePositions) == 0)
+}
+
+// Slow subscribers are disconnected once this many updates are waiting.
+const subscriberBufferSize = 16
+
+// broker sends updates to subscribers.
+type broker struct {
+	lock sync.Mutex
+	subs map[chan *Update]bool
+}
+
+func newBroker() *broker {
+	return &broker{subs: map[chan *Update]bool{}}
+}
+
+// subscribe returns a channel that receives the initial updates, followed by all published updates.
+// The channel is closed when the context is done, or when the subscriber falls too far behind.
+// Updates after the initial updates only contain changes, so a subscriber whose channel is closed
+// must subscribe again to receive the complete current state.
+func (b *broker) subscribe(ctx context.Context, initial []*Update) <-chan *Update {
+	ch := make(chan *Update, subscriberBufferSize+len(initial))
+	for _, u := range initial {
+		if !u.empty() {
+			ch <- u
+		}
+	}
+	b.lock.Lock()
+	b.subs[ch] = true
+	b.lock.Unlock()
+	go func() {
+		<-ctx.Done()
+		b.lock.Lock()
+		b.unsubscribe(ch)
+		b.lock.Unlock()
+	}()
+	return ch
+}
+
+func (b *broker) publish(u *Update) {
+	if u.empty() {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- u:
+		default:
+			// Do not block on slow subscribers; disconnect them instead of dropping updates
+			log.Info().Str("topic", u.Topic).Msg("cache: closing slow subscriber")
+			b.unsubscribe(ch)
+		}
+	}
+}
+
+// unsubscribe removes and closes a subscriber channel, if it is still open.
+// The lock must be held.
+func (b *broker) unsubscribe(ch chan *Update) {
+	if b.subs[ch] {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// SubscribeVehiclePositions returns a channel that receives matching vehicle positions each time new data is processed.
+// The first value contains all current matching vehicle positions; later values contain only changed vehicle positions.
+func (f *Finder) SubscribeVehiclePositions(ctx context.Context, where *model.VehiclePositionFilter) (<-chan []*model.VehiclePosition, error) {
+	permitted := f.permittedFeeds(model.PermsForContext(ctx))
+	updates := f.cache.Subscribe(ctx)
+	out := make(chan []*model.VehiclePosition, 1)
+	go func() {
+		defer close(out)
+		for update := range updates {
+			feed, _ := parseTopicKey(update.Topic)
+			if where != nil && where.FeedOnestopID != nil && *where.FeedOnestopID != feed {
+				continue
+			}
+			if !permitted(feed) {
+				continue
+			}
+			var ret []*model.VehiclePosition
+			for _, vp := range update.VehiclePositions {
+				if checkVehiclePosition(where, vp) {
+					ret = append(ret, makeVehiclePosition(feed, vp))
+				}
+			}
+			if len(ret) == 0 {
+				continue
+			}
+			select {
+			case out <- ret:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// SubscribeTripUpdates returns a channel that receives matching trip updates each time new data is processed.
+// The first value contains all current matching trip updates; later values contain only changed trip updates.
+func (f *Finder) SubscribeTripUpdates(ctx context.Context, where *model.TripUpdateFilter) (<-chan []*model.RTTripUpdate, error) {
+	permitted := f.permittedFeeds(model.PermsForContext(ctx))
+	updates := f.cache.Subscribe(ctx)
+	out := make(chan []*model.RTTripUpdate, 1)
+	go func() {
+		defer close(out)
+		for update := range updates {
+			feed, _ := parseTopicKey(update.Topic)
+			if where != nil && where.FeedOnestopID != nil && *where.FeedOnestopID != feed {
+				continue
+			}
+			if !permitted(feed) {
+				continue
+			}
+			var ret []*model.RTTripUpdate
+			for _, tu := range update.TripUpdates {
+				if checkTripUpdate(where, tu) {
+					ret = append(ret, makeTripUpdate(feed, tu))
+				}
+			}
+			if len(ret) == 0 {
+				continue
+			}
+			select {
+			case out <- ret:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// permittedFeeds returns a function that checks if RT data for a feed is visible with the permission filter.
+// Feeds are visible if the feed is public, or if the feed or its active feed version is explicitly allowed.
+// Unknown feeds are not visible. Results are remembered for the lifetime of the subscription.
+func (f *Finder) permittedFeeds(pf *model.PermFilter) func(string) bool {
+	checked := map[string]bool{}
+	return func(feed string) bool {
+		if ok, found := checked[feed]; found {
+			return ok
+		}
+		ok := false
+		if fp, found := f.lc.GetFeedPermission(feed); found {
+			ok = fp.Public ||
+				slices.Contains(pf.GetAllowedFeeds(), fp.FeedID) ||
+				(fp.FeedVersionID > 0 && slices.Contains(pf.GetAllowedFeedVersions(), fp.FeedVersionID))
+		}
+		checked[feed] = ok
+		return ok
+	}
+}
+
+func checkVehiclePosition(where *model.VehiclePositionFilter, vp *pb.VehiclePosition) bool {
+	if where == nil {
+		return true
+	}
+	if len(where.RouteIds) > 0 && !containsString(where.RouteIds, vp.GetTrip().GetRouteId()) {
+		return false
+	}
+	if bbox := where.Bbox; bbox != nil {
+		pos := vp.GetPosition()
+		if pos == nil {
+			return false
+		}
+		lon, lat := float64(pos.GetLongitude()), float64(pos.GetLatitude())
+		if lon < bbox.MinLon || lon > bbox.MaxLon || lat < bbox.MinLat || lat > bbox.MaxLat {
+			return false
+		}
+	}
+	return true
+}
+
+func checkTripUpdate(where *model.TripUpdateFilter, tu *pb.TripUpdate) bool {
+	if where == nil {
+		return true
+	}
+	if len(where.TripIds) > 0 && !containsString(where.TripIds, tu.GetTrip().GetTripId()) {
+		return false
+	}
+	if len(where.StopIds) > 0 {
+		found := false
+		for _, stu := range tu.GetStopTimeUpdate() {
+			if containsString(where.StopIds, stu.GetStopId()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func makeVehiclePosition(feed string, vp *pb.VehiclePosition) *model.VehiclePosition {
+	r := model.VehiclePosition{
+		FeedOnestopID: feed,
+		Vehicle:       makeVehicleDescriptor(vp.Vehicle),
+		Trip:          makeTripDescriptor(vp.Trip),
+	}
+	if pos := vp.Position; pos != nil {
+		p := tt.NewPoint(float64(pos.GetLongitude()), float64(pos.GetLatitude()))
+		r.Position = &p
+	}
+	if vp.CurrentStopSequence != nil {
+		v := int(vp.GetCurrentStopSequence())
+		r.CurrentStopSequence = &v
+	}
+	if vp.CurrentStatus != nil {
+		r.CurrentStatus = pstr(vp.CurrentStatus.String())
+	}
+	if vp.CongestionLevel != nil {
+		r.CongestionLevel = pstr(vp.CongestionLevel.String())
+	}
+	if vp.Timestamp != nil {
+		v := time.Unix(int64(vp.GetTimestamp()), 0).UTC()
+		r.Timestamp = &v
+	}
+	return &r
+}
+
+func makeTripUpdate(feed string, tu *pb.TripUpdate) *model.RTTripUpdate {
+	r := model.RTTripUpdate{
+		FeedOnestopID: feed,
+		Trip:          makeTripDescriptor(tu.Trip),
+		Vehicle:       makeVehicleDescriptor(tu.Vehicle),
+	}
+	if tu.Timestamp != nil {
+		v := time.Unix(int64(tu.GetTimestamp()), 0).UTC()
+		r.Timestamp = &v
+	}
+	if tu.Delay != nil {
+		v := int(tu.GetDelay())
+		r.Delay = &v
+	}
+	for _, stu := range tu.StopTimeUpdate {
+		rstu := model.RTTripStopTimeUpdate{
+			StopID:    pstr(stu.GetStopId()),
+			Arrival:   makeStopTimeEvent(stu.Arrival),
+			Departure: makeStopTimeEvent(stu.Departure),
+		}
+		if stu.StopSequence != nil {
+			v := int(stu.GetStopSequence())
+			rstu.StopSequence = &v
+		}
+		if stu.ScheduleRelationship != nil {
+			rstu.ScheduleRelationship = pstr(stu.ScheduleRelationship.String())
+		}
+		r.StopTimeUpdates = append(r.StopTimeUpdates, &rstu)
+	}
+	return &r
+}
+
+func makeStopTimeEvent(ste *pb.TripUpdate_StopTimeEvent) *model.RTStopTimeEvent {
+	if ste == nil {
+		return nil
+	}
+	r := model.RTStopTimeEvent{}
+	if ste.Time != nil {
+		v := int(ste.GetTime())
+		r.Time = &v
+	}
+	if ste.Delay != nil {
+		v := int(ste.GetDelay())
+		r.Delay = &v
+	}
+	if ste.Uncertainty != nil {
+		v := int(ste.GetUncertainty())
+		r.Uncertainty = &v
+	}
+	return &r
+}
+
+func makeTripDescriptor(td *pb.TripDescriptor) *model.RTTripDescriptor {
+	if td == nil {
+		return nil
+	}
+	r := model.RTTripDescriptor{
+		TripID:  pstr(td.GetTripId()),
+		RouteID: pstr(td.GetRouteId()),
+	}
+	if td.DirectionId != nil {
+		v := int(td.GetDirectionId())
+		r.DirectionID = &v
+	}
+	if td.StartTime != nil {
+		if v, err := tt.NewSecondsFromString(td.GetStartTime()); err == nil {
+			r.StartTime = &v
+		}
+	}
+	if td.StartDate != nil {
+		if v, err := tt.ParseDate(td.GetStartDate()); err == nil {
+			r.StartDate = &v
+		}
+	}
+	if td.ScheduleRelationship != nil {
+		r.ScheduleRelationship = pstr(td.ScheduleRelationship.String())
+	}
+	return &r
+}
+
+func makeVehicleDescriptor(vd *pb.VehicleDescriptor) *model.RTVehicleDescriptor {
+	if vd == nil {
+		return nil
+	}
+	return &model.RTVehicleDescriptor{
+		ID:           pstr(vd.GetId()),
+		Label:        pstr(vd.GetLabel()),
+		LicensePlate: pstr(vd.GetLicensePlate()),
+	}
+}
+
+// parseTopicKey returns the feed onestop ID and url type for a topic key created by getTopicKey.
+func parseTopicKey(topicKey string) (string, string) {
+	s := strings.TrimPrefix(topicKey, "rtdata:")
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
@@ -0,0 +1,235 @@
+package rtfinder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/interline-io/transitland-lib/dmfr"
+	"github.com/interline-io/transitland-lib/internal/testdb"
+	"github.com/interline-io/transitland-lib/rt/pb"
+	"github.com/interline-io/transitland-lib/server/auth/authz"
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestSubscribeFinder returns a finder with a public feed "f-test" and a private feed "f-private".
+func newTestSubscribeFinder(t *testing.T, rtCache Cache) (*Finder, map[string]int) {
+	t.Helper()
+	atx := testdb.TempSqliteAdapter()
+	feedIds := map[string]int{}
+	for _, feed := range []struct {
+		onestopId string
+		public    bool
+	}{{"f-test", true}, {"f-private", false}} {
+		ent := dmfr.Feed{FeedID: feed.onestopId}
+		ent.ID = testdb.ShouldInsert(t, atx, &ent)
+		testdb.ShouldInsert(t, atx, &dmfr.FeedState{FeedID: ent.ID, Public: feed.public})
+		feedIds[feed.onestopId] = ent.ID
+	}
+	return NewFinder(rtCache, atx.DBX()), feedIds
+}
+
+// testChecker allows access to a fixed set of feeds.
+type testChecker struct {
+	authz.UnimplementedCheckerServer
+	feedIds []int
+}
+
+func (c *testChecker) FeedList(context.Context, *authz.FeedListRequest) (*authz.FeedListResponse, error) {
+	ret := &authz.FeedListResponse{}
+	for _, id := range c.feedIds {
+		ret.Feeds = append(ret.Feeds, &authz.Feed{Id: int64(id)})
+	}
+	return ret, nil
+}
+
+func (c *testChecker) FeedVersionList(context.Context, *authz.FeedVersionListRequest) (*authz.FeedVersionListResponse, error) {
+	return &authz.FeedVersionListResponse{}, nil
+}
+
+func newTestVehicleMessage(t *testing.T, positions map[string][2]float32) []byte {
+	t.Helper()
+	v := "2.0"
+	ts := uint64(1700000000)
+	msg := pb.FeedMessage{Header: &pb.FeedHeader{GtfsRealtimeVersion: &v, Timestamp: &ts}}
+	for vid, pos := range positions {
+		vid := vid
+		routeId := "r-" + vid
+		msg.Entity = append(msg.Entity, &pb.FeedEntity{
+			Id: &vid,
+			Vehicle: &pb.VehiclePosition{
+				Vehicle:  &pb.VehicleDescriptor{Id: &vid},
+				Trip:     &pb.TripDescriptor{RouteId: &routeId},
+				Position: &pb.Position{Longitude: &pos[0], Latitude: &pos[1]},
+			},
+		})
+	}
+	data, err := proto.Marshal(&msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func receiveVehicles(t *testing.T, ch <-chan []*model.VehiclePosition) []string {
+	t.Helper()
+	select {
+	case vps := <-ch:
+		var ret []string
+		for _, vp := range vps {
+			ret = append(ret, *vp.Vehicle.ID)
+		}
+		return ret
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for vehicle positions")
+	}
+	return nil
+}
+
+func TestFinder_SubscribeVehiclePositions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	topic := getTopicKey("f-test", "realtime_vehicle_positions")
+	rtCache := NewLocalCache()
+	finder, _ := newTestSubscribeFinder(t, rtCache)
+	if err := rtCache.AddData(ctx, topic, newTestVehicleMessage(t, map[string][2]float32{
+		"a": {-122.0, 37.0},
+	})); err != nil {
+		t.Fatal(err)
+	}
+	feedOnestopId := "f-test"
+	all, err := finder.SubscribeVehiclePositions(ctx, &model.VehiclePositionFilter{FeedOnestopID: &feedOnestopId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bbox, err := finder.SubscribeVehiclePositions(ctx, &model.VehiclePositionFilter{
+		Bbox: &model.BoundingBox{MinLon: -123, MinLat: 36, MaxLon: -121, MaxLat: 38},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes, err := finder.SubscribeVehiclePositions(ctx, &model.VehiclePositionFilter{RouteIds: []string{"r-b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Current vehicle positions are sent first
+	assert.ElementsMatch(t, []string{"a"}, receiveVehicles(t, all))
+	assert.ElementsMatch(t, []string{"a"}, receiveVehicles(t, bbox))
+	// Only changed vehicle positions are sent for later messages
+	if err := rtCache.AddData(ctx, topic, newTestVehicleMessage(t, map[string][2]float32{
+		"a": {-122.0, 37.0},
+		"b": {-100.0, 37.0},
+	})); err != nil {
+		t.Fatal(err)
+	}
+	assert.ElementsMatch(t, []string{"b"}, receiveVehicles(t, all))
+	assert.ElementsMatch(t, []string{"b"}, receiveVehicles(t, routes))
+	if err := rtCache.AddData(ctx, topic, newTestVehicleMessage(t, map[string][2]float32{
+		"a": {-122.1, 37.1},
+		"b": {-100.0, 37.0},
+	})); err != nil {
+		t.Fatal(err)
+	}
+	assert.ElementsMatch(t, []string{"a"}, receiveVehicles(t, all))
+	// Vehicle b was outside the bounding box
+	assert.ElementsMatch(t, []string{"a"}, receiveVehicles(t, bbox))
+	// Channels are closed when the context is done
+	cancel()
+	for range all {
+	}
+}
+
+func TestFinder_SubscribeTripUpdates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rtCache := NewLocalCache()
+	finder, _ := newTestSubscribeFinder(t, rtCache)
+	tripUpdates, err := finder.SubscribeTripUpdates(ctx, &model.TripUpdateFilter{StopIds: []string{"s2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := "2.0"
+	msg := pb.FeedMessage{Header: &pb.FeedHeader{GtfsRealtimeVersion: &v}}
+	for _, trip := range []struct{ tripId, stopId string }{{"t1", "s1"}, {"t2", "s2"}} {
+		tripId, stopId := trip.tripId, trip.stopId
+		delay := int32(60)
+		msg.Entity = append(msg.Entity, &pb.FeedEntity{
+			Id: &tripId,
+			TripUpdate: &pb.TripUpdate{
+				Trip: &pb.TripDescriptor{TripId: &tripId},
+				StopTimeUpdate: []*pb.TripUpdate_StopTimeUpdate{
+					{StopId: &stopId, Arrival: &pb.TripUpdate_StopTimeEvent{Delay: &delay}},
+				},
+			},
+		})
+	}
+	data, err := proto.Marshal(&msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rtCache.AddData(ctx, getTopicKey("f-test", "realtime_trip_updates"), data); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case tus := <-tripUpdates:
+		if assert.Equal(t, 1, len(tus)) {
+			tu := tus[0]
+			assert.Equal(t, "f-test", tu.FeedOnestopID)
+			assert.Equal(t, "t2", *tu.Trip.TripID)
+			if assert.Equal(t, 1, len(tu.StopTimeUpdates)) {
+				assert.Equal(t, 60, *tu.StopTimeUpdates[0].Arrival.Delay)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for trip updates")
+	}
+}
+
+func TestFinder_SubscribeVehiclePositions_Permissions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rtCache := NewLocalCache()
+	finder, feedIds := newTestSubscribeFinder(t, rtCache)
+	public, err := finder.SubscribeVehiclePositions(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowedCtx := model.WithPerms(ctx, &testChecker{feedIds: []int{feedIds["f-private"]}})
+	allowed, err := finder.SubscribeVehiclePositions(allowedCtx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, topic := range []struct{ feed, vehicle string }{{"f-private", "p"}, {"f-unknown", "u"}, {"f-test", "a"}} {
+		if err := rtCache.AddData(ctx, getTopicKey(topic.feed, "realtime_vehicle_positions"), newTestVehicleMessage(t, map[string][2]float32{
+			topic.vehicle: {-122.0, 37.0},
+		})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Restricted and unknown feeds are not delivered without permission
+	assert.ElementsMatch(t, []string{"a"}, receiveVehicles(t, public))
+	assert.ElementsMatch(t, []string{"p"}, receiveVehicles(t, allowed))
+	assert.ElementsMatch(t, []string{"a"}, receiveVehicles(t, allowed))
+}
+
+func TestBroker_SlowSubscriber(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := newBroker()
+	ch := b.subscribe(ctx, nil)
+	for i := 0; i < subscriberBufferSize+1; i++ {
+		b.publish(&Update{Topic: "test", TripUpdates: []*pb.TripUpdate{{}}})
+	}
+	// Buffered updates are delivered, then the channel is closed instead of dropping updates
+	count := 0
+	for range ch {
+		count++
+	}
+	assert.Equal(t, subscriberBufferSize, count)
+	assert.Equal(t, 0, len(b.subs))
+	// Closing the context after the subscriber was removed is safe
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+}
@@ -13,3 +13,9 @@ type directionsResolver struct{ *Resolver }
 func (r *directionsResolver) Directions(ctx context.Context, where model.DirectionRequest) (*model.Directions, error) {
 	return directions.HandleRequest(ctx, "", where)
 }
+
+type itineraryResolver struct{ *Resolver }
+
+func (r *itineraryResolver) Fares(ctx context.Context, obj *model.Itinerary, riderCategoryID *string, fareMediaID *string) ([]*model.ItineraryFare, error) {
+	return directions.ItineraryFares(ctx, obj, riderCategoryID, fareMediaID)
+}
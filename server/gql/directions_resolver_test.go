@@ -0,0 +1,83 @@
+package gql
+
+import (
+	"testing"
+)
+
+func TestDirectionsResolver_Fares(t *testing.T) {
+	// The EX-fares feed is testdata/gtfs-examples/example-fares, and is the only active feed in this area
+	t.Setenv("TL_ROUTER_TRANSIT", "raptor")
+	q := `query($depart_at: Time, $rider_category_id: String, $fare_media_id: String) {
+		directions(where: {mode: TRANSIT, from: {lon: -116.751677, lat: 36.916682}, to: {lon: -116.81797, lat: 36.88208}, depart_at: $depart_at}) {
+			success
+			itineraries {
+				legs {
+					trip {
+						feed_version_sha1
+						route {
+							route_id
+						}
+					}
+				}
+				fares(rider_category_id: $rider_category_id, fare_media_id: $fare_media_id) {
+					total
+					currency
+					unmatched_legs
+					products {
+						fare_product_id
+						legs
+						transfer
+					}
+				}
+			}
+		}
+	}`
+	// Stagecoach to Bullfrog on a weekday: the STBA shuttle to the airport, then route AB during the morning peak
+	vars := hw{"depart_at": "2008-01-02T06:45:00-08:00", "fare_media_id": "card"}
+	testcases := []testcase{
+		{
+			name:         "routes",
+			query:        q,
+			vars:         vars,
+			selector:     "directions.itineraries.0.legs.#.trip.route.route_id",
+			selectExpect: []string{"STBA", "AB"},
+		},
+		{
+			name:         "fare totals",
+			query:        q,
+			vars:         vars,
+			selector:     "directions.itineraries.0.fares.#.total",
+			selectExpect: []string{"3.25", "6.75"},
+		},
+		{
+			name:         "fare products with transfer",
+			query:        q,
+			vars:         vars,
+			selector:     "directions.itineraries.0.fares.0.products.#.fare_product_id",
+			selectExpect: []string{"local_fare", "upgrade"},
+		},
+		{
+			name:         "transfer product legs",
+			query:        q,
+			vars:         vars,
+			selector:     "directions.itineraries.0.fares.0.products.#(transfer==true).legs",
+			selectExpect: []string{"1", "2"},
+		},
+		{
+			name:         "fare products without transfer",
+			query:        q,
+			vars:         vars,
+			selector:     "directions.itineraries.0.fares.1.products.#.fare_product_id",
+			selectExpect: []string{"local_fare", "regional_peak"},
+		},
+		{
+			name:         "rider category",
+			query:        q,
+			vars:         hw{"depart_at": "2008-01-02T06:45:00-08:00", "rider_category_id": "senior"},
+			selector:     "directions.itineraries.0.fares.#.total",
+			selectExpect: []string{"2.5", "3.5"},
+		},
+	}
+	c := newFaresTestClient(t)
+	queryTestcases(t, c, testcases)
+}
@@ -0,0 +1,75 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/interline-io/transitland-lib/server/model"
+)
+
+// FARE PRODUCTS
+
+type fareProductResolver struct{ *Resolver }
+
+func (r *fareProductResolver) FareMedia(ctx context.Context, obj *model.FareProduct) (*model.FareMedia, error) {
+	if !obj.FareMediaID.Valid {
+		return nil, nil
+	}
+	return LoaderFor(ctx).FareMediaByIDs.Load(ctx, obj.FareMediaID.Int())()
+}
+
+// FARE LEG RULES
+
+type fareLegRuleResolver struct{ *Resolver }
+
+func (r *fareLegRuleResolver) Network(ctx context.Context, obj *model.FareLegRule) (*model.Network, error) {
+	if !obj.NetworkID.Valid {
+		return nil, nil
+	}
+	return LoaderFor(ctx).NetworksByIDs.Load(ctx, obj.NetworkID.Int())()
+}
+
+func (r *fareLegRuleResolver) FromArea(ctx context.Context, obj *model.FareLegRule) (*model.Area, error) {
+	if !obj.FromAreaID.Valid {
+		return nil, nil
+	}
+	return LoaderFor(ctx).AreasByIDs.Load(ctx, obj.FromAreaID.Int())()
+}
+
+func (r *fareLegRuleResolver) ToArea(ctx context.Context, obj *model.FareLegRule) (*model.Area, error) {
+	if !obj.ToAreaID.Valid {
+		return nil, nil
+	}
+	return LoaderFor(ctx).AreasByIDs.Load(ctx, obj.ToAreaID.Int())()
+}
+
+func (r *fareLegRuleResolver) FareProducts(ctx context.Context, obj *model.FareLegRule, limit *int) ([]*model.FareProduct, error) {
+	return LoaderFor(ctx).FareProductsByFareLegRuleIDs.Load(ctx, fareProductLoaderParam{FareLegRuleID: obj.ID, Limit: checkLimit(limit)})()
+}
+
+// FARE TRANSFER RULES
+
+type fareTransferRuleResolver struct{ *Resolver }
+
+func (r *fareTransferRuleResolver) FareProducts(ctx context.Context, obj *model.FareTransferRule, limit *int) ([]*model.FareProduct, error) {
+	return LoaderFor(ctx).FareProductsByFareTransferRuleIDs.Load(ctx, fareProductLoaderParam{FareTransferRuleID: obj.ID, Limit: checkLimit(limit)})()
+}
+
+// AREAS
+
+type areaResolver struct{ *Resolver }
+
+func (r *areaResolver) Stops(ctx context.Context, obj *model.Area, limit *int, where *model.StopFilter) ([]*model.Stop, error) {
+	return LoaderFor(ctx).StopsByAreaIDs.Load(ctx, stopLoaderParam{AreaID: obj.ID, Limit: checkLimit(limit), Where: where})()
+}
+
+func (r *areaResolver) FareLegRules(ctx context.Context, obj *model.Area, limit *int, where *model.FareLegRuleFilter) ([]*model.FareLegRule, error) {
+	return LoaderFor(ctx).FareLegRulesByAreaIDs.Load(ctx, fareLegRuleLoaderParam{AreaID: obj.ID, Limit: checkLimit(limit), Where: where})()
+}
+
+// NETWORKS
+
+type networkResolver struct{ *Resolver }
+
+func (r *networkResolver) FareLegRules(ctx context.Context, obj *model.Network, limit *int, where *model.FareLegRuleFilter) ([]*model.FareLegRule, error) {
+	return LoaderFor(ctx).FareLegRulesByNetworkIDs.Load(ctx, fareLegRuleLoaderParam{NetworkID: obj.ID, Limit: checkLimit(limit), Where: where})()
+}
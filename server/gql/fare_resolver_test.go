@@ -0,0 +1,243 @@
+package gql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/interline-io/transitland-lib/internal/testconfig"
+	"github.com/interline-io/transitland-lib/server/auth/authn"
+	"github.com/interline-io/transitland-lib/server/auth/authz"
+	"github.com/interline-io/transitland-lib/server/auth/mw/usercheck"
+	"github.com/interline-io/transitland-lib/server/model"
+)
+
+func TestFareResolver(t *testing.T) {
+	// The EX-fares feed is testdata/gtfs-examples/example-fares
+	vars := hw{"feed_version_sha1": "376f03fe1e472dda6594fd1e6717faf9de4750f5"}
+	testcases := []testcase{
+		{
+			name:         "fare products",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { fare_products { fare_product_id } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.fare_products.#.fare_product_id",
+			selectExpect: []string{"local_fare", "local_fare", "local_fare", "regional_peak", "regional_peak", "regional_offpeak", "regional_offpeak", "valley_fare", "upgrade"},
+		},
+		{
+			name:         "fare products filter fare_product_id",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { fare_products(where:{fare_product_id:"local_fare"}) { amount } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.fare_products.#.amount",
+			selectExpect: []string{"2", "1.75", "1"},
+		},
+		{
+			name:         "fare products filter rider_category_id",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { fare_products(where:{rider_category_id:"senior"}) { fare_product_id } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.fare_products.#.fare_product_id",
+			selectExpect: []string{"local_fare", "regional_peak", "regional_offpeak"},
+		},
+		{
+			name:         "fare products filter no match",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { fare_products(where:{fare_product_id:"test"}) { fare_product_id } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.fare_products.#.fare_product_id",
+			selectExpect: []string{},
+		},
+		{
+			name:         "fare product fare media",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { fare_products(where:{fare_product_id:"local_fare"}) { fare_media { fare_media_id } } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.fare_products.#.fare_media.fare_media_id",
+			selectExpect: []string{"cash", "card"},
+		},
+		{
+			name:         "fare leg rules",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { fare_leg_rules { fare_product_id } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.fare_leg_rules.#.fare_product_id",
+			selectExpect: []string{"local_fare", "regional_peak", "regional_offpeak", "valley_fare"},
+		},
+		{
+			name:         "fare leg rules filter leg_group_id",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { fare_leg_rules(where:{leg_group_id:"regional"}) { fare_product_id } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.fare_leg_rules.#.fare_product_id",
+			selectExpect: []string{"regional_peak", "regional_offpeak"},
+		},
+		{
+			name:         "fare leg rule network",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { fare_leg_rules { network { network_id } } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.fare_leg_rules.#.network.network_id",
+			selectExpect: []string{"local", "regional", "regional", "regional"},
+		},
+		{
+			name:         "fare leg rule to area",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { fare_leg_rules(where:{fare_product_id:"valley_fare"}) { to_area { area_id } } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.fare_leg_rules.#.to_area.area_id",
+			selectExpect: []string{"valley"},
+		},
+		{
+			name:         "fare leg rule fare products",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { fare_leg_rules(where:{fare_product_id:"regional_peak"}) { fare_products { amount } } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.fare_leg_rules.0.fare_products.#.amount",
+			selectExpect: []string{"5", "2.5"},
+		},
+		{
+			name:         "fare transfer rules",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { fare_transfer_rules { to_leg_group_id } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.fare_transfer_rules.#.to_leg_group_id",
+			selectExpect: []string{"local", "regional"},
+		},
+		{
+			name:         "fare transfer rule fare products",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { fare_transfer_rules { fare_products { fare_product_id } } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.fare_transfer_rules.#.fare_products.#.fare_product_id|@flatten",
+			selectExpect: []string{"upgrade"},
+		},
+		{
+			name:         "fare media",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { fare_media { fare_media_id } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.fare_media.#.fare_media_id",
+			selectExpect: []string{"cash", "card"},
+		},
+		{
+			name:         "areas",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { areas { area_id } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.areas.#.area_id",
+			selectExpect: []string{"town", "airport", "resort", "valley"},
+		},
+		{
+			name:         "area stops",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { areas { area_id stops { stop_id } } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.areas.#(area_id==\"town\").stops.#.stop_id",
+			selectExpect: []string{"BULLFROG", "STAGECOACH", "NADAV", "NANAA", "DADAN", "EMSI"},
+		},
+		{
+			name:         "area fare leg rules",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { areas { area_id fare_leg_rules { fare_product_id } } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.areas.#(area_id==\"valley\").fare_leg_rules.#.fare_product_id",
+			selectExpect: []string{"valley_fare"},
+		},
+		{
+			name:         "area fare leg rules filter",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { areas { area_id fare_leg_rules(where:{leg_group_id:"regional"}) { fare_product_id } } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.areas.#(area_id==\"valley\").fare_leg_rules.#.fare_product_id",
+			selectExpect: []string{},
+		},
+		{
+			name:         "networks",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { networks { network_id } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.networks.#.network_id",
+			selectExpect: []string{"local", "regional"},
+		},
+		{
+			name:         "network fare leg rules",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { networks { network_id fare_leg_rules { fare_product_id } } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.networks.#(network_id==\"regional\").fare_leg_rules.#.fare_product_id",
+			selectExpect: []string{"regional_peak", "regional_offpeak", "valley_fare"},
+		},
+		{
+			name:         "network fare leg rules filter",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { networks { network_id fare_leg_rules(where:{fare_product_id:"valley_fare"}) { fare_product_id } } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.networks.#(network_id==\"regional\").fare_leg_rules.#.fare_product_id",
+			selectExpect: []string{"valley_fare"},
+		},
+		{
+			name:         "network fare leg rules limit",
+			query:        `query($feed_version_sha1:String!) { feed_versions(where:{sha1:$feed_version_sha1}) { networks { network_id fare_leg_rules(limit:1) { fare_product_id } } } }`,
+			vars:         vars,
+			selector:     "feed_versions.0.networks.#.fare_leg_rules.#.fare_product_id|@flatten",
+			selectExpect: []string{"local_fare", "regional_peak"},
+		},
+		{
+			name:         "route networks",
+			query:        `query($feed_version_sha1:String!) { routes(where:{feed_version_sha1:$feed_version_sha1}) { route_id networks { network_id } } }`,
+			vars:         vars,
+			selector:     "routes.#(route_id==\"AB\").networks.#.network_id",
+			selectExpect: []string{"regional"},
+		},
+		{
+			name:         "route networks local",
+			query:        `query($feed_version_sha1:String!) { routes(where:{feed_version_sha1:$feed_version_sha1}) { route_id networks { network_id } } }`,
+			vars:         vars,
+			selector:     "routes.#(route_id==\"CITY\").networks.#.network_id",
+			selectExpect: []string{"local"},
+		},
+		{
+			name:         "route networks none",
+			query:        `query($feed_version_sha1:String!) { routes(where:{feed_version_sha1:$feed_version_sha1, route_id:"NOTRIPS"}) { networks { network_id } } }`,
+			vars:         vars,
+			selector:     "routes.0.networks.#.network_id",
+			selectExpect: []string{},
+		},
+		{
+			name:         "stop areas",
+			query:        `query($feed_version_sha1:String!) { stops(where:{feed_version_sha1:$feed_version_sha1, stop_id:"AMV"}) { areas { area_id } } }`,
+			vars:         vars,
+			selector:     "stops.0.areas.#.area_id",
+			selectExpect: []string{"valley"},
+		},
+		{
+			name:         "stop areas fare leg rules",
+			query:        `query($feed_version_sha1:String!) { stops(where:{feed_version_sha1:$feed_version_sha1, stop_id:"AMV"}) { areas { fare_leg_rules { fare_product_id } } } }`,
+			vars:         vars,
+			selector:     "stops.0.areas.0.fare_leg_rules.#.fare_product_id",
+			selectExpect: []string{"valley_fare"},
+		},
+		{
+			name:   "stop areas none",
+			query:  `query { stops(where:{feed_version_sha1:"43e2278aa272879c79460582152b04e7487f0493", stop_id:"NOTRIPS"}) { areas { area_id } } }`,
+			expect: `{"stops":[{"areas":[]}]}`,
+		},
+		{
+			name:   "no fares",
+			query:  `query { feed_versions(where:{sha1:"e535eb2b3b9ac3ef15d82c56575e914575e732e0"}) { fare_products { fare_product_id } fare_leg_rules { fare_product_id } areas { area_id } networks { network_id } } }`,
+			expect: `{"feed_versions":[{"areas":[],"fare_leg_rules":[],"fare_products":[],"networks":[]}]}`,
+		},
+	}
+	c := newFaresTestClient(t)
+	queryTestcases(t, c, testcases)
+}
+
+// newFaresTestClient returns a client that can access the non-public EX-fares feed.
+func newFaresTestClient(t testing.TB) *client.Client {
+	cfg := testconfig.Config(t, testconfig.Options{WhenUtc: DEFAULT_WHEN})
+	feedID := 0
+	if err := cfg.Finder.DBX().QueryRowxContext(context.Background(), `select id from current_feeds where onestop_id = $1`, "EX-fares").Scan(&feedID); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Checker = &faresTestChecker{feedID: feedID}
+	srv, _ := NewServer()
+	srvMiddleware := usercheck.NewUserDefaultMiddleware(func() authn.User {
+		return authn.NewCtxUser("testuser", "", "").WithRoles("testrole")
+	})
+	return client.New(srvMiddleware(model.AddConfigAndPerms(cfg, srv)))
+}
+
+// faresTestChecker allows access to a single feed.
+type faresTestChecker struct {
+	authz.UnimplementedCheckerServer
+	feedID int
+}
+
+func (c *faresTestChecker) FeedList(context.Context, *authz.FeedListRequest) (*authz.FeedListResponse, error) {
+	return &authz.FeedListResponse{Feeds: []*authz.Feed{{Id: int64(c.feedID)}}}, nil
+}
+
+func (c *faresTestChecker) FeedVersionList(context.Context, *authz.FeedVersionListRequest) (*authz.FeedVersionListResponse, error) {
+	return &authz.FeedVersionListResponse{}, nil
+}
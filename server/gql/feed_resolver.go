@@ -47,6 +47,10 @@ func (r *feedResolver) FeedFetches(ctx context.Context, obj *model.Feed, limit *
 	return LoaderFor(ctx).FeedFetchesByFeedIDs.Load(ctx, feedFetchLoaderParam{FeedID: obj.ID, Limit: checkLimit(limit), Where: where})()
 }
 
+func (r *feedResolver) MdsTripSummary(ctx context.Context, obj *model.Feed, limit *int, where *model.MdsTripSummaryFilter) ([]*model.MdsTripSummary, error) {
+	return LoaderFor(ctx).MdsTripSummariesByFeedIDs.Load(ctx, mdsTripSummaryLoaderParam{FeedID: obj.ID, Limit: checkLimit(limit), Where: where})()
+}
+
 func (r *feedResolver) Spec(ctx context.Context, obj *model.Feed) (*model.FeedSpecTypes, error) {
 	var s model.FeedSpecTypes
 	s2 := s.FromDBString(obj.Spec)
@@ -439,3 +439,77 @@ func TestFeedResolver_License(t *testing.T) {
 	c, _ := newTestClient(t)
 	queryTestcases(t, c, testcases)
 }
+
+func TestFeedResolver_MdsTripSummary(t *testing.T) {
+	q := `query($where: MdsTripSummaryFilter) {feeds(where:{onestop_id:"test-gbfs"}) {mds_trip_summary(where: $where) {hour zone trip_count avg_duration avg_distance}}}`
+	testcases := []testcase{
+		{
+			name:         "zones",
+			query:        q,
+			selector:     "feeds.0.mds_trip_summary.#.zone",
+			selectExpect: []string{"9q8yyk", "9q8yym"},
+		},
+		{
+			name:         "trip_count",
+			query:        q,
+			selector:     "feeds.0.mds_trip_summary.#.trip_count",
+			selectExpect: []string{"2", "1"},
+		},
+		{
+			name:         "avg_duration",
+			query:        q,
+			selector:     "feeds.0.mds_trip_summary.#.avg_duration",
+			selectExpect: []string{"900", "300"},
+		},
+		{
+			name:         "avg_distance",
+			query:        q,
+			selector:     "feeds.0.mds_trip_summary.#.avg_distance",
+			selectExpect: []string{"2000", "800"},
+		},
+		{
+			name:         "zone_precision",
+			query:        q,
+			vars:         hw{"where": hw{"zone_precision": 5}},
+			selector:     "feeds.0.mds_trip_summary.#.trip_count",
+			selectExpect: []string{"2", "1"},
+		},
+		{
+			name:               "zone_precision zones",
+			query:              q,
+			vars:               hw{"where": hw{"zone_precision": 5}},
+			selector:           "feeds.0.mds_trip_summary.#.zone",
+			selectExpectUnique: []string{"9q8yy"},
+		},
+		{
+			name:         "vehicle_type",
+			query:        q,
+			vars:         hw{"where": hw{"vehicle_type": "bicycle"}},
+			selector:     "feeds.0.mds_trip_summary.#.zone",
+			selectExpect: []string{"9q8yym"},
+		},
+		{
+			name:         "start_time",
+			query:        q,
+			vars:         hw{"where": hw{"start_time": "2024-05-01T11:00:00Z"}},
+			selector:     "feeds.0.mds_trip_summary.#.trip_count",
+			selectExpect: []string{"1"},
+		},
+		{
+			name:         "end_time",
+			query:        q,
+			vars:         hw{"where": hw{"end_time": "2024-05-01T11:00:00Z"}},
+			selector:     "feeds.0.mds_trip_summary.#.trip_count",
+			selectExpect: []string{"2"},
+		},
+		{
+			name:         "no trips",
+			query:        q,
+			vars:         hw{"where": hw{"start_time": "2025-01-01T00:00:00Z"}},
+			selector:     "feeds.0.mds_trip_summary.#.zone",
+			selectExpect: []string{},
+		},
+	}
+	c, _ := newTestClient(t)
+	queryTestcases(t, c, testcases)
+}
@@ -53,6 +53,10 @@ func (r *feedVersionResolver) ServiceWindow(ctx context.Context, obj *model.Feed
 	return LoaderFor(ctx).FeedVersionServiceWindowByFeedVersionIDs.Load(ctx, obj.ID)()
 }
 
+func (r *feedVersionResolver) ChangesFromPrevious(ctx context.Context, obj *model.FeedVersion) (*model.FeedVersionChanges, error) {
+	return LoaderFor(ctx).FeedVersionChangesByFeedVersionIDs.Load(ctx, obj.ID)()
+}
+
 func (r *feedVersionResolver) ServiceLevels(ctx context.Context, obj *model.FeedVersion, limit *int, where *model.FeedVersionServiceLevelFilter) ([]*model.FeedVersionServiceLevel, error) {
 	return LoaderFor(ctx).FeedVersionServiceLevelsByFeedVersionIDs.Load(ctx, feedVersionServiceLevelLoaderParam{FeedVersionID: obj.ID, Limit: checkLimit(limit), Where: where})()
 }
@@ -73,6 +77,32 @@ func (r *feedVersionResolver) Segments(ctx context.Context, obj *model.FeedVersi
 	return LoaderFor(ctx).SegmentsByFeedVersionIDs.Load(ctx, segmentLoaderParam{FeedVersionID: obj.ID, Limit: limit})()
 }
 
+// FARES
+
+func (r *feedVersionResolver) FareProducts(ctx context.Context, obj *model.FeedVersion, limit *int, where *model.FareProductFilter) ([]*model.FareProduct, error) {
+	return LoaderFor(ctx).FareProductsByFeedVersionIDs.Load(ctx, fareProductLoaderParam{FeedVersionID: obj.ID, Limit: checkLimit(limit), Where: where})()
+}
+
+func (r *feedVersionResolver) FareLegRules(ctx context.Context, obj *model.FeedVersion, limit *int, where *model.FareLegRuleFilter) ([]*model.FareLegRule, error) {
+	return LoaderFor(ctx).FareLegRulesByFeedVersionIDs.Load(ctx, fareLegRuleLoaderParam{FeedVersionID: obj.ID, Limit: checkLimit(limit), Where: where})()
+}
+
+func (r *feedVersionResolver) FareTransferRules(ctx context.Context, obj *model.FeedVersion, limit *int) ([]*model.FareTransferRule, error) {
+	return LoaderFor(ctx).FareTransferRulesByFeedVersionIDs.Load(ctx, fareTransferRuleLoaderParam{FeedVersionID: obj.ID, Limit: checkLimit(limit)})()
+}
+
+func (r *feedVersionResolver) FareMedia(ctx context.Context, obj *model.FeedVersion, limit *int) ([]*model.FareMedia, error) {
+	return LoaderFor(ctx).FareMediaByFeedVersionIDs.Load(ctx, fareMediaLoaderParam{FeedVersionID: obj.ID, Limit: checkLimit(limit)})()
+}
+
+func (r *feedVersionResolver) Areas(ctx context.Context, obj *model.FeedVersion, limit *int) ([]*model.Area, error) {
+	return LoaderFor(ctx).AreasByFeedVersionIDs.Load(ctx, areaLoaderParam{FeedVersionID: obj.ID, Limit: checkLimit(limit)})()
+}
+
+func (r *feedVersionResolver) Networks(ctx context.Context, obj *model.FeedVersion, limit *int) ([]*model.Network, error) {
+	return LoaderFor(ctx).NetworksByFeedVersionIDs.Load(ctx, networkLoaderParam{FeedVersionID: obj.ID, Limit: checkLimit(limit)})()
+}
+
 // FEED VERSION GTFS IMPORT
 
 type feedVersionGtfsImportResolver struct{ *Resolver }
@@ -101,6 +131,14 @@ func (r *feedVersionGtfsImportResolver) SkipEntityMarkedCount(ctx context.Contex
 	return obj.SkipEntityMarkedCount, nil
 }
 
+// FEED VERSION CHANGES
+
+type feedVersionChangesResolver struct{ *Resolver }
+
+func (r *feedVersionChangesResolver) PreviousFeedVersion(ctx context.Context, obj *model.FeedVersionChanges) (*model.FeedVersion, error) {
+	return LoaderFor(ctx).FeedVersionsByIDs.Load(ctx, obj.PreviousFeedVersionID)()
+}
+
 func (r *feedStateResolver) FeedVersion(ctx context.Context, obj *model.FeedState) (*model.FeedVersion, error) {
 	return LoaderFor(ctx).FeedVersionsByIDs.Load(ctx, int(obj.FeedVersionID.Val))()
 }
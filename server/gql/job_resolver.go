@@ -0,0 +1,96 @@
+package gql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/interline-io/transitland-lib/server/auth/authn"
+	"github.com/interline-io/transitland-lib/server/auth/authz"
+	"github.com/interline-io/transitland-lib/server/jobs"
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+func (r *queryResolver) Jobs(ctx context.Context, limit *int, after *string, where *model.JobFilter) ([]*jobs.JobStatus, error) {
+	ctx = addMetric(ctx, "jobs")
+	jh, err := checkJobHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter := jobs.JobFilter{Limit: *checkLimit(limit)}
+	if after != nil {
+		filter.After = *after
+	}
+	if where != nil {
+		if where.Queue != nil {
+			filter.Queue = *where.Queue
+		}
+		if where.JobType != nil {
+			filter.JobType = *where.JobType
+		}
+		filter.States = where.States
+	}
+	ents, err := jh.ListJobs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*jobs.JobStatus, len(ents))
+	for i := range ents {
+		ret[i] = &ents[i]
+	}
+	return ret, nil
+}
+
+func (r *queryResolver) Job(ctx context.Context, id string) (*jobs.JobStatus, error) {
+	ctx = addMetric(ctx, "job")
+	jh, err := checkJobHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	js, err := jh.GetJob(ctx, id)
+	if errors.Is(err, jobs.ErrJobNotFound) {
+		return nil, nil
+	}
+	return js, err
+}
+
+// JOB
+
+type jobResolver struct{ *Resolver }
+
+func (r *jobResolver) JobArgs(ctx context.Context, obj *jobs.JobStatus) (*tt.Map, error) {
+	ret := tt.NewMap(obj.JobArgs)
+	return &ret, nil
+}
+
+func (r *jobResolver) Logs(ctx context.Context, obj *jobs.JobStatus) ([]*jobs.JobLog, error) {
+	jh, err := checkJobHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ents, err := jh.JobLogs(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*jobs.JobLog, len(ents))
+	for i := range ents {
+		ret[i] = &ents[i]
+	}
+	return ret, nil
+}
+
+// checkJobHistory requires an admin user and a job queue that keeps job history.
+func checkJobHistory(ctx context.Context) (jobs.JobHistory, error) {
+	if user := authn.ForContext(ctx); user == nil || !user.HasRole("admin") {
+		return nil, authz.ErrUnauthorized
+	}
+	jobQueue := model.ForContext(ctx).JobQueue
+	if jobQueue == nil {
+		return nil, errors.New("no job queue available")
+	}
+	jh, ok := jobs.GetJobHistory(jobQueue)
+	if !ok {
+		return nil, errors.New("job queue does not support job history")
+	}
+	return jh, nil
+}
@@ -0,0 +1,97 @@
+package gql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/interline-io/transitland-lib/server/auth/authn"
+	"github.com/interline-io/transitland-lib/server/auth/mw/usercheck"
+	"github.com/interline-io/transitland-lib/server/finders/dbfinder"
+	"github.com/interline-io/transitland-lib/server/jobs"
+	localjobs "github.com/interline-io/transitland-lib/server/jobs/local"
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+type testJobWorker struct{}
+
+func (w *testJobWorker) Kind() string {
+	return "test"
+}
+
+func (w *testJobWorker) Run(ctx context.Context) error {
+	return nil
+}
+
+func TestJobResolver(t *testing.T) {
+	ctx := context.Background()
+	jobQueue := jobs.NewJobLogger(localjobs.NewLocalJobs())
+	jobQueue.AddQueue("default", 1)
+	jobQueue.AddJobType(func() jobs.JobWorker { return &testJobWorker{} })
+	jh, _ := jobs.GetJobHistory(jobQueue)
+	id1, err := jh.SubmitJob(ctx, jobs.Job{JobType: "test", JobArgs: jobs.JobArgs{"feed_id": "BA"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id2, err := jh.SubmitJob(ctx, jobs.Job{JobType: "test", JobArgs: jobs.JobArgs{"feed_id": "CT"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jh.CancelJob(ctx, id2); err != nil {
+		t.Fatal(err)
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go jobQueue.Run(runCtx)
+	for i := 0; i < 100; i++ {
+		if js, _ := jh.GetJob(ctx, id1); js != nil && js.State == jobs.JobStateCompleted {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	newClient := func(role string) *client.Client {
+		srv, _ := NewServer()
+		graphqlServer := model.AddConfigAndPerms(model.Config{Finder: dbfinder.NewFinder(nil), JobQueue: jobQueue}, srv)
+		srvMiddleware := usercheck.NewUserDefaultMiddleware(func() authn.User {
+			return authn.NewCtxUser("testuser", "", "").WithRoles(role)
+		})
+		return client.New(srvMiddleware(graphqlServer))
+	}
+	c := newClient("admin")
+	t.Run("jobs", func(t *testing.T) {
+		var resp map[string]any
+		c.MustPost(`query{jobs(where:{job_type:"test"}){id state job_args}}`, &resp)
+		jj := toJson(resp)
+		assert.Equal(t, []string{id2, id1}, astr(gjson.Get(jj, "jobs.#.id").Array()))
+		assert.Equal(t, []string{"cancelled", "completed"}, astr(gjson.Get(jj, "jobs.#.state").Array()))
+		assert.Equal(t, "CT", gjson.Get(jj, "jobs.0.job_args.feed_id").String())
+	})
+	t.Run("jobs by state", func(t *testing.T) {
+		var resp map[string]any
+		c.MustPost(`query{jobs(where:{states:["completed"]}){id}}`, &resp)
+		assert.Equal(t, []string{id1}, astr(gjson.Get(toJson(resp), "jobs.#.id").Array()))
+	})
+	t.Run("job", func(t *testing.T) {
+		var resp map[string]any
+		c.MustPost(`query($id:String!){job(id:$id){id job_type state attempt created_at started_at finished_at logs{level message}}}`, &resp, client.Var("id", id1))
+		jj := toJson(resp)
+		assert.Equal(t, id1, gjson.Get(jj, "job.id").String())
+		assert.Equal(t, "completed", gjson.Get(jj, "job.state").String())
+		assert.Equal(t, int64(1), gjson.Get(jj, "job.attempt").Int())
+		assert.True(t, gjson.Get(jj, "job.finished_at").Exists())
+		assert.Contains(t, astr(gjson.Get(jj, "job.logs.#.message").Array()), "job: completed")
+	})
+	t.Run("job not found", func(t *testing.T) {
+		var resp map[string]any
+		c.MustPost(`query{job(id:"999"){id}}`, &resp)
+		assert.Nil(t, resp["job"])
+	})
+	t.Run("requires admin", func(t *testing.T) {
+		var resp map[string]any
+		err := newClient("user").Post(`query{jobs{id}}`, &resp)
+		assert.ErrorContains(t, err, "unauthorized")
+	})
+}
@@ -102,6 +102,7 @@ type stopLoaderParam struct {
 	ParentStopID  int
 	AgencyID      int
 	LevelID       int
+	AreaID        int
 	Limit         *int
 	Where         *model.StopFilter
 	RouteID       int
@@ -124,6 +125,50 @@ type feedFetchLoaderParam struct {
 	Where  *model.FeedFetchFilter
 }
 
+type mdsTripSummaryLoaderParam struct {
+	FeedID int
+	Limit  *int
+	Where  *model.MdsTripSummaryFilter
+}
+
+type areaLoaderParam struct {
+	FeedVersionID int
+	StopID        int
+	Limit         *int
+}
+
+type networkLoaderParam struct {
+	FeedVersionID int
+	RouteID       int
+	Limit         *int
+}
+
+type fareProductLoaderParam struct {
+	FeedVersionID      int
+	FareLegRuleID      int
+	FareTransferRuleID int
+	Limit              *int
+	Where              *model.FareProductFilter
+}
+
+type fareLegRuleLoaderParam struct {
+	FeedVersionID int
+	NetworkID     int
+	AreaID        int
+	Limit         *int
+	Where         *model.FareLegRuleFilter
+}
+
+type fareTransferRuleLoaderParam struct {
+	FeedVersionID int
+	Limit         *int
+}
+
+type fareMediaLoaderParam struct {
+	FeedVersionID int
+	Limit         *int
+}
+
 type agencyPlaceLoaderParam struct {
 	AgencyID int
 	Limit    *int
@@ -28,6 +28,9 @@ type Loaders struct {
 	AgenciesByIDs                                                 *dataloader.Loader[int, *model.Agency]
 	AgenciesByOnestopIDs                                          *dataloader.Loader[agencyLoaderParam, []*model.Agency]
 	AgencyPlacesByAgencyIDs                                       *dataloader.Loader[agencyPlaceLoaderParam, []*model.AgencyPlace]
+	AreasByFeedVersionIDs                                         *dataloader.Loader[areaLoaderParam, []*model.Area]
+	AreasByIDs                                                    *dataloader.Loader[int, *model.Area]
+	AreasByStopIDs                                                *dataloader.Loader[areaLoaderParam, []*model.Area]
 	CalendarDatesByServiceIDs                                     *dataloader.Loader[calendarDateLoaderParam, []*model.CalendarDate]
 	CalendarsByIDs                                                *dataloader.Loader[int, *model.Calendar]
 	CensusDatasetLayersByDatasetIDs                               *dataloader.Loader[int, []*model.CensusLayer]
@@ -42,6 +45,15 @@ type Loaders struct {
 	CensusLayersByIDs                                             *dataloader.Loader[int, *model.CensusLayer]
 	CensusTableByIDs                                              *dataloader.Loader[int, *model.CensusTable]
 	CensusValuesByGeographyIDs                                    *dataloader.Loader[censusValueLoaderParam, []*model.CensusValue]
+	FareLegRulesByAreaIDs                                         *dataloader.Loader[fareLegRuleLoaderParam, []*model.FareLegRule]
+	FareLegRulesByFeedVersionIDs                                  *dataloader.Loader[fareLegRuleLoaderParam, []*model.FareLegRule]
+	FareLegRulesByNetworkIDs                                      *dataloader.Loader[fareLegRuleLoaderParam, []*model.FareLegRule]
+	FareMediaByFeedVersionIDs                                     *dataloader.Loader[fareMediaLoaderParam, []*model.FareMedia]
+	FareMediaByIDs                                                *dataloader.Loader[int, *model.FareMedia]
+	FareProductsByFareLegRuleIDs                                  *dataloader.Loader[fareProductLoaderParam, []*model.FareProduct]
+	FareProductsByFareTransferRuleIDs                             *dataloader.Loader[fareProductLoaderParam, []*model.FareProduct]
+	FareProductsByFeedVersionIDs                                  *dataloader.Loader[fareProductLoaderParam, []*model.FareProduct]
+	FareTransferRulesByFeedVersionIDs                             *dataloader.Loader[fareTransferRuleLoaderParam, []*model.FareTransferRule]
 	FeedFetchesByFeedIDs                                          *dataloader.Loader[feedFetchLoaderParam, []*model.FeedFetch]
 	FeedInfosByFeedVersionIDs                                     *dataloader.Loader[feedInfoLoaderParam, []*model.FeedInfo]
 	FeedsByIDs                                                    *dataloader.Loader[int, *model.Feed]
@@ -54,9 +66,14 @@ type Loaders struct {
 	FeedVersionsByIDs                                             *dataloader.Loader[int, *model.FeedVersion]
 	FeedVersionServiceLevelsByFeedVersionIDs                      *dataloader.Loader[feedVersionServiceLevelLoaderParam, []*model.FeedVersionServiceLevel]
 	FeedVersionServiceWindowByFeedVersionIDs                      *dataloader.Loader[int, *model.FeedVersionServiceWindow]
+	FeedVersionChangesByFeedVersionIDs                            *dataloader.Loader[int, *model.FeedVersionChanges]
 	FrequenciesByTripIDs                                          *dataloader.Loader[frequencyLoaderParam, []*model.Frequency]
 	LevelsByIDs                                                   *dataloader.Loader[int, *model.Level]
 	LevelsByParentStationIDs                                      *dataloader.Loader[levelLoaderParam, []*model.Level]
+	MdsTripSummariesByFeedIDs                                     *dataloader.Loader[mdsTripSummaryLoaderParam, []*model.MdsTripSummary]
+	NetworksByFeedVersionIDs                                      *dataloader.Loader[networkLoaderParam, []*model.Network]
+	NetworksByIDs                                                 *dataloader.Loader[int, *model.Network]
+	NetworksByRouteIDs                                            *dataloader.Loader[networkLoaderParam, []*model.Network]
 	OperatorsByAgencyIDs                                          *dataloader.Loader[int, *model.Operator]
 	OperatorsByCOIFs                                              *dataloader.Loader[int, *model.Operator]
 	OperatorsByFeedIDs                                            *dataloader.Loader[operatorLoaderParam, []*model.Operator]
@@ -81,6 +98,7 @@ type Loaders struct {
 	StopExternalReferencesByStopIDs                               *dataloader.Loader[int, *model.StopExternalReference]
 	StopObservationsByStopIDs                                     *dataloader.Loader[stopObservationLoaderParam, []*model.StopObservation]
 	StopPlacesByStopID                                            *dataloader.Loader[model.StopPlaceParam, *model.StopPlace]
+	StopsByAreaIDs                                                *dataloader.Loader[stopLoaderParam, []*model.Stop]
 	StopsByFeedVersionIDs                                         *dataloader.Loader[stopLoaderParam, []*model.Stop]
 	StopsByIDs                                                    *dataloader.Loader[int, *model.Stop]
 	StopsByLevelIDs                                               *dataloader.Loader[stopLoaderParam, []*model.Stop]
@@ -126,6 +144,19 @@ func NewLoaders(dbf model.Finder, batchSize int, stopTimeBatchSize int) *Loaders
 				return p.AgencyID, p.Where, p.Limit
 			},
 		),
+		AreasByFeedVersionIDs: withWaitAndCapacityGroup(waitTime, batchSize,
+			paramGroupAdapter(dbf.AreasByFeedVersionIDs),
+			func(p areaLoaderParam) (int, bool, *int) {
+				return p.FeedVersionID, false, p.Limit
+			},
+		),
+		AreasByIDs: withWaitAndCapacity(waitTime, batchSize, dbf.AreasByIDs),
+		AreasByStopIDs: withWaitAndCapacityGroup(waitTime, batchSize,
+			paramGroupAdapter(dbf.AreasByStopIDs),
+			func(p areaLoaderParam) (int, bool, *int) {
+				return p.StopID, false, p.Limit
+			},
+		),
 		CalendarDatesByServiceIDs: withWaitAndCapacityGroup(waitTime, batchSize, dbf.CalendarDatesByServiceIDs,
 			func(p calendarDateLoaderParam) (int, *model.CalendarDateFilter, *int) {
 				return p.ServiceID, p.Where, p.Limit
@@ -189,6 +220,51 @@ func NewLoaders(dbf model.Finder, batchSize int, stopTimeBatchSize int) *Loaders
 				return p.Geoid, p.TableNames, p.Limit
 			},
 		),
+		FareLegRulesByAreaIDs: withWaitAndCapacityGroup(waitTime, batchSize, dbf.FareLegRulesByAreaIDs,
+			func(p fareLegRuleLoaderParam) (int, *model.FareLegRuleFilter, *int) {
+				return p.AreaID, p.Where, p.Limit
+			},
+		),
+		FareLegRulesByFeedVersionIDs: withWaitAndCapacityGroup(waitTime, batchSize, dbf.FareLegRulesByFeedVersionIDs,
+			func(p fareLegRuleLoaderParam) (int, *model.FareLegRuleFilter, *int) {
+				return p.FeedVersionID, p.Where, p.Limit
+			},
+		),
+		FareLegRulesByNetworkIDs: withWaitAndCapacityGroup(waitTime, batchSize, dbf.FareLegRulesByNetworkIDs,
+			func(p fareLegRuleLoaderParam) (int, *model.FareLegRuleFilter, *int) {
+				return p.NetworkID, p.Where, p.Limit
+			},
+		),
+		FareMediaByFeedVersionIDs: withWaitAndCapacityGroup(waitTime, batchSize,
+			paramGroupAdapter(dbf.FareMediaByFeedVersionIDs),
+			func(p fareMediaLoaderParam) (int, bool, *int) {
+				return p.FeedVersionID, false, p.Limit
+			},
+		),
+		FareMediaByIDs: withWaitAndCapacity(waitTime, batchSize, dbf.FareMediaByIDs),
+		FareProductsByFareLegRuleIDs: withWaitAndCapacityGroup(waitTime, batchSize,
+			paramGroupAdapter(dbf.FareProductsByFareLegRuleIDs),
+			func(p fareProductLoaderParam) (int, bool, *int) {
+				return p.FareLegRuleID, false, p.Limit
+			},
+		),
+		FareProductsByFareTransferRuleIDs: withWaitAndCapacityGroup(waitTime, batchSize,
+			paramGroupAdapter(dbf.FareProductsByFareTransferRuleIDs),
+			func(p fareProductLoaderParam) (int, bool, *int) {
+				return p.FareTransferRuleID, false, p.Limit
+			},
+		),
+		FareProductsByFeedVersionIDs: withWaitAndCapacityGroup(waitTime, batchSize, dbf.FareProductsByFeedVersionIDs,
+			func(p fareProductLoaderParam) (int, *model.FareProductFilter, *int) {
+				return p.FeedVersionID, p.Where, p.Limit
+			},
+		),
+		FareTransferRulesByFeedVersionIDs: withWaitAndCapacityGroup(waitTime, batchSize,
+			paramGroupAdapter(dbf.FareTransferRulesByFeedVersionIDs),
+			func(p fareTransferRuleLoaderParam) (int, bool, *int) {
+				return p.FeedVersionID, false, p.Limit
+			},
+		),
 		FeedFetchesByFeedIDs: withWaitAndCapacityGroup(waitTime, batchSize, dbf.FeedFetchesByFeedIDs,
 			func(p feedFetchLoaderParam) (int, *model.FeedFetchFilter, *int) {
 				return p.FeedID, p.Where, p.Limit
@@ -228,6 +304,7 @@ func NewLoaders(dbf model.Finder, batchSize int, stopTimeBatchSize int) *Loaders
 		),
 
 		FeedVersionServiceWindowByFeedVersionIDs: withWaitAndCapacity(waitTime, maxBatch, dbf.FeedVersionServiceWindowByFeedVersionIDs),
+		FeedVersionChangesByFeedVersionIDs:       withWaitAndCapacity(waitTime, maxBatch, dbf.FeedVersionChangesByFeedVersionIDs),
 		FrequenciesByTripIDs: withWaitAndCapacityGroup(waitTime, batchSize,
 			paramGroupAdapter(dbf.FrequenciesByTripIDs),
 			func(p frequencyLoaderParam) (int, bool, *int) {
@@ -242,7 +319,25 @@ func NewLoaders(dbf model.Finder, batchSize int, stopTimeBatchSize int) *Loaders
 				return p.ParentStationID, false, p.Limit
 			},
 		),
+		MdsTripSummariesByFeedIDs: withWaitAndCapacityGroup(waitTime, batchSize, dbf.MdsTripSummariesByFeedIDs,
+			func(p mdsTripSummaryLoaderParam) (int, *model.MdsTripSummaryFilter, *int) {
+				return p.FeedID, p.Where, p.Limit
+			},
+		),
 
+		NetworksByFeedVersionIDs: withWaitAndCapacityGroup(waitTime, batchSize,
+			paramGroupAdapter(dbf.NetworksByFeedVersionIDs),
+			func(p networkLoaderParam) (int, bool, *int) {
+				return p.FeedVersionID, false, p.Limit
+			},
+		),
+		NetworksByIDs: withWaitAndCapacity(waitTime, batchSize, dbf.NetworksByIDs),
+		NetworksByRouteIDs: withWaitAndCapacityGroup(waitTime, batchSize,
+			paramGroupAdapter(dbf.NetworksByRouteIDs),
+			func(p networkLoaderParam) (int, bool, *int) {
+				return p.RouteID, false, p.Limit
+			},
+		),
 		OperatorsByAgencyIDs: withWaitAndCapacity(waitTime, batchSize, dbf.OperatorsByAgencyIDs),
 		OperatorsByCOIFs:     withWaitAndCapacity(waitTime, batchSize, dbf.OperatorsByCOIFs),
 		OperatorsByFeedIDs: withWaitAndCapacityGroup(waitTime, batchSize, dbf.OperatorsByFeedIDs,
@@ -332,6 +427,11 @@ func NewLoaders(dbf model.Finder, batchSize int, stopTimeBatchSize int) *Loaders
 			},
 		),
 		StopPlacesByStopID: withWaitAndCapacity(waitTime, batchSize, dbf.StopPlacesByStopID),
+		StopsByAreaIDs: withWaitAndCapacityGroup(waitTime, batchSize, dbf.StopsByAreaIDs,
+			func(p stopLoaderParam) (int, *model.StopFilter, *int) {
+				return p.AreaID, p.Where, p.Limit
+			},
+		),
 		StopsByFeedVersionIDs: withWaitAndCapacityGroup(waitTime, batchSize, dbf.StopsByFeedVersionIDs,
 			func(p stopLoaderParam) (int, *model.StopFilter, *int) {
 				return p.FeedVersionID, p.Where, p.Limit
@@ -0,0 +1,96 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/interline-io/transitland-lib/server/directions/raptor"
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/interline-io/transitland-lib/tlxy"
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+const (
+	defaultReachableDuration  = 1800
+	maxReachableDuration      = 4 * 3600
+	defaultReachableTransfers = 2
+	maxReachableTransfers     = 4
+)
+
+func (r *queryResolver) ReachableStops(ctx context.Context, from model.FocusPoint, departAt *time.Time, maxDuration *int, maxTransfers *int, within *model.PointRadius) (*model.ReachableStops, error) {
+	cfg := model.ForContext(ctx)
+	ctx = addMetric(ctx, "reachableStops")
+	if err := checkGeo(cfg.MaxRadius, within, nil); err != nil {
+		return nil, err
+	}
+	duration := defaultReachableDuration
+	if maxDuration != nil {
+		duration = *maxDuration
+	}
+	if duration <= 0 || duration > maxReachableDuration {
+		return nil, errors.New("max_duration must be between 1 and 14400 seconds")
+	}
+	transfers := defaultReachableTransfers
+	if maxTransfers != nil {
+		transfers = *maxTransfers
+	}
+	if transfers < 0 || transfers > maxReachableTransfers {
+		return nil, errors.New("max_transfers must be between 0 and 4")
+	}
+	departTime := time.Now()
+	if cfg.Clock != nil {
+		departTime = cfg.Clock.Now()
+	}
+	if departAt != nil {
+		departTime = *departAt
+	}
+	departTime = departTime.In(time.UTC)
+
+	// Search; only stops from feed versions visible to the caller are reachable,
+	// so each result can be resolved through the permission-filtered stop loader
+	pt := tlxy.Point{Lon: from.Lon, Lat: from.Lat}
+	router := raptor.DefaultRouter
+	stops, err := router.Reachable(ctx, pt, departTime, duration, transfers)
+	if err != nil {
+		return nil, err
+	}
+	if within != nil {
+		center := tlxy.Point{Lon: within.Lon, Lat: within.Lat}
+		var filtered []raptor.ReachableStop
+		for _, s := range stops {
+			if tlxy.DistanceHaversine(center, s.Point) <= within.Radius {
+				filtered = append(filtered, s)
+			}
+		}
+		stops = filtered
+	}
+	ret := model.ReachableStops{
+		DepartAt:    departTime,
+		MaxDuration: duration,
+		Stops:       []*model.ReachableStop{},
+	}
+	for _, s := range stops {
+		ret.Stops = append(ret.Stops, &model.ReachableStop{
+			StopID:      s.StopID,
+			ArrivalTime: s.Arrival,
+			Duration:    s.Duration,
+			Transfers:   s.Transfers,
+		})
+	}
+	if containsField(ctx, "isochrone") {
+		mp, err := router.Isochrone(pt, stops, duration)
+		if err != nil {
+			return nil, err
+		}
+		isochrone := tt.NewMultiPolygon(mp)
+		ret.Isochrone = &isochrone
+	}
+	return &ret, nil
+}
+
+type reachableStopResolver struct{ *Resolver }
+
+func (r *reachableStopResolver) Stop(ctx context.Context, obj *model.ReachableStop) (*model.Stop, error) {
+	return LoaderFor(ctx).StopsByIDs.Load(ctx, obj.StopID)()
+}
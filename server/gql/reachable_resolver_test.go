@@ -0,0 +1,103 @@
+package gql
+
+import (
+	"testing"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/interline-io/transitland-lib/internal/testconfig"
+	"github.com/interline-io/transitland-lib/server/auth/mw/usercheck"
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/interline-io/transitland-lib/server/testutil"
+	"github.com/interline-io/transitland-lib/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// MacArthur BART is served by BA (public) and EG (not public)
+const reachableStopsQuery = `query($depart_at: Time) {
+	reachable_stops(from: {lon: -122.267040, lat: 37.829065}, depart_at: $depart_at, max_duration: 1800) {
+		stops {
+			duration
+			stop {
+				stop_id
+				feed_version {
+					feed {
+						onestop_id
+					}
+				}
+			}
+		}
+	}
+}`
+
+func TestReachableStopsResolver(t *testing.T) {
+	c, _ := newTestClient(t)
+	testcases := []testcase{
+		{
+			name:               "public feeds",
+			query:              reachableStopsQuery,
+			vars:               hw{"depart_at": "2018-06-04T15:00:00Z"},
+			selector:           "reachable_stops.stops.#.stop.feed_version.feed.onestop_id",
+			selectExpectUnique: []string{"BA"},
+		},
+		{
+			name:  "reached by transit",
+			query: reachableStopsQuery,
+			vars:  hw{"depart_at": "2018-06-04T15:00:00Z"},
+			f: func(t *testing.T, jj string) {
+				stopIds := map[string]bool{}
+				for _, v := range gjson.Get(jj, "reachable_stops.stops").Array() {
+					stopId := v.Get("stop.stop_id").String()
+					assert.NotEmpty(t, stopId)
+					stopIds[stopId] = true
+				}
+				assert.True(t, stopIds["MCAR"], "expected walking access to MCAR")
+				assert.True(t, stopIds["19TH"], "expected transit to 19TH")
+			},
+		},
+	}
+	queryTestcases(t, c, testcases)
+}
+
+func TestReachableStopsResolver_Authz(t *testing.T) {
+	ep, a, ok := testutil.CheckEnv("TL_TEST_FGA_ENDPOINT")
+	if !ok {
+		t.Skip(a)
+		return
+	}
+	cfg := testconfig.Config(t, testconfig.Options{
+		WhenUtc:        DEFAULT_WHEN,
+		FGAEndpoint:    ep,
+		FGAModelFile:   testdata.Path("server/authz/tls.json"),
+		FGAModelTuples: fgaTestTuples,
+	})
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv = model.AddConfigAndPerms(cfg, srv)
+	testcases := []testcase{
+		{
+			name:               "restricted feed is excluded",
+			query:              reachableStopsQuery,
+			vars:               hw{"depart_at": "2022-09-01T20:00:00Z"},
+			user:               "public",
+			selector:           "reachable_stops.stops.#.stop.feed_version.feed.onestop_id",
+			selectExpectUnique: []string{"BA"},
+		},
+		{
+			name:               "restricted feed is included with permission",
+			query:              reachableStopsQuery,
+			vars:               hw{"depart_at": "2022-09-01T20:00:00Z"},
+			user:               "ian",
+			selector:           "reachable_stops.stops.#.stop.feed_version.feed.onestop_id",
+			selectExpectUnique: []string{"BA", "EG"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := client.New(usercheck.UserDefaultMiddleware(tc.user)(srv))
+			queryTestcase(t, c, tc)
+		})
+	}
+}
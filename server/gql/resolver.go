@@ -131,12 +131,32 @@ func (r *Resolver) Query() gqlout.QueryResolver { return &queryResolver{r} }
 // Mutation .
 func (r *Resolver) Mutation() gqlout.MutationResolver { return &mutationResolver{r} }
 
+// Subscription .
+func (r *Resolver) Subscription() gqlout.SubscriptionResolver { return &subscriptionResolver{r} }
+
 // Agency .
 func (r *Resolver) Agency() gqlout.AgencyResolver { return &agencyResolver{r} }
 
 // Feed .
 func (r *Resolver) Feed() gqlout.FeedResolver { return &feedResolver{r} }
 
+// FareProduct .
+func (r *Resolver) FareProduct() gqlout.FareProductResolver { return &fareProductResolver{r} }
+
+// FareLegRule .
+func (r *Resolver) FareLegRule() gqlout.FareLegRuleResolver { return &fareLegRuleResolver{r} }
+
+// FareTransferRule .
+func (r *Resolver) FareTransferRule() gqlout.FareTransferRuleResolver {
+	return &fareTransferRuleResolver{r}
+}
+
+// Area .
+func (r *Resolver) Area() gqlout.AreaResolver { return &areaResolver{r} }
+
+// Network .
+func (r *Resolver) Network() gqlout.NetworkResolver { return &networkResolver{r} }
+
 // FeedState .
 func (r *Resolver) FeedState() gqlout.FeedStateResolver { return &feedStateResolver{r} }
 
@@ -180,6 +200,26 @@ func (r *Resolver) FeedVersionGtfsImport() gqlout.FeedVersionGtfsImportResolver
 	return &feedVersionGtfsImportResolver{r}
 }
 
+// FeedVersionChanges .
+func (r *Resolver) FeedVersionChanges() gqlout.FeedVersionChangesResolver {
+	return &feedVersionChangesResolver{r}
+}
+
+// Job .
+func (r *Resolver) Job() gqlout.JobResolver {
+	return &jobResolver{r}
+}
+
+// Itinerary .
+func (r *Resolver) Itinerary() gqlout.ItineraryResolver {
+	return &itineraryResolver{r}
+}
+
+// ReachableStop .
+func (r *Resolver) ReachableStop() gqlout.ReachableStopResolver {
+	return &reachableStopResolver{r}
+}
+
 func (r *Resolver) Level() gqlout.LevelResolver {
 	return &levelResolver{r}
 }
@@ -91,6 +91,10 @@ func (r *routeResolver) Segments(ctx context.Context, obj *model.Route, limit *i
 	return LoaderFor(ctx).SegmentsByRouteIDs.Load(ctx, segmentLoaderParam{RouteID: obj.ID, Where: where, Limit: limit})()
 }
 
+func (r *routeResolver) Networks(ctx context.Context, obj *model.Route, limit *int) ([]*model.Network, error) {
+	return LoaderFor(ctx).NetworksByRouteIDs.Load(ctx, networkLoaderParam{RouteID: obj.ID, Limit: checkLimit(limit)})()
+}
+
 // ROUTE HEADWAYS
 
 type routeHeadwayResolver struct{ *Resolver }
@@ -2,10 +2,19 @@ package gql
 
 import (
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
 	"github.com/interline-io/transitland-lib/internal/generated/gqlout"
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 // ServerOption configures the gqlgen server instance
@@ -25,7 +34,23 @@ func WithExtensions(exts ...graphql.HandlerExtension) ServerOption {
 func NewServer(opts ...ServerOption) (http.Handler, error) {
 	c := gqlout.Config{Resolvers: &Resolver{}}
 	// Setup server
-	srv := handler.NewDefaultServer(gqlout.NewExecutableSchema(c))
+	srv := handler.New(gqlout.NewExecutableSchema(c))
+	// Subscriptions are served over WebSocket using graphql-transport-ws or graphql-ws.
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: checkWebsocketOrigin,
+		},
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New[string](100),
+	})
 	// Apply functional options
 	for _, opt := range opts {
 		if opt != nil {
@@ -35,3 +60,39 @@ func NewServer(opts ...ServerOption) (http.Handler, error) {
 	graphqlServer := loaderMiddleware(srv)
 	return graphqlServer, nil
 }
+
+// checkWebsocketOrigin allows WebSocket upgrades from the allowed origins in the config.
+// CORS does not apply to WebSocket upgrades, so without this check any page could open a subscription with the user's credentials.
+// Without configured origins, only same origin requests are allowed.
+// Requests without an Origin header are not sent by browsers and are allowed.
+func checkWebsocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	allowed := model.ForContext(r.Context()).AllowedOrigins
+	if len(allowed) == 0 {
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		return strings.EqualFold(u.Host, r.Host)
+	}
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if prefix, suffix, ok := strings.Cut(pattern, "*"); ok {
+			if len(origin) >= len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		} else if origin == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWebsocketUpgrade returns true if the request is a WebSocket upgrade request.
+func IsWebsocketUpgrade(r *http.Request) bool {
+	return websocket.IsWebSocketUpgrade(r)
+}
@@ -0,0 +1,38 @@
+package gql
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckWebsocketOrigin(t *testing.T) {
+	tcs := []struct {
+		name    string
+		origin  string
+		allowed []string
+		expect  bool
+	}{
+		{"no origin", "", nil, true},
+		{"same origin", "https://example.com", nil, true},
+		{"cross origin", "https://evil.example", nil, false},
+		{"allowed origin", "https://app.example.org", []string{"https://app.example.org"}, true},
+		{"allowed wildcard", "https://app.example.org", []string{"https://*.example.org"}, true},
+		{"allowed wildcard suffix", "https://example.org.evil.example", []string{"https://*.example.org"}, false},
+		{"not allowed", "https://evil.example", []string{"https://*.example.org"}, false},
+		{"configured excludes same origin", "https://example.com", []string{"https://*.example.org"}, false},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "https://example.com/query", nil)
+			r = r.WithContext(model.WithConfig(context.Background(), model.Config{AllowedOrigins: tc.allowed}))
+			if tc.origin != "" {
+				r.Header.Set("Origin", tc.origin)
+			}
+			assert.Equal(t, tc.expect, checkWebsocketOrigin(r))
+		})
+	}
+}
@@ -73,6 +73,10 @@ func (r *stopResolver) Observations(ctx context.Context, obj *model.Stop, limit
 	return LoaderFor(ctx).StopObservationsByStopIDs.Load(ctx, stopObservationLoaderParam{StopID: obj.ID, Where: where, Limit: checkLimit(limit)})()
 }
 
+func (r *stopResolver) Areas(ctx context.Context, obj *model.Stop, limit *int) ([]*model.Area, error) {
+	return LoaderFor(ctx).AreasByStopIDs.Load(ctx, areaLoaderParam{StopID: obj.ID, Limit: checkLimit(limit)})()
+}
+
 func (r *stopResolver) Departures(ctx context.Context, obj *model.Stop, limit *int, where *model.StopTimeFilter) ([]*model.StopTime, error) {
 	if where == nil {
 		where = &model.StopTimeFilter{}
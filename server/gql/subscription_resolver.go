@@ -0,0 +1,19 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/interline-io/transitland-lib/server/model"
+)
+
+// SUBSCRIPTIONS
+
+type subscriptionResolver struct{ *Resolver }
+
+func (r *subscriptionResolver) VehiclePositions(ctx context.Context, where *model.VehiclePositionFilter) (<-chan []*model.VehiclePosition, error) {
+	return model.ForContext(ctx).RTFinder.SubscribeVehiclePositions(ctx, where)
+}
+
+func (r *subscriptionResolver) TripUpdates(ctx context.Context, where *model.TripUpdateFilter) (<-chan []*model.RTTripUpdate, error) {
+	return model.ForContext(ctx).RTFinder.SubscribeTripUpdates(ctx, where)
+}
@@ -0,0 +1,146 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed five field cron expression: minute, hour, day of month, month, and day of week.
+type CronSchedule struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// Standard cron matches either day field when both are restricted
+	domStar bool
+	dowStar bool
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression.
+// Fields support "*", single values, ranges ("1-5"), steps ("*/15", "0-30/10") and lists ("1,15").
+// The descriptors @yearly, @monthly, @weekly, @daily and @hourly are also supported.
+func ParseCron(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if v, ok := cronDescriptors[spec]; ok {
+		spec = v
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression '%s': expected 5 fields", spec)
+	}
+	s := CronSchedule{}
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	// Sunday is 0 or 7
+	if s.dow&(1<<7) > 0 {
+		s.dow |= 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+	return &s, nil
+}
+
+// Next returns the first time matching the schedule that is after t.
+// Returns the zero time if no time matches within five years.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) > 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) > 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func parseCronField(field string, min int, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		lo, hi, step := min, max, 1
+		rangePart := part
+		if i := strings.Index(part, "/"); i >= 0 {
+			v, err := strconv.Atoi(part[i+1:])
+			if err != nil || v <= 0 {
+				return 0, fmt.Errorf("invalid cron step '%s'", part)
+			}
+			step = v
+			rangePart = part[:i]
+		}
+		if rangePart != "*" {
+			if i := strings.Index(rangePart, "-"); i >= 0 {
+				a, err1 := strconv.Atoi(rangePart[:i])
+				b, err2 := strconv.Atoi(rangePart[i+1:])
+				if err1 != nil || err2 != nil {
+					return 0, fmt.Errorf("invalid cron range '%s'", part)
+				}
+				lo, hi = a, b
+			} else {
+				v, err := strconv.Atoi(rangePart)
+				if err != nil {
+					return 0, fmt.Errorf("invalid cron value '%s'", part)
+				}
+				lo, hi = v, v
+				if step > 1 {
+					hi = max
+				}
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("cron value '%s' out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
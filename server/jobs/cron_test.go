@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	base := time.Date(2024, 1, 31, 10, 17, 30, 0, time.UTC) // Wednesday
+	tcs := []struct {
+		spec   string
+		expect time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 31, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)},
+		{"5 * * * *", time.Date(2024, 1, 31, 11, 5, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 2, 1, 3, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2024, 2, 4, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 0", time.Date(2024, 2, 4, 12, 0, 0, 0, time.UTC)},
+		{"0 0,12 * * *", time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{"10-20/5 10 * * *", time.Date(2024, 1, 31, 10, 20, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted
+		{"0 0 15 * 5", time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.spec, func(t *testing.T) {
+			s, err := ParseCron(tc.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.expect, s.Next(base))
+		})
+	}
+}
+
+func TestParseCron_Errors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err := ParseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCronSchedule_Never(t *testing.T) {
+	s, err := ParseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, s.Next(time.Now()).IsZero())
+}
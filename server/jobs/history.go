@@ -0,0 +1,154 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/interline-io/log"
+	"github.com/rs/zerolog"
+)
+
+// Job states
+const (
+	JobStateAvailable = "available"
+	JobStateRunning   = "running"
+	JobStateCompleted = "completed"
+	JobStateFailed    = "failed"
+	JobStateDiscarded = "discarded"
+	JobStateCancelled = "cancelled"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobFinished = errors.New("job already finished")
+)
+
+// JobStatus describes a job and its current state.
+type JobStatus struct {
+	ID          string     `json:"id"`
+	Queue       string     `json:"queue"`
+	JobType     string     `json:"job_type"`
+	JobArgs     JobArgs    `json:"job_args"`
+	State       string     `json:"state"`
+	Attempt     int        `json:"attempt"`
+	MaxAttempts int        `json:"max_attempts"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// Finished returns true if the job will not run again.
+func (js *JobStatus) Finished() bool {
+	switch js.State {
+	case JobStateCompleted, JobStateFailed, JobStateDiscarded, JobStateCancelled:
+		return true
+	}
+	return false
+}
+
+// JobLog is a log message written while a job was running.
+type JobLog struct {
+	Attempt   int       `json:"attempt"`
+	Level     string    `json:"level"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// JobFilter limits the jobs returned by ListJobs.
+// Jobs are returned newest first; After is the ID of the last job on the previous page.
+type JobFilter struct {
+	Queue   string
+	JobType string
+	States  []string
+	Limit   int
+	After   string
+}
+
+// JobHistory is implemented by job queues that assign IDs to jobs and keep a record of job state.
+type JobHistory interface {
+	// SubmitJob adds a job and returns its ID.
+	// For a unique job that is already waiting to run, the ID of the waiting job is returned.
+	SubmitJob(context.Context, Job) (string, error)
+	GetJob(context.Context, string) (*JobStatus, error)
+	ListJobs(context.Context, JobFilter) ([]JobStatus, error)
+	JobLogs(context.Context, string) ([]JobLog, error)
+	// CancelJob prevents a waiting job from running, or cancels the context of a running job.
+	CancelJob(context.Context, string) error
+}
+
+// GetJobHistory returns the job history for a queue, or false if the queue does not keep job history.
+func GetJobHistory(q JobQueue) (JobHistory, bool) {
+	for q != nil {
+		if jh, ok := q.(JobHistory); ok {
+			return jh, true
+		}
+		uw, ok := q.(interface{ Unwrap() JobQueue })
+		if !ok {
+			break
+		}
+		q = uw.Unwrap()
+	}
+	return nil, false
+}
+
+// DefaultJobLogLimit is the maximum number of log messages kept for each job attempt.
+const DefaultJobLogLimit = 1000
+
+// JobLogBuffer collects log messages written while a job is running.
+type JobLogBuffer struct {
+	attempt int
+	limit   int
+	logs    []JobLog
+	dropped int
+	lock    sync.Mutex
+}
+
+// NewJobLogBuffer returns a buffer that keeps up to limit log messages.
+func NewJobLogBuffer(attempt int, limit int) *JobLogBuffer {
+	return &JobLogBuffer{attempt: attempt, limit: limit}
+}
+
+// WithContext returns a context with a logger that also writes to this buffer.
+func (b *JobLogBuffer) WithContext(ctx context.Context) context.Context {
+	logger := log.For(ctx).Hook(b)
+	return logger.WithContext(ctx)
+}
+
+// Run implements zerolog.Hook.
+func (b *JobLogBuffer) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	if level == zerolog.NoLevel || !e.Enabled() {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.limit > 0 && len(b.logs) >= b.limit {
+		b.dropped++
+		return
+	}
+	b.logs = append(b.logs, JobLog{
+		Attempt:   b.attempt,
+		Level:     level.String(),
+		Message:   msg,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// Logs returns the collected messages.
+// A final message is added if messages were dropped because of the limit.
+func (b *JobLogBuffer) Logs() []JobLog {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	ret := append([]JobLog{}, b.logs...)
+	if b.dropped > 0 {
+		ret = append(ret, JobLog{
+			Attempt:   b.attempt,
+			Level:     zerolog.WarnLevel.String(),
+			Message:   "log limit reached, messages dropped",
+			CreatedAt: time.Now().UTC(),
+		})
+	}
+	return ret
+}
@@ -2,6 +2,7 @@ package jobtest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/interline-io/log"
 	"github.com/interline-io/transitland-lib/server/jobs"
 	"github.com/stretchr/testify/assert"
 )
@@ -203,3 +205,145 @@ func (w *testJobMiddleware) Run(ctx context.Context) error {
 	}
 	return nil
 }
+
+// TestJobHistory tests queues that implement jobs.JobHistory.
+func TestJobHistory(t *testing.T, newQueue func(string) JobQueue) {
+	ctx := context.Background()
+	queueName := func(t testing.TB) string {
+		tName := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "-"))
+		return fmt.Sprintf("%s-%d-%d", tName, os.Getpid(), time.Now().UnixNano())
+	}
+	getHistory := func(t testing.TB, q JobQueue) jobs.JobHistory {
+		jh, ok := jobs.GetJobHistory(q)
+		if !ok {
+			t.Fatal("queue does not implement JobHistory")
+		}
+		return jh
+	}
+	waitState := func(t testing.TB, jh jobs.JobHistory, id string, state string) *jobs.JobStatus {
+		var js *jobs.JobStatus
+		for i := 0; i < 100; i++ {
+			var err error
+			js, err = jh.GetJob(ctx, id)
+			checkErr(t, err)
+			if js.State == state {
+				return js
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("job %s: got state '%s', expected '%s'", id, js.State, state)
+		return nil
+	}
+	t.Run("submit and cancel", func(t *testing.T) {
+		rtJobs := newQueue(queueName(t))
+		jh := getHistory(t, rtJobs)
+		id1, err := jh.SubmitJob(ctx, Job{JobType: "testHistory", JobArgs: JobArgs{"n": "1"}})
+		checkErr(t, err)
+		id2, err := jh.SubmitJob(ctx, Job{JobType: "testHistory", JobArgs: JobArgs{"n": "2"}, Unique: true})
+		checkErr(t, err)
+		assert.NotEqual(t, id1, id2)
+		// Unique job that is waiting returns same ID
+		id3, err := jh.SubmitJob(ctx, Job{JobType: "testHistory", JobArgs: JobArgs{"n": "2"}, Unique: true})
+		checkErr(t, err)
+		assert.Equal(t, id2, id3)
+		// Get
+		js, err := jh.GetJob(ctx, id1)
+		checkErr(t, err)
+		assert.Equal(t, id1, js.ID)
+		assert.Equal(t, "testHistory", js.JobType)
+		assert.Equal(t, jobs.JobStateAvailable, js.State)
+		assert.Equal(t, "1", js.JobArgs["n"])
+		_, err = jh.GetJob(ctx, "0")
+		assert.ErrorIs(t, err, jobs.ErrJobNotFound)
+		// List, newest first
+		list, err := jh.ListJobs(ctx, jobs.JobFilter{JobType: "testHistory", Limit: 10})
+		checkErr(t, err)
+		var ids []string
+		for _, j := range list {
+			if j.ID == id1 || j.ID == id2 {
+				ids = append(ids, j.ID)
+			}
+		}
+		assert.Equal(t, []string{id2, id1}, ids)
+		list, err = jh.ListJobs(ctx, jobs.JobFilter{JobType: "testHistory", After: id2, Limit: 1})
+		checkErr(t, err)
+		if assert.Equal(t, 1, len(list)) {
+			assert.Equal(t, id1, list[0].ID)
+		}
+		// Cancel
+		checkErr(t, jh.CancelJob(ctx, id1))
+		js, err = jh.GetJob(ctx, id1)
+		checkErr(t, err)
+		assert.Equal(t, jobs.JobStateCancelled, js.State)
+		assert.NotNil(t, js.FinishedAt)
+		assert.ErrorIs(t, jh.CancelJob(ctx, id1), jobs.ErrJobFinished)
+		list, err = jh.ListJobs(ctx, jobs.JobFilter{JobType: "testHistory", States: []string{jobs.JobStateCancelled}})
+		checkErr(t, err)
+		if assert.Equal(t, 1, len(list)) {
+			assert.Equal(t, id1, list[0].ID)
+		}
+		// Cancelled unique job can be submitted again
+		checkErr(t, jh.CancelJob(ctx, id2))
+		id4, err := jh.SubmitJob(ctx, Job{JobType: "testHistory", JobArgs: JobArgs{"n": "2"}, Unique: true})
+		checkErr(t, err)
+		assert.NotEqual(t, id2, id4)
+	})
+	t.Run("run, logs and cancel running job", func(t *testing.T) {
+		rtJobs := newQueue(queueName(t))
+		jh := getHistory(t, rtJobs)
+		checkErr(t, rtJobs.AddJobType(func() JobWorker { return &testHistoryWorker{} }))
+		idOk, err := jh.SubmitJob(ctx, Job{JobType: "testHistoryWorker", JobArgs: JobArgs{"message": "hello from job"}})
+		checkErr(t, err)
+		idWait, err := jh.SubmitJob(ctx, Job{JobType: "testHistoryWorker", JobArgs: JobArgs{"wait": true}})
+		checkErr(t, err)
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go rtJobs.Run(runCtx)
+		defer rtJobs.Stop(ctx)
+		// Completed job
+		js := waitState(t, jh, idOk, jobs.JobStateCompleted)
+		assert.Equal(t, 1, js.Attempt)
+		assert.NotNil(t, js.StartedAt)
+		assert.NotNil(t, js.FinishedAt)
+		logs, err := jh.JobLogs(ctx, idOk)
+		checkErr(t, err)
+		found := false
+		for _, l := range logs {
+			if l.Message == "hello from job" {
+				found = true
+				assert.Equal(t, "info", l.Level)
+				assert.Equal(t, 1, l.Attempt)
+			}
+		}
+		assert.True(t, found, "expected job log message")
+		// Cancel running job through context
+		waitState(t, jh, idWait, jobs.JobStateRunning)
+		checkErr(t, jh.CancelJob(ctx, idWait))
+		js = waitState(t, jh, idWait, jobs.JobStateCancelled)
+		assert.Contains(t, js.Error, "context canceled")
+	})
+}
+
+type testHistoryWorker struct {
+	Message string `json:"message"`
+	Wait    bool   `json:"wait"`
+}
+
+func (w *testHistoryWorker) Kind() string {
+	return "testHistoryWorker"
+}
+
+func (w *testHistoryWorker) Run(ctx context.Context) error {
+	if w.Message != "" {
+		log.For(ctx).Info().Msg(w.Message)
+	}
+	if w.Wait {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(30 * time.Second):
+			return errors.New("not cancelled")
+		}
+	}
+	return nil
+}
@@ -3,6 +3,9 @@ package jobs
 import (
 	"context"
 	"errors"
+	"slices"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -12,25 +15,47 @@ import (
 
 func init() {
 	var _ jobs.JobQueue = &LocalJobs{}
+	var _ jobs.JobHistory = &LocalJobs{}
+}
+
+// DefaultHistoryLimit is the number of finished jobs kept in memory.
+const DefaultHistoryLimit = 1000
+
+type localJob struct {
+	id  string
+	job jobs.Job
 }
 
 type LocalJobs struct {
-	jobs           chan jobs.Job
-	jobfuncs       []func(context.Context, jobs.Job) error
+	// Number of finished jobs kept in memory
+	HistoryLimit   int
+	jobs           chan localJob
+	jobfuncs       []func(context.Context, localJob)
 	running        bool
 	middlewares    []jobs.JobMiddleware
-	uniqueJobs     map[string]bool
+	uniqueJobs     map[string]string
 	uniqueJobsLock sync.Mutex
 	jobMapper      *jobs.JobMapper
 	ctx            context.Context
 	cancel         context.CancelFunc
+	// job history
+	nextID     int
+	statuses   map[string]*jobs.JobStatus
+	logs       map[string][]jobs.JobLog
+	cancels    map[string]context.CancelFunc
+	finished   []string
+	statusLock sync.Mutex
 }
 
 func NewLocalJobs() *LocalJobs {
 	f := &LocalJobs{
-		jobs:       make(chan jobs.Job, 1000),
-		uniqueJobs: map[string]bool{},
-		jobMapper:  jobs.NewJobMapper(),
+		HistoryLimit: DefaultHistoryLimit,
+		jobs:         make(chan localJob, 1000),
+		uniqueJobs:   map[string]string{},
+		jobMapper:    jobs.NewJobMapper(),
+		statuses:     map[string]*jobs.JobStatus{},
+		logs:         map[string][]jobs.JobLog{},
+		cancels:      map[string]context.CancelFunc{},
 	}
 	return f
 }
@@ -41,7 +66,7 @@ func (f *LocalJobs) Use(mwf jobs.JobMiddleware) {
 
 func (f *LocalJobs) AddQueue(queue string, count int) error {
 	for i := 0; i < count; i++ {
-		f.jobfuncs = append(f.jobfuncs, f.RunJob)
+		f.jobfuncs = append(f.jobfuncs, f.runQueued)
 	}
 	return nil
 }
@@ -61,26 +86,34 @@ func (f *LocalJobs) AddJobs(ctx context.Context, jobs []jobs.Job) error {
 }
 
 func (f *LocalJobs) AddJob(ctx context.Context, job jobs.Job) error {
+	_, err := f.SubmitJob(ctx, job)
+	return err
+}
+
+func (f *LocalJobs) SubmitJob(ctx context.Context, job jobs.Job) (string, error) {
 	if f.jobs == nil {
-		return errors.New("closed")
+		return "", errors.New("closed")
 	}
 	if job.Unique {
 		f.uniqueJobsLock.Lock()
 		defer f.uniqueJobsLock.Unlock()
 		key, err := job.HexKey()
 		if err != nil {
-			return err
+			return "", err
 		}
-		if _, ok := f.uniqueJobs[key]; ok {
+		if id, ok := f.uniqueJobs[key]; ok && f.isAvailable(id) {
 			log.Trace().Interface("job", job).Msgf("already locked: %s", key)
-			return nil
-		} else {
-			f.uniqueJobs[key] = true
-			log.Trace().Interface("job", job).Msgf("locked: %s", key)
+			return id, nil
 		}
+		id := f.addStatus(job)
+		f.uniqueJobs[key] = id
+		log.Trace().Interface("job", job).Msgf("locked: %s", key)
+		f.jobs <- localJob{id: id, job: job}
+		return id, nil
 	}
-	f.jobs <- job
-	return nil
+	id := f.addStatus(job)
+	f.jobs <- localJob{id: id, job: job}
+	return id, nil
 }
 
 func (w *LocalJobs) AddPeriodicJob(ctx context.Context, jobFunc func() jobs.Job, period time.Duration, cronTab string) error {
@@ -137,7 +170,7 @@ func (f *LocalJobs) Run(ctx context.Context) error {
 	f.ctx, f.cancel = context.WithCancel(ctx)
 	f.running = true
 	for _, jobfunc := range f.jobfuncs {
-		go func(jf func(context.Context, jobs.Job) error) {
+		go func(jf func(context.Context, localJob)) {
 			for job := range f.jobs {
 				jf(ctx, job)
 			}
@@ -157,3 +190,182 @@ func (f *LocalJobs) Stop(ctx context.Context) error {
 	f.jobs = nil
 	return nil
 }
+
+// runQueued runs a queued job and records the result.
+func (f *LocalJobs) runQueued(ctx context.Context, lj localJob) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if !f.startStatus(lj.id, cancel) {
+		// Cancelled before starting
+		if lj.job.Unique {
+			f.uniqueJobsLock.Lock()
+			if key, err := lj.job.HexKey(); err == nil && f.uniqueJobs[key] == lj.id {
+				delete(f.uniqueJobs, key)
+			}
+			f.uniqueJobsLock.Unlock()
+		}
+		return
+	}
+	logBuf := jobs.NewJobLogBuffer(1, jobs.DefaultJobLogLimit)
+	err := f.RunJob(logBuf.WithContext(jobCtx), lj.job)
+	f.finishStatus(lj.id, err, jobCtx.Err() != nil && ctx.Err() == nil, logBuf.Logs())
+}
+
+//////////
+
+func (f *LocalJobs) GetJob(ctx context.Context, id string) (*jobs.JobStatus, error) {
+	f.statusLock.Lock()
+	defer f.statusLock.Unlock()
+	js, ok := f.statuses[id]
+	if !ok {
+		return nil, jobs.ErrJobNotFound
+	}
+	ret := *js
+	return &ret, nil
+}
+
+func (f *LocalJobs) ListJobs(ctx context.Context, filter jobs.JobFilter) ([]jobs.JobStatus, error) {
+	f.statusLock.Lock()
+	defer f.statusLock.Unlock()
+	after := 0
+	if filter.After != "" {
+		after, _ = strconv.Atoi(filter.After)
+	}
+	var ret []jobs.JobStatus
+	for _, js := range f.statuses {
+		if after > 0 && localJobID(js.ID) >= after {
+			continue
+		}
+		if filter.Queue != "" && js.Queue != filter.Queue {
+			continue
+		}
+		if filter.JobType != "" && js.JobType != filter.JobType {
+			continue
+		}
+		if len(filter.States) > 0 && !slices.Contains(filter.States, js.State) {
+			continue
+		}
+		ret = append(ret, *js)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return localJobID(ret[i].ID) > localJobID(ret[j].ID)
+	})
+	if filter.Limit > 0 && len(ret) > filter.Limit {
+		ret = ret[:filter.Limit]
+	}
+	return ret, nil
+}
+
+func (f *LocalJobs) JobLogs(ctx context.Context, id string) ([]jobs.JobLog, error) {
+	f.statusLock.Lock()
+	defer f.statusLock.Unlock()
+	if _, ok := f.statuses[id]; !ok {
+		return nil, jobs.ErrJobNotFound
+	}
+	return append([]jobs.JobLog{}, f.logs[id]...), nil
+}
+
+func (f *LocalJobs) CancelJob(ctx context.Context, id string) error {
+	f.statusLock.Lock()
+	defer f.statusLock.Unlock()
+	js, ok := f.statuses[id]
+	if !ok {
+		return jobs.ErrJobNotFound
+	}
+	if js.Finished() {
+		return jobs.ErrJobFinished
+	}
+	if cancel, ok := f.cancels[id]; ok {
+		// Running; the job is marked as cancelled when it returns
+		cancel()
+		return nil
+	}
+	now := time.Now().UTC()
+	js.State = jobs.JobStateCancelled
+	js.FinishedAt = &now
+	f.addFinished(id)
+	return nil
+}
+
+func (f *LocalJobs) addStatus(job jobs.Job) string {
+	f.statusLock.Lock()
+	defer f.statusLock.Unlock()
+	f.nextID++
+	id := strconv.Itoa(f.nextID)
+	queue := job.Queue
+	if queue == "" {
+		queue = "default"
+	}
+	f.statuses[id] = &jobs.JobStatus{
+		ID:          id,
+		Queue:       queue,
+		JobType:     job.JobType,
+		JobArgs:     job.JobArgs,
+		State:       jobs.JobStateAvailable,
+		MaxAttempts: 1,
+		CreatedAt:   time.Now().UTC(),
+	}
+	return id
+}
+
+func (f *LocalJobs) isAvailable(id string) bool {
+	f.statusLock.Lock()
+	defer f.statusLock.Unlock()
+	js, ok := f.statuses[id]
+	return ok && js.State == jobs.JobStateAvailable
+}
+
+// startStatus marks a job as running, or returns false if the job was cancelled.
+func (f *LocalJobs) startStatus(id string, cancel context.CancelFunc) bool {
+	f.statusLock.Lock()
+	defer f.statusLock.Unlock()
+	js, ok := f.statuses[id]
+	if !ok || js.State != jobs.JobStateAvailable {
+		return false
+	}
+	now := time.Now().UTC()
+	js.State = jobs.JobStateRunning
+	js.Attempt = 1
+	js.StartedAt = &now
+	f.cancels[id] = cancel
+	return true
+}
+
+func (f *LocalJobs) finishStatus(id string, jobErr error, cancelled bool, logs []jobs.JobLog) {
+	f.statusLock.Lock()
+	defer f.statusLock.Unlock()
+	delete(f.cancels, id)
+	js, ok := f.statuses[id]
+	if !ok {
+		return
+	}
+	now := time.Now().UTC()
+	js.FinishedAt = &now
+	if cancelled {
+		js.State = jobs.JobStateCancelled
+	} else if jobErr != nil {
+		js.State = jobs.JobStateFailed
+	} else {
+		js.State = jobs.JobStateCompleted
+	}
+	if jobErr != nil {
+		js.Error = jobErr.Error()
+	}
+	f.logs[id] = logs
+	f.addFinished(id)
+}
+
+// addFinished records a finished job and removes the oldest finished jobs over the history limit.
+func (f *LocalJobs) addFinished(id string) {
+	f.finished = append(f.finished, id)
+	for f.HistoryLimit > 0 && len(f.finished) > f.HistoryLimit {
+		delete(f.statuses, f.finished[0])
+		delete(f.logs, f.finished[0])
+		f.finished = f.finished[1:]
+	}
+}
+
+func localJobID(id string) int {
+	v, _ := strconv.Atoi(id)
+	return v
+}
@@ -15,3 +15,12 @@ func TestLocalJobs(t *testing.T) {
 	}
 	jobtest.TestJobQueue(t, newQueue)
 }
+
+func TestLocalJobs_History(t *testing.T) {
+	newQueue := func(queueName string) jobs.JobQueue {
+		q := jobs.NewJobLogger(NewLocalJobs())
+		q.AddQueue("default", 4)
+		return q
+	}
+	jobtest.TestJobHistory(t, newQueue)
+}
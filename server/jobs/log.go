@@ -26,6 +26,11 @@ func NewJobLogger(jq JobQueue) *JobLogger {
 	}
 }
 
+// Unwrap returns the underlying queue.
+func (w *JobLogger) Unwrap() JobQueue {
+	return w.JobQueue
+}
+
 func (w *JobLogger) Use(jmw JobMiddleware) {
 	w.log.Trace().Msg("jobs: using middleware")
 	w.JobQueue.Use(jmw)
@@ -0,0 +1,165 @@
+package pgjobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/interline-io/transitland-lib/server/jobs"
+	sq "github.com/irees/squirrel"
+)
+
+const jobStatusColumns = "id, queue, job_type, job_args, state, attempt, max_attempts, last_error, created_at, attempted_at, finalized_at"
+
+func (f *PostgresJobs) GetJob(ctx context.Context, id string) (*jobs.JobStatus, error) {
+	jobID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, jobs.ErrJobNotFound
+	}
+	q := f.statusQuery().Where(sq.Eq{"id": jobID})
+	ret, err := f.selectStatus(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, jobs.ErrJobNotFound
+	}
+	return &ret[0], nil
+}
+
+func (f *PostgresJobs) ListJobs(ctx context.Context, filter jobs.JobFilter) ([]jobs.JobStatus, error) {
+	q := f.statusQuery().OrderBy("id desc")
+	if filter.Queue != "" {
+		q = q.Where(sq.Eq{"queue": f.queueName(filter.Queue)})
+	}
+	if filter.JobType != "" {
+		q = q.Where(sq.Eq{"job_type": filter.JobType})
+	}
+	if len(filter.States) > 0 {
+		q = q.Where(sq.Eq{"state": filter.States})
+	}
+	if filter.After != "" {
+		after, err := strconv.ParseInt(filter.After, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where(sq.Lt{"id": after})
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(uint64(filter.Limit))
+	}
+	return f.selectStatus(ctx, q)
+}
+
+func (f *PostgresJobs) JobLogs(ctx context.Context, id string) ([]jobs.JobLog, error) {
+	js, err := f.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := f.db.QueryContext(
+		ctx,
+		`select attempt, level, message, created_at from tl_job_logs where job_id = $1 order by id`,
+		js.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ret []jobs.JobLog
+	for rows.Next() {
+		l := jobs.JobLog{}
+		if err := rows.Scan(&l.Attempt, &l.Level, &l.Message, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		ret = append(ret, l)
+	}
+	return ret, rows.Err()
+}
+
+// CancelJob marks a waiting job as cancelled.
+// Running jobs are flagged, and the worker running the job cancels its context.
+func (f *PostgresJobs) CancelJob(ctx context.Context, id string) error {
+	js, err := f.GetJob(ctx, id)
+	if err != nil {
+		return err
+	}
+	res, err := f.db.ExecContext(
+		ctx,
+		`update tl_jobs set
+			state = case when state = $2 then $4 else state end,
+			finalized_at = case when state = $2 then now() else finalized_at end,
+			cancel_requested = true,
+			updated_at = now()
+		where id = $1 and state in ($2, $3)`,
+		js.ID,
+		StateAvailable,
+		StateRunning,
+		StateCancelled,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return jobs.ErrJobFinished
+	}
+	return nil
+}
+
+func (f *PostgresJobs) statusQuery() sq.SelectBuilder {
+	q := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select(jobStatusColumns).
+		From("tl_jobs")
+	if f.queuePrefix != "" {
+		q = q.Where(sq.Like{"queue": f.queuePrefix + ":%"})
+	}
+	return q
+}
+
+func (f *PostgresJobs) selectStatus(ctx context.Context, q sq.SelectBuilder) ([]jobs.JobStatus, error) {
+	qstr, qargs, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := f.db.QueryContext(ctx, qstr, qargs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ret []jobs.JobStatus
+	for rows.Next() {
+		var (
+			id          int64
+			args        []byte
+			lastError   sql.NullString
+			attemptedAt sql.NullTime
+			finalizedAt sql.NullTime
+		)
+		js := jobs.JobStatus{}
+		if err := rows.Scan(&id, &js.Queue, &js.JobType, &args, &js.State, &js.Attempt, &js.MaxAttempts, &lastError, &js.CreatedAt, &attemptedAt, &finalizedAt); err != nil {
+			return nil, err
+		}
+		js.ID = strconv.FormatInt(id, 10)
+		if f.queuePrefix != "" {
+			js.Queue = strings.TrimPrefix(js.Queue, f.queuePrefix+":")
+		}
+		if err := json.Unmarshal(args, &js.JobArgs); err != nil {
+			return nil, err
+		}
+		js.Error = lastError.String
+		js.StartedAt = nullTime(attemptedAt)
+		js.FinishedAt = nullTime(finalizedAt)
+		ret = append(ret, js)
+	}
+	return ret, rows.Err()
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time.UTC()
+	return &v
+}
@@ -0,0 +1,581 @@
+// Package pgjobs implements a durable job queue stored in Postgres.
+package pgjobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/server/jobs"
+	"github.com/interline-io/transitland-lib/tldb"
+	sq "github.com/irees/squirrel"
+)
+
+func init() {
+	var _ jobs.JobQueue = &PostgresJobs{}
+	var _ jobs.JobHistory = &PostgresJobs{}
+}
+
+// Job states
+const (
+	StateAvailable = jobs.JobStateAvailable
+	StateRunning   = jobs.JobStateRunning
+	StateCompleted = jobs.JobStateCompleted
+	StateFailed    = jobs.JobStateFailed
+	StateDiscarded = jobs.JobStateDiscarded
+	StateCancelled = jobs.JobStateCancelled
+)
+
+const defaultQueue = "default"
+
+// DefaultBackoff doubles the wait between attempts, starting at 10 seconds, up to one hour.
+func DefaultBackoff(attempt int) time.Duration {
+	if attempt > 10 {
+		return time.Hour
+	}
+	return min(time.Duration(1<<attempt)*5*time.Second, time.Hour)
+}
+
+// PostgresJobs is a job queue stored in the tl_jobs table.
+// Workers in any number of processes claim jobs using row locks.
+type PostgresJobs struct {
+	// Number of times a job is attempted before it is marked as failed
+	MaxAttempts int
+	// Wait between retries, by attempt number
+	Backoff func(int) time.Duration
+	// How often idle workers check for new jobs
+	PollInterval time.Duration
+	// Running jobs that have not finished after this duration are returned to the queue;
+	// this recovers jobs from worker processes that exited unexpectedly.
+	RescueAfter time.Duration
+	db          tldb.Ext
+	queuePrefix string
+	workerID    string
+	queues      map[string]int
+	middlewares []jobs.JobMiddleware
+	jobMapper   *jobs.JobMapper
+	lock        sync.Mutex
+	running     bool
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// NewPostgresJobs returns a new queue.
+// The prefix is added to queue names to allow separate queues in the same database.
+func NewPostgresJobs(db tldb.Ext, queuePrefix string) *PostgresJobs {
+	hostname, _ := os.Hostname()
+	return &PostgresJobs{
+		MaxAttempts:  3,
+		Backoff:      DefaultBackoff,
+		PollInterval: 1 * time.Second,
+		RescueAfter:  6 * time.Hour,
+		db:           db,
+		queuePrefix:  queuePrefix,
+		workerID:     fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		queues:       map[string]int{},
+		jobMapper:    jobs.NewJobMapper(),
+	}
+}
+
+func (f *PostgresJobs) Use(mwf jobs.JobMiddleware) {
+	f.middlewares = append(f.middlewares, mwf)
+}
+
+func (f *PostgresJobs) AddQueue(queue string, count int) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.running {
+		return errors.New("cannot add queue while running")
+	}
+	f.queues[queue] += count
+	return nil
+}
+
+func (f *PostgresJobs) AddJobType(jobFn jobs.JobFn) error {
+	return f.jobMapper.AddJobType(jobFn)
+}
+
+func (f *PostgresJobs) AddJobs(ctx context.Context, jobs []jobs.Job) error {
+	for _, job := range jobs {
+		if err := f.AddJob(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *PostgresJobs) AddJob(ctx context.Context, job jobs.Job) error {
+	_, err := f.SubmitJob(ctx, job)
+	return err
+}
+
+func (f *PostgresJobs) SubmitJob(ctx context.Context, job jobs.Job) (string, error) {
+	args, err := json.Marshal(job.JobArgs)
+	if err != nil {
+		return "", err
+	}
+	var uniqueKey sql.NullString
+	if job.Unique {
+		key, err := job.HexKey()
+		if err != nil {
+			return "", err
+		}
+		uniqueKey = sql.NullString{String: f.queueName(job.Queue) + ":" + key, Valid: true}
+	}
+	// Unique jobs that are already waiting to run are ignored
+	var id int64
+	err = f.db.QueryRowContext(
+		ctx,
+		`insert into tl_jobs(queue, job_type, job_args, job_deadline, unique_key, max_attempts) values ($1, $2, $3, $4, $5, $6) on conflict do nothing returning id`,
+		f.queueName(job.Queue),
+		job.JobType,
+		args,
+		job.JobDeadline,
+		uniqueKey,
+		max(1, f.MaxAttempts),
+	).Scan(&id)
+	if err == sql.ErrNoRows && uniqueKey.Valid {
+		log.For(ctx).Trace().Interface("job", job).Msgf("already queued: %s", uniqueKey.String)
+		err = f.db.QueryRowContext(
+			ctx,
+			`select id from tl_jobs where unique_key = $1 and state = $2`,
+			uniqueKey,
+			StateAvailable,
+		).Scan(&id)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+// AddPeriodicJob adds a job on a cron schedule, or at a fixed period if the cron schedule is empty.
+// Schedules are shared between processes so that each scheduled job is added only once.
+func (f *PostgresJobs) AddPeriodicJob(ctx context.Context, jobFunc func() jobs.Job, period time.Duration, cronTab string) error {
+	var next func(time.Time) time.Time
+	schedule := cronTab
+	if cronTab != "" {
+		cs, err := jobs.ParseCron(cronTab)
+		if err != nil {
+			return err
+		}
+		next = cs.Next
+	} else if period > 0 {
+		next = func(t time.Time) time.Time { return t.Add(period) }
+		schedule = period.String()
+	} else {
+		return errors.New("periodic job requires period or cron schedule")
+	}
+	job := jobFunc()
+	key, err := job.HexKey()
+	if err != nil {
+		return err
+	}
+	name := f.queueName(job.Queue) + ":" + key + ":" + schedule
+	if _, err := f.db.ExecContext(
+		ctx,
+		`insert into tl_periodic_jobs(name, next_run_at) values ($1, $2) on conflict do nothing`,
+		name,
+		next(time.Now()).Unix(),
+	); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(f.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// Claim this scheduled run
+			now := time.Now()
+			res, err := f.db.ExecContext(
+				ctx,
+				`update tl_periodic_jobs set next_run_at = $2, updated_at = now() where name = $1 and next_run_at <= $3`,
+				name,
+				next(now).Unix(),
+				now.Unix(),
+			)
+			if err != nil {
+				log.For(ctx).Error().Err(err).Str("schedule", schedule).Msg("jobs: failed to check periodic job")
+				continue
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				continue
+			}
+			if err := f.AddJob(ctx, jobFunc()); err != nil {
+				log.For(ctx).Error().Err(err).Str("schedule", schedule).Msg("jobs: failed to add periodic job")
+			}
+		}
+	}()
+	return nil
+}
+
+func (f *PostgresJobs) RunJob(ctx context.Context, job jobs.Job) error {
+	now := time.Now().In(time.UTC).Unix()
+	if job.JobDeadline > 0 && job.JobDeadline < now {
+		log.Trace().Int64("job_deadline", job.JobDeadline).Int64("now", now).Msg("job skipped - deadline in past")
+		return nil
+	}
+	w, err := f.jobMapper.GetRunner(job.JobType, job.JobArgs)
+	if err != nil {
+		return err
+	}
+	if w == nil {
+		return errors.New("no job")
+	}
+	for _, mwf := range f.middlewares {
+		w = mwf(w, job)
+		if w == nil {
+			return errors.New("no job")
+		}
+	}
+	if job.JobDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, time.Unix(job.JobDeadline, 0))
+		defer cancel()
+	}
+	return w.Run(ctx)
+}
+
+func (f *PostgresJobs) Run(ctx context.Context) error {
+	f.lock.Lock()
+	if f.running {
+		f.lock.Unlock()
+		return errors.New("already running")
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	f.cancel = cancel
+	f.running = true
+	for queue, count := range f.queues {
+		for i := 0; i < count; i++ {
+			f.wg.Add(1)
+			go func() {
+				defer f.wg.Done()
+				f.worker(runCtx, queue)
+			}()
+		}
+	}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.rescuer(runCtx)
+	}()
+	f.lock.Unlock()
+	<-runCtx.Done()
+	f.wg.Wait()
+	return nil
+}
+
+// Stop stops claiming new jobs and waits for running jobs to finish, or for the context to be done.
+// Jobs that are still running when the process exits are returned to the queue after RescueAfter.
+func (f *PostgresJobs) Stop(ctx context.Context) error {
+	f.lock.Lock()
+	if !f.running {
+		f.lock.Unlock()
+		return errors.New("not running")
+	}
+	f.cancel()
+	f.running = false
+	f.lock.Unlock()
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *PostgresJobs) queueName(queue string) string {
+	if queue == "" {
+		queue = defaultQueue
+	}
+	if f.queuePrefix == "" {
+		return queue
+	}
+	return f.queuePrefix + ":" + queue
+}
+
+// worker claims and runs jobs until the context is done.
+func (f *PostgresJobs) worker(ctx context.Context, queue string) {
+	for {
+		found, err := f.runNext(ctx, f.queueName(queue))
+		if err != nil && ctx.Err() == nil {
+			log.For(ctx).Error().Err(err).Str("queue", queue).Msg("jobs: worker error")
+		}
+		if found && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(f.PollInterval):
+		}
+	}
+}
+
+type jobRecord struct {
+	ID          int64
+	Queue       string
+	JobType     string
+	JobArgs     []byte
+	JobDeadline int64
+	UniqueKey   sql.NullString
+	Attempt     int
+	MaxAttempts int
+}
+
+// runNext claims and runs the next available job in the queue.
+func (f *PostgresJobs) runNext(ctx context.Context, queue string) (bool, error) {
+	rec := jobRecord{}
+	err := f.db.QueryRowContext(
+		ctx,
+		`update tl_jobs set state = $3, attempt = attempt + 1, attempted_at = now(), updated_at = now(), worker_id = $2
+		where id = (
+			select id from tl_jobs
+			where queue = $1 and state = $4 and run_at <= now()
+			order by run_at, id
+			limit 1
+			for update skip locked
+		)
+		returning id, queue, job_type, job_args, job_deadline, unique_key, attempt, max_attempts`,
+		queue,
+		f.workerID,
+		StateRunning,
+		StateAvailable,
+	).Scan(&rec.ID, &rec.Queue, &rec.JobType, &rec.JobArgs, &rec.JobDeadline, &rec.UniqueKey, &rec.Attempt, &rec.MaxAttempts)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	job := jobs.Job{
+		Queue:       rec.Queue,
+		JobType:     rec.JobType,
+		JobDeadline: rec.JobDeadline,
+		Unique:      rec.UniqueKey.Valid,
+	}
+	if err := json.Unmarshal(rec.JobArgs, &job.JobArgs); err != nil {
+		return true, f.finish(rec, StateDiscarded, err)
+	}
+
+	// Check deadline
+	now := time.Now().In(time.UTC).Unix()
+	if job.JobDeadline > 0 && job.JobDeadline < now {
+		log.For(ctx).Trace().Int64("job_id", rec.ID).Int64("job_deadline", job.JobDeadline).Int64("now", now).Msg("job skipped - deadline in past")
+		return true, f.finish(rec, StateDiscarded, errors.New("deadline in past"))
+	}
+
+	// Running jobs are allowed to finish after the queue is stopped,
+	// but are cancelled if requested through CancelJob
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	watchDone := make(chan struct{})
+	defer close(watchDone)
+	go f.watchCancel(jobCtx, rec.ID, cancel, watchDone)
+	logBuf := jobs.NewJobLogBuffer(rec.Attempt, jobs.DefaultJobLogLimit)
+	jobErr := f.runRecovered(logBuf.WithContext(jobCtx), job)
+	if err := f.saveLogs(rec.ID, logBuf.Logs()); err != nil {
+		log.For(ctx).Error().Err(err).Int64("job_id", rec.ID).Msg("jobs: failed to save job logs")
+	}
+	if jobCtx.Err() != nil {
+		return true, f.finish(rec, StateCancelled, jobErr)
+	}
+	if jobErr == nil {
+		return true, f.finish(rec, StateCompleted, nil)
+	}
+	deadlinePassed := job.JobDeadline > 0 && job.JobDeadline < time.Now().Unix()
+	if rec.Attempt >= rec.MaxAttempts || deadlinePassed {
+		return true, f.finish(rec, StateFailed, jobErr)
+	}
+	return true, f.retry(rec, jobErr)
+}
+
+// watchCancel cancels a running job when cancellation is requested.
+func (f *PostgresJobs) watchCancel(ctx context.Context, id int64, cancel context.CancelFunc, done chan struct{}) {
+	ticker := time.NewTicker(f.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		requested := false
+		if err := f.db.QueryRowContext(ctx, `select cancel_requested from tl_jobs where id = $1`, id).Scan(&requested); err != nil {
+			if ctx.Err() == nil {
+				log.For(ctx).Error().Err(err).Int64("job_id", id).Msg("jobs: failed to check job cancellation")
+			}
+			continue
+		}
+		if requested {
+			log.For(ctx).Info().Int64("job_id", id).Msg("jobs: cancelling job")
+			cancel()
+			return
+		}
+	}
+}
+
+func (f *PostgresJobs) saveLogs(id int64, logs []jobs.JobLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	q := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Insert("tl_job_logs").
+		Columns("job_id", "attempt", "level", "message", "created_at")
+	for _, l := range logs {
+		q = q.Values(id, l.Attempt, l.Level, l.Message, l.CreatedAt)
+	}
+	qstr, qargs, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = f.db.ExecContext(ctx, qstr, qargs...)
+	return err
+}
+
+func (f *PostgresJobs) runRecovered(ctx context.Context, job jobs.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panic: %v", r)
+		}
+	}()
+	return f.RunJob(ctx, job)
+}
+
+// finish records the final state of a job.
+// Updates use a new context so that results are saved when the queue is stopped.
+func (f *PostgresJobs) finish(rec jobRecord, state string, jobErr error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	res, err := f.db.ExecContext(
+		ctx,
+		`update tl_jobs set state = $2, last_error = $3, finalized_at = now(), updated_at = now()
+		where id = $1 and state = $4 and worker_id = $5 and attempt = $6`,
+		rec.ID,
+		state,
+		errorString(jobErr),
+		StateRunning,
+		f.workerID,
+		rec.Attempt,
+	)
+	return f.checkOwned(res, err, rec, "finish")
+}
+
+// retry returns a job to the queue after the backoff period.
+// If an identical unique job was added in the meantime, this job is discarded.
+func (f *PostgresJobs) retry(rec jobRecord, jobErr error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	backoff := f.Backoff
+	if backoff == nil {
+		backoff = DefaultBackoff
+	}
+	wait := backoff(rec.Attempt)
+	log.Info().Int64("job_id", rec.ID).Str("job_type", rec.JobType).Int("attempt", rec.Attempt).Str("retry_in", wait.String()).Err(jobErr).Msg("jobs: job failed, will retry")
+	res, err := f.db.ExecContext(
+		ctx,
+		`update tl_jobs set
+			state = case when unique_key is not null and exists(select 1 from tl_jobs j2 where j2.unique_key = tl_jobs.unique_key and j2.state = $4 and j2.id <> tl_jobs.id) then $5 else $4 end,
+			run_at = now() + make_interval(secs => $2),
+			last_error = $3,
+			updated_at = now()
+		where id = $1 and state = $6 and worker_id = $7 and attempt = $8`,
+		rec.ID,
+		wait.Seconds(),
+		errorString(jobErr),
+		StateAvailable,
+		StateDiscarded,
+		StateRunning,
+		f.workerID,
+		rec.Attempt,
+	)
+	return f.checkOwned(res, err, rec, "retry")
+}
+
+// checkOwned logs when a job update matched no rows.
+// This happens when the job was rescued and claimed again while this worker was still running it;
+// the newer attempt is left untouched.
+func (f *PostgresJobs) checkOwned(res sql.Result, err error, rec jobRecord, op string) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		log.Info().Int64("job_id", rec.ID).Str("job_type", rec.JobType).Int("attempt", rec.Attempt).Str("op", op).Msg("jobs: job no longer owned by this worker, result not saved")
+	}
+	return nil
+}
+
+// rescuer periodically returns stalled jobs to the queue.
+func (f *PostgresJobs) rescuer(ctx context.Context) {
+	interval := min(f.RescueAfter, 5*time.Minute)
+	if interval <= 0 {
+		return
+	}
+	for {
+		if n, err := f.rescue(ctx); err != nil && ctx.Err() == nil {
+			log.For(ctx).Error().Err(err).Msg("jobs: failed to rescue stalled jobs")
+		} else if n > 0 {
+			log.For(ctx).Info().Int64("count", n).Msg("jobs: rescued stalled jobs")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (f *PostgresJobs) rescue(ctx context.Context) (int64, error) {
+	res, err := f.db.ExecContext(
+		ctx,
+		`update tl_jobs set
+			state = case
+				when cancel_requested then $6
+				when attempt >= max_attempts then $3
+				when unique_key is not null and exists(select 1 from tl_jobs j2 where j2.unique_key = tl_jobs.unique_key and j2.state = $4) then $5
+				else $4 end,
+			finalized_at = case when attempt >= max_attempts or cancel_requested then now() else null end,
+			run_at = now(),
+			last_error = 'job stalled',
+			updated_at = now()
+		where state = $2 and attempted_at < now() - make_interval(secs => $1)`,
+		f.RescueAfter.Seconds(),
+		StateRunning,
+		StateFailed,
+		StateAvailable,
+		StateDiscarded,
+		StateCancelled,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func errorString(err error) sql.NullString {
+	if err == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: err.Error(), Valid: true}
+}
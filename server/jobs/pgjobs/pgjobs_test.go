@@ -0,0 +1,183 @@
+package pgjobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/interline-io/transitland-lib/server/jobs"
+	"github.com/interline-io/transitland-lib/server/jobs/jobtest"
+	"github.com/interline-io/transitland-lib/server/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresJobs(t *testing.T) {
+	if a, ok := testutil.CheckTestDB(); !ok {
+		t.Skip(a)
+		return
+	}
+	db := testutil.MustOpenTestDB(t)
+	newQueue := func(queueName string) jobs.JobQueue {
+		q := NewPostgresJobs(db, queueName)
+		q.PollInterval = 10 * time.Millisecond
+		q.AddQueue("default", 4)
+		return jobs.NewJobLogger(q)
+	}
+	jobtest.TestJobQueue(t, newQueue)
+}
+
+func TestPostgresJobs_History(t *testing.T) {
+	if a, ok := testutil.CheckTestDB(); !ok {
+		t.Skip(a)
+		return
+	}
+	db := testutil.MustOpenTestDB(t)
+	newQueue := func(queueName string) jobs.JobQueue {
+		q := NewPostgresJobs(db, queueName)
+		q.PollInterval = 10 * time.Millisecond
+		q.AddQueue("default", 4)
+		return jobs.NewJobLogger(q)
+	}
+	jobtest.TestJobHistory(t, newQueue)
+}
+
+type flakyWorker struct {
+	failures int64
+	count    *int64
+}
+
+func (w *flakyWorker) Kind() string {
+	return "testFlaky"
+}
+
+func (w *flakyWorker) Run(ctx context.Context) error {
+	if atomic.AddInt64(w.count, 1) <= w.failures {
+		return errors.New("try again")
+	}
+	return nil
+}
+
+func TestPostgresJobs_Retry(t *testing.T) {
+	if a, ok := testutil.CheckTestDB(); !ok {
+		t.Skip(a)
+		return
+	}
+	ctx := context.Background()
+	db := testutil.MustOpenTestDB(t)
+	runQueue := func(t *testing.T, failures int64, maxAttempts int) (int64, string, int) {
+		prefix := fmt.Sprintf("test-retry-%d-%d", os.Getpid(), time.Now().UnixNano())
+		q := NewPostgresJobs(db, prefix)
+		q.PollInterval = 10 * time.Millisecond
+		q.MaxAttempts = maxAttempts
+		q.Backoff = func(int) time.Duration { return 0 }
+		count := int64(0)
+		q.AddQueue("default", 1)
+		q.AddJobType(func() jobs.JobWorker { return &flakyWorker{failures: failures, count: &count} })
+		if err := q.AddJob(ctx, jobs.Job{JobType: "testFlaky", JobArgs: jobs.JobArgs{"test": prefix}}); err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			time.Sleep(1 * time.Second)
+			q.Stop(ctx)
+		}()
+		if err := q.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		state := ""
+		attempt := 0
+		if err := db.QueryRowContext(ctx, `select state, attempt from tl_jobs where queue = $1`, prefix+":default").Scan(&state, &attempt); err != nil {
+			t.Fatal(err)
+		}
+		return count, state, attempt
+	}
+	t.Run("succeeds after retry", func(t *testing.T) {
+		count, state, attempt := runQueue(t, 2, 3)
+		assert.Equal(t, int64(3), count)
+		assert.Equal(t, StateCompleted, state)
+		assert.Equal(t, 3, attempt)
+	})
+	t.Run("fails after max attempts", func(t *testing.T) {
+		count, state, attempt := runQueue(t, 5, 2)
+		assert.Equal(t, int64(2), count)
+		assert.Equal(t, StateFailed, state)
+		assert.Equal(t, 2, attempt)
+	})
+}
+
+func TestPostgresJobs_StaleWorker(t *testing.T) {
+	if a, ok := testutil.CheckTestDB(); !ok {
+		t.Skip(a)
+		return
+	}
+	ctx := context.Background()
+	db := testutil.MustOpenTestDB(t)
+	prefix := fmt.Sprintf("test-stale-%d-%d", os.Getpid(), time.Now().UnixNano())
+	q := NewPostgresJobs(db, prefix)
+	// The job was rescued and claimed again by another worker, which is now on attempt 2
+	var id int64
+	if err := db.QueryRowContext(
+		ctx,
+		`insert into tl_jobs(queue, job_type, state, attempt, max_attempts, worker_id, attempted_at) values ($1, 'testFlaky', $2, 2, 3, 'other-worker', now()) returning id`,
+		prefix+":default",
+		StateRunning,
+	).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	rec := jobRecord{ID: id, JobType: "testFlaky", Attempt: 1, MaxAttempts: 3}
+	checkState := func(t *testing.T) {
+		state := ""
+		if err := db.QueryRowContext(ctx, `select state from tl_jobs where id = $1`, id).Scan(&state); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, StateRunning, state)
+	}
+	t.Run("finish", func(t *testing.T) {
+		if err := q.finish(rec, StateCompleted, nil); err != nil {
+			t.Fatal(err)
+		}
+		checkState(t)
+	})
+	t.Run("retry", func(t *testing.T) {
+		if err := q.retry(rec, errors.New("try again")); err != nil {
+			t.Fatal(err)
+		}
+		checkState(t)
+	})
+}
+
+func TestPostgresJobs_PeriodicJob(t *testing.T) {
+	if a, ok := testutil.CheckTestDB(); !ok {
+		t.Skip(a)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db := testutil.MustOpenTestDB(t)
+	prefix := fmt.Sprintf("test-periodic-%d-%d", os.Getpid(), time.Now().UnixNano())
+	// Two processes sharing the same schedule add each job once
+	var queues []*PostgresJobs
+	for i := 0; i < 2; i++ {
+		q := NewPostgresJobs(db, prefix)
+		q.PollInterval = 10 * time.Millisecond
+		jobFunc := func() jobs.Job { return jobs.Job{JobType: "testPeriodic", JobArgs: jobs.JobArgs{"test": prefix}} }
+		if err := q.AddPeriodicJob(ctx, jobFunc, 200*time.Millisecond, ""); err != nil {
+			t.Fatal(err)
+		}
+		queues = append(queues, q)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	cancel()
+	count := 0
+	if err := db.QueryRowContext(context.Background(), `select count(*) from tl_jobs where queue = $1`, prefix+":default").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	// Schedule is checked at second resolution
+	assert.GreaterOrEqual(t, count, 1)
+	assert.LessOrEqual(t, count, 2)
+	// Invalid schedule
+	assert.Error(t, queues[0].AddPeriodicJob(ctx, func() jobs.Job { return jobs.Job{} }, 0, "invalid"))
+}
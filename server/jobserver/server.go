@@ -4,23 +4,41 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/interline-io/transitland-lib/internal/util"
+	"github.com/interline-io/transitland-lib/server/auth/mw/usercheck"
 	"github.com/interline-io/transitland-lib/server/jobs"
 	"github.com/interline-io/transitland-lib/server/model"
 )
 
-// NewServer creates a simple api for submitting and running jobs.
+const (
+	defaultJobLimit = 100
+	maxJobLimit     = 1000
+)
+
+// NewServer creates a simple api for submitting, running, and checking the status of jobs.
+// Jobs submitted without a queue are added to queueName.
+// Job status, logs and cancellation require a queue that keeps job history, and the admin role.
 func NewServer(queueName string, workers int) (http.Handler, error) {
 	r := chi.NewRouter()
-	r.HandleFunc("/add", addJobRequest)
+	r.HandleFunc("/add", func(w http.ResponseWriter, req *http.Request) { addJobRequest(queueName, w, req) })
 	r.HandleFunc("/run", runJobRequest)
+	r.Group(func(r chi.Router) {
+		r.Use(usercheck.AdminRequired)
+		r.Get("/", listJobsRequest)
+		r.Get("/{id}", getJobRequest)
+		r.Get("/{id}/logs", getJobLogsRequest)
+		r.Post("/{id}/cancel", cancelJobRequest)
+	})
 	return r, nil
 }
 
 // job response
 type jobResponse struct {
+	ID      string   `json:"id,omitempty"`
 	Status  string   `json:"status"`
 	Success bool     `json:"success"`
 	Error   string   `json:"error,omitempty"`
@@ -28,21 +46,34 @@ type jobResponse struct {
 }
 
 // addJobRequest adds the request to the appropriate queue
-func addJobRequest(w http.ResponseWriter, req *http.Request) {
+func addJobRequest(queueName string, w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	job, err := requestGetJob(req)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if job.Queue == "" {
+		job.Queue = queueName
+	}
 
 	// add job to queue
 	ret := jobResponse{
 		Job: job,
 	}
-	if jobQueue := model.ForContext(ctx).JobQueue; jobQueue == nil {
+	jobQueue := model.ForContext(ctx).JobQueue
+	if jobQueue == nil {
 		ret.Status = "failed"
 		ret.Error = "no job queue available"
+	} else if jh, ok := jobs.GetJobHistory(jobQueue); ok {
+		if id, err := jh.SubmitJob(ctx, job); err != nil {
+			ret.Status = "failed"
+			ret.Error = err.Error()
+		} else {
+			ret.ID = id
+			ret.Status = "added"
+			ret.Success = true
+		}
 	} else if err := jobQueue.AddJob(ctx, job); err != nil {
 		ret.Status = "failed"
 		ret.Error = err.Error()
@@ -79,6 +110,130 @@ func runJobRequest(w http.ResponseWriter, req *http.Request) {
 	writeJobResponse(ret, w)
 }
 
+// listJobsRequest returns jobs, newest first
+func listJobsRequest(w http.ResponseWriter, req *http.Request) {
+	jh, ok := requestJobHistory(w, req)
+	if !ok {
+		return
+	}
+	q := req.URL.Query()
+	filter := jobs.JobFilter{
+		Queue:   q.Get("queue"),
+		JobType: q.Get("job_type"),
+		After:   q.Get("after"),
+		Limit:   defaultJobLimit,
+	}
+	if v := q.Get("state"); v != "" {
+		filter.States = strings.Split(v, ",")
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			util.WriteJsonError(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = min(limit, maxJobLimit)
+	}
+	ret, err := jh.ListJobs(req.Context(), filter)
+	if err != nil {
+		util.WriteJsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if ret == nil {
+		ret = []jobs.JobStatus{}
+	}
+	writeJson(w, map[string]any{"jobs": ret})
+}
+
+// getJobRequest returns the status of a single job
+func getJobRequest(w http.ResponseWriter, req *http.Request) {
+	jh, ok := requestJobHistory(w, req)
+	if !ok {
+		return
+	}
+	js, err := jh.GetJob(req.Context(), chi.URLParam(req, "id"))
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+	writeJson(w, js)
+}
+
+// getJobLogsRequest returns the log messages for a job
+func getJobLogsRequest(w http.ResponseWriter, req *http.Request) {
+	jh, ok := requestJobHistory(w, req)
+	if !ok {
+		return
+	}
+	logs, err := jh.JobLogs(req.Context(), chi.URLParam(req, "id"))
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+	if logs == nil {
+		logs = []jobs.JobLog{}
+	}
+	writeJson(w, map[string]any{"logs": logs})
+}
+
+// cancelJobRequest cancels a waiting or running job
+func cancelJobRequest(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	jh, ok := requestJobHistory(w, req)
+	if !ok {
+		return
+	}
+	id := chi.URLParam(req, "id")
+	if err := jh.CancelJob(ctx, id); err != nil {
+		writeJobError(w, err)
+		return
+	}
+	js, err := jh.GetJob(ctx, id)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+	writeJson(w, js)
+}
+
+// requestJobHistory gets the job history from the request context, or writes an error
+func requestJobHistory(w http.ResponseWriter, req *http.Request) (jobs.JobHistory, bool) {
+	jobQueue := model.ForContext(req.Context()).JobQueue
+	if jobQueue == nil {
+		util.WriteJsonError(w, "no job queue available", http.StatusNotFound)
+		return nil, false
+	}
+	jh, ok := jobs.GetJobHistory(jobQueue)
+	if !ok {
+		util.WriteJsonError(w, "job queue does not support job history", http.StatusNotFound)
+		return nil, false
+	}
+	return jh, true
+}
+
+// writeJobError writes an error with an appropriate status code
+func writeJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, jobs.ErrJobNotFound):
+		util.WriteJsonError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, jobs.ErrJobFinished):
+		util.WriteJsonError(w, err.Error(), http.StatusConflict)
+	default:
+		util.WriteJsonError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// writeJson writes a JSON response
+func writeJson(w http.ResponseWriter, v any) {
+	rj, err := json.Marshal(v)
+	if err != nil {
+		util.WriteJsonError(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(rj)
+}
+
 // requestGetJob parses job from request body
 func requestGetJob(req *http.Request) (jobs.Job, error) {
 	var job jobs.Job
@@ -1,11 +1,22 @@
 package jobserver
 
 import (
+	"context"
+	"encoding/json"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/interline-io/transitland-lib/server/auth/mw/usercheck"
+	"github.com/interline-io/transitland-lib/server/jobs"
+	localjobs "github.com/interline-io/transitland-lib/server/jobs/local"
+	"github.com/interline-io/transitland-lib/server/model"
 	"github.com/interline-io/transitland-lib/server/testutil"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMain(m *testing.M) {
@@ -15,3 +26,95 @@ func TestMain(m *testing.M) {
 	}
 	os.Exit(m.Run())
 }
+
+type testWorker struct {
+	Wait bool `json:"wait"`
+}
+
+func (w *testWorker) Kind() string {
+	return "test"
+}
+
+func (w *testWorker) Run(ctx context.Context) error {
+	if w.Wait {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func TestJobServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobQueue := jobs.NewJobLogger(localjobs.NewLocalJobs())
+	jobQueue.AddQueue("default", 1)
+	jobQueue.AddJobType(func() jobs.JobWorker { return &testWorker{} })
+	srv, err := NewServer("default", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := model.AddConfig(model.Config{JobQueue: jobQueue})(srv)
+	adminHandler := usercheck.AdminDefaultMiddleware("test")(handler)
+	doRequest := func(method string, path string, body string, expectCode int) map[string]any {
+		rr := httptest.NewRecorder()
+		adminHandler.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		assert.Equal(t, expectCode, rr.Code, rr.Body.String())
+		ret := map[string]any{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &ret); err != nil {
+			t.Fatal(err)
+		}
+		return ret
+	}
+
+	// Add jobs; the first job waits until cancelled
+	added := doRequest("POST", "/add", `{"job_type":"test","job_args":{"wait":true}}`, http.StatusOK)
+	waitID, _ := added["id"].(string)
+	assert.NotEmpty(t, waitID)
+	added = doRequest("POST", "/add", `{"job_type":"test","job_args":{"wait":false}}`, http.StatusOK)
+	queuedID, _ := added["id"].(string)
+	assert.NotEmpty(t, queuedID)
+
+	// List and get
+	list := doRequest("GET", "/?job_type=test&state=available", "", http.StatusOK)
+	assert.Len(t, list["jobs"], 2)
+	job := doRequest("GET", "/"+queuedID, "", http.StatusOK)
+	assert.Equal(t, jobs.JobStateAvailable, job["state"])
+	doRequest("GET", "/999", "", http.StatusNotFound)
+	doRequest("GET", "/?limit=x", "", http.StatusBadRequest)
+
+	// Job history requires the admin role
+	for _, h := range []http.Handler{handler, usercheck.UserDefaultMiddleware("test")(handler)} {
+		for _, r := range []struct{ method, path string }{
+			{"GET", "/"},
+			{"GET", "/" + queuedID},
+			{"GET", "/" + queuedID + "/logs"},
+			{"POST", "/" + queuedID + "/cancel"},
+		} {
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(r.method, r.path, nil))
+			assert.Equal(t, http.StatusUnauthorized, rr.Code, r.path)
+		}
+	}
+
+	// Cancel a waiting job
+	job = doRequest("POST", "/"+queuedID+"/cancel", "", http.StatusOK)
+	assert.Equal(t, jobs.JobStateCancelled, job["state"])
+	doRequest("POST", "/"+queuedID+"/cancel", "", http.StatusConflict)
+
+	// Cancel a running job
+	go jobQueue.Run(ctx)
+	for i := 0; i < 100 && job["state"] != jobs.JobStateRunning; i++ {
+		time.Sleep(10 * time.Millisecond)
+		job = doRequest("GET", "/"+waitID, "", http.StatusOK)
+	}
+	assert.Equal(t, jobs.JobStateRunning, job["state"])
+	doRequest("POST", "/"+waitID+"/cancel", "", http.StatusOK)
+	for i := 0; i < 100 && job["state"] != jobs.JobStateCancelled; i++ {
+		time.Sleep(10 * time.Millisecond)
+		job = doRequest("GET", "/"+waitID, "", http.StatusOK)
+	}
+	assert.Equal(t, jobs.JobStateCancelled, job["state"])
+	assert.Equal(t, "context canceled", job["error"])
+	logs := doRequest("GET", "/"+waitID+"/logs", "", http.StatusOK)
+	assert.NotEmpty(t, logs["logs"])
+}
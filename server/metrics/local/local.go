@@ -6,6 +6,12 @@ import (
 	"github.com/interline-io/transitland-lib/server/metrics"
 )
 
+func init() {
+	if err := metrics.RegisterProvider("local", func() metrics.MetricProvider { return NewLocalMetric() }); err != nil {
+		panic(err)
+	}
+}
+
 type LocalMetric struct{}
 
 func NewLocalMetric() *LocalMetric {
@@ -20,6 +26,13 @@ func (m *LocalMetric) NewApiMetric(handlerName string) metrics.ApiMetric {
 	return &LocalMetric{}
 }
 
+func (m *LocalMetric) NewFetchMetric() metrics.FetchMetric {
+	return &LocalMetric{}
+}
+
+func (m *LocalMetric) AddStatusSource(metrics.StatusSource) {
+}
+
 func (m *LocalMetric) MetricsHandler() http.Handler {
 	return nil
 }
@@ -30,5 +43,8 @@ func (m *LocalMetric) AddStartedJob(queueName string, jobType string) {
 func (m *LocalMetric) AddCompletedJob(queueName string, jobType string, success bool) {
 }
 
+func (m *LocalMetric) AddFetch(urlType string, success bool) {
+}
+
 func (m *LocalMetric) AddResponse(method string, responseCode int, requestSize int64, responseSize int64, responseTime float64) {
 }
@@ -1,6 +1,13 @@
 package metrics
 
-import "net/http"
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/interline-io/log"
+)
 
 type ApiMetric interface {
 	AddResponse(method string, responseCode int, requestSize int64, responseSize int64, responseTime float64)
@@ -11,9 +18,24 @@ type JobMetric interface {
 	AddCompletedJob(string, string, bool)
 }
 
+// FetchMetric records the outcome of feed fetches by URL type.
+type FetchMetric interface {
+	AddFetch(urlType string, success bool)
+}
+
+// StatusSource provides values that are read when metrics are collected.
+type StatusSource interface {
+	// RTTimestamps returns the header timestamp of the most recent message for each realtime topic.
+	RTTimestamps(context.Context) map[string]uint64
+	// ActiveFeedVersionCount returns the number of active feed versions.
+	ActiveFeedVersionCount(context.Context) (int, error)
+}
+
 type MetricProvider interface {
 	NewApiMetric(handlerName string) ApiMetric
 	NewJobMetric(queue string) JobMetric
+	NewFetchMetric() FetchMetric
+	AddStatusSource(StatusSource)
 	MetricsHandler() http.Handler
 }
 
@@ -21,3 +43,37 @@ type Config struct {
 	EnableMetrics   bool
 	MetricsProvider string
 }
+
+var providersLock sync.Mutex
+var providers = map[string]func() MetricProvider{}
+
+// RegisterProvider registers a metric provider by name.
+func RegisterProvider(name string, f func() MetricProvider) error {
+	providersLock.Lock()
+	defer providersLock.Unlock()
+	if _, ok := providers[name]; ok {
+		return fmt.Errorf("metric provider '%s' already registered", name)
+	}
+	log.Tracef("Registering metric provider: %s", name)
+	providers[name] = f
+	return nil
+}
+
+// NewProvider returns the metric provider selected in the config.
+// Returns nil if metrics are not enabled.
+func NewProvider(cfg Config) (MetricProvider, error) {
+	if !cfg.EnableMetrics {
+		return nil, nil
+	}
+	name := cfg.MetricsProvider
+	if name == "" {
+		name = "local"
+	}
+	providersLock.Lock()
+	f, ok := providers[name]
+	providersLock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown metric provider '%s'", name)
+	}
+	return f(), nil
+}
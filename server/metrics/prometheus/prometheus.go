@@ -0,0 +1,242 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/server/metrics"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func init() {
+	if err := metrics.RegisterProvider("prometheus", func() metrics.MetricProvider { return NewPromMetric() }); err != nil {
+		panic(err)
+	}
+}
+
+const namespace = "transitland"
+
+// Time allowed for reading status values during collection
+const statusTimeout = 10 * time.Second
+
+// PromMetric is a metric provider that exports metrics in the Prometheus text format.
+type PromMetric struct {
+	registry        *prom.Registry
+	requests        *prom.CounterVec
+	requestDuration *prom.HistogramVec
+	requestSize     *prom.HistogramVec
+	responseSize    *prom.HistogramVec
+	jobsStarted     *prom.CounterVec
+	jobsCompleted   *prom.CounterVec
+	fetches         *prom.CounterVec
+	rtStaleness     *prom.Desc
+	rtTimestamp     *prom.Desc
+	activeFVs       *prom.Desc
+	statusLock      sync.Mutex
+	statusSources   []metrics.StatusSource
+	now             func() time.Time
+}
+
+func NewPromMetric() *PromMetric {
+	httpLabels := []string{"handler", "method", "code"}
+	m := &PromMetric{
+		registry: prom.NewRegistry(),
+		requests: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests.",
+		}, httpLabels),
+		requestDuration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request duration.",
+			Buckets:   prom.DefBuckets,
+		}, httpLabels),
+		requestSize: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_size_bytes",
+			Help:      "HTTP request size.",
+			Buckets:   prom.ExponentialBuckets(100, 10, 6),
+		}, httpLabels),
+		responseSize: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_response_size_bytes",
+			Help:      "HTTP response size.",
+			Buckets:   prom.ExponentialBuckets(100, 10, 6),
+		}, httpLabels),
+		jobsStarted: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "jobs_started_total",
+			Help:      "Number of jobs started.",
+		}, []string{"queue", "job_type"}),
+		jobsCompleted: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "jobs_completed_total",
+			Help:      "Number of jobs completed.",
+		}, []string{"queue", "job_type", "success"}),
+		fetches: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "feed_fetches_total",
+			Help:      "Number of feed fetches.",
+		}, []string{"url_type", "success"}),
+		rtStaleness: prom.NewDesc(
+			prom.BuildFQName(namespace, "rt", "feed_staleness_seconds"),
+			"Age of the most recent GTFS Realtime message header timestamp.",
+			[]string{"topic"},
+			nil,
+		),
+		rtTimestamp: prom.NewDesc(
+			prom.BuildFQName(namespace, "rt", "feed_timestamp_seconds"),
+			"GTFS Realtime message header timestamp.",
+			[]string{"topic"},
+			nil,
+		),
+		activeFVs: prom.NewDesc(
+			prom.BuildFQName(namespace, "", "active_feed_versions"),
+			"Number of active feed versions.",
+			nil,
+			nil,
+		),
+		now: time.Now,
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.requestSize,
+		m.responseSize,
+		m.jobsStarted,
+		m.jobsCompleted,
+		m.fetches,
+		&statusCollector{m},
+	)
+	return m
+}
+
+func (m *PromMetric) NewApiMetric(handlerName string) metrics.ApiMetric {
+	return &promApiMetric{handler: handlerName, m: m}
+}
+
+func (m *PromMetric) NewJobMetric(queue string) metrics.JobMetric {
+	return &promJobMetric{queue: queue, m: m}
+}
+
+func (m *PromMetric) NewFetchMetric() metrics.FetchMetric {
+	return &promFetchMetric{m: m}
+}
+
+// AddStatusSource adds a source of values that are read when metrics are collected.
+func (m *PromMetric) AddStatusSource(s metrics.StatusSource) {
+	m.statusLock.Lock()
+	defer m.statusLock.Unlock()
+	m.statusSources = append(m.statusSources, s)
+}
+
+func (m *PromMetric) MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+//////////
+
+type promApiMetric struct {
+	handler string
+	m       *PromMetric
+}
+
+func (a *promApiMetric) AddResponse(method string, responseCode int, requestSize int64, responseSize int64, responseTime float64) {
+	code := strconv.Itoa(responseCode)
+	a.m.requests.WithLabelValues(a.handler, method, code).Inc()
+	a.m.requestDuration.WithLabelValues(a.handler, method, code).Observe(responseTime)
+	if requestSize >= 0 {
+		a.m.requestSize.WithLabelValues(a.handler, method, code).Observe(float64(requestSize))
+	}
+	a.m.responseSize.WithLabelValues(a.handler, method, code).Observe(float64(responseSize))
+}
+
+type promJobMetric struct {
+	queue string
+	m     *PromMetric
+}
+
+func (a *promJobMetric) AddStartedJob(queueName string, jobType string) {
+	a.m.jobsStarted.WithLabelValues(a.queueName(queueName), jobType).Inc()
+}
+
+func (a *promJobMetric) AddCompletedJob(queueName string, jobType string, success bool) {
+	a.m.jobsCompleted.WithLabelValues(a.queueName(queueName), jobType, strconv.FormatBool(success)).Inc()
+}
+
+func (a *promJobMetric) queueName(queueName string) string {
+	if queueName == "" {
+		return a.queue
+	}
+	return queueName
+}
+
+type promFetchMetric struct {
+	m *PromMetric
+}
+
+func (a *promFetchMetric) AddFetch(urlType string, success bool) {
+	a.m.fetches.WithLabelValues(urlType, strconv.FormatBool(success)).Inc()
+}
+
+//////////
+
+// statusCollector reads values from status sources when metrics are collected.
+type statusCollector struct {
+	m *PromMetric
+}
+
+func (c *statusCollector) Describe(ch chan<- *prom.Desc) {
+	ch <- c.m.rtStaleness
+	ch <- c.m.rtTimestamp
+	ch <- c.m.activeFVs
+}
+
+func (c *statusCollector) Collect(ch chan<- prom.Metric) {
+	c.m.statusLock.Lock()
+	sources := append([]metrics.StatusSource{}, c.m.statusSources...)
+	c.m.statusLock.Unlock()
+	if len(sources) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), statusTimeout)
+	defer cancel()
+	now := c.m.now()
+	timestamps := map[string]uint64{}
+	activeCount := 0
+	activeOk := false
+	for _, s := range sources {
+		for topic, ts := range s.RTTimestamps(ctx) {
+			if ts > timestamps[topic] {
+				timestamps[topic] = ts
+			}
+		}
+		count, err := s.ActiveFeedVersionCount(ctx)
+		if err != nil {
+			log.For(ctx).Error().Err(err).Msg("metrics: failed to count active feed versions")
+			continue
+		}
+		activeCount += count
+		activeOk = true
+	}
+	for topic, ts := range timestamps {
+		if ts == 0 {
+			continue
+		}
+		t := time.Unix(int64(ts), 0)
+		ch <- prom.MustNewConstMetric(c.m.rtTimestamp, prom.GaugeValue, float64(ts), topic)
+		ch <- prom.MustNewConstMetric(c.m.rtStaleness, prom.GaugeValue, now.Sub(t).Seconds(), topic)
+	}
+	if activeOk {
+		ch <- prom.MustNewConstMetric(c.m.activeFVs, prom.GaugeValue, float64(activeCount))
+	}
+}
@@ -0,0 +1,102 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/interline-io/transitland-lib/server/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStatus struct {
+	timestamps  map[string]uint64
+	activeCount int
+	err         error
+}
+
+func (s *testStatus) RTTimestamps(context.Context) map[string]uint64 {
+	return s.timestamps
+}
+
+func (s *testStatus) ActiveFeedVersionCount(context.Context) (int, error) {
+	return s.activeCount, s.err
+}
+
+func scrape(t *testing.T, m metrics.MetricProvider) string {
+	rr := httptest.NewRecorder()
+	m.MetricsHandler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestPromMetric(t *testing.T) {
+	now := time.Unix(1700000100, 0)
+	m := NewPromMetric()
+	m.now = func() time.Time { return now }
+
+	api := m.NewApiMetric("rest")
+	api.AddResponse("GET", 200, 0, 1234, 0.25)
+	api.AddResponse("GET", 200, 0, 100, 0.05)
+	api.AddResponse("POST", 500, 10, 0, 1.5)
+
+	jobs := m.NewJobMetric("default")
+	jobs.AddStartedJob("", "fetch")
+	jobs.AddCompletedJob("", "fetch", true)
+	jobs.AddCompletedJob("other", "fetch", false)
+
+	fetches := m.NewFetchMetric()
+	fetches.AddFetch("static_current", true)
+	fetches.AddFetch("static_current", true)
+	fetches.AddFetch("realtime_trip_updates", false)
+
+	m.AddStatusSource(&testStatus{
+		timestamps:  map[string]uint64{"rtdata:BA:realtime_trip_updates": 1700000000, "empty": 0},
+		activeCount: 3,
+	})
+	m.AddStatusSource(&testStatus{err: errors.New("database unavailable")})
+
+	body := scrape(t, m)
+	expect := []string{
+		`transitland_http_requests_total{code="200",handler="rest",method="GET"} 2`,
+		`transitland_http_requests_total{code="500",handler="rest",method="POST"} 1`,
+		`transitland_http_request_duration_seconds_count{code="200",handler="rest",method="GET"} 2`,
+		`transitland_http_response_size_bytes_sum{code="200",handler="rest",method="GET"} 1334`,
+		`transitland_jobs_started_total{job_type="fetch",queue="default"} 1`,
+		`transitland_jobs_completed_total{job_type="fetch",queue="default",success="true"} 1`,
+		`transitland_jobs_completed_total{job_type="fetch",queue="other",success="false"} 1`,
+		`transitland_feed_fetches_total{success="true",url_type="static_current"} 2`,
+		`transitland_feed_fetches_total{success="false",url_type="realtime_trip_updates"} 1`,
+		`transitland_rt_feed_timestamp_seconds{topic="rtdata:BA:realtime_trip_updates"} 1.7e+09`,
+		`transitland_rt_feed_staleness_seconds{topic="rtdata:BA:realtime_trip_updates"} 100`,
+		`transitland_active_feed_versions 3`,
+	}
+	for _, line := range expect {
+		assert.Contains(t, body, line)
+	}
+	// Topics without messages are not reported
+	assert.NotContains(t, body, `topic="empty"`)
+}
+
+func TestPromMetric_NoStatus(t *testing.T) {
+	body := scrape(t, NewPromMetric())
+	assert.NotContains(t, body, "transitland_active_feed_versions")
+	assert.NotContains(t, body, "transitland_rt_feed_staleness_seconds")
+}
+
+func TestNewProvider(t *testing.T) {
+	m, err := metrics.NewProvider(metrics.Config{EnableMetrics: true, MetricsProvider: "prometheus"})
+	assert.NoError(t, err)
+	assert.IsType(t, &PromMetric{}, m)
+	m, err = metrics.NewProvider(metrics.Config{MetricsProvider: "prometheus"})
+	assert.NoError(t, err)
+	assert.Nil(t, m)
+	_, err = metrics.NewProvider(metrics.Config{EnableMetrics: true, MetricsProvider: "unknown"})
+	assert.Error(t, err)
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/interline-io/transitland-lib/dmfr"
 	"github.com/interline-io/transitland-lib/internal/clock"
+	"github.com/interline-io/transitland-lib/request"
 	"github.com/interline-io/transitland-lib/server/jobs"
+	"github.com/interline-io/transitland-lib/server/metrics"
 )
 
 type Config struct {
@@ -16,6 +18,7 @@ type Config struct {
 	Checker                 Checker
 	Actions                 Actions
 	JobQueue                jobs.JobQueue
+	Metrics                 metrics.MetricProvider
 	Clock                   clock.Clock
 	Secrets                 []dmfr.Secret
 	ValidateLargeFiles      bool
@@ -26,6 +29,8 @@ type Config struct {
 	LoaderBatchSize         int
 	LoaderStopTimeBatchSize int
 	MaxRadius               float64
+	HostLimiter             *request.HostLimiter
+	AllowedOrigins          []string // origins allowed to open WebSocket subscriptions; empty for same origin only
 }
 
 var finderCtxKey = &contextKey{"finderConfig"}
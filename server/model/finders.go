@@ -50,6 +50,9 @@ type EntityLoader interface {
 	AgenciesByIDs(context.Context, []int) ([]*Agency, []error)
 	AgenciesByOnestopIDs(context.Context, *int, *AgencyFilter, []string) ([][]*Agency, error)
 	AgencyPlacesByAgencyIDs(context.Context, *int, *AgencyPlaceFilter, []int) ([][]*AgencyPlace, error)
+	AreasByIDs(context.Context, []int) ([]*Area, []error)
+	AreasByFeedVersionIDs(context.Context, *int, []int) ([][]*Area, error)
+	AreasByStopIDs(context.Context, *int, []int) ([][]*Area, error)
 	CalendarDatesByServiceIDs(context.Context, *int, *CalendarDateFilter, []int) ([][]*CalendarDate, error)
 	CalendarsByIDs(context.Context, []int) ([]*Calendar, []error)
 	CensusDatasetLayersByDatasetIDs(context.Context, []int) ([][]*CensusLayer, []error)
@@ -64,6 +67,15 @@ type EntityLoader interface {
 	CensusSourcesByDatasetIDs(context.Context, *int, *CensusSourceFilter, []int) ([][]*CensusSource, error)
 	CensusTableByIDs(context.Context, []int) ([]*CensusTable, []error)
 	CensusValuesByGeographyIDs(context.Context, *int, []string, []string) ([][]*CensusValue, error)
+	FareLegRulesByAreaIDs(context.Context, *int, *FareLegRuleFilter, []int) ([][]*FareLegRule, error)
+	FareLegRulesByFeedVersionIDs(context.Context, *int, *FareLegRuleFilter, []int) ([][]*FareLegRule, error)
+	FareLegRulesByNetworkIDs(context.Context, *int, *FareLegRuleFilter, []int) ([][]*FareLegRule, error)
+	FareMediaByIDs(context.Context, []int) ([]*FareMedia, []error)
+	FareMediaByFeedVersionIDs(context.Context, *int, []int) ([][]*FareMedia, error)
+	FareProductsByFareLegRuleIDs(context.Context, *int, []int) ([][]*FareProduct, error)
+	FareProductsByFareTransferRuleIDs(context.Context, *int, []int) ([][]*FareProduct, error)
+	FareProductsByFeedVersionIDs(context.Context, *int, *FareProductFilter, []int) ([][]*FareProduct, error)
+	FareTransferRulesByFeedVersionIDs(context.Context, *int, []int) ([][]*FareTransferRule, error)
 	FeedFetchesByFeedIDs(context.Context, *int, *FeedFetchFilter, []int) ([][]*FeedFetch, error)
 	FeedInfosByFeedVersionIDs(context.Context, *int, []int) ([][]*FeedInfo, error)
 	FeedsByIDs(context.Context, []int) ([]*Feed, []error)
@@ -76,9 +88,14 @@ type EntityLoader interface {
 	FeedVersionsByIDs(context.Context, []int) ([]*FeedVersion, []error)
 	FeedVersionServiceLevelsByFeedVersionIDs(context.Context, *int, *FeedVersionServiceLevelFilter, []int) ([][]*FeedVersionServiceLevel, error)
 	FeedVersionServiceWindowByFeedVersionIDs(context.Context, []int) ([]*FeedVersionServiceWindow, []error)
+	FeedVersionChangesByFeedVersionIDs(context.Context, []int) ([]*FeedVersionChanges, []error)
 	FrequenciesByTripIDs(context.Context, *int, []int) ([][]*Frequency, error)
 	LevelsByIDs(context.Context, []int) ([]*Level, []error)
 	LevelsByParentStationIDs(context.Context, *int, []int) ([][]*Level, error)
+	MdsTripSummariesByFeedIDs(context.Context, *int, *MdsTripSummaryFilter, []int) ([][]*MdsTripSummary, error)
+	NetworksByIDs(context.Context, []int) ([]*Network, []error)
+	NetworksByFeedVersionIDs(context.Context, *int, []int) ([][]*Network, error)
+	NetworksByRouteIDs(context.Context, *int, []int) ([][]*Network, error)
 	OperatorsByAgencyIDs(context.Context, []int) ([]*Operator, []error)
 	OperatorsByCOIFs(context.Context, []int) ([]*Operator, []error)
 	OperatorsByFeedIDs(context.Context, *int, *OperatorFilter, []int) ([][]*Operator, error)
@@ -103,6 +120,7 @@ type EntityLoader interface {
 	StopExternalReferencesByStopIDs(context.Context, []int) ([]*StopExternalReference, []error)
 	StopObservationsByStopIDs(context.Context, *int, *StopObservationFilter, []int) ([][]*StopObservation, error)
 	StopPlacesByStopID(context.Context, []StopPlaceParam) ([]*StopPlace, []error)
+	StopsByAreaIDs(context.Context, *int, *StopFilter, []int) ([][]*Stop, error)
 	StopsByFeedVersionIDs(context.Context, *int, *StopFilter, []int) ([][]*Stop, error)
 	StopsByIDs(context.Context, []int) ([]*Stop, []error)
 	StopsByLevelIDs(context.Context, *int, *StopFilter, []int) ([][]*Stop, error)
@@ -146,6 +164,9 @@ type RTFinder interface {
 	StopTimezone(context.Context, int, string) (*time.Location, bool)
 	GetGtfsTripID(context.Context, int) (string, bool)
 	GetMessage(context.Context, string, string) (*pb.FeedMessage, bool)
+	// subscription methods
+	SubscribeVehiclePositions(context.Context, *VehiclePositionFilter) (<-chan []*VehiclePosition, error)
+	SubscribeTripUpdates(context.Context, *TripUpdateFilter) (<-chan []*RTTripUpdate, error)
 }
 
 // GbfsFinder manages and looks up GBFS data
@@ -121,6 +121,7 @@ type Stop struct {
 	SearchRank      *string
 	WithinFeatures  tt.Strings
 	WithRouteID     tt.Int
+	WithAreaID      tt.Int
 	gtfs.Stop
 }
 
@@ -146,6 +147,34 @@ type FeedInfo struct {
 	gtfs.FeedInfo
 }
 
+type FareProduct struct {
+	WithFareLegRuleID      tt.Int // internal
+	WithFareTransferRuleID tt.Int // internal
+	gtfs.FareProduct
+}
+
+type FareLegRule struct {
+	gtfs.FareLegRule
+}
+
+type FareTransferRule struct {
+	gtfs.FareTransferRule
+}
+
+type FareMedia struct {
+	gtfs.FareMedia
+}
+
+type Area struct {
+	WithStopID tt.Int // internal
+	gtfs.Area
+}
+
+type Network struct {
+	WithRouteID tt.Int // internal
+	gtfs.Network
+}
+
 type Pathway struct {
 	gtfs.Pathway
 }
@@ -154,6 +183,10 @@ type FeedVersionFileInfo struct {
 	dmfr.FeedVersionFileInfo
 }
 
+type FeedVersionChanges struct {
+	dmfr.FeedVersionChanges
+}
+
 type FeedVersionGtfsImport struct {
 	WarningCount             *json.RawMessage `json:"warning_count"`
 	EntityCount              *json.RawMessage `json:"entity_count"`
@@ -343,6 +343,22 @@ type EntityDeleteResult struct {
 	ID int `json:"id"`
 }
 
+// Search options for fare leg rules
+type FareLegRuleFilter struct {
+	// Search for fare leg rules with this GTFS leg_group_id
+	LegGroupID *string `json:"leg_group_id,omitempty"`
+	// Search for fare leg rules with this GTFS fare_product_id
+	FareProductID *string `json:"fare_product_id,omitempty"`
+}
+
+// Search options for fare products
+type FareProductFilter struct {
+	// Search for fare products with this GTFS fare_product_id
+	FareProductID *string `json:"fare_product_id,omitempty"`
+	// Search for fare products with this GTFS rider_category_id
+	RiderCategoryID *string `json:"rider_category_id,omitempty"`
+}
+
 type Feature struct {
 	ID         *string      `json:"id,omitempty"`
 	Geometry   *tt.Geometry `json:"geometry,omitempty"`
@@ -512,6 +528,50 @@ type Itinerary struct {
 	From      *Waypoint `json:"from"`
 	To        *Waypoint `json:"to"`
 	Legs      []*Leg    `json:"legs,omitempty"`
+	// Candidate fares for the transit legs of this itinerary, calculated from GTFS Fares v2 rules and ordered by number of unmatched legs and then by total
+	Fares []*ItineraryFare `json:"fares"`
+}
+
+// A candidate fare for an itinerary
+type ItineraryFare struct {
+	// Total amount of all fare products
+	Total float64 `json:"total"`
+	// Currency of the total amount
+	Currency string `json:"currency"`
+	// Fare products used to pay for the itinerary
+	Products []*ItineraryFareProduct `json:"products"`
+	// Indexes of itinerary legs that did not match any fare leg rule
+	UnmatchedLegs []int `json:"unmatched_legs"`
+}
+
+// A fare product used to pay for one or more itinerary legs
+type ItineraryFareProduct struct {
+	// Fare product ID
+	FareProductID string `json:"fare_product_id"`
+	// Fare product name
+	FareProductName *string `json:"fare_product_name,omitempty"`
+	// Rider category ID
+	RiderCategoryID *string `json:"rider_category_id,omitempty"`
+	// Fare media ID
+	FareMediaID *string `json:"fare_media_id,omitempty"`
+	// Fare product amount
+	Amount float64 `json:"amount"`
+	// Fare product currency
+	Currency string `json:"currency"`
+	// Indexes of itinerary legs paid for by this fare product
+	Legs []int `json:"legs"`
+	// True if this fare product was applied by a fare transfer rule
+	Transfer bool `json:"transfer"`
+}
+
+// Search options for jobs
+type JobFilter struct {
+	// Only return jobs in this queue
+	Queue *string `json:"queue,omitempty"`
+	// Only return jobs of this type
+	JobType *string `json:"job_type,omitempty"`
+	// Only return jobs in these states
+	States []string `json:"states,omitempty"`
 }
 
 type Leg struct {
@@ -586,6 +646,33 @@ type LicenseFilter struct {
 	RedistributionAllowed *LicenseValue `json:"redistribution_allowed,omitempty"`
 }
 
+// Number of MDS trips starting in a zone during an hour
+type MdsTripSummary struct {
+	// Start of the hour, in UTC
+	Hour time.Time `json:"hour"`
+	// Geohash of the trip start locations
+	Zone string `json:"zone"`
+	// Number of trips
+	TripCount int `json:"trip_count"`
+	// Average trip duration, in seconds
+	AvgDuration *float64 `json:"avg_duration,omitempty"`
+	// Average trip distance, in meters
+	AvgDistance *float64 `json:"avg_distance,omitempty"`
+	FeedID      int      `json:"-"`
+}
+
+// Search options for MDS trip summaries
+type MdsTripSummaryFilter struct {
+	// Include trips starting at or after this time
+	StartTime *time.Time `json:"start_time,omitempty"`
+	// Include trips starting before this time
+	EndTime *time.Time `json:"end_time,omitempty"`
+	// Geohash precision used for zones, from 1 to 7; default is 6
+	ZonePrecision *int `json:"zone_precision,omitempty"`
+	// Include only trips with this vehicle type
+	VehicleType *string `json:"vehicle_type,omitempty"`
+}
+
 // Current user metadata
 type Me struct {
 	// Internal identifier
@@ -715,6 +802,16 @@ type PointRadius struct {
 type Query struct {
 }
 
+// See https://gtfs.org/realtime/reference/#message-stoptimeevent
+type RTStopTimeEvent struct {
+	// GTFS-RT StopTimeEvent time, in Unix epoch seconds
+	Time *int `json:"time,omitempty"`
+	// GTFS-RT StopTimeEvent delay, in seconds
+	Delay *int `json:"delay,omitempty"`
+	// GTFS-RT StopTimeEvent uncertainty, in seconds
+	Uncertainty *int `json:"uncertainty,omitempty"`
+}
+
 // See https://gtfs.org/reference/realtime/v2/#message-timerange
 type RTTimeRange struct {
 	// GTFS-RT TimeRange start time, in Unix epoch seconds
@@ -747,6 +844,36 @@ type RTTripDescriptor struct {
 	ScheduleRelationship *string `json:"schedule_relationship,omitempty"`
 }
 
+// See https://gtfs.org/realtime/reference/#message-stoptimeupdate
+type RTTripStopTimeUpdate struct {
+	// GTFS-RT StopTimeUpdate stop sequence
+	StopSequence *int `json:"stop_sequence,omitempty"`
+	// GTFS-RT StopTimeUpdate stop ID
+	StopID *string `json:"stop_id,omitempty"`
+	// GTFS-RT StopTimeUpdate arrival
+	Arrival *RTStopTimeEvent `json:"arrival,omitempty"`
+	// GTFS-RT StopTimeUpdate departure
+	Departure *RTStopTimeEvent `json:"departure,omitempty"`
+	// GTFS-RT StopTimeUpdate schedule relationship. See https://gtfs.org/realtime/reference/#enum-schedulerelationship
+	ScheduleRelationship *string `json:"schedule_relationship,omitempty"`
+}
+
+// [Trip Update](https://gtfs.org/realtime/reference/#message-tripupdate) message provided by a source GTFS Realtime feed.
+type RTTripUpdate struct {
+	// OnestopID of the source GTFS-RT feed
+	FeedOnestopID string `json:"feed_onestop_id"`
+	// GTFS-RT TripUpdate trip. See https://gtfs.org/realtime/reference/#message-tripdescriptor
+	Trip *RTTripDescriptor `json:"trip,omitempty"`
+	// GTFS-RT TripUpdate vehicle. See https://gtfs.org/realtime/reference/#message-vehicledescriptor
+	Vehicle *RTVehicleDescriptor `json:"vehicle,omitempty"`
+	// GTFS-RT TripUpdate timestamp
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	// GTFS-RT TripUpdate trip delay, in seconds
+	Delay *int `json:"delay,omitempty"`
+	// GTFS-RT TripUpdate stop time updates
+	StopTimeUpdates []*RTTripStopTimeUpdate `json:"stop_time_updates"`
+}
+
 // See https://gtfs.org/reference/realtime/v2/#message-vehicledescriptor
 type RTVehicleDescriptor struct {
 	// GTFS-RT VehicleDescriptor vehicle ID
@@ -757,6 +884,31 @@ type RTVehicleDescriptor struct {
 	LicensePlate *string `json:"license_plate,omitempty"`
 }
 
+// A stop that can be reached from the origin
+type ReachableStop struct {
+	// Reachable stop
+	Stop *Stop `json:"stop"`
+	// Earliest arrival time
+	ArrivalTime time.Time `json:"arrival_time"`
+	// Travel time from the origin, in seconds
+	Duration int `json:"duration"`
+	// Number of transfers between vehicles
+	Transfers int `json:"transfers"`
+	StopID    int `json:"-"`
+}
+
+// Stops that can be reached from a point within a maximum travel time
+type ReachableStops struct {
+	// Departure time from the origin
+	DepartAt time.Time `json:"depart_at"`
+	// Maximum travel time, in seconds
+	MaxDuration int `json:"max_duration"`
+	// Reachable stops, ordered by travel time
+	Stops []*ReachableStop `json:"stops"`
+	// Approximate reachable area, as walking buffers around the origin and each reachable stop. Buffers may overlap.
+	Isochrone *tt.MultiPolygon `json:"isochrone,omitempty"`
+}
+
 // MTC GTFS+ Extension: route_attributes.txt
 type RouteAttribute struct {
 	// Route category
@@ -1188,6 +1340,9 @@ type StopTimeFilter struct {
 	ExcludeLast *bool `json:"exclude_last,omitempty"`
 }
 
+type Subscription struct {
+}
+
 // Search options for trips
 type TripFilter struct {
 	// Search for trips scheduled on the specified GTFS calendar service date
@@ -1220,6 +1375,16 @@ type TripStopTimeFilter struct {
 	End *tt.Seconds `json:"end,omitempty"`
 }
 
+// Search options for trip update subscriptions
+type TripUpdateFilter struct {
+	// Search for trip updates from this GTFS-RT feed OnestopID
+	FeedOnestopID *string `json:"feed_onestop_id,omitempty"`
+	// Search for trip updates with these GTFS trip_id values
+	TripIds []string `json:"trip_ids,omitempty"`
+	// Search for trip updates with a stop time update for any of these GTFS stop_id values
+	StopIds []string `json:"stop_ids,omitempty"`
+}
+
 // Source URL and JSON representation of GTFS-RT data used for validation
 type ValidationRealtimeResult struct {
 	// Source URL
@@ -1349,8 +1514,12 @@ type ValidationReportFilter struct {
 
 // [Vehicle Position](https://gtfs.org/reference/realtime/v2/#message-vehicleposition) message provided by a source GTFS Realtime feed.
 type VehiclePosition struct {
+	// OnestopID of the source GTFS-RT feed
+	FeedOnestopID string `json:"feed_onestop_id"`
 	// GTFS-RT VehiclePosition vehicle. See https://gtfs.org/realtime/reference/#message-vehicledescriptor
 	Vehicle *RTVehicleDescriptor `json:"vehicle,omitempty"`
+	// GTFS-RT VehiclePosition trip. See https://gtfs.org/realtime/reference/#message-tripdescriptor
+	Trip *RTTripDescriptor `json:"trip,omitempty"`
 	// GTFS-RT VehiclePosition current vehicle position
 	Position *tt.Point `json:"position,omitempty"`
 	// GTFS-RT VehiclePosition current stop sequence in trip
@@ -1365,6 +1534,16 @@ type VehiclePosition struct {
 	CongestionLevel *string `json:"congestion_level,omitempty"`
 }
 
+// Search options for vehicle position subscriptions
+type VehiclePositionFilter struct {
+	// Search for vehicle positions from this GTFS-RT feed OnestopID
+	FeedOnestopID *string `json:"feed_onestop_id,omitempty"`
+	// Search for vehicle positions on trips with these GTFS route_id values
+	RouteIds []string `json:"route_ids,omitempty"`
+	// Search for vehicle positions within this bounding box
+	Bbox *BoundingBox `json:"bbox,omitempty"`
+}
+
 type Waypoint struct {
 	Lon  float64       `json:"lon"`
 	Lat  float64       `json:"lat"`
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -281,12 +282,18 @@ func serveFromStorage(w http.ResponseWriter, r *http.Request, storage string, fv
 		return fmt.Errorf("failed to access file; could not get from storage: %w", err)
 	}
 	fvkey := fmt.Sprintf("%s.zip", fvsha1)
+	signedUrl := ""
 	if v, ok := store.(request.Presigner); ok {
-		signedUrl, err := v.CreateSignedUrl(ctx, fvkey, downloadKey)
-		if err != nil {
+		signedUrl, err = v.CreateSignedUrl(ctx, fvkey, downloadKey)
+		if errors.Is(err, request.ErrPresignUnavailable) {
+			// Fall back to serving the file directly
+			signedUrl = ""
+		} else if err != nil {
 			util.WriteJsonError(w, "failed access file", http.StatusInternalServerError)
 			return fmt.Errorf("failed to access file; could not presign: %w", err)
 		}
+	}
+	if signedUrl != "" {
 		w.Header().Add("Location", signedUrl)
 		w.WriteHeader(http.StatusFound)
 	} else {
@@ -295,6 +302,7 @@ func serveFromStorage(w http.ResponseWriter, r *http.Request, storage string, fv
 			util.WriteJsonError(w, "failed access file", http.StatusInternalServerError)
 			return fmt.Errorf("failed to access file; not authorized: %w", err)
 		}
+		defer rdr.Close()
 		if _, err := io.Copy(w, rdr); err != nil {
 			util.WriteJsonError(w, "failed access file", http.StatusInternalServerError)
 			return fmt.Errorf("failed to access file; failed to copy to client: %w", err)
@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"context"
+	_ "embed"
+	"strconv"
+
+	oa "github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed feed_version_fares_request.gql
+var feedVersionFaresQuery string
+
+// FeedVersionFaresRequest holds options for a /feed_versions/_/fares request
+type FeedVersionFaresRequest struct {
+	FeedVersionKey string `json:"feed_version_key"`
+	WithCursor
+}
+
+func (r FeedVersionFaresRequest) RequestInfo() RequestInfo {
+	return RequestInfo{
+		Path:        "/feed_versions/{feed_version_key}/fares",
+		Description: `GTFS Fares v2 fare products, fare leg rules, fare transfer rules, fare media, areas and networks for a feed version`,
+		Get: RequestOperation{
+			Query: feedVersionFaresQuery,
+			Operation: &oa.Operation{
+				Summary: "Feed version fares",
+				Parameters: oa.Parameters{
+					&pref{Value: &param{
+						Name:        "feed_version_key",
+						In:          "path",
+						Required:    true,
+						Description: `Feed version lookup key; can be an integer ID or a SHA1 value`,
+						Schema:      newSRVal("string", "", nil),
+						Extensions:  newExt("", "dd7aca4a8e4c90908fd3603c097fabee75fea907", "/feed_versions/dd7aca4a8e4c90908fd3603c097fabee75fea907/fares"),
+					}},
+					newPRefExt("limitParam", "Maximum number of each fare entity to return", "limit=100", ""),
+				},
+			},
+		},
+	}
+}
+
+// Query returns a GraphQL query string and variables.
+func (r FeedVersionFaresRequest) Query(ctx context.Context) (string, map[string]interface{}) {
+	id := 0
+	where := hw{}
+	if v, err := strconv.Atoi(r.FeedVersionKey); err == nil {
+		id = v
+	} else {
+		where["sha1"] = r.FeedVersionKey
+	}
+	return feedVersionFaresQuery, hw{"limit": r.CheckLimit(), "ids": checkIds(id), "where": where}
+}
+
+// ResponseKey .
+func (r FeedVersionFaresRequest) ResponseKey() string {
+	return "feed_versions"
+}
+
+// IncludeNext .
+func (r FeedVersionFaresRequest) IncludeNext() bool {
+	return false
+}
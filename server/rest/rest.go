@@ -37,6 +37,7 @@ func NewServer(graphqlHandler http.Handler) (http.Handler, error) {
 
 	feedHandler := makeHandler(graphqlHandler, "feeds", func() apiHandler { return &FeedRequest{} })
 	feedVersionHandler := makeHandler(graphqlHandler, "feedVersions", func() apiHandler { return &FeedVersionRequest{} })
+	feedVersionFaresHandler := makeHandler(graphqlHandler, "feedVersionFares", func() apiHandler { return &FeedVersionFaresRequest{} })
 	agencyHandler := makeHandler(graphqlHandler, "agencies", func() apiHandler { return &AgencyRequest{} })
 	routeHandler := makeHandler(graphqlHandler, "routes", func() apiHandler { return &RouteRequest{} })
 	tripHandler := makeHandler(graphqlHandler, "trips", func() apiHandler { return &TripRequest{} })
@@ -81,6 +82,7 @@ func NewServer(graphqlHandler http.Handler) (http.Handler, error) {
 	r.HandleFunc("/feed_versions/{feed_version_key}.{format}", feedVersionHandler)
 	r.HandleFunc("/feed_versions/{feed_version_key}", feedVersionHandler)
 	r.HandleFunc("/feeds/{feed_key}/feed_versions", feedVersionHandler)
+	r.HandleFunc("/feed_versions/{feed_version_key}/fares", feedVersionFaresHandler)
 	r.Handle("/feed_versions/{feed_version_key}/download", usercheck.RoleRequired("tl_download_fv_historic")(makeHandlerFunc(graphqlHandler, "feedVersionDownload", feedVersionDownloadHandler)))
 
 	r.HandleFunc("/agencies.{format}", agencyHandler)
@@ -112,6 +114,8 @@ func NewServer(graphqlHandler http.Handler) (http.Handler, error) {
 	r.HandleFunc("/operators/{operator_key}.{format}", operatorHandler)
 	r.HandleFunc("/operators/{operator_key}", operatorHandler)
 
+	r.HandleFunc("/tiles/{layer}/{z}/{x}/{y}.mvt", makeHandlerFunc(graphqlHandler, "tiles", tileHandler))
+
 	// OnestopID generic handler
 	r.Handle("/onestop_id/{onestop_id}", &OnestopIdEntityRedirectRequest{})
 
@@ -29,19 +29,21 @@ var RestHandlersList = []RestHandlers{
 	&StopDepartureRequest{}, // /stops/{stop_key}/departures
 
 	// Individual resource endpoints (for direct lookups)
-	&FeedKeyRequest{},        // /feeds/{feed_key}
-	&FeedVersionKeyRequest{}, // /feed_versions/{feed_version_key}
-	&OperatorKeyRequest{},    // /operators/{operator_key}
-	&AgencyKeyRequest{},      // /agencies/{agency_key}
-	&RouteKeyRequest{},       // /routes/{route_key}
-	&TripEntityRequest{},     // /routes/{route_key}/trips/{id}
-	&StopEntityRequest{},     // /stops/{stop_key}
+	&FeedKeyRequest{},          // /feeds/{feed_key}
+	&FeedVersionKeyRequest{},   // /feed_versions/{feed_version_key}
+	&FeedVersionFaresRequest{}, // /feed_versions/{feed_version_key}/fares
+	&OperatorKeyRequest{},      // /operators/{operator_key}
+	&AgencyKeyRequest{},        // /agencies/{agency_key}
+	&RouteKeyRequest{},         // /routes/{route_key}
+	&TripEntityRequest{},       // /routes/{route_key}/trips/{id}
+	&StopEntityRequest{},       // /stops/{stop_key}
 
 	// Download/special endpoints
 	&FeedDownloadLatestFeedVersionRequest{}, // /feeds/{feed_key}/download_latest_feed_version
 	&FeedVersionDownloadRequest{},           // /feed_versions/{feed_version_key}/download
 	&FeedDownloadRtRequest{},                // /feeds/{feed_key}/download_latest_rt/{rt_type}.{format}
 	&OnestopIdEntityRedirectRequest{},       // /onestop_id/{onestop_id} - redirect to entity by Onestop ID
+	&TileRequest{},                          // /tiles/{layer}/{z}/{x}/{y}.mvt
 }
 
 func GenerateOpenAPI(restPrefix string, opts ...SchemaOption) (*oa.T, error) {
@@ -253,7 +255,8 @@ var gqlScalarToOASchema = map[string]oa.Schema{
 		Type: oa.NewBoolSchema().Type,
 	},
 	"Strings": {
-		Type: oa.NewArraySchema().Type,
+		Type:  oa.NewArraySchema().Type,
+		Items: oa.NewStringSchema().NewRef(),
 	},
 	"Color": {
 		Type: oa.NewStringSchema().Type,
@@ -380,6 +383,7 @@ func queryRecurse(gs *ast.Schema, recurseValue any, parentSchema oa.Schemas, lev
 		schema.Type = scalarType.Type
 		schema.Format = scalarType.Format
 		schema.Example = scalarType.Example
+		schema.Items = scalarType.Items
 	} else {
 		schema.Type = oa.NewObjectSchema().Type
 		if gqlType != "" {
@@ -0,0 +1,309 @@
+package rest
+
+import (
+	"context"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	oa "github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/interline-io/log"
+	"github.com/interline-io/transitland-lib/internal/util"
+	"github.com/interline-io/transitland-lib/server/auth/authn"
+	"github.com/interline-io/transitland-lib/server/caches/httpcache"
+	"github.com/interline-io/transitland-lib/server/model"
+	"github.com/interline-io/transitland-lib/tlxy"
+	"github.com/interline-io/transitland-lib/tlxy/mvt"
+	"github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/encoding/geojson"
+)
+
+//go:embed tile_request.gql
+var tileQuery string
+
+// Zoom levels served for each tile layer.
+// Entities are found by bounding box, which is limited in area, so low zoom levels are not available.
+var tileLayerZooms = map[string][2]int{
+	"stops":  {10, 16},
+	"routes": {9, 16},
+}
+
+// Routes are found by their stops; at higher zoom levels, search the surrounding area
+// to include routes that cross the tile without stopping.
+const tileRouteSearchZoom = 11
+
+// Maximum number of pages of entities in a single tile
+const tileMaxPages = 10
+
+// Rendered tiles, keyed by user and request
+var tileCache httpcache.Cacher = httpcache.NewTTLCache(1024, 10*time.Minute)
+
+const tileCacheMaxAge = 600
+
+// TileRequest holds options for a vector tile request
+type TileRequest struct {
+	Layer string `json:"layer"`
+	Z     int    `json:"z,string"`
+	X     int    `json:"x,string"`
+	Y     int    `json:"y,string"`
+	After int    `json:"-"`
+	LicenseFilter
+}
+
+func (r TileRequest) RequestInfo() RequestInfo {
+	return RequestInfo{
+		Path:        "/tiles/{layer}/{z}/{x}/{y}.mvt",
+		Description: `Mapbox Vector Tiles of stops and route geometries from active feed versions. The stops layer is available at zoom levels 10 to 16, and the routes layer at zoom levels 9 to 16.`,
+		Get: RequestOperation{
+			Operation: &oa.Operation{
+				Summary: "Vector tiles",
+				Parameters: oa.Parameters{
+					&pref{Value: &param{
+						Name:        "layer",
+						In:          "path",
+						Required:    true,
+						Description: `Tile layer`,
+						Schema:      newSRVal("string", "", []any{"stops", "routes"}),
+					}},
+					&pref{Value: &param{
+						Name:        "z",
+						In:          "path",
+						Required:    true,
+						Description: `Zoom level`,
+						Schema:      newSRVal("integer", "", nil),
+					}},
+					&pref{Value: &param{
+						Name:        "x",
+						In:          "path",
+						Required:    true,
+						Description: `Tile column`,
+						Schema:      newSRVal("integer", "", nil),
+					}},
+					&pref{Value: &param{
+						Name:        "y",
+						In:          "path",
+						Required:    true,
+						Description: `Tile row`,
+						Schema:      newSRVal("integer", "", nil),
+					}},
+					newPRef("licenseCommercialUseAllowedParam"),
+					newPRef("licenseShareAlikeOptionalParam"),
+					newPRef("licenseCreateDerivedProductParam"),
+					newPRef("licenseRedistributionAllowedParam"),
+					newPRef("licenseUseWithoutAttributionParam"),
+				},
+				Responses: oa.NewResponses(
+					oa.WithStatus(200, &oa.ResponseRef{
+						Value: &oa.Response{
+							Description: toPtr("Success"),
+							Content: oa.Content{
+								mvt.ContentType: &oa.MediaType{
+									Schema: newSRVal("string", "binary", nil),
+								},
+							},
+						},
+					}),
+					oa.WithStatus(400, &oa.ResponseRef{
+						Value: &oa.Response{
+							Description: toPtr("Invalid tile coordinates or zoom level not available for layer"),
+						},
+					}),
+					oa.WithStatus(404, &oa.ResponseRef{
+						Value: &oa.Response{
+							Description: toPtr("Unknown layer"),
+						},
+					}),
+				),
+			},
+		},
+	}
+}
+
+// Query returns a GraphQL query string and variables.
+func (r TileRequest) Query(ctx context.Context) (string, map[string]interface{}) {
+	tile := mvt.Tile{Z: r.Z, X: r.X, Y: r.Y}
+	vars := hw{
+		"limit":          MAXLIMIT,
+		"after":          r.After,
+		"include_stops":  r.Layer == "stops",
+		"include_routes": r.Layer == "routes",
+	}
+	license := checkLicenseFilter(r.LicenseFilter)
+	if r.Layer == "stops" {
+		vars["stop_where"] = hw{"bbox": tileBbox(tile.BufferedBbox()), "license": license}
+	} else if r.Layer == "routes" {
+		if tile.Z > tileRouteSearchZoom {
+			shift := tile.Z - tileRouteSearchZoom
+			tile = mvt.Tile{Z: tileRouteSearchZoom, X: tile.X >> shift, Y: tile.Y >> shift}
+		}
+		vars["route_where"] = hw{"bbox": tileBbox(tile.BufferedBbox()), "license": license}
+	}
+	return tileQuery, vars
+}
+
+func tileHandler(graphqlHandler http.Handler, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	opts := queryToMap(r.URL.Query())
+	for _, k := range []string{"layer", "z", "x", "y"} {
+		opts[k] = chi.URLParam(r, k)
+	}
+	req := TileRequest{}
+	s, err := json.Marshal(opts)
+	if err != nil {
+		util.WriteJsonError(w, "parameter error", http.StatusInternalServerError)
+		return
+	}
+	if err := json.Unmarshal(s, &req); err != nil {
+		util.WriteJsonError(w, "invalid tile coordinates", http.StatusBadRequest)
+		return
+	}
+	zooms, ok := tileLayerZooms[req.Layer]
+	if !ok {
+		util.WriteJsonError(w, "unknown layer", http.StatusNotFound)
+		return
+	}
+	tile, err := mvt.NewTile(req.Z, req.X, req.Y)
+	if err != nil {
+		util.WriteJsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if tile.Z < zooms[0] || tile.Z > zooms[1] {
+		util.WriteJsonError(w, fmt.Sprintf("layer %s is available at zoom levels %d to %d", req.Layer, zooms[0], zooms[1]), http.StatusBadRequest)
+		return
+	}
+
+	// Check the cache; results depend on the user's permissions
+	cacheControl := "public"
+	cacheKey := r.URL.Path + "?" + r.URL.RawQuery
+	if user := authn.ForContext(ctx); user != nil {
+		cacheControl = "private"
+		roles := user.Roles()
+		sort.Strings(roles)
+		cacheKey = user.ID() + ":" + strings.Join(roles, ",") + ":" + cacheKey
+	}
+	var data []byte
+	if cached, ok := tileCache.Get(cacheKey); ok {
+		data, _ = cached.([]byte)
+	} else {
+		data, err = renderTile(ctx, graphqlHandler, req, tile)
+		if err != nil {
+			log.For(ctx).Error().Err(err).Str("tile", tile.String()).Msg("failed to render tile")
+			util.WriteJsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := tileCache.Set(cacheKey, data); err != nil {
+			log.For(ctx).Error().Err(err).Msg("tile cache error")
+		}
+	}
+
+	// Write response
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum(data))
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", cacheControl, tileCacheMaxAge))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", mvt.ContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// renderTile queries the entities for the tile layer and encodes them.
+func renderTile(ctx context.Context, graphqlHandler http.Handler, req TileRequest, tile mvt.Tile) ([]byte, error) {
+	layer := mvt.Layer{Name: req.Layer}
+	for page := 0; page < tileMaxPages; page++ {
+		query, vars := req.Query(ctx)
+		response, err := makeGraphQLRequest(ctx, graphqlHandler, query, vars)
+		if err != nil {
+			return nil, err
+		}
+		ents, _ := response[req.Layer].([]interface{})
+		for _, ent := range ents {
+			v, ok := ent.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			f, err := tileFeature(req.Layer, v)
+			if err != nil {
+				return nil, err
+			}
+			layer.Features = append(layer.Features, f)
+			if id, ok := v["id"].(float64); ok {
+				req.After = int(id)
+			}
+		}
+		if len(ents) < MAXLIMIT {
+			break
+		}
+	}
+	return mvt.Encode(tile, []mvt.Layer{layer})
+}
+
+// tileFeature creates a feature from a GraphQL stop or route response.
+func tileFeature(layer string, ent map[string]interface{}) (*mvt.Feature, error) {
+	f := mvt.Feature{Properties: map[string]any{}}
+	if id, ok := ent["id"].(float64); ok {
+		f.ID = uint64(id)
+	}
+	if v, ok := ent["geometry"]; ok && v != nil {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var g geom.T
+		if err := geojson.Unmarshal(b, &g); err != nil {
+			return nil, err
+		}
+		f.Geometry = g
+	}
+	var keys []string
+	if layer == "stops" {
+		keys = []string{"stop_id", "stop_name", "stop_code", "location_type", "wheelchair_boarding", "onestop_id"}
+	} else {
+		keys = []string{"route_id", "route_short_name", "route_long_name", "route_type", "route_color", "route_text_color", "onestop_id"}
+		if agency, ok := ent["agency"].(map[string]interface{}); ok {
+			setTileProperty(f.Properties, "agency_id", agency["agency_id"])
+			setTileProperty(f.Properties, "agency_name", agency["agency_name"])
+			setTileProperty(f.Properties, "agency_onestop_id", agency["onestop_id"])
+		}
+	}
+	for _, k := range keys {
+		setTileProperty(f.Properties, k, ent[k])
+	}
+	if fv, ok := ent["feed_version"].(map[string]interface{}); ok {
+		setTileProperty(f.Properties, "feed_version_sha1", fv["sha1"])
+		if feed, ok := fv["feed"].(map[string]interface{}); ok {
+			setTileProperty(f.Properties, "feed_onestop_id", feed["onestop_id"])
+		}
+	}
+	return &f, nil
+}
+
+// setTileProperty sets a property, skipping empty values and converting integer values.
+func setTileProperty(props map[string]any, key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			props[key] = v
+		}
+	case float64:
+		if v == float64(int64(v)) {
+			props[key] = int64(v)
+		} else {
+			props[key] = v
+		}
+	case bool:
+		props[key] = v
+	}
+}
+
+func tileBbox(bbox tlxy.BoundingBox) hw {
+	return (&restBbox{BoundingBox: model.BoundingBox(bbox)}).AsJson()
+}
@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/interline-io/transitland-lib/internal/testconfig"
+	"github.com/interline-io/transitland-lib/testdata"
+	"github.com/interline-io/transitland-lib/tlxy"
+	"github.com/interline-io/transitland-lib/tlxy/mvt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTileRequest(t *testing.T) {
+	_, restSrv, _ := testHandlersWithOptions(t, testconfig.Options{
+		Storage: testdata.Path("server", "tmp"),
+	})
+	// Embarcadero BART
+	embr := mvt.TileForPoint(14, tlxy.Point{Lon: -122.396959, Lat: 37.792976})
+	getTile := func(t *testing.T, path string, header http.Header) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rr := httptest.NewRecorder()
+		restSrv.ServeHTTP(rr, req)
+		return rr
+	}
+	decodeTile := func(t *testing.T, rr *httptest.ResponseRecorder) map[string][]*mvt.Feature {
+		layers, err := mvt.Decode(rr.Body.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret := map[string][]*mvt.Feature{}
+		for _, layer := range layers {
+			ret[layer.Name] = layer.Features
+		}
+		return ret
+	}
+	t.Run("stops", func(t *testing.T) {
+		rr := getTile(t, fmt.Sprintf("/tiles/stops/%d/%d/%d.mvt", embr.Z, embr.X, embr.Y), nil)
+		if !assert.Equal(t, 200, rr.Code) {
+			return
+		}
+		assert.Equal(t, mvt.ContentType, rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Header().Get("Cache-Control"), "public")
+		features := decodeTile(t, rr)["stops"]
+		var found *mvt.Feature
+		for _, f := range features {
+			if f.Properties["stop_id"] == "EMBR" {
+				found = f
+			}
+		}
+		if assert.NotNil(t, found, "expected stop EMBR") {
+			assert.Equal(t, "BA", found.Properties["feed_onestop_id"])
+			assert.Equal(t, "Embarcadero", found.Properties["stop_name"])
+			assert.Greater(t, found.ID, uint64(0))
+		}
+	})
+	t.Run("routes", func(t *testing.T) {
+		rr := getTile(t, fmt.Sprintf("/tiles/routes/%d/%d/%d.mvt", embr.Z, embr.X, embr.Y), nil)
+		if !assert.Equal(t, 200, rr.Code) {
+			return
+		}
+		features := decodeTile(t, rr)["routes"]
+		agencies := map[any]bool{}
+		for _, f := range features {
+			agencies[f.Properties["agency_onestop_id"]] = true
+			assert.NotNil(t, f.Properties["route_type"])
+		}
+		assert.True(t, agencies["o-9q9-bayarearapidtransit"], "expected bart routes")
+	})
+	t.Run("license filter", func(t *testing.T) {
+		rr := getTile(t, fmt.Sprintf("/tiles/stops/%d/%d/%d.mvt?license_share_alike_optional=yes", embr.Z, embr.X, embr.Y), nil)
+		if !assert.Equal(t, 200, rr.Code) {
+			return
+		}
+		for _, f := range decodeTile(t, rr)["stops"] {
+			assert.NotEqual(t, "BA", f.Properties["feed_onestop_id"])
+		}
+	})
+	t.Run("not modified", func(t *testing.T) {
+		path := fmt.Sprintf("/tiles/stops/%d/%d/%d.mvt", embr.Z, embr.X, embr.Y)
+		rr := getTile(t, path, nil)
+		etag := rr.Header().Get("ETag")
+		if !assert.NotEmpty(t, etag) {
+			return
+		}
+		rr = getTile(t, path, http.Header{"If-None-Match": []string{etag}})
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+	})
+	t.Run("errors", func(t *testing.T) {
+		tcs := []struct {
+			path string
+			code int
+		}{
+			{"/tiles/unknown/14/0/0.mvt", 404},
+			{"/tiles/stops/14/100000/0.mvt", 400},
+			{"/tiles/stops/2/0/0.mvt", 400},
+			{"/tiles/routes/20/0/0.mvt", 400},
+			{"/tiles/stops/a/b/c.mvt", 400},
+		}
+		for _, tc := range tcs {
+			t.Run(tc.path, func(t *testing.T) {
+				rr := getTile(t, tc.path, nil)
+				assert.Equal(t, tc.code, rr.Code)
+			})
+		}
+	})
+}
@@ -0,0 +1,270 @@
+package stats
+
+import (
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"github.com/interline-io/transitland-lib/dmfr"
+	"github.com/interline-io/transitland-lib/tlcsv"
+	"github.com/interline-io/transitland-lib/tt"
+)
+
+// changeKeyColumns are the columns that identify a row for each file.
+// Rows in other files are identified by their entire contents, so changes are counted as a removal and an addition.
+var changeKeyColumns = map[string][]string{
+	"agency.txt":           {"agency_id"},
+	"stops.txt":            {"stop_id"},
+	"routes.txt":           {"route_id"},
+	"trips.txt":            {"trip_id"},
+	"stop_times.txt":       {"trip_id", "stop_sequence"},
+	"calendar.txt":         {"service_id"},
+	"calendar_dates.txt":   {"service_id", "date"},
+	"fare_attributes.txt":  {"fare_id"},
+	"shapes.txt":           {"shape_id", "shape_pt_sequence"},
+	"frequencies.txt":      {"trip_id", "start_time"},
+	"transfers.txt":        {"from_stop_id", "to_stop_id", "from_trip_id", "to_trip_id", "from_route_id", "to_route_id"},
+	"pathways.txt":         {"pathway_id"},
+	"levels.txt":           {"level_id"},
+	"feed_info.txt":        {},
+	"areas.txt":            {"area_id"},
+	"stop_areas.txt":       {"area_id", "stop_id"},
+	"networks.txt":         {"network_id"},
+	"route_networks.txt":   {"route_id"},
+	"fare_media.txt":       {"fare_media_id"},
+	"fare_products.txt":    {"fare_product_id", "rider_category_id", "fare_media_id"},
+	"rider_categories.txt": {"rider_category_id"},
+	"location_groups.txt":  {"location_group_id"},
+	"booking_rules.txt":    {"booking_rule_id"},
+}
+
+// changeIDColumns are the files where added and removed IDs are reported.
+var changeIDColumns = map[string]string{
+	"routes.txt": "route_id",
+	"stops.txt":  "stop_id",
+}
+
+// NewFeedVersionChangesFromReaders compares the raw rows of two feed versions.
+// Rows are matched by their key columns; matched rows with any differing non-empty value are counted as changed.
+// Only sorted key and row hashes are kept in memory, so duplicate rows are counted individually.
+func NewFeedVersionChangesFromReaders(previous *tlcsv.Reader, current *tlcsv.Reader) (dmfr.FeedVersionChanges, error) {
+	ret := dmfr.NewFeedVersionChanges()
+	adapterA, okA := previous.Adapter.(canFileInfo)
+	adapterB, okB := current.Adapter.(canFileInfo)
+	if !okA || !okB {
+		return *ret, errors.New("adapter does not support FileInfo")
+	}
+	fnsA, err := changeFilenames(adapterA)
+	if err != nil {
+		return *ret, err
+	}
+	fnsB, err := changeFilenames(adapterB)
+	if err != nil {
+		return *ret, err
+	}
+	var fns []string
+	for fn := range fnsA {
+		fns = append(fns, fn)
+	}
+	for fn := range fnsB {
+		if !fnsA[fn] {
+			fns = append(fns, fn)
+		}
+	}
+	sort.Strings(fns)
+
+	var routesAdded, routesRemoved, stopsAdded, stopsRemoved []string
+	for _, fn := range fns {
+		idColumn := changeIDColumns[fn]
+		rowsA, idsA, err := readRowHashes(adapterA, fnsA[fn], fn, idColumn)
+		if err != nil {
+			return *ret, err
+		}
+		rowsB, idsB, err := readRowHashes(adapterB, fnsB[fn], fn, idColumn)
+		if err != nil {
+			return *ret, err
+		}
+		var idsAdded, idsRemoved []string
+		added, removed, changed := compareRowHashes(rowsA, rowsB, func(key uint64) {
+			if idColumn != "" {
+				idsRemoved = append(idsRemoved, idsA[key])
+			}
+		}, func(key uint64) {
+			if idColumn != "" {
+				idsAdded = append(idsAdded, idsB[key])
+			}
+		})
+		if added > 0 {
+			ret.AddedCount[fn] = added
+		}
+		if removed > 0 {
+			ret.RemovedCount[fn] = removed
+		}
+		if changed > 0 {
+			ret.ChangedCount[fn] = changed
+		}
+		sort.Strings(idsAdded)
+		sort.Strings(idsRemoved)
+		switch fn {
+		case "routes.txt":
+			routesAdded, routesRemoved = idsAdded, idsRemoved
+		case "stops.txt":
+			stopsAdded, stopsRemoved = idsAdded, idsRemoved
+		}
+	}
+	ret.RoutesAdded = tt.NewStrings(routesAdded)
+	ret.RoutesRemoved = tt.NewStrings(routesRemoved)
+	ret.StopsAdded = tt.NewStrings(stopsAdded)
+	ret.StopsRemoved = tt.NewStrings(stopsRemoved)
+	return *ret, nil
+}
+
+// rowHash is the key and content hash of a single row.
+type rowHash struct {
+	key  uint64
+	hash uint64
+}
+
+// readRowHashes returns the row hashes for a file, sorted by key and then content.
+// IDs are only kept for files with an ID column.
+func readRowHashes(adapter canFileInfo, ok bool, fn string, idColumn string) ([]rowHash, map[uint64]string, error) {
+	var rows []rowHash
+	ids := map[uint64]string{}
+	if !ok {
+		return rows, ids, nil
+	}
+	hasher := newRowHasher(fn)
+	if err := adapter.ReadRows(fn, func(row tlcsv.Row) {
+		key, hash := hasher.hash(row)
+		rows = append(rows, rowHash{key: key, hash: hash})
+		if idColumn != "" {
+			ids[key], _ = row.Get(idColumn)
+		}
+	}); err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].key == rows[j].key {
+			return rows[i].hash < rows[j].hash
+		}
+		return rows[i].key < rows[j].key
+	})
+	return rows, ids, nil
+}
+
+// compareRowHashes merges two sorted lists of row hashes and counts added, removed and changed rows.
+// Rows sharing a key are first matched by content; any remaining rows are paired as changed.
+func compareRowHashes(rowsA []rowHash, rowsB []rowHash, onRemoved func(uint64), onAdded func(uint64)) (int, int, int) {
+	added, removed, changed := 0, 0, 0
+	i, j := 0, 0
+	for i < len(rowsA) || j < len(rowsB) {
+		if j >= len(rowsB) || (i < len(rowsA) && rowsA[i].key < rowsB[j].key) {
+			removed++
+			onRemoved(rowsA[i].key)
+			i++
+			continue
+		}
+		if i >= len(rowsA) || rowsB[j].key < rowsA[i].key {
+			added++
+			onAdded(rowsB[j].key)
+			j++
+			continue
+		}
+		// Runs of rows with the same key
+		key := rowsA[i].key
+		ie, je := i, j
+		for ie < len(rowsA) && rowsA[ie].key == key {
+			ie++
+		}
+		for je < len(rowsB) && rowsB[je].key == key {
+			je++
+		}
+		unmatchedA, unmatchedB := 0, 0
+		for i < ie || j < je {
+			switch {
+			case j >= je || (i < ie && rowsA[i].hash < rowsB[j].hash):
+				unmatchedA++
+				i++
+			case i >= ie || rowsB[j].hash < rowsA[i].hash:
+				unmatchedB++
+				j++
+			default:
+				i++
+				j++
+			}
+		}
+		paired := min(unmatchedA, unmatchedB)
+		changed += paired
+		for range unmatchedA - paired {
+			removed++
+			onRemoved(key)
+		}
+		for range unmatchedB - paired {
+			added++
+			onAdded(key)
+		}
+	}
+	return added, removed, changed
+}
+
+// changeFilenames returns the set of files with lowercase names that end with .txt
+func changeFilenames(adapter canFileInfo) (map[string]bool, error) {
+	fis, err := adapter.FileInfos()
+	if err != nil {
+		return nil, err
+	}
+	ret := map[string]bool{}
+	for _, fi := range fis {
+		if fi.Name() != strings.ToLower(fi.Name()) || !strings.HasSuffix(fi.Name(), ".txt") {
+			continue
+		}
+		ret[fi.Name()] = true
+	}
+	return ret, nil
+}
+
+// rowHasher returns key and content hashes for rows in a single file.
+type rowHasher struct {
+	keyColumns []string
+	hasKeys    bool
+	columns    []int // header indexes, ordered by column name
+}
+
+func newRowHasher(fn string) *rowHasher {
+	keyColumns, ok := changeKeyColumns[fn]
+	return &rowHasher{keyColumns: keyColumns, hasKeys: ok}
+}
+
+func (h *rowHasher) hash(row tlcsv.Row) (uint64, uint64) {
+	// Order columns by name so column order does not affect the hash
+	if h.columns == nil {
+		h.columns = make([]int, len(row.Header))
+		for i := range row.Header {
+			h.columns[i] = i
+		}
+		sort.Slice(h.columns, func(i, j int) bool { return row.Header[h.columns[i]] < row.Header[h.columns[j]] })
+	}
+	rh := fnv.New64a()
+	for _, i := range h.columns {
+		if i >= len(row.Row) || row.Row[i] == "" {
+			// Empty values are equivalent to missing columns
+			continue
+		}
+		rh.Write([]byte(row.Header[i]))
+		rh.Write([]byte{0})
+		rh.Write([]byte(row.Row[i]))
+		rh.Write([]byte{0})
+	}
+	rowHash := rh.Sum64()
+	if !h.hasKeys {
+		return rowHash, rowHash
+	}
+	kh := fnv.New64a()
+	for _, k := range h.keyColumns {
+		v, _ := row.Get(k)
+		kh.Write([]byte(v))
+		kh.Write([]byte{0})
+	}
+	return kh.Sum64(), rowHash
+}
@@ -0,0 +1,97 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/interline-io/transitland-lib/internal/testutil"
+	"github.com/interline-io/transitland-lib/tlcsv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFeedVersionChangesFromReaders(t *testing.T) {
+	// Copy example feed and apply modifications
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS(testutil.ExampleDir.URL)); err != nil {
+		t.Fatal(err)
+	}
+	replacements := map[string][][2]string{
+		"routes.txt": {
+			{"AAMV,DTA,50,Airport - Amargosa Valley,,3,,,\n", "NEW,DTA,60,New Route,,3,,,\n"},
+			{"CITY,DTA,40,City,,3,,,", "CITY,DTA,40,City Loop,,3,,,"},
+		},
+		"stops.txt": {
+			{"AMV,Amargosa Valley (Demo),,36.641496", "AMV,Amargosa Valley (Demo),,36.651496"},
+			{"BULLFROG,Bullfrog (Demo),,36.88108,-116.81797,,\n", ""},
+		},
+		"agency.txt": {
+			// Reordered columns are not a change
+			{"agency_id,agency_name,agency_url,agency_timezone", "agency_name,agency_id,agency_url,agency_timezone"},
+			{"DTA,Demo Transit Authority", "Demo Transit Authority,DTA"},
+		},
+		"fare_rules.txt": {
+			// Duplicate rows in files without key columns are counted
+			{"p,AB,,,\n", "p,AB,,,\np,AB,,,\n"},
+		},
+	}
+	for fn, reps := range replacements {
+		data, err := os.ReadFile(filepath.Join(dir, fn))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := string(data)
+		for _, rep := range reps {
+			if !strings.Contains(s, rep[0]) {
+				t.Fatalf("%s does not contain '%s'", fn, rep[0])
+			}
+			s = strings.Replace(s, rep[0], rep[1], 1)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fn), []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readerA, err := tlcsv.NewReader(testutil.ExampleDir.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readerB, err := tlcsv.NewReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("changes", func(t *testing.T) {
+		fvc, err := NewFeedVersionChangesFromReaders(readerA, readerB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, map[string]int{"routes.txt": 1, "fare_rules.txt": 1}, map[string]int(fvc.AddedCount))
+		assert.Equal(t, map[string]int{"routes.txt": 1, "stops.txt": 1}, map[string]int(fvc.RemovedCount))
+		assert.Equal(t, map[string]int{"routes.txt": 1, "stops.txt": 1}, map[string]int(fvc.ChangedCount))
+		assert.Equal(t, []string{"NEW"}, fvc.RoutesAdded.Val)
+		assert.Equal(t, []string{"AAMV"}, fvc.RoutesRemoved.Val)
+		assert.Equal(t, 0, len(fvc.StopsAdded.Val))
+		assert.Equal(t, []string{"BULLFROG"}, fvc.StopsRemoved.Val)
+	})
+	t.Run("same", func(t *testing.T) {
+		fvc, err := NewFeedVersionChangesFromReaders(readerA, readerA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 0, len(fvc.AddedCount))
+		assert.Equal(t, 0, len(fvc.RemovedCount))
+		assert.Equal(t, 0, len(fvc.ChangedCount))
+	})
+}
+
+func TestCompareRowHashes(t *testing.T) {
+	rowsA := []rowHash{{1, 10}, {2, 20}, {2, 21}, {3, 30}}
+	rowsB := []rowHash{{2, 20}, {2, 22}, {2, 23}, {3, 31}, {4, 40}}
+	var removedKeys, addedKeys []uint64
+	added, removed, changed := compareRowHashes(rowsA, rowsB, func(k uint64) { removedKeys = append(removedKeys, k) }, func(k uint64) { addedKeys = append(addedKeys, k) })
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, 2, changed)
+	assert.Equal(t, []uint64{1}, removedKeys)
+	assert.Equal(t, []uint64{2, 4}, addedKeys)
+}
@@ -0,0 +1,131 @@
+package tlcsv
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/interline-io/transitland-lib/causes"
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/tt"
+	"github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/encoding/geojson"
+)
+
+// GeoJSON files are not CSV, so locations.geojson is handled separately from the row based readers and writers.
+
+type geojsonFeatureCollection struct {
+	Type     string            `json:"type"`
+	Features []json.RawMessage `json:"features"`
+}
+
+// Locations reads locations.geojson.
+func (reader *Reader) Locations() (out chan gtfs.Location) {
+	eout := make(chan gtfs.Location, bufferSize)
+	go func() {
+		reader.Adapter.OpenFile(getFilename(&gtfs.Location{}), func(in io.Reader) {
+			for _, ent := range readLocations(in) {
+				eout <- ent
+			}
+		})
+		close(eout)
+	}()
+	return eout
+}
+
+func readLocations(in io.Reader) []gtfs.Location {
+	var ret []gtfs.Location
+	var fc geojsonFeatureCollection
+	if err := json.NewDecoder(in).Decode(&fc); err != nil {
+		ent := gtfs.Location{}
+		ent.AddError(causes.NewFileUnreadableError("locations.geojson", err))
+		return append(ret, ent)
+	}
+	for i, data := range fc.Features {
+		ent := gtfs.Location{}
+		ent.SetLine(i + 1)
+		feature := geojson.Feature{}
+		if err := json.Unmarshal(data, &feature); err != nil {
+			ent.AddError(causes.NewRowParseError(i+1, err))
+			ret = append(ret, ent)
+			continue
+		}
+		ent.LocationID.Set(feature.ID)
+		if feature.Geometry != nil {
+			switch g := feature.Geometry.(type) {
+			case *geom.Polygon:
+				g.SetSRID(4326)
+			case *geom.MultiPolygon:
+				g.SetSRID(4326)
+			}
+			ent.Geometry = tt.NewGeometry(feature.Geometry)
+		}
+		for k, v := range feature.Properties {
+			vs, ok := v.(string)
+			if !ok {
+				if v == nil {
+					continue
+				}
+				vs = fmt.Sprintf("%v", v)
+			}
+			setLocationProperty(&ent, k, vs)
+		}
+		if ent.LocationID.Val == "" {
+			ent.AddError(causes.NewRequiredFieldError("id"))
+		}
+		ret = append(ret, ent)
+	}
+	return ret
+}
+
+func setLocationProperty(ent *gtfs.Location, key string, value string) {
+	switch key {
+	case "stop_name":
+		ent.StopName.Set(value)
+	case "stop_desc":
+		ent.StopDesc.Set(value)
+	case "zone_id":
+		ent.ZoneID.Set(value)
+	case "stop_url":
+		if value != "" {
+			ent.StopURL.Set(value)
+		}
+	default:
+		ent.SetExtra(key, value)
+	}
+}
+
+// writeLocations encodes locations as a GeoJSON FeatureCollection.
+func writeLocations(ents []*gtfs.Location) ([]byte, error) {
+	fc := geojson.FeatureCollection{}
+	for _, ent := range ents {
+		if !ent.Geometry.Valid {
+			return nil, errors.New("location has no geometry")
+		}
+		props := map[string]any{}
+		if ent.StopName.Valid {
+			props["stop_name"] = ent.StopName.Val
+		}
+		if ent.StopDesc.Valid {
+			props["stop_desc"] = ent.StopDesc.Val
+		}
+		if ent.ZoneID.Valid {
+			props["zone_id"] = ent.ZoneID.Val
+		}
+		if ent.StopURL.Valid {
+			props["stop_url"] = ent.StopURL.Val
+		}
+		fc.Features = append(fc.Features, &geojson.Feature{
+			ID:         ent.EntityKey(),
+			Geometry:   ent.Geometry.Val,
+			Properties: props,
+		})
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(&fc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
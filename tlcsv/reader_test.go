@@ -31,3 +31,9 @@ func TestReader(t *testing.T) {
 		})
 	}
 }
+
+func TestReader_Flex(t *testing.T) {
+	testutil.TestReader(t, testutil.ExampleFlex, func() adapters.Reader {
+		return &Reader{Adapter: NewDirAdapter(testutil.ExampleFlex.URL)}
+	})
+}
@@ -1,7 +1,9 @@
 package tlcsv
 
 import (
+	"bytes"
 	"errors"
+	"io"
 	"strings"
 
 	"github.com/interline-io/transitland-lib/adapters"
@@ -19,6 +21,7 @@ type Writer struct {
 	writeExtraColumns bool
 	headers           map[string][]string
 	extraHeaders      map[string][]string
+	locations         []*gtfs.Location
 }
 
 // NewWriter returns a new Writer.
@@ -55,6 +58,28 @@ func (writer *Writer) Delete() error {
 	return nil
 }
 
+// Close writes any buffered non-CSV files and closes the WriterAdapter.
+func (writer *Writer) Close() error {
+	if len(writer.locations) > 0 {
+		type canAddFile interface {
+			AddFile(string, io.Reader) error
+		}
+		writerAdapter, ok := writer.WriterAdapter.(canAddFile)
+		if !ok {
+			return errors.New("writer does not support writing locations.geojson")
+		}
+		data, err := writeLocations(writer.locations)
+		if err != nil {
+			return err
+		}
+		if err := writerAdapter.AddFile(writer.locations[0].Filename(), bytes.NewReader(data)); err != nil {
+			return err
+		}
+		writer.locations = nil
+	}
+	return writer.WriterAdapter.Close()
+}
+
 // NewReader returns a new Reader for the Writer destination.
 func (writer *Writer) NewReader() (adapters.Reader, error) {
 	return NewReader(writer.WriterAdapter.Path())
@@ -99,6 +124,20 @@ func (writer *Writer) AddEntities(ents []tt.Entity) ([]string, error) {
 		return originalEids, nil
 	}
 
+	// GeoJSON locations are buffered and written on Close
+	if _, ok := ents[0].(*gtfs.Location); ok {
+		var eids []string
+		for _, ent := range ents {
+			v, ok := ent.(*gtfs.Location)
+			if !ok {
+				return nil, errors.New("all entities must be same type")
+			}
+			writer.locations = append(writer.locations, v)
+			eids = append(eids, v.EntityKey())
+		}
+		return eids, nil
+	}
+
 	// Normal write path
 	return writer.addBatch(ents)
 }
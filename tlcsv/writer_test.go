@@ -85,3 +85,35 @@ func TestWriterExtraColumn(t *testing.T) {
 		t.Error("expected to get a stop with extra columns")
 	}
 }
+
+func TestWriter_Flex(t *testing.T) {
+	fe := testutil.ExampleFlex
+	reader, err := NewReader(fe.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpdir := t.TempDir()
+	writer, err := NewWriter(tmpdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testutil.DirectCopy(reader, writer); err != nil {
+		t.Fatal(err)
+	}
+	// locations.geojson is written when the writer is closed
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reader2, err := NewReader(tmpdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.CheckReader(t, fe, reader2)
+	count := 0
+	for ent := range reader2.Locations() {
+		assert.True(t, ent.Geometry.Valid)
+		assert.Empty(t, ent.LoadErrors())
+		count++
+	}
+	assert.Equal(t, 2, count)
+}
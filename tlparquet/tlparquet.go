@@ -0,0 +1,13 @@
+// Package tlparquet provides a Writer that exports GTFS as one GeoParquet file per table.
+package tlparquet
+
+import (
+	"github.com/interline-io/transitland-lib/adapters"
+	"github.com/interline-io/transitland-lib/ext"
+)
+
+func init() {
+	// Register writers
+	w := func(url string) (adapters.Writer, error) { return NewWriter(url) }
+	ext.RegisterWriter("parquet", w)
+}
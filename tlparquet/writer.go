@@ -0,0 +1,380 @@
+package tlparquet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/interline-io/transitland-lib/adapters"
+	"github.com/interline-io/transitland-lib/gtfs"
+	"github.com/interline-io/transitland-lib/service"
+	"github.com/interline-io/transitland-lib/tlcsv"
+	"github.com/interline-io/transitland-lib/tt"
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/parquet-go/parquet-go"
+	geom "github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/encoding/wkb"
+)
+
+type hasEntityKey interface {
+	EntityKey() string
+}
+
+type hasFeedVersionID interface {
+	GetFeedVersionID() int
+}
+
+const (
+	geometryColumn    = "geometry"
+	feedVersionColumn = "feed_version_id"
+)
+
+// Writer writes each GTFS table to a Parquet file in the output directory, e.g. stops.txt to stops.parquet.
+// Columns use typed values where possible: tt.Int as int64, tt.Seconds as int32 seconds since midnight,
+// tt.Float as double, tt.Date as date, and everything else as strings.
+// Stops, shapes and flex locations include a WKB geometry column with GeoParquet metadata.
+// Every table includes a feed_version_id column to distinguish feeds in merged output.
+type Writer struct {
+	path   string
+	tables map[string]*table
+}
+
+type table struct {
+	file    *os.File
+	writer  *parquet.Writer
+	columns []column
+}
+
+type column struct {
+	name  string
+	index []int // struct field index, nil for geometry and feed_version_id
+}
+
+// NewWriter returns a new Writer for an output directory.
+// The path may include a "parquet://" prefix.
+func NewWriter(path string) (*Writer, error) {
+	path = strings.TrimPrefix(path, "parquet://")
+	if path == "" {
+		return nil, errors.New("no output path")
+	}
+	return &Writer{
+		path:   path,
+		tables: map[string]*table{},
+	}, nil
+}
+
+func (writer *Writer) String() string {
+	return writer.path
+}
+
+// Open the Writer.
+func (writer *Writer) Open() error {
+	return os.MkdirAll(writer.path, 0755)
+}
+
+// Create the necessary files for the Writer.
+func (writer *Writer) Create() error {
+	return nil
+}
+
+// Delete the Writer.
+func (writer *Writer) Delete() error {
+	return nil
+}
+
+// NewReader is not supported; Parquet output can not be read back as GTFS.
+func (writer *Writer) NewReader() (adapters.Reader, error) {
+	return nil, errors.New("parquet writer does not support reading")
+}
+
+// Close flushes and closes all open Parquet files.
+func (writer *Writer) Close() error {
+	var errs []error
+	for _, t := range writer.tables {
+		if err := t.writer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		if err := t.file.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	writer.tables = map[string]*table{}
+	return errors.Join(errs...)
+}
+
+// AddEntity writes an entity to the output.
+func (writer *Writer) AddEntity(ent tt.Entity) (string, error) {
+	eids, err := writer.AddEntities([]tt.Entity{ent})
+	if err != nil {
+		return "", err
+	}
+	if len(eids) == 0 {
+		return "", errors.New("did not write expected number of entities")
+	}
+	return eids[0], nil
+}
+
+// AddEntities writes entities to the output.
+func (writer *Writer) AddEntities(ents []tt.Entity) ([]string, error) {
+	if len(ents) == 0 {
+		return nil, nil
+	}
+	efn := ents[0].Filename()
+	for _, ent := range ents {
+		if efn != ent.Filename() {
+			return nil, errors.New("all entities must be same type")
+		}
+		// Stop coordinates are loaded into Geometry
+		if v, ok := ent.(*gtfs.Stop); ok {
+			c := v.Coordinates()
+			v.StopLon.Set(c[0])
+			v.StopLat.Set(c[1])
+		}
+	}
+	t, ok := writer.tables[efn]
+	if !ok {
+		var err error
+		t, err = writer.createTable(ents[0])
+		if err != nil {
+			return nil, err
+		}
+		writer.tables[efn] = t
+	}
+	rows := make([]parquet.Row, 0, len(ents))
+	eids := make([]string, 0, len(ents))
+	for _, ent := range ents {
+		row, err := t.row(ent)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+		eid := ent.EntityID()
+		if v, ok := ent.(hasEntityKey); ok {
+			eid = v.EntityKey()
+		}
+		eids = append(eids, eid)
+	}
+	if _, err := t.writer.WriteRows(rows); err != nil {
+		return nil, err
+	}
+	return eids, nil
+}
+
+// createTable creates the output file and schema for an entity type.
+func (writer *Writer) createTable(ent tt.Entity) (*table, error) {
+	header, err := tlcsv.MapperCache.GetHeader(ent)
+	if err != nil {
+		return nil, err
+	}
+	fmap := tlcsv.MapperCache.GetStructTagMap(ent)
+	elem := reflect.ValueOf(ent).Elem()
+	group := parquet.Group{}
+	var columns []column
+	for _, name := range header {
+		fi, ok := fmap[name]
+		if !ok || fi == nil {
+			continue
+		}
+		v := reflectx.FieldByIndexesReadOnly(elem, fi.Index).Interface()
+		group[name] = parquet.Optional(columnNode(v))
+		columns = append(columns, column{name: name, index: fi.Index})
+	}
+	group[feedVersionColumn] = parquet.Optional(parquet.Int(64))
+	columns = append(columns, column{name: feedVersionColumn})
+	options := []parquet.WriterOption{parquet.Compression(&parquet.Snappy)}
+	if geomTypes, ok := geometryTypes(ent); ok {
+		group[geometryColumn] = parquet.Optional(parquet.Leaf(parquet.ByteArrayType))
+		columns = append(columns, column{name: geometryColumn})
+		geo, err := geoMetadata(geomTypes)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, parquet.KeyValueMetadata("geo", geo))
+	}
+	// Parquet group fields are ordered by name
+	sort.Slice(columns, func(i, j int) bool { return columns[i].name < columns[j].name })
+	options = append(options, parquet.NewSchema(tableName(ent), group))
+
+	fn := filepath.Join(writer.path, tableName(ent)+".parquet")
+	f, err := os.Create(fn)
+	if err != nil {
+		return nil, err
+	}
+	return &table{
+		file:    f,
+		writer:  parquet.NewWriter(f, options...),
+		columns: columns,
+	}, nil
+}
+
+// row returns a Parquet row for an entity.
+func (t *table) row(ent tt.Entity) (parquet.Row, error) {
+	elem := reflect.ValueOf(ent).Elem()
+	row := make(parquet.Row, len(t.columns))
+	for i, col := range t.columns {
+		var value parquet.Value
+		var ok bool
+		switch col.name {
+		case feedVersionColumn:
+			if v, fvok := ent.(hasFeedVersionID); fvok {
+				value, ok = parquet.Int64Value(int64(v.GetFeedVersionID())), true
+			}
+		case geometryColumn:
+			g, err := entityGeometry(ent)
+			if err != nil {
+				return nil, err
+			}
+			if g != nil {
+				value, ok = parquet.ByteArrayValue(g), true
+			}
+		default:
+			v := reflectx.FieldByIndexesReadOnly(elem, col.index).Interface()
+			var err error
+			value, ok, err = columnValue(v)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if ok {
+			row[i] = value.Level(0, 1, i)
+		} else {
+			row[i] = parquet.NullValue().Level(0, 0, i)
+		}
+	}
+	return row, nil
+}
+
+// tableName returns the output table name for an entity, e.g. "stops" for stops.txt.
+func tableName(ent tt.Entity) string {
+	fn := ent.Filename()
+	return strings.TrimSuffix(fn, filepath.Ext(fn))
+}
+
+// columnNode returns the Parquet type for a field value.
+func columnNode(v any) parquet.Node {
+	switch v.(type) {
+	case tt.Int, int, int64:
+		return parquet.Int(64)
+	case tt.Seconds:
+		return parquet.Int(32)
+	case tt.Float, tt.CurrencyAmount, float64:
+		return parquet.Leaf(parquet.DoubleType)
+	case tt.Bool, bool:
+		return parquet.Leaf(parquet.BooleanType)
+	case tt.Date:
+		return parquet.Date()
+	case tt.Time:
+		return parquet.Timestamp(parquet.Millisecond)
+	}
+	return parquet.String()
+}
+
+// columnValue returns the Parquet value for a field value, or false if the value is null.
+func columnValue(v any) (parquet.Value, bool, error) {
+	switch a := v.(type) {
+	case tt.Int:
+		return parquet.Int64Value(a.Val), a.Valid, nil
+	case int:
+		return parquet.Int64Value(int64(a)), true, nil
+	case int64:
+		return parquet.Int64Value(a), true, nil
+	case tt.Seconds:
+		return parquet.Int32Value(int32(a.Val)), a.Valid, nil
+	case tt.Float:
+		return parquet.DoubleValue(a.Val), a.Valid, nil
+	case tt.CurrencyAmount:
+		return parquet.DoubleValue(a.Val), a.Valid, nil
+	case float64:
+		return parquet.DoubleValue(a), true, nil
+	case tt.Bool:
+		return parquet.BooleanValue(a.Val), a.Valid, nil
+	case bool:
+		return parquet.BooleanValue(a), true, nil
+	case tt.Date:
+		return parquet.Int32Value(daysSinceEpoch(a.Val)), a.Valid, nil
+	case tt.Time:
+		return parquet.Int64Value(a.Val.UnixMilli()), a.Valid, nil
+	}
+	s, err := tt.ToCsv(v)
+	if err != nil {
+		return parquet.Value{}, false, err
+	}
+	return parquet.ByteArrayValue([]byte(s)), s != "", nil
+}
+
+func daysSinceEpoch(t time.Time) int32 {
+	d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return int32(d.Unix() / 86400)
+}
+
+// geometryTypes returns the GeoParquet geometry types for entities that have a geometry.
+func geometryTypes(ent tt.Entity) ([]string, bool) {
+	switch ent.(type) {
+	case *gtfs.Stop:
+		return []string{"Point"}, true
+	case *service.ShapeLine:
+		return []string{"LineString"}, true
+	case *gtfs.Location:
+		return []string{"Polygon", "MultiPolygon"}, true
+	}
+	return nil, false
+}
+
+// entityGeometry returns the WKB encoded 2D geometry for an entity, or nil if there is no geometry.
+func entityGeometry(ent tt.Entity) ([]byte, error) {
+	var g geom.T
+	switch v := ent.(type) {
+	case *gtfs.Stop:
+		if v.Geometry.Valid {
+			g = v.Geometry.Val
+		}
+	case *service.ShapeLine:
+		if v.Geometry.Valid {
+			// Drop shape_dist_traveled measures; GeoParquet geometries are XY or XYZ
+			g = geom.NewLineStringFlat(geom.XY, flatXY(v.Geometry.Val))
+		}
+	case *gtfs.Location:
+		if v.Geometry.Valid {
+			g = v.Geometry.Val
+		}
+	}
+	if g == nil {
+		return nil, nil
+	}
+	return wkb.Marshal(g, wkb.NDR)
+}
+
+func flatXY(g geom.T) []float64 {
+	stride := g.Stride()
+	coords := g.FlatCoords()
+	ret := make([]float64, 0, len(coords)/stride*2)
+	for i := 0; i+1 < len(coords); i += stride {
+		ret = append(ret, coords[i], coords[i+1])
+	}
+	return ret
+}
+
+// geoMetadata returns the GeoParquet file metadata; the default CRS is OGC:CRS84.
+func geoMetadata(geomTypes []string) (string, error) {
+	md := map[string]any{
+		"version":        "1.1.0",
+		"primary_column": geometryColumn,
+		"columns": map[string]any{
+			geometryColumn: map[string]any{
+				"encoding":       "WKB",
+				"geometry_types": geomTypes,
+			},
+		},
+	}
+	data, err := json.Marshal(md)
+	if err != nil {
+		return "", fmt.Errorf("could not encode geoparquet metadata: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,146 @@
+package tlparquet
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/interline-io/transitland-lib/copier"
+	"github.com/interline-io/transitland-lib/ext"
+	"github.com/interline-io/transitland-lib/internal/testutil"
+	"github.com/interline-io/transitland-lib/tlcsv"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/encoding/wkb"
+)
+
+func TestNewWriter(t *testing.T) {
+	w, err := ext.NewWriter("parquet://output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, ok := w.(*Writer)
+	if !ok {
+		t.Fatalf("got writer %T, expected parquet writer", w)
+	}
+	assert.Equal(t, "output", writer.String())
+}
+
+type testStopRow struct {
+	StopID        *string  `parquet:"stop_id,optional"`
+	StopLat       *float64 `parquet:"stop_lat,optional"`
+	LocationType  *int64   `parquet:"location_type,optional"`
+	FeedVersionID *int64   `parquet:"feed_version_id,optional"`
+	Geometry      []byte   `parquet:"geometry,optional"`
+}
+
+type testStopTimeRow struct {
+	TripID        *string `parquet:"trip_id,optional"`
+	ArrivalTime   *int32  `parquet:"arrival_time,optional"`
+	DepartureTime *int32  `parquet:"departure_time,optional"`
+}
+
+type testCalendarRow struct {
+	ServiceID *string `parquet:"service_id,optional"`
+	StartDate *int32  `parquet:"start_date,optional"`
+}
+
+func readTable[T any](t *testing.T, fn string) ([]T, *parquet.File) {
+	t.Helper()
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pf, err := parquet.OpenFile(f, st.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := parquet.NewGenericReader[T](pf)
+	defer reader.Close()
+	rows := make([]T, pf.NumRows())
+	if n, err := reader.Read(rows); n != len(rows) {
+		t.Fatalf("read %d rows, expected %d: %v", n, len(rows), err)
+	}
+	return rows, pf
+}
+
+func TestWriter(t *testing.T) {
+	reader, err := tlcsv.NewReader(testutil.ExampleDir.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outpath := t.TempDir()
+	writer, err := NewWriter(outpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := copier.QuietCopy(context.Background(), reader, writer); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	t.Run("stops", func(t *testing.T) {
+		rows, pf := readTable[testStopRow](t, filepath.Join(outpath, "stops.parquet"))
+		assert.Equal(t, 9, len(rows))
+		geo, ok := pf.Lookup("geo")
+		assert.True(t, ok, "expected geoparquet metadata")
+		assert.Contains(t, geo, `"primary_column":"geometry"`)
+		for _, row := range rows {
+			if row.StopID == nil || row.Geometry == nil || row.FeedVersionID == nil {
+				t.Fatal("expected stop_id, geometry and feed_version_id")
+			}
+			g, err := wkb.Unmarshal(row.Geometry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, ok := g.(*geom.Point)
+			if !ok {
+				t.Fatalf("got %T, expected point", g)
+			}
+			assert.InDelta(t, *row.StopLat, pt.Y(), 1e-6)
+		}
+	})
+	t.Run("shapes", func(t *testing.T) {
+		rows, _ := readTable[testStopRow](t, filepath.Join(outpath, "shapes.parquet"))
+		assert.Equal(t, 3, len(rows))
+		for _, row := range rows {
+			g, err := wkb.Unmarshal(row.Geometry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := g.(*geom.LineString); !ok {
+				t.Fatalf("got %T, expected linestring", g)
+			}
+		}
+	})
+	t.Run("stop_times", func(t *testing.T) {
+		rows, _ := readTable[testStopTimeRow](t, filepath.Join(outpath, "stop_times.parquet"))
+		assert.Equal(t, 28, len(rows))
+		found := false
+		for _, row := range rows {
+			if *row.TripID == "AB1" && row.DepartureTime != nil && *row.DepartureTime == 8*3600 {
+				found = true
+			}
+		}
+		assert.True(t, found, "expected AB1 departure at 08:00:00")
+	})
+	t.Run("calendar", func(t *testing.T) {
+		rows, _ := readTable[testCalendarRow](t, filepath.Join(outpath, "calendar.parquet"))
+		assert.Equal(t, 2, len(rows))
+		for _, row := range rows {
+			// 2007-01-01
+			assert.Equal(t, int32(13514), *row.StartDate)
+		}
+	})
+}
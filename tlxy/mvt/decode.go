@@ -0,0 +1,211 @@
+package mvt
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/twpayne/go-geom"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Decode decodes a tile.
+// Feature geometries are returned in tile coordinates, as MultiPoint or MultiLineString.
+func Decode(data []byte) ([]Layer, error) {
+	var ret []Layer
+	err := eachField(data, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+		if num != tileLayers {
+			return nil
+		}
+		layer, err := decodeLayer(b)
+		if err != nil {
+			return err
+		}
+		ret = append(ret, layer)
+		return nil
+	})
+	return ret, err
+}
+
+func decodeLayer(data []byte) (Layer, error) {
+	ret := Layer{}
+	var keys []string
+	var values []any
+	var features [][]byte
+	err := eachField(data, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+		switch num {
+		case layerName:
+			ret.Name = string(b)
+		case layerFeatures:
+			features = append(features, b)
+		case layerKeys:
+			keys = append(keys, string(b))
+		case layerValues:
+			value, err := decodeValue(b)
+			if err != nil {
+				return err
+			}
+			values = append(values, value)
+		}
+		return nil
+	})
+	if err != nil {
+		return ret, err
+	}
+	for _, b := range features {
+		f, err := decodeFeature(b, keys, values)
+		if err != nil {
+			return ret, err
+		}
+		ret.Features = append(ret.Features, f)
+	}
+	return ret, nil
+}
+
+func decodeFeature(data []byte, keys []string, values []any) (*Feature, error) {
+	ret := Feature{Properties: map[string]any{}}
+	var gtype uint64
+	var cmds []uint64
+	err := eachField(data, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+		switch num {
+		case featureID:
+			ret.ID = v
+		case featureType:
+			gtype = v
+		case featureTags:
+			tags, err := unpack(b)
+			if err != nil {
+				return err
+			}
+			for i := 0; i+1 < len(tags); i += 2 {
+				if tags[i] >= uint64(len(keys)) || tags[i+1] >= uint64(len(values)) {
+					return errors.New("invalid tag index")
+				}
+				ret.Properties[keys[tags[i]]] = values[tags[i+1]]
+			}
+		case featureGeometry:
+			var err error
+			cmds, err = unpack(b)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret.Geometry, err = decodeGeometry(gtype, cmds)
+	return &ret, err
+}
+
+func decodeGeometry(gtype uint64, cmds []uint64) (geom.T, error) {
+	var parts [][]float64
+	cx, cy := int64(0), int64(0)
+	for i := 0; i < len(cmds); {
+		id, count := int(cmds[i]&0x7), int(cmds[i]>>3)
+		i++
+		if id != cmdMoveTo && id != cmdLineTo {
+			continue
+		}
+		if i+count*2 > len(cmds) {
+			return nil, errors.New("invalid geometry")
+		}
+		for j := 0; j < count; j++ {
+			cx += protowire.DecodeZigZag(cmds[i])
+			cy += protowire.DecodeZigZag(cmds[i+1])
+			i += 2
+			if id == cmdMoveTo && (gtype == geomLineString || len(parts) == 0) {
+				parts = append(parts, nil)
+			}
+			parts[len(parts)-1] = append(parts[len(parts)-1], float64(cx), float64(cy))
+		}
+	}
+	switch gtype {
+	case geomPoint:
+		var coords []float64
+		for _, p := range parts {
+			coords = append(coords, p...)
+		}
+		return geom.NewMultiPointFlat(geom.XY, coords), nil
+	case geomLineString:
+		var coords []float64
+		var ends []int
+		for _, p := range parts {
+			coords = append(coords, p...)
+			ends = append(ends, len(coords))
+		}
+		return geom.NewMultiLineStringFlat(geom.XY, coords, ends), nil
+	}
+	return nil, fmt.Errorf("unsupported geometry type: %d", gtype)
+}
+
+func decodeValue(data []byte) (any, error) {
+	var ret any
+	err := eachField(data, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+		switch num {
+		case valueString:
+			ret = string(b)
+		case valueFloat:
+			ret = math.Float32frombits(uint32(v))
+		case valueDouble:
+			ret = math.Float64frombits(v)
+		case valueInt:
+			ret = int64(v)
+		case valueUint:
+			ret = v
+		case valueSint:
+			ret = protowire.DecodeZigZag(v)
+		case valueBool:
+			ret = protowire.DecodeBool(v)
+		}
+		return nil
+	})
+	return ret, err
+}
+
+// eachField calls the function for each field in a protobuf message.
+func eachField(data []byte, cb func(protowire.Number, protowire.Type, uint64, []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		var v uint64
+		var b []byte
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed32Type:
+			var v32 uint32
+			v32, n = protowire.ConsumeFixed32(data)
+			v = uint64(v32)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(data)
+		case protowire.BytesType:
+			b, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if err := cb(num, typ, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unpack(data []byte) ([]uint64, error) {
+	var ret []uint64
+	for len(data) > 0 {
+		v, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		ret = append(ret, v)
+		data = data[n:]
+	}
+	return ret, nil
+}
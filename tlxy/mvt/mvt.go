@@ -0,0 +1,392 @@
+package mvt
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/twpayne/go-geom"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Extent is the size of the tile coordinate space.
+const Extent = 4096
+
+// Buffer is the number of tile units outside the tile edges included in each tile.
+const Buffer = 64
+
+// ContentType is the media type of an encoded tile.
+const ContentType = "application/vnd.mapbox-vector-tile"
+
+// Feature is a geometry and its properties.
+// Points and line strings are supported, in WGS84 lon/lat coordinates.
+type Feature struct {
+	ID         uint64
+	Geometry   geom.T
+	Properties map[string]any
+}
+
+// Layer is a named collection of features.
+type Layer struct {
+	Name     string
+	Features []*Feature
+}
+
+// Geometry types
+const (
+	geomPoint      = 1
+	geomLineString = 2
+)
+
+// Geometry commands
+const (
+	cmdMoveTo = 1
+	cmdLineTo = 2
+)
+
+// Protobuf field numbers from the vector tile specification, version 2.1
+const (
+	tileLayers      = 3
+	layerName       = 1
+	layerFeatures   = 2
+	layerKeys       = 3
+	layerValues     = 4
+	layerExtent     = 5
+	layerVersion    = 15
+	featureID       = 1
+	featureTags     = 2
+	featureType     = 3
+	featureGeometry = 4
+	valueString     = 1
+	valueFloat      = 2
+	valueDouble     = 3
+	valueInt        = 4
+	valueUint       = 5
+	valueSint       = 6
+	valueBool       = 7
+)
+
+// Encode encodes the layers as a tile.
+// Geometries are clipped to the tile and its buffer; features outside the tile are skipped.
+// Layers with no features in the tile are omitted.
+func Encode(t Tile, layers []Layer) ([]byte, error) {
+	var ret []byte
+	for _, layer := range layers {
+		b, err := encodeLayer(t, layer)
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			continue
+		}
+		ret = protowire.AppendTag(ret, tileLayers, protowire.BytesType)
+		ret = protowire.AppendBytes(ret, b)
+	}
+	return ret, nil
+}
+
+type layerEncoder struct {
+	keys     []string
+	keyIdx   map[string]int
+	values   [][]byte
+	valueIdx map[string]int
+	features [][]byte
+	toTile   func(float64, float64) (float64, float64)
+}
+
+func encodeLayer(t Tile, layer Layer) ([]byte, error) {
+	e := layerEncoder{
+		keyIdx:   map[string]int{},
+		valueIdx: map[string]int{},
+		toTile: func(lon float64, lat float64) (float64, float64) {
+			x, y := project(t.Z, lon, lat)
+			return (x - float64(t.X)) * Extent, (y - float64(t.Y)) * Extent
+		},
+	}
+	for _, f := range layer.Features {
+		if err := e.addFeature(f); err != nil {
+			return nil, err
+		}
+	}
+	if len(e.features) == 0 {
+		return nil, nil
+	}
+	var ret []byte
+	ret = protowire.AppendTag(ret, layerVersion, protowire.VarintType)
+	ret = protowire.AppendVarint(ret, 2)
+	ret = protowire.AppendTag(ret, layerName, protowire.BytesType)
+	ret = protowire.AppendString(ret, layer.Name)
+	for _, f := range e.features {
+		ret = protowire.AppendTag(ret, layerFeatures, protowire.BytesType)
+		ret = protowire.AppendBytes(ret, f)
+	}
+	for _, k := range e.keys {
+		ret = protowire.AppendTag(ret, layerKeys, protowire.BytesType)
+		ret = protowire.AppendString(ret, k)
+	}
+	for _, v := range e.values {
+		ret = protowire.AppendTag(ret, layerValues, protowire.BytesType)
+		ret = protowire.AppendBytes(ret, v)
+	}
+	ret = protowire.AppendTag(ret, layerExtent, protowire.VarintType)
+	ret = protowire.AppendVarint(ret, Extent)
+	return ret, nil
+}
+
+func (e *layerEncoder) addFeature(f *Feature) error {
+	var gtype uint64
+	var parts [][]int
+	switch g := f.Geometry.(type) {
+	case *geom.Point:
+		gtype = geomPoint
+		parts = e.points(g.FlatCoords(), g.Stride())
+	case *geom.MultiPoint:
+		gtype = geomPoint
+		parts = e.points(g.FlatCoords(), g.Stride())
+	case *geom.LineString:
+		gtype = geomLineString
+		parts = e.lines(g.FlatCoords(), g.Stride())
+	case *geom.MultiLineString:
+		gtype = geomLineString
+		for i := 0; i < g.NumLineStrings(); i++ {
+			ls := g.LineString(i)
+			parts = append(parts, e.lines(ls.FlatCoords(), ls.Stride())...)
+		}
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("unsupported geometry type: %T", f.Geometry)
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	tags, err := e.tags(f.Properties)
+	if err != nil {
+		return err
+	}
+	var ret []byte
+	if f.ID > 0 {
+		ret = protowire.AppendTag(ret, featureID, protowire.VarintType)
+		ret = protowire.AppendVarint(ret, f.ID)
+	}
+	if len(tags) > 0 {
+		ret = protowire.AppendTag(ret, featureTags, protowire.BytesType)
+		ret = protowire.AppendBytes(ret, packed(tags))
+	}
+	ret = protowire.AppendTag(ret, featureType, protowire.VarintType)
+	ret = protowire.AppendVarint(ret, gtype)
+	ret = protowire.AppendTag(ret, featureGeometry, protowire.BytesType)
+	ret = protowire.AppendBytes(ret, packed(encodeGeometry(gtype, parts)))
+	e.features = append(e.features, ret)
+	return nil
+}
+
+// points returns a single part containing the points within the tile buffer.
+func (e *layerEncoder) points(coords []float64, stride int) [][]int {
+	var part []int
+	for i := 0; i+1 < len(coords); i += stride {
+		x, y := e.toTile(coords[i], coords[i+1])
+		if x < -Buffer || x > Extent+Buffer || y < -Buffer || y > Extent+Buffer {
+			continue
+		}
+		part = append(part, int(math.Round(x)), int(math.Round(y)))
+	}
+	if len(part) == 0 {
+		return nil
+	}
+	return [][]int{part}
+}
+
+// lines returns the parts of a line string within the tile buffer.
+func (e *layerEncoder) lines(coords []float64, stride int) [][]int {
+	var pts [][2]float64
+	for i := 0; i+1 < len(coords); i += stride {
+		x, y := e.toTile(coords[i], coords[i+1])
+		pts = append(pts, [2]float64{x, y})
+	}
+	var ret [][]int
+	for _, clipped := range clipLine(pts, -Buffer, Extent+Buffer) {
+		// Snap to integer coordinates and drop repeated points
+		var part []int
+		for _, p := range clipped {
+			x, y := int(math.Round(p[0])), int(math.Round(p[1]))
+			if n := len(part); n > 0 && part[n-2] == x && part[n-1] == y {
+				continue
+			}
+			part = append(part, x, y)
+		}
+		if len(part) >= 4 {
+			ret = append(ret, part)
+		}
+	}
+	return ret
+}
+
+// tags returns the key and value indexes for the properties, sorted by key.
+func (e *layerEncoder) tags(props map[string]any) ([]uint64, error) {
+	var keys []string
+	for k, v := range props {
+		if v != nil {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var ret []uint64
+	for _, k := range keys {
+		v, err := encodeValue(props[k])
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %w", k, err)
+		}
+		ki, ok := e.keyIdx[k]
+		if !ok {
+			ki = len(e.keys)
+			e.keyIdx[k] = ki
+			e.keys = append(e.keys, k)
+		}
+		vi, ok := e.valueIdx[string(v)]
+		if !ok {
+			vi = len(e.values)
+			e.valueIdx[string(v)] = vi
+			e.values = append(e.values, v)
+		}
+		ret = append(ret, uint64(ki), uint64(vi))
+	}
+	return ret, nil
+}
+
+func encodeValue(v any) ([]byte, error) {
+	var ret []byte
+	switch a := v.(type) {
+	case string:
+		ret = protowire.AppendTag(ret, valueString, protowire.BytesType)
+		ret = protowire.AppendString(ret, a)
+	case float32:
+		ret = protowire.AppendTag(ret, valueFloat, protowire.Fixed32Type)
+		ret = protowire.AppendFixed32(ret, math.Float32bits(a))
+	case float64:
+		ret = protowire.AppendTag(ret, valueDouble, protowire.Fixed64Type)
+		ret = protowire.AppendFixed64(ret, math.Float64bits(a))
+	case int:
+		ret = protowire.AppendTag(ret, valueInt, protowire.VarintType)
+		ret = protowire.AppendVarint(ret, uint64(a))
+	case int64:
+		ret = protowire.AppendTag(ret, valueInt, protowire.VarintType)
+		ret = protowire.AppendVarint(ret, uint64(a))
+	case uint64:
+		ret = protowire.AppendTag(ret, valueUint, protowire.VarintType)
+		ret = protowire.AppendVarint(ret, a)
+	case bool:
+		ret = protowire.AppendTag(ret, valueBool, protowire.VarintType)
+		ret = protowire.AppendVarint(ret, protowire.EncodeBool(a))
+	default:
+		return nil, fmt.Errorf("unsupported value type: %T", v)
+	}
+	return ret, nil
+}
+
+// encodeGeometry returns the command stream for the parts.
+func encodeGeometry(gtype uint64, parts [][]int) []uint64 {
+	var ret []uint64
+	cx, cy := 0, 0
+	for _, part := range parts {
+		count := len(part) / 2
+		if gtype == geomPoint {
+			ret = append(ret, command(cmdMoveTo, count))
+		}
+		for i := 0; i < count; i++ {
+			if gtype == geomLineString && i == 0 {
+				ret = append(ret, command(cmdMoveTo, 1))
+			} else if gtype == geomLineString && i == 1 {
+				ret = append(ret, command(cmdLineTo, count-1))
+			}
+			x, y := part[i*2], part[i*2+1]
+			ret = append(ret, protowire.EncodeZigZag(int64(x-cx)), protowire.EncodeZigZag(int64(y-cy)))
+			cx, cy = x, y
+		}
+	}
+	return ret
+}
+
+func command(id int, count int) uint64 {
+	return uint64((id & 0x7) | (count << 3))
+}
+
+func packed(values []uint64) []byte {
+	var ret []byte
+	for _, v := range values {
+		ret = protowire.AppendVarint(ret, v)
+	}
+	return ret
+}
+
+// clipLine clips a line to a square, returning the parts inside.
+func clipLine(pts [][2]float64, min float64, max float64) [][][2]float64 {
+	var ret [][][2]float64
+	var cur [][2]float64
+	for i := 1; i < len(pts); i++ {
+		a, b, ok := clipSegment(pts[i-1], pts[i], min, max)
+		if !ok {
+			if len(cur) > 0 {
+				ret = append(ret, cur)
+				cur = nil
+			}
+			continue
+		}
+		if len(cur) == 0 {
+			cur = append(cur, a)
+		}
+		cur = append(cur, b)
+		// The segment left the square
+		if b != pts[i] {
+			ret = append(ret, cur)
+			cur = nil
+		}
+	}
+	if len(cur) > 0 {
+		ret = append(ret, cur)
+	}
+	return ret
+}
+
+// clipSegment clips a segment to a square using the Liang-Barsky algorithm.
+func clipSegment(a [2]float64, b [2]float64, min float64, max float64) ([2]float64, [2]float64, bool) {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	t0, t1 := 0.0, 1.0
+	checks := [][2]float64{
+		{-dx, a[0] - min},
+		{dx, max - a[0]},
+		{-dy, a[1] - min},
+		{dy, max - a[1]},
+	}
+	for _, c := range checks {
+		p, q := c[0], c[1]
+		if p == 0 {
+			if q < 0 {
+				return a, b, false
+			}
+			continue
+		}
+		r := q / p
+		if p < 0 {
+			if r > t1 {
+				return a, b, false
+			} else if r > t0 {
+				t0 = r
+			}
+		} else {
+			if r < t0 {
+				return a, b, false
+			} else if r < t1 {
+				t1 = r
+			}
+		}
+	}
+	ca, cb := a, b
+	if t0 > 0 {
+		ca = [2]float64{a[0] + t0*dx, a[1] + t0*dy}
+	}
+	if t1 < 1 {
+		cb = [2]float64{a[0] + t1*dx, a[1] + t1*dy}
+	}
+	return ca, cb, true
+}
@@ -0,0 +1,177 @@
+package mvt
+
+import (
+	"testing"
+
+	"github.com/interline-io/transitland-lib/tlxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/twpayne/go-geom"
+)
+
+func TestTileForPoint(t *testing.T) {
+	tcs := []struct {
+		z      int
+		pt     tlxy.Point
+		expect Tile
+	}{
+		{0, tlxy.Point{Lon: -122.4, Lat: 37.8}, Tile{0, 0, 0}},
+		{1, tlxy.Point{Lon: -122.4, Lat: 37.8}, Tile{1, 0, 0}},
+		{1, tlxy.Point{Lon: 151.2, Lat: -33.9}, Tile{1, 1, 1}},
+		{12, tlxy.Point{Lon: -122.4194, Lat: 37.7749}, Tile{12, 655, 1583}},
+		{14, tlxy.Point{Lon: -116.751677, Lat: 36.916682}, Tile{14, 2878, 6381}},
+		{2, tlxy.Point{Lon: 180, Lat: -90}, Tile{2, 3, 3}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.expect.String(), func(t *testing.T) {
+			tile := TileForPoint(tc.z, tc.pt)
+			assert.Equal(t, tc.expect, tile)
+			if tc.pt.Lat > -maxLat && tc.pt.Lon < 180 {
+				bbox := tile.Bbox()
+				assert.True(t, bbox.Contains(tc.pt))
+			}
+		})
+	}
+}
+
+func TestNewTile(t *testing.T) {
+	_, err := NewTile(2, 3, 3)
+	assert.NoError(t, err)
+	_, err = NewTile(2, 4, 0)
+	assert.Error(t, err)
+	_, err = NewTile(-1, 0, 0)
+	assert.Error(t, err)
+	_, err = NewTile(MaxZoom+1, 0, 0)
+	assert.Error(t, err)
+}
+
+func TestTiles(t *testing.T) {
+	bbox := Tile{10, 163, 395}.Bbox()
+	// Shrink slightly to stay inside the tile
+	bbox.MinLon += 0.001
+	bbox.MaxLon -= 0.001
+	bbox.MinLat += 0.001
+	bbox.MaxLat -= 0.001
+	assert.Equal(t, []Tile{{10, 163, 395}}, Tiles(10, bbox))
+	assert.Equal(t, []Tile{{11, 326, 790}, {11, 326, 791}, {11, 327, 790}, {11, 327, 791}}, Tiles(11, bbox))
+}
+
+func TestGeometryTiles(t *testing.T) {
+	tile := Tile{Z: 10, X: 163, Y: 395}
+	bbox := tile.Bbox()
+	// The top left corner of the last child tile is the center of the tile
+	child := Tile{Z: 11, X: 327, Y: 791}.Bbox()
+	center := tlxy.Point{Lon: child.MinLon, Lat: child.MaxLat}
+	t.Run("point", func(t *testing.T) {
+		g := geom.NewPointFlat(geom.XY, []float64{center.Lon, center.Lat})
+		assert.Equal(t, []Tile{tile}, GeometryTiles(10, g))
+		assert.Equal(t, []Tile{{11, 326, 790}, {11, 326, 791}, {11, 327, 790}, {11, 327, 791}}, GeometryTiles(11, g))
+	})
+	t.Run("line", func(t *testing.T) {
+		// Horizontal line crossing three tiles
+		g := geom.NewMultiLineStringFlat(geom.XY, []float64{bbox.MinLon - 0.1, center.Lat, bbox.MaxLon + 0.1, center.Lat}, []int{4})
+		assert.Equal(t, []Tile{{10, 162, 395}, {10, 163, 395}, {10, 164, 395}}, GeometryTiles(10, g))
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		assert.Equal(t, 0, len(GeometryTiles(10, geom.NewPolygon(geom.XY))))
+	})
+}
+
+func TestEncode(t *testing.T) {
+	tile := Tile{Z: 10, X: 163, Y: 395}
+	bbox := tile.Bbox()
+	// The top left corner of the last child tile is the center of the tile
+	child := Tile{Z: 11, X: 327, Y: 791}.Bbox()
+	center := tlxy.Point{Lon: child.MinLon, Lat: child.MaxLat}
+	outside := tlxy.Point{Lon: bbox.MaxLon + 1, Lat: center.Lat}
+	layers := []Layer{
+		{
+			Name: "stops",
+			Features: []*Feature{
+				{
+					ID:       1,
+					Geometry: geom.NewPointFlat(geom.XY, []float64{center.Lon, center.Lat}),
+					Properties: map[string]any{
+						"stop_name":     "Center",
+						"location_type": 0,
+						"wheelchair":    true,
+						"ignored":       nil,
+					},
+				},
+				{
+					ID:         2,
+					Geometry:   geom.NewPointFlat(geom.XY, []float64{outside.Lon, outside.Lat}),
+					Properties: map[string]any{"stop_name": "Outside"},
+				},
+			},
+		},
+		{
+			Name: "routes",
+			Features: []*Feature{
+				{
+					ID:         3,
+					Geometry:   geom.NewLineStringFlat(geom.XY, []float64{bbox.MinLon - 1, center.Lat, center.Lon, center.Lat, center.Lon, bbox.MaxLat + 1}),
+					Properties: map[string]any{"route_type": 3, "route_color": "ff0000", "length": 1.5},
+				},
+			},
+		},
+		{
+			Name: "empty",
+			Features: []*Feature{
+				{ID: 4, Geometry: geom.NewPointFlat(geom.XY, []float64{outside.Lon, outside.Lat})},
+			},
+		},
+	}
+	data, err := Encode(tile, layers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Equal(t, 2, len(decoded)) {
+		return
+	}
+
+	// Points outside the buffer are skipped
+	stops := decoded[0]
+	assert.Equal(t, "stops", stops.Name)
+	if assert.Equal(t, 1, len(stops.Features)) {
+		f := stops.Features[0]
+		assert.Equal(t, uint64(1), f.ID)
+		assert.Equal(t, map[string]any{"stop_name": "Center", "location_type": int64(0), "wheelchair": true}, f.Properties)
+		assert.Equal(t, []float64{Extent / 2, Extent / 2}, f.Geometry.FlatCoords())
+	}
+
+	// Lines are clipped to the buffer
+	routes := decoded[1]
+	assert.Equal(t, "routes", routes.Name)
+	if assert.Equal(t, 1, len(routes.Features)) {
+		f := routes.Features[0]
+		assert.Equal(t, uint64(3), f.ID)
+		assert.Equal(t, map[string]any{"route_type": int64(3), "route_color": "ff0000", "length": 1.5}, f.Properties)
+		assert.Equal(t, []float64{-Buffer, Extent / 2, Extent / 2, Extent / 2, Extent / 2, -Buffer}, f.Geometry.FlatCoords())
+	}
+
+	// Unsupported geometries are an error
+	_, err = Encode(tile, []Layer{{Name: "polygons", Features: []*Feature{{Geometry: geom.NewPolygon(geom.XY)}}}})
+	assert.Error(t, err)
+}
+
+func TestClipLine(t *testing.T) {
+	tcs := []struct {
+		name   string
+		pts    [][2]float64
+		expect [][][2]float64
+	}{
+		{"inside", [][2]float64{{1, 1}, {2, 2}, {3, 1}}, [][][2]float64{{{1, 1}, {2, 2}, {3, 1}}}},
+		{"outside", [][2]float64{{-5, -5}, {-1, -1}, {20, -1}}, nil},
+		{"crossing", [][2]float64{{-5, 5}, {15, 5}}, [][][2]float64{{{0, 5}, {10, 5}}}},
+		{"reentering", [][2]float64{{5, 5}, {15, 5}, {15, 8}, {5, 8}}, [][][2]float64{{{5, 5}, {10, 5}}, {{10, 8}, {5, 8}}}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, clipLine(tc.pts, 0, 10))
+		})
+	}
+}
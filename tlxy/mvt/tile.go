@@ -0,0 +1,166 @@
+// Package mvt encodes Mapbox Vector Tiles.
+package mvt
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/interline-io/transitland-lib/tlxy"
+	"github.com/twpayne/go-geom"
+)
+
+// MaxZoom is the highest supported zoom level.
+const MaxZoom = 24
+
+// Latitude limit of the Web Mercator projection
+const maxLat = 85.05112878
+
+// Tile is a z/x/y tile coordinate.
+type Tile struct {
+	Z int
+	X int
+	Y int
+}
+
+// NewTile returns a tile, checking the coordinates are valid for the zoom level.
+func NewTile(z int, x int, y int) (Tile, error) {
+	if z < 0 || z > MaxZoom {
+		return Tile{}, fmt.Errorf("invalid zoom level: %d", z)
+	}
+	n := 1 << z
+	if x < 0 || x >= n || y < 0 || y >= n {
+		return Tile{}, fmt.Errorf("invalid tile coordinates: %d/%d/%d", z, x, y)
+	}
+	return Tile{Z: z, X: x, Y: y}, nil
+}
+
+func (t Tile) String() string {
+	return fmt.Sprintf("%d/%d/%d", t.Z, t.X, t.Y)
+}
+
+// TileForPoint returns the tile containing a point at the zoom level.
+func TileForPoint(z int, pt tlxy.Point) Tile {
+	x, y := project(z, pt.Lon, pt.Lat)
+	n := 1 << z
+	return Tile{
+		Z: z,
+		X: clampInt(int(math.Floor(x)), 0, n-1),
+		Y: clampInt(int(math.Floor(y)), 0, n-1),
+	}
+}
+
+// Tiles returns the tiles covering a bounding box at the zoom level.
+func Tiles(z int, bbox tlxy.BoundingBox) []Tile {
+	a := TileForPoint(z, tlxy.Point{Lon: bbox.MinLon, Lat: bbox.MaxLat})
+	b := TileForPoint(z, tlxy.Point{Lon: bbox.MaxLon, Lat: bbox.MinLat})
+	var ret []Tile
+	for x := a.X; x <= b.X; x++ {
+		for y := a.Y; y <= b.Y; y++ {
+			ret = append(ret, Tile{Z: z, X: x, Y: y})
+		}
+	}
+	return ret
+}
+
+// GeometryTiles returns the tiles at the zoom level that may contain part of the geometry, including the tile buffer.
+// Points and line strings are supported; tiles are returned in order.
+func GeometryTiles(z int, g geom.T) []Tile {
+	n := 1 << z
+	b := float64(Buffer) / float64(Extent)
+	found := map[Tile]bool{}
+	add := func(x0, y0, x1, y1 float64) {
+		minX := clampInt(int(math.Floor(math.Min(x0, x1)-b)), 0, n-1)
+		maxX := clampInt(int(math.Floor(math.Max(x0, x1)+b)), 0, n-1)
+		minY := clampInt(int(math.Floor(math.Min(y0, y1)-b)), 0, n-1)
+		maxY := clampInt(int(math.Floor(math.Max(y0, y1)+b)), 0, n-1)
+		for x := minX; x <= maxX; x++ {
+			for y := minY; y <= maxY; y++ {
+				found[Tile{Z: z, X: x, Y: y}] = true
+			}
+		}
+	}
+	addCoords := func(coords []float64, stride int, segments bool) {
+		px, py := 0.0, 0.0
+		for i := 0; i+1 < len(coords); i += stride {
+			x, y := project(z, coords[i], coords[i+1])
+			if !segments {
+				add(x, y, x, y)
+			} else if i > 0 {
+				add(px, py, x, y)
+			}
+			px, py = x, y
+		}
+	}
+	switch v := g.(type) {
+	case *geom.Point:
+		addCoords(v.FlatCoords(), v.Stride(), false)
+	case *geom.MultiPoint:
+		addCoords(v.FlatCoords(), v.Stride(), false)
+	case *geom.LineString:
+		addCoords(v.FlatCoords(), v.Stride(), true)
+	case *geom.MultiLineString:
+		for i := 0; i < v.NumLineStrings(); i++ {
+			ls := v.LineString(i)
+			addCoords(ls.FlatCoords(), ls.Stride(), true)
+		}
+	}
+	var ret []Tile
+	for t := range found {
+		ret = append(ret, t)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].X == ret[j].X {
+			return ret[i].Y < ret[j].Y
+		}
+		return ret[i].X < ret[j].X
+	})
+	return ret
+}
+
+// Bbox returns the geographic bounds of the tile.
+func (t Tile) Bbox() tlxy.BoundingBox {
+	return t.bbox(0)
+}
+
+// BufferedBbox returns the geographic bounds of the tile, including the encoding buffer.
+func (t Tile) BufferedBbox() tlxy.BoundingBox {
+	return t.bbox(float64(Buffer) / float64(Extent))
+}
+
+func (t Tile) bbox(buffer float64) tlxy.BoundingBox {
+	west, north := unproject(t.Z, float64(t.X)-buffer, float64(t.Y)-buffer)
+	east, south := unproject(t.Z, float64(t.X+1)+buffer, float64(t.Y+1)+buffer)
+	return tlxy.BoundingBox{
+		MinLon: math.Max(west, -180),
+		MinLat: math.Max(south, -maxLat),
+		MaxLon: math.Min(east, 180),
+		MaxLat: math.Min(north, maxLat),
+	}
+}
+
+// project converts a point to Web Mercator tile units at the zoom level.
+func project(z int, lon float64, lat float64) (float64, float64) {
+	n := float64(int64(1) << z)
+	lat = math.Max(math.Min(lat, maxLat), -maxLat) * math.Pi / 180
+	x := (lon + 180) / 360 * n
+	y := (1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2 * n
+	return x, y
+}
+
+// unproject converts Web Mercator tile units at the zoom level to a point.
+func unproject(z int, x float64, y float64) (float64, float64) {
+	n := float64(int64(1) << z)
+	lon := x/n*360 - 180
+	lat := math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+	return lon, lat
+}
+
+func clampInt(v int, min int, max int) int {
+	if v < min {
+		return min
+	} else if v > max {
+		return max
+	}
+	return v
+}
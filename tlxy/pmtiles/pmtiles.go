@@ -0,0 +1,249 @@
+// Package pmtiles reads and writes PMTiles version 3 tile archives.
+package pmtiles
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/interline-io/transitland-lib/tlxy"
+)
+
+// Compression types
+const (
+	CompressionUnknown = 0
+	CompressionNone    = 1
+	CompressionGzip    = 2
+)
+
+// Tile types
+const (
+	TileTypeUnknown = 0
+	TileTypeMVT     = 1
+)
+
+const headerLength = 127
+
+// Maximum length of the header and root directory
+const rootLength = 16384
+
+// Header is the fixed length archive header.
+type Header struct {
+	RootOffset          uint64
+	RootLength          uint64
+	MetadataOffset      uint64
+	MetadataLength      uint64
+	LeafOffset          uint64
+	LeafLength          uint64
+	TileDataOffset      uint64
+	TileDataLength      uint64
+	AddressedTiles      uint64
+	TileEntries         uint64
+	TileContents        uint64
+	Clustered           bool
+	InternalCompression uint8
+	TileCompression     uint8
+	TileType            uint8
+	MinZoom             uint8
+	MaxZoom             uint8
+	Bounds              tlxy.BoundingBox
+	CenterZoom          uint8
+	Center              tlxy.Point
+}
+
+func (h *Header) marshal() []byte {
+	b := make([]byte, 0, headerLength)
+	b = append(b, "PMTiles"...)
+	b = append(b, 3)
+	for _, v := range []uint64{
+		h.RootOffset, h.RootLength,
+		h.MetadataOffset, h.MetadataLength,
+		h.LeafOffset, h.LeafLength,
+		h.TileDataOffset, h.TileDataLength,
+		h.AddressedTiles, h.TileEntries, h.TileContents,
+	} {
+		b = binary.LittleEndian.AppendUint64(b, v)
+	}
+	clustered := uint8(0)
+	if h.Clustered {
+		clustered = 1
+	}
+	b = append(b, clustered, h.InternalCompression, h.TileCompression, h.TileType, h.MinZoom, h.MaxZoom)
+	for _, v := range []float64{h.Bounds.MinLon, h.Bounds.MinLat, h.Bounds.MaxLon, h.Bounds.MaxLat} {
+		b = binary.LittleEndian.AppendUint32(b, uint32(e7(v)))
+	}
+	b = append(b, h.CenterZoom)
+	b = binary.LittleEndian.AppendUint32(b, uint32(e7(h.Center.Lon)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(e7(h.Center.Lat)))
+	return b
+}
+
+func unmarshalHeader(b []byte) (Header, error) {
+	h := Header{}
+	if len(b) < headerLength || string(b[0:7]) != "PMTiles" {
+		return h, errors.New("not a pmtiles archive")
+	}
+	if b[7] != 3 {
+		return h, fmt.Errorf("unsupported pmtiles version: %d", b[7])
+	}
+	u64 := func(i int) uint64 { return binary.LittleEndian.Uint64(b[8+i*8:]) }
+	i32 := func(pos int) float64 { return float64(int32(binary.LittleEndian.Uint32(b[pos:]))) / 1e7 }
+	h.RootOffset, h.RootLength = u64(0), u64(1)
+	h.MetadataOffset, h.MetadataLength = u64(2), u64(3)
+	h.LeafOffset, h.LeafLength = u64(4), u64(5)
+	h.TileDataOffset, h.TileDataLength = u64(6), u64(7)
+	h.AddressedTiles, h.TileEntries, h.TileContents = u64(8), u64(9), u64(10)
+	h.Clustered = b[96] == 1
+	h.InternalCompression = b[97]
+	h.TileCompression = b[98]
+	h.TileType = b[99]
+	h.MinZoom = b[100]
+	h.MaxZoom = b[101]
+	h.Bounds = tlxy.BoundingBox{MinLon: i32(102), MinLat: i32(106), MaxLon: i32(110), MaxLat: i32(114)}
+	h.CenterZoom = b[118]
+	h.Center = tlxy.Point{Lon: i32(119), Lat: i32(123)}
+	return h, nil
+}
+
+func e7(v float64) int32 {
+	if v < 0 {
+		return int32(v*1e7 - 0.5)
+	}
+	return int32(v*1e7 + 0.5)
+}
+
+// TileID returns the position of a tile along the Hilbert curve, counting all tiles at lower zoom levels.
+func TileID(z int, x int, y int) uint64 {
+	// Tiles at lower zoom levels
+	acc := ((uint64(1) << (2 * z)) - 1) / 3
+	tx, ty := uint64(x), uint64(y)
+	for s := uint64(1) << z >> 1; s > 0; s >>= 1 {
+		rx, ry := uint64(0), uint64(0)
+		if tx&s > 0 {
+			rx = 1
+		}
+		if ty&s > 0 {
+			ry = 1
+		}
+		acc += s * s * ((3 * rx) ^ ry)
+		// Rotate quadrant
+		if ry == 0 {
+			if rx == 1 {
+				tx = s - 1 - tx&(s-1)
+				ty = s - 1 - ty&(s-1)
+			}
+			tx, ty = ty, tx
+		}
+	}
+	return acc
+}
+
+// entry is a directory entry.
+// A run length of zero points to a leaf directory.
+type entry struct {
+	TileID    uint64
+	Offset    uint64
+	Length    uint64
+	RunLength uint64
+}
+
+func marshalDirectory(entries []entry) ([]byte, error) {
+	var b []byte
+	b = binary.AppendUvarint(b, uint64(len(entries)))
+	last := uint64(0)
+	for _, e := range entries {
+		b = binary.AppendUvarint(b, e.TileID-last)
+		last = e.TileID
+	}
+	for _, e := range entries {
+		b = binary.AppendUvarint(b, e.RunLength)
+	}
+	for _, e := range entries {
+		b = binary.AppendUvarint(b, e.Length)
+	}
+	for i, e := range entries {
+		if i > 0 && e.Offset == entries[i-1].Offset+entries[i-1].Length {
+			b = binary.AppendUvarint(b, 0)
+		} else {
+			b = binary.AppendUvarint(b, e.Offset+1)
+		}
+	}
+	return compress(b)
+}
+
+func unmarshalDirectory(data []byte) ([]entry, error) {
+	b, err := decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	r := bytes.NewReader(b)
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(b)) {
+		return nil, errors.New("invalid directory")
+	}
+	entries := make([]entry, n)
+	last := uint64(0)
+	for i := range entries {
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		last += v
+		entries[i].TileID = last
+	}
+	for i := range entries {
+		if entries[i].RunLength, err = binary.ReadUvarint(r); err != nil {
+			return nil, err
+		}
+	}
+	for i := range entries {
+		if entries[i].Length, err = binary.ReadUvarint(r); err != nil {
+			return nil, err
+		}
+	}
+	for i := range entries {
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if v == 0 && i > 0 {
+			entries[i].Offset = entries[i-1].Offset + entries[i-1].Length
+		} else {
+			entries[i].Offset = v - 1
+		}
+	}
+	return entries, nil
+}
+
+// Reuse gzip writers; allocating a new writer for each tile is expensive
+var gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(w)
+	w.Reset(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,146 @@
+package pmtiles
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/interline-io/transitland-lib/tlxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTileID(t *testing.T) {
+	tcs := []struct {
+		z, x, y int
+		expect  uint64
+	}{
+		{0, 0, 0, 0},
+		{1, 0, 0, 1},
+		{1, 0, 1, 2},
+		{1, 1, 1, 3},
+		{1, 1, 0, 4},
+		{2, 0, 0, 5},
+		{3, 0, 0, 21},
+		{3, 7, 0, 84},
+		{12, 3423, 1763, 19078479},
+	}
+	for _, tc := range tcs {
+		t.Run(fmt.Sprintf("%d/%d/%d", tc.z, tc.x, tc.y), func(t *testing.T) {
+			assert.Equal(t, tc.expect, TileID(tc.z, tc.x, tc.y))
+		})
+	}
+	// Tile IDs are unique
+	seen := map[uint64]bool{}
+	for z := 0; z <= 4; z++ {
+		for x := 0; x < 1<<z; x++ {
+			for y := 0; y < 1<<z; y++ {
+				id := TileID(z, x, y)
+				assert.False(t, seen[id], "duplicate tile id")
+				seen[id] = true
+			}
+		}
+	}
+	assert.Equal(t, 341, len(seen))
+}
+
+func TestWriter(t *testing.T) {
+	w := NewWriter(TileTypeMVT)
+	w.Bounds = tlxy.BoundingBox{MinLon: -122.5, MinLat: 37.5, MaxLon: -122.0, MaxLat: 38.0}
+	if err := w.AddTile(0, 0, 0, []byte("root")); err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			// Repeated content
+			if err := w.AddTile(2, x, y, []byte("same")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := w.AddTile(2, 3, 3, []byte("different")); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := w.Write(&buf, map[string]any{"name": "test"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := r.Header
+	assert.Equal(t, uint8(0), h.MinZoom)
+	assert.Equal(t, uint8(2), h.MaxZoom)
+	assert.Equal(t, uint64(17), h.AddressedTiles)
+	assert.Equal(t, uint64(3), h.TileContents)
+	assert.Less(t, h.TileEntries, h.AddressedTiles)
+	assert.Equal(t, uint8(TileTypeMVT), h.TileType)
+	assert.InDelta(t, -122.5, h.Bounds.MinLon, 1e-7)
+	assert.InDelta(t, 38.0, h.Bounds.MaxLat, 1e-7)
+	assert.InDelta(t, -122.25, h.Center.Lon, 1e-7)
+
+	meta, err := r.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "test", meta["name"])
+
+	tcs := []struct {
+		z, x, y int
+		expect  string
+	}{
+		{0, 0, 0, "root"},
+		{2, 0, 0, "same"},
+		{2, 2, 1, "same"},
+		{2, 3, 3, "different"},
+		{1, 0, 0, ""},
+		{3, 0, 0, ""},
+	}
+	for _, tc := range tcs {
+		t.Run(fmt.Sprintf("%d/%d/%d", tc.z, tc.x, tc.y), func(t *testing.T) {
+			b, err := r.Tile(tc.z, tc.x, tc.y)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.expect, string(b))
+		})
+	}
+}
+
+func TestWriter_LeafDirectories(t *testing.T) {
+	// Sparse tiles with varying lengths do not fit in the root directory
+	w := NewWriter(TileTypeMVT)
+	rnd := rand.New(rand.NewSource(1))
+	z := 12
+	expect := map[[2]int]string{}
+	for i := 0; i < 50_000; i++ {
+		x, y := rnd.Intn(1<<z), rnd.Intn(1<<z)
+		data := fmt.Sprintf("%d/%d/%s", x, y, strings.Repeat("x", rnd.Intn(100)))
+		expect[[2]int{x, y}] = data
+		if err := w.AddTile(z, x, y, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := w.Write(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Greater(t, r.Header.LeafLength, uint64(0))
+	count := 0
+	for xy, data := range expect {
+		b, err := r.Tile(z, xy[0], xy[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, data, string(b))
+		if count++; count > 1000 {
+			break
+		}
+	}
+}
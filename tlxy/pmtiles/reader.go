@@ -0,0 +1,109 @@
+package pmtiles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Reader reads tiles from an archive.
+type Reader struct {
+	Header Header
+	r      io.ReaderAt
+	root   []entry
+}
+
+// NewReader reads the header and root directory of an archive.
+func NewReader(r io.ReaderAt) (*Reader, error) {
+	b := make([]byte, headerLength)
+	if _, err := r.ReadAt(b, 0); err != nil {
+		return nil, err
+	}
+	h, err := unmarshalHeader(b)
+	if err != nil {
+		return nil, err
+	}
+	if h.InternalCompression != CompressionGzip {
+		return nil, fmt.Errorf("unsupported compression: %d", h.InternalCompression)
+	}
+	ret := Reader{Header: h, r: r}
+	if ret.root, err = ret.directory(h.RootOffset, h.RootLength); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// Metadata returns the archive metadata.
+func (r *Reader) Metadata() (map[string]any, error) {
+	b, err := r.read(r.Header.MetadataOffset, r.Header.MetadataLength)
+	if err != nil {
+		return nil, err
+	}
+	if b, err = decompress(b); err != nil {
+		return nil, err
+	}
+	ret := map[string]any{}
+	if err := json.Unmarshal(b, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Tile returns the decompressed tile, or nil if the tile is not in the archive.
+func (r *Reader) Tile(z int, x int, y int) ([]byte, error) {
+	id := TileID(z, x, y)
+	entries := r.root
+	// Limit the depth of leaf directories
+	for depth := 0; depth < 4; depth++ {
+		e, ok := findEntry(entries, id)
+		if !ok {
+			return nil, nil
+		}
+		if e.RunLength > 0 {
+			b, err := r.read(r.Header.TileDataOffset+e.Offset, e.Length)
+			if err != nil {
+				return nil, err
+			}
+			if r.Header.TileCompression == CompressionGzip {
+				return decompress(b)
+			}
+			return b, nil
+		}
+		var err error
+		if entries, err = r.directory(r.Header.LeafOffset+e.Offset, e.Length); err != nil {
+			return nil, err
+		}
+	}
+	return nil, errors.New("directory too deep")
+}
+
+func (r *Reader) directory(offset uint64, length uint64) ([]entry, error) {
+	b, err := r.read(offset, length)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalDirectory(b)
+}
+
+func (r *Reader) read(offset uint64, length uint64) ([]byte, error) {
+	b := make([]byte, length)
+	if _, err := r.r.ReadAt(b, int64(offset)); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// findEntry returns the entry containing the tile ID, or the leaf directory that may contain it.
+func findEntry(entries []entry, id uint64) (entry, bool) {
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].TileID > id }) - 1
+	if i < 0 {
+		return entry{}, false
+	}
+	e := entries[i]
+	if e.RunLength == 0 || id < e.TileID+e.RunLength {
+		return e, true
+	}
+	return entry{}, false
+}
@@ -0,0 +1,165 @@
+package pmtiles
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/interline-io/transitland-lib/tlxy"
+)
+
+// Writer collects tiles and writes them as an archive.
+// Tiles are compressed with gzip; identical tiles are stored once.
+type Writer struct {
+	TileType uint8
+	Bounds   tlxy.BoundingBox
+	tiles    map[uint64][]byte
+	minZoom  int
+	maxZoom  int
+}
+
+// NewWriter returns a new Writer for tiles of the given type.
+func NewWriter(tileType uint8) *Writer {
+	return &Writer{
+		TileType: tileType,
+		tiles:    map[uint64][]byte{},
+		minZoom:  -1,
+	}
+}
+
+// AddTile adds a tile, replacing any previous tile with the same coordinates.
+func (w *Writer) AddTile(z int, x int, y int, data []byte) error {
+	b, err := compress(data)
+	if err != nil {
+		return err
+	}
+	w.tiles[TileID(z, x, y)] = b
+	if w.minZoom < 0 || z < w.minZoom {
+		w.minZoom = z
+	}
+	if z > w.maxZoom {
+		w.maxZoom = z
+	}
+	return nil
+}
+
+// Len returns the number of tiles.
+func (w *Writer) Len() int {
+	return len(w.tiles)
+}
+
+// Write writes the archive, with the metadata encoded as JSON.
+func (w *Writer) Write(out io.Writer, metadata map[string]any) error {
+	if len(w.tiles) == 0 {
+		return errors.New("no tiles")
+	}
+
+	// Tile data is written in tile ID order
+	var tileIds []uint64
+	for id := range w.tiles {
+		tileIds = append(tileIds, id)
+	}
+	sort.Slice(tileIds, func(i, j int) bool { return tileIds[i] < tileIds[j] })
+	var entries []entry
+	var tileData [][]byte
+	offsets := map[[sha1.Size]byte]uint64{}
+	offset := uint64(0)
+	var lastKey [sha1.Size]byte
+	for _, id := range tileIds {
+		data := w.tiles[id]
+		key := sha1.Sum(data)
+		// Extend the previous run if this tile is the same as the previous tile
+		if n := len(entries); n > 0 && key == lastKey && entries[n-1].TileID+entries[n-1].RunLength == id {
+			entries[n-1].RunLength += 1
+			continue
+		}
+		lastKey = key
+		tileOffset, ok := offsets[key]
+		if !ok {
+			tileOffset = offset
+			offsets[key] = offset
+			offset += uint64(len(data))
+			tileData = append(tileData, data)
+		}
+		entries = append(entries, entry{TileID: id, Offset: tileOffset, Length: uint64(len(data)), RunLength: 1})
+	}
+
+	// Directories
+	root, leaves, err := buildDirectories(entries)
+	if err != nil {
+		return err
+	}
+	meta, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	if meta, err = compress(meta); err != nil {
+		return err
+	}
+
+	// Header
+	zoom := uint8(w.minZoom)
+	h := Header{
+		RootOffset:          headerLength,
+		RootLength:          uint64(len(root)),
+		MetadataLength:      uint64(len(meta)),
+		LeafLength:          uint64(len(leaves)),
+		TileDataLength:      offset,
+		AddressedTiles:      uint64(len(w.tiles)),
+		TileEntries:         uint64(len(entries)),
+		TileContents:        uint64(len(tileData)),
+		Clustered:           true,
+		InternalCompression: CompressionGzip,
+		TileCompression:     CompressionGzip,
+		TileType:            w.TileType,
+		MinZoom:             zoom,
+		MaxZoom:             uint8(w.maxZoom),
+		Bounds:              w.Bounds,
+		CenterZoom:          zoom,
+		Center: tlxy.Point{
+			Lon: (w.Bounds.MinLon + w.Bounds.MaxLon) / 2,
+			Lat: (w.Bounds.MinLat + w.Bounds.MaxLat) / 2,
+		},
+	}
+	h.MetadataOffset = h.RootOffset + h.RootLength
+	h.LeafOffset = h.MetadataOffset + h.MetadataLength
+	h.TileDataOffset = h.LeafOffset + h.LeafLength
+	for _, b := range append([][]byte{h.marshal(), root, meta, leaves}, tileData...) {
+		if _, err := out.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildDirectories returns the root directory, and leaf directories if the entries do not fit in the root.
+func buildDirectories(entries []entry) ([]byte, []byte, error) {
+	root, err := marshalDirectory(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(root) <= rootLength-headerLength {
+		return root, nil, nil
+	}
+	for leafSize := 4096; ; leafSize *= 2 {
+		var rootEntries []entry
+		var leaves []byte
+		for i := 0; i < len(entries); i += leafSize {
+			leaf, err := marshalDirectory(entries[i:min(i+leafSize, len(entries))])
+			if err != nil {
+				return nil, nil, err
+			}
+			rootEntries = append(rootEntries, entry{TileID: entries[i].TileID, Offset: uint64(len(leaves)), Length: uint64(len(leaf))})
+			leaves = append(leaves, leaf...)
+		}
+		root, err := marshalDirectory(rootEntries)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(root) <= rootLength-headerLength {
+			return root, leaves, nil
+		}
+	}
+}
@@ -6,47 +6,53 @@ import (
 
 // Option[T] types need MarshalGQL / UnmarshalGQL defined directly
 
-func (r Bool) MarshalGQL(w io.Writer)         { marshalGql(r, w) }
-func (r Color) MarshalGQL(w io.Writer)        { marshalGql(r, w) }
-func (r Date) MarshalGQL(w io.Writer)         { marshalGql(r, w) }
-func (r Email) MarshalGQL(w io.Writer)        { marshalGql(r, w) }
-func (r Float) MarshalGQL(w io.Writer)        { marshalGql(r, w) }
-func (r Geometry) MarshalGQL(w io.Writer)     { marshalGql(r, w) }
-func (r Int) MarshalGQL(w io.Writer)          { marshalGql(r, w) }
-func (r Language) MarshalGQL(w io.Writer)     { marshalGql(r, w) }
-func (r LineString) MarshalGQL(w io.Writer)   { marshalGql(r, w) }
-func (r Map) MarshalGQL(w io.Writer)          { marshalGql(r, w) }
-func (r Point) MarshalGQL(w io.Writer)        { marshalGql(r, w) }
-func (r Polygon) MarshalGQL(w io.Writer)      { marshalGql(r, w) }
-func (r MultiPolygon) MarshalGQL(w io.Writer) { marshalGql(r, w) }
-func (r Seconds) MarshalGQL(w io.Writer)      { marshalGql(r, w) }
-func (r String) MarshalGQL(w io.Writer)       { marshalGql(r, w) }
-func (r Strings) MarshalGQL(w io.Writer)      { marshalGql(r, w) }
-func (r Tags) MarshalGQL(w io.Writer)         { marshalGql(r, w) }
-func (r Time) MarshalGQL(w io.Writer)         { marshalGql(r, w) }
-func (r Timezone) MarshalGQL(w io.Writer)     { marshalGql(r, w) }
-func (r Url) MarshalGQL(w io.Writer)          { marshalGql(r, w) }
+func (r Bool) MarshalGQL(w io.Writer)           { marshalGql(r, w) }
+func (r Color) MarshalGQL(w io.Writer)          { marshalGql(r, w) }
+func (r Currency) MarshalGQL(w io.Writer)       { marshalGql(r, w) }
+func (r CurrencyAmount) MarshalGQL(w io.Writer) { marshalGql(r, w) }
+func (r Date) MarshalGQL(w io.Writer)           { marshalGql(r, w) }
+func (r Email) MarshalGQL(w io.Writer)          { marshalGql(r, w) }
+func (r Float) MarshalGQL(w io.Writer)          { marshalGql(r, w) }
+func (r Geometry) MarshalGQL(w io.Writer)       { marshalGql(r, w) }
+func (r Int) MarshalGQL(w io.Writer)            { marshalGql(r, w) }
+func (r Key) MarshalGQL(w io.Writer)            { marshalGql(r, w) }
+func (r Language) MarshalGQL(w io.Writer)       { marshalGql(r, w) }
+func (r LineString) MarshalGQL(w io.Writer)     { marshalGql(r, w) }
+func (r Map) MarshalGQL(w io.Writer)            { marshalGql(r, w) }
+func (r Point) MarshalGQL(w io.Writer)          { marshalGql(r, w) }
+func (r Polygon) MarshalGQL(w io.Writer)        { marshalGql(r, w) }
+func (r MultiPolygon) MarshalGQL(w io.Writer)   { marshalGql(r, w) }
+func (r Seconds) MarshalGQL(w io.Writer)        { marshalGql(r, w) }
+func (r String) MarshalGQL(w io.Writer)         { marshalGql(r, w) }
+func (r Strings) MarshalGQL(w io.Writer)        { marshalGql(r, w) }
+func (r Tags) MarshalGQL(w io.Writer)           { marshalGql(r, w) }
+func (r Time) MarshalGQL(w io.Writer)           { marshalGql(r, w) }
+func (r Timezone) MarshalGQL(w io.Writer)       { marshalGql(r, w) }
+func (r Url) MarshalGQL(w io.Writer)            { marshalGql(r, w) }
 
-func (r *Bool) UnmarshalGQL(v any) error         { return unmarshalGql(r, v) }
-func (r *Color) UnmarshalGQL(v any) error        { return unmarshalGql(r, v) }
-func (r *Date) UnmarshalGQL(v any) error         { return unmarshalGql(r, v) }
-func (r *Email) UnmarshalGQL(v any) error        { return unmarshalGql(r, v) }
-func (r *Float) UnmarshalGQL(v any) error        { return unmarshalGql(r, v) }
-func (r *Geometry) UnmarshalGQL(v any) error     { return unmarshalGql(r, v) }
-func (r *Int) UnmarshalGQL(v any) error          { return unmarshalGql(r, v) }
-func (r *Language) UnmarshalGQL(v any) error     { return unmarshalGql(r, v) }
-func (r *LineString) UnmarshalGQL(v any) error   { return unmarshalGql(r, v) }
-func (r *Map) UnmarshalGQL(v any) error          { return unmarshalGql(r, v) }
-func (r *Point) UnmarshalGQL(v any) error        { return unmarshalGql(r, v) }
-func (r *Polygon) UnmarshalGQL(v any) error      { return unmarshalGql(r, v) }
-func (r *MultiPolygon) UnmarshalGQL(v any) error { return unmarshalGql(r, v) }
-func (r *Seconds) UnmarshalGQL(v any) error      { return unmarshalGql(r, v) }
-func (r *String) UnmarshalGQL(v any) error       { return unmarshalGql(r, v) }
-func (r *Strings) UnmarshalGQL(v any) error      { return unmarshalGql(r, v) }
-func (r *Tags) UnmarshalGQL(v any) error         { return unmarshalGql(r, v) }
-func (r *Time) UnmarshalGQL(v any) error         { return unmarshalGql(r, v) }
-func (r *Timezone) UnmarshalGQL(v any) error     { return unmarshalGql(r, v) }
-func (r *Url) UnmarshalGQL(v any) error          { return unmarshalGql(r, v) }
+func (r *Bool) UnmarshalGQL(v any) error           { return unmarshalGql(r, v) }
+func (r *Color) UnmarshalGQL(v any) error          { return unmarshalGql(r, v) }
+func (r *Currency) UnmarshalGQL(v any) error       { return unmarshalGql(r, v) }
+func (r *CurrencyAmount) UnmarshalGQL(v any) error { return unmarshalGql(r, v) }
+func (r *Date) UnmarshalGQL(v any) error           { return unmarshalGql(r, v) }
+func (r *Email) UnmarshalGQL(v any) error          { return unmarshalGql(r, v) }
+func (r *Float) UnmarshalGQL(v any) error          { return unmarshalGql(r, v) }
+func (r *Geometry) UnmarshalGQL(v any) error       { return unmarshalGql(r, v) }
+func (r *Int) UnmarshalGQL(v any) error            { return unmarshalGql(r, v) }
+func (r *Key) UnmarshalGQL(v any) error            { return unmarshalGql(r, v) }
+func (r *Language) UnmarshalGQL(v any) error       { return unmarshalGql(r, v) }
+func (r *LineString) UnmarshalGQL(v any) error     { return unmarshalGql(r, v) }
+func (r *Map) UnmarshalGQL(v any) error            { return unmarshalGql(r, v) }
+func (r *Point) UnmarshalGQL(v any) error          { return unmarshalGql(r, v) }
+func (r *Polygon) UnmarshalGQL(v any) error        { return unmarshalGql(r, v) }
+func (r *MultiPolygon) UnmarshalGQL(v any) error   { return unmarshalGql(r, v) }
+func (r *Seconds) UnmarshalGQL(v any) error        { return unmarshalGql(r, v) }
+func (r *String) UnmarshalGQL(v any) error         { return unmarshalGql(r, v) }
+func (r *Strings) UnmarshalGQL(v any) error        { return unmarshalGql(r, v) }
+func (r *Tags) UnmarshalGQL(v any) error           { return unmarshalGql(r, v) }
+func (r *Time) UnmarshalGQL(v any) error           { return unmarshalGql(r, v) }
+func (r *Timezone) UnmarshalGQL(v any) error       { return unmarshalGql(r, v) }
+func (r *Url) UnmarshalGQL(v any) error            { return unmarshalGql(r, v) }
 
 type canUnmarshalJson interface {
 	Scan(any) error
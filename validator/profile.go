@@ -0,0 +1,203 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/interline-io/transitland-lib/causes"
+	"github.com/interline-io/transitland-lib/copier"
+	"github.com/interline-io/transitland-lib/rules"
+	"github.com/interline-io/transitland-lib/tt"
+	"github.com/invopop/yaml"
+)
+
+// Profile severity levels.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityOff     = "off"
+)
+
+// Profile configures validation severity, rule parameters, and suppressions.
+type Profile struct {
+	// Severity overrides, keyed by error code (e.g. E001) or error type (e.g. StopTooFarError)
+	Severity map[string]string `json:"severity"`
+	// Rules sets parameters for best practices rules
+	Rules ProfileRules `json:"rules"`
+	// Suppressions ignore known issues
+	Suppressions []ProfileSuppression `json:"suppressions"`
+}
+
+// ProfileRules sets parameters for best practices rules.
+type ProfileRules struct {
+	StopTooFarCheck *struct {
+		MaxDistance float64 `json:"max_distance"` // meters
+	} `json:"StopTooFarCheck"`
+	StopTimeFastTravelCheck *struct {
+		MaxSpeeds map[int]float64 `json:"max_speeds"` // km/h, keyed by route_type
+	} `json:"StopTimeFastTravelCheck"`
+	ShapeMaxSegmentLengthCheck *struct {
+		MaxDistance float64 `json:"max_distance"` // meters
+	} `json:"ShapeMaxSegmentLengthCheck"`
+}
+
+// ProfileSuppression ignores errors for a file and/or set of entities, optionally until an expiry date.
+type ProfileSuppression struct {
+	Filename  string   `json:"filename"`
+	EntityIDs []string `json:"entity_ids"`
+	Error     string   `json:"error"`   // error code or error type; optional
+	Expires   string   `json:"expires"` // YYYY-MM-DD, inclusive; optional
+	Reason    string   `json:"reason"`
+}
+
+// LoadProfile reads a YAML or JSON validation profile.
+func LoadProfile(fn string) (*Profile, error) {
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	p := Profile{}
+	if err := yaml.Unmarshal(data, &p, func(d *json.Decoder) *json.Decoder {
+		d.DisallowUnknownFields()
+		return d
+	}); err != nil {
+		return nil, fmt.Errorf("could not parse validation profile '%s': %w", fn, err)
+	}
+	if err := p.Check(); err != nil {
+		return nil, fmt.Errorf("invalid validation profile '%s': %w", fn, err)
+	}
+	return &p, nil
+}
+
+// Check returns an error if the profile is not valid.
+func (p *Profile) Check() error {
+	for k, v := range p.Severity {
+		switch v {
+		case SeverityError, SeverityWarning, SeverityOff:
+		default:
+			return fmt.Errorf("invalid severity '%s' for '%s', must be one of error, warning, off", v, k)
+		}
+	}
+	for i, s := range p.Suppressions {
+		if s.Filename == "" && len(s.EntityIDs) == 0 {
+			return fmt.Errorf("suppression %d requires filename or entity_ids", i)
+		}
+		if s.Expires != "" {
+			if _, err := time.Parse("2006-01-02", s.Expires); err != nil {
+				return fmt.Errorf("suppression %d has invalid expires '%s', must be YYYY-MM-DD", i, s.Expires)
+			}
+		}
+	}
+	return nil
+}
+
+// configureRules sets rule parameters.
+func (p *Profile) configureRules(stopTooFar *rules.StopTooFarCheck, fastTravel *rules.StopTimeFastTravelCheck, shapeMaxSegmentLength *rules.ShapeMaxSegmentLengthCheck) {
+	if p == nil {
+		return
+	}
+	if r := p.Rules.StopTooFarCheck; r != nil && r.MaxDistance > 0 {
+		stopTooFar.MaxDistance = r.MaxDistance
+	}
+	if r := p.Rules.StopTimeFastTravelCheck; r != nil {
+		fastTravel.MaxSpeeds = r.MaxSpeeds
+	}
+	if r := p.Rules.ShapeMaxSegmentLengthCheck; r != nil && r.MaxDistance > 0 {
+		shapeMaxSegmentLength.MaxAllowedDistance = r.MaxDistance
+	}
+}
+
+// apply reclassifies errors and warnings according to severity overrides and active suppressions.
+// The filename and entity ID, if set, take precedence over the values in the error context.
+func (p *Profile) apply(fn string, eid string, evaluateAt time.Time, errs []error, warns []error) ([]error, []error) {
+	if p == nil {
+		return errs, warns
+	}
+	var retErrs, retWarns []error
+	check := func(err error, severity string) {
+		eg := copier.NewValidationErrorGroup(err, 0)
+		efn, ceid := eg.Filename, ""
+		if v, ok := err.(hasContext); ok {
+			ceid = v.Context().EntityID
+		}
+		if fn != "" {
+			efn = fn
+		}
+		if eid != "" {
+			ceid = eid
+		}
+		if s, ok := p.Severity[eg.ErrorCode]; ok && eg.ErrorCode != "" {
+			severity = s
+		} else if s, ok := p.Severity[eg.ErrorType]; ok {
+			severity = s
+		}
+		if p.suppressed(efn, ceid, eg.ErrorCode, eg.ErrorType, evaluateAt) {
+			severity = SeverityOff
+		}
+		switch severity {
+		case SeverityError:
+			retErrs = append(retErrs, err)
+		case SeverityWarning:
+			retWarns = append(retWarns, err)
+		}
+	}
+	for _, err := range errs {
+		check(err, SeverityError)
+	}
+	for _, err := range warns {
+		check(err, SeverityWarning)
+	}
+	return retErrs, retWarns
+}
+
+func (p *Profile) suppressed(fn string, eid string, errorCode string, errorType string, evaluateAt time.Time) bool {
+	today := evaluateAt.Format("2006-01-02")
+	for _, s := range p.Suppressions {
+		if s.Expires != "" && today > s.Expires {
+			continue
+		}
+		if s.Filename != "" && s.Filename != fn {
+			continue
+		}
+		if s.Error != "" && s.Error != errorCode && s.Error != errorType {
+			continue
+		}
+		if len(s.EntityIDs) > 0 {
+			found := false
+			for _, v := range s.EntityIDs {
+				if v == eid {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+type hasContext interface {
+	Context() *causes.Context
+}
+
+// profileErrorHandler applies a profile before passing errors to the next handler.
+type profileErrorHandler struct {
+	profile    *Profile
+	evaluateAt time.Time
+	next       copier.ErrorHandler
+}
+
+func (h *profileErrorHandler) HandleEntityErrors(ent tt.Entity, errs []error, warns []error) {
+	errs, warns = h.profile.apply(ent.Filename(), ent.EntityID(), h.evaluateAt, errs, warns)
+	h.next.HandleEntityErrors(ent, errs, warns)
+}
+
+func (h *profileErrorHandler) HandleSourceErrors(fn string, errs []error, warns []error) {
+	errs, warns = h.profile.apply(fn, "", h.evaluateAt, errs, warns)
+	h.next.HandleSourceErrors(fn, errs, warns)
+}
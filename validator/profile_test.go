@@ -0,0 +1,142 @@
+package validator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/interline-io/transitland-lib/internal/testpath"
+	"github.com/interline-io/transitland-lib/tlcsv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadProfile(t *testing.T) {
+	yamlProfile, err := LoadProfile(testpath.RelPath("testdata/validator/profiles/example.yaml"))
+	require.NoError(t, err)
+	jsonProfile, err := LoadProfile(testpath.RelPath("testdata/validator/profiles/example.json"))
+	require.NoError(t, err)
+	assert.Equal(t, jsonProfile, yamlProfile)
+	assert.Equal(t, SeverityOff, yamlProfile.Severity["StopTooCloseError"])
+	if assert.NotNil(t, yamlProfile.Rules.StopTimeFastTravelCheck) {
+		assert.Equal(t, 150.0, yamlProfile.Rules.StopTimeFastTravelCheck.MaxSpeeds[3])
+	}
+	require.Equal(t, 2, len(yamlProfile.Suppressions))
+	assert.Equal(t, []string{"invalid_stop_url"}, yamlProfile.Suppressions[0].EntityIDs)
+}
+
+func TestLoadProfile_Invalid(t *testing.T) {
+	tcs := []struct {
+		name        string
+		fn          string
+		errContains string
+	}{
+		{"invalid severity", "invalid-severity.yaml", "invalid severity 'ignore'"},
+		{"unknown field", "unknown-field.yaml", "unknown field"},
+		{"invalid expires", "invalid-expires.yaml", "invalid expires"},
+		{"not found", "not-found.yaml", "no such file"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadProfile(testpath.RelPath("testdata/validator/profiles/" + tc.fn))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.errContains)
+			}
+		})
+	}
+}
+
+func TestValidator_Profile(t *testing.T) {
+	validate := func(t *testing.T, path string, profile *Profile) *Result {
+		reader, err := tlcsv.NewReader(testpath.RelPath(path))
+		require.NoError(t, err)
+		opts := Options{
+			BestPractices: true,
+			EvaluateAt:    time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			Profile:       profile,
+		}
+		opts.Quiet = true
+		v, err := NewValidator(reader, opts)
+		require.NoError(t, err)
+		result, err := v.Validate(context.Background())
+		require.NoError(t, err)
+		return result
+	}
+	findGroup := func(groups map[string]*ValidationReportErrorGroup, fn string, errorType string, field string) *ValidationReportErrorGroup {
+		for _, g := range groups {
+			if g.Filename == fn && g.ErrorType == errorType && (field == "" || g.Field == field) {
+				return g
+			}
+		}
+		return nil
+	}
+
+	t.Run("bad-entities", func(t *testing.T) {
+		profile, err := LoadProfile(testpath.RelPath("testdata/validator/profiles/example.yaml"))
+		require.NoError(t, err)
+		base := validate(t, "testdata/gtfs-examples/bad-entities", nil)
+		result := validate(t, "testdata/gtfs-examples/bad-entities", profile)
+
+		// Severity overrides
+		assert.NotNil(t, findGroup(base.Errors, "agency.txt", "InconsistentTimezoneError", ""))
+		assert.Nil(t, findGroup(result.Errors, "agency.txt", "InconsistentTimezoneError", ""))
+		assert.NotNil(t, findGroup(result.Warnings, "agency.txt", "InconsistentTimezoneError", ""))
+
+		assert.NotNil(t, findGroup(base.Warnings, "stops.txt", "ZeroCoordinateError", ""))
+		assert.Nil(t, findGroup(result.Warnings, "stops.txt", "ZeroCoordinateError", ""))
+		if g := findGroup(result.Errors, "stops.txt", "ZeroCoordinateError", ""); assert.NotNil(t, g) {
+			assert.Equal(t, findGroup(base.Warnings, "stops.txt", "ZeroCoordinateError", "").Count, g.Count)
+		}
+
+		assert.NotNil(t, findGroup(base.Warnings, "stops.txt", "StopTooCloseError", ""))
+		assert.Nil(t, findGroup(result.Warnings, "stops.txt", "StopTooCloseError", ""))
+		assert.Nil(t, findGroup(result.Errors, "stops.txt", "StopTooCloseError", ""))
+
+		// Rule parameters
+		assert.NotNil(t, findGroup(base.Warnings, "stops.txt", "StopTooFarError", ""))
+		assert.Nil(t, findGroup(result.Warnings, "stops.txt", "StopTooFarError", ""))
+
+		// Active suppression
+		assert.NotNil(t, findGroup(base.Errors, "stops.txt", "InvalidFieldError", "stop_url"))
+		assert.Nil(t, findGroup(result.Errors, "stops.txt", "InvalidFieldError", "stop_url"))
+		assert.NotNil(t, findGroup(result.Errors, "stops.txt", "InvalidFieldError", "stop_lat"))
+
+		// Expired suppression
+		assert.NotNil(t, findGroup(result.Errors, "routes.txt", "InvalidFieldError", "route_url"))
+	})
+
+	t.Run("realtime", func(t *testing.T) {
+		validateRT := func(t *testing.T, profile *Profile) *Result {
+			reader, err := tlcsv.NewReader(testpath.RelPath("testdata/rt/ct.zip"))
+			require.NoError(t, err)
+			opts := Options{
+				Profile:                  profile,
+				ValidateRealtimeMessages: []string{testpath.RelPath("testdata/rt/errors/E003.trip_update-trip-trip_id.json")},
+			}
+			opts.Quiet = true
+			opts.ErrorLimit = 100
+			v, err := NewValidator(reader, opts)
+			require.NoError(t, err)
+			result, err := v.Validate(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, len(result.Details.Realtime))
+			return result
+		}
+		base := validateRT(t, nil)
+		assert.NotNil(t, findGroup(base.Errors, "", "RealtimeError", ""))
+		assert.NotEqual(t, 0, len(base.Details.Realtime[0].Errors))
+
+		result := validateRT(t, &Profile{Severity: map[string]string{"E003": SeverityOff}})
+		assert.Nil(t, findGroup(result.Errors, "", "RealtimeError", ""))
+		assert.Equal(t, 0, len(result.Details.Realtime[0].Errors))
+	})
+
+	t.Run("fast travel max speeds", func(t *testing.T) {
+		profile, err := LoadProfile(testpath.RelPath("testdata/validator/profiles/fast-travel.yaml"))
+		require.NoError(t, err)
+		base := validate(t, "testdata/gtfs-examples/example", nil)
+		result := validate(t, "testdata/gtfs-examples/example", profile)
+		assert.Nil(t, findGroup(base.Warnings, "trips.txt", "FastTravelError", ""))
+		assert.NotNil(t, findGroup(result.Warnings, "trips.txt", "FastTravelError", ""))
+	})
+}
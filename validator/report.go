@@ -0,0 +1,137 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/interline-io/transitland-lib/internal/snakejson"
+)
+
+// Validation report output formats.
+const (
+	ReportFormatJSON  = "json"
+	ReportFormatSARIF = "sarif"
+	ReportFormatJUnit = "junit"
+	ReportFormatHTML  = "html"
+)
+
+// ReportOptions configures how a validation report is written.
+type ReportOptions struct {
+	// Format is one of json, sarif, junit or html; defaults to json
+	Format string
+	// SourcePath is prepended to GTFS filenames in file locations,
+	// e.g. the directory containing the feed within a git repository
+	SourcePath string
+}
+
+// ReportFormatFromFilename returns the report format matching the file extension, or json.
+func ReportFormatFromFilename(fn string) string {
+	switch strings.ToLower(filepath.Ext(fn)) {
+	case ".sarif":
+		return ReportFormatSARIF
+	case ".xml":
+		return ReportFormatJUnit
+	case ".html", ".htm":
+		return ReportFormatHTML
+	}
+	return ReportFormatJSON
+}
+
+// WriteReport writes a validation result in the requested format.
+func WriteReport(w io.Writer, result *Result, opts ReportOptions) error {
+	switch opts.Format {
+	case "", ReportFormatJSON:
+		b, err := json.MarshalIndent(snakejson.SnakeMarshaller{Value: result}, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case ReportFormatSARIF:
+		return writeSARIF(w, result, opts)
+	case ReportFormatJUnit:
+		return writeJUnit(w, result, opts)
+	case ReportFormatHTML:
+		return writeHTML(w, result, opts)
+	}
+	return fmt.Errorf("unknown report format '%s'", opts.Format)
+}
+
+// reportGroup is an error group with its severity.
+type reportGroup struct {
+	Level string // "error" or "warning"
+	*ValidationReportErrorGroup
+}
+
+// Title returns a short description of the group.
+func (g reportGroup) Title() string {
+	if g.Field != "" {
+		return fmt.Sprintf("%s: %s", g.ErrorType, g.Field)
+	}
+	return g.ErrorType
+}
+
+// reportGroups returns errors and then warnings, in a stable order.
+func reportGroups(result *Result) []reportGroup {
+	var ret []reportGroup
+	for _, level := range []string{"error", "warning"} {
+		groups := result.Errors
+		if level == "warning" {
+			groups = result.Warnings
+		}
+		var lgroups []reportGroup
+		for _, g := range groups {
+			lgroups = append(lgroups, reportGroup{Level: level, ValidationReportErrorGroup: g})
+		}
+		sort.Slice(lgroups, func(i, j int) bool {
+			a, b := lgroups[i], lgroups[j]
+			if a.Filename != b.Filename {
+				return a.Filename < b.Filename
+			}
+			if a.ErrorType != b.ErrorType {
+				return a.ErrorType < b.ErrorType
+			}
+			if a.Field != b.Field {
+				return a.Field < b.Field
+			}
+			return a.GroupKey < b.GroupKey
+		})
+		ret = append(ret, lgroups...)
+	}
+	return ret
+}
+
+// ReportSourcePath returns a SourcePath for a feed directory.
+// The path is relative to the working directory if the directory is inside it, otherwise a file URI.
+func ReportSourcePath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(wd, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(rel), nil
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(), nil
+}
+
+// reportFilePath returns the path of a GTFS file for file locations.
+func reportFilePath(opts ReportOptions, fn string) string {
+	if fn == "" || opts.SourcePath == "" || strings.Contains(fn, "://") {
+		return fn
+	}
+	if strings.Contains(opts.SourcePath, "://") {
+		return strings.TrimSuffix(opts.SourcePath, "/") + "/" + fn
+	}
+	return path.Join(filepath.ToSlash(opts.SourcePath), fn)
+}
@@ -0,0 +1,224 @@
+package validator
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/interline-io/transitland-lib/tt"
+	geom "github.com/twpayne/go-geom"
+)
+
+// Self-contained HTML output.
+// Exemplar geometries are drawn as inline SVG so the report has no external dependencies.
+
+const (
+	htmlMapWidth   = 480.0
+	htmlMapHeight  = 320.0
+	htmlMapPadding = 16.0
+)
+
+type htmlReport struct {
+	Result   *Result
+	Source   string
+	Errors   int
+	Warnings int
+	Groups   []htmlGroup
+}
+
+type htmlGroup struct {
+	reportGroup
+	Anchor string
+	File   string
+	More   int
+	Map    *htmlMap
+}
+
+type htmlMap struct {
+	Width    float64
+	Height   float64
+	Points   [][2]float64
+	Lines    []string
+	Polygons []string
+}
+
+func writeHTML(w io.Writer, result *Result, opts ReportOptions) error {
+	report := htmlReport{Result: result, Source: opts.SourcePath}
+	for i, g := range reportGroups(result) {
+		hg := htmlGroup{
+			reportGroup: g,
+			Anchor:      fmt.Sprintf("group-%d", i),
+			File:        reportFilePath(opts, g.Filename),
+			More:        g.Count - len(g.Errors),
+		}
+		var geoms []tt.Geometry
+		for _, ex := range g.Errors {
+			if ex.Geometry.Valid {
+				geoms = append(geoms, ex.Geometry)
+			}
+		}
+		hg.Map = newHTMLMap(geoms)
+		if g.Level == "error" {
+			report.Errors += g.Count
+		} else {
+			report.Warnings += g.Count
+		}
+		report.Groups = append(report.Groups, hg)
+	}
+	return htmlReportTemplate.Execute(w, report)
+}
+
+// newHTMLMap projects geometries into SVG coordinates.
+// Uses an equirectangular projection scaled by the cosine of the center latitude.
+func newHTMLMap(geoms []tt.Geometry) *htmlMap {
+	minx, miny, maxx, maxy := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, g := range geoms {
+		coords := g.FlatCoords()
+		stride := g.Stride()
+		for i := 0; i+1 < len(coords); i += stride {
+			minx, maxx = math.Min(minx, coords[i]), math.Max(maxx, coords[i])
+			miny, maxy = math.Min(miny, coords[i+1]), math.Max(maxy, coords[i+1])
+		}
+	}
+	if math.IsInf(minx, 1) {
+		return nil
+	}
+	kx := math.Cos((miny + maxy) / 2 * math.Pi / 180)
+	dx, dy := (maxx-minx)*kx, maxy-miny
+	scale := math.Min(
+		(htmlMapWidth-2*htmlMapPadding)/math.Max(dx, 1e-9),
+		(htmlMapHeight-2*htmlMapPadding)/math.Max(dy, 1e-9),
+	)
+	if dx == 0 && dy == 0 {
+		scale = 0
+	}
+	project := func(x, y float64) (float64, float64) {
+		px := htmlMapWidth/2 + ((x-minx)*kx-dx/2)*scale
+		py := htmlMapHeight/2 - ((y-miny)-dy/2)*scale
+		return math.Round(px*10) / 10, math.Round(py*10) / 10
+	}
+	path := func(coords []float64, stride int) string {
+		var pts []string
+		for i := 0; i+1 < len(coords); i += stride {
+			px, py := project(coords[i], coords[i+1])
+			pts = append(pts, fmt.Sprintf("%g,%g", px, py))
+		}
+		return strings.Join(pts, " ")
+	}
+	m := &htmlMap{Width: htmlMapWidth, Height: htmlMapHeight}
+	for _, g := range geoms {
+		stride := g.Stride()
+		switch v := g.Val.(type) {
+		case *geom.LineString:
+			m.Lines = append(m.Lines, path(v.FlatCoords(), stride))
+			// Mark the start of the line, in case the line is too short to be visible
+			if coords := v.FlatCoords(); len(coords) >= 2 {
+				px, py := project(coords[0], coords[1])
+				m.Points = append(m.Points, [2]float64{px, py})
+			}
+		case *geom.MultiLineString:
+			for i := 0; i < v.NumLineStrings(); i++ {
+				m.Lines = append(m.Lines, path(v.LineString(i).FlatCoords(), stride))
+			}
+		case *geom.Polygon:
+			if v.NumLinearRings() > 0 {
+				m.Polygons = append(m.Polygons, path(v.LinearRing(0).FlatCoords(), stride))
+			}
+		default:
+			coords := g.FlatCoords()
+			for i := 0; i+1 < len(coords); i += stride {
+				px, py := project(coords[i], coords[i+1])
+				m.Points = append(m.Points, [2]float64{px, py})
+			}
+		}
+	}
+	return m
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Validation report{{if .Result.File.Val}}: {{.Result.File.Val}}{{end}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.2em; margin-top: 2em; }
+table { border-collapse: collapse; margin: 0.5em 0; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; vertical-align: top; font-size: 0.9em; }
+th { background: #f5f5f5; }
+.level { display: inline-block; padding: 1px 6px; border-radius: 3px; color: #fff; font-size: 0.8em; text-transform: uppercase; }
+.level-error { background: #c0392b; }
+.level-warning { background: #d68910; }
+.success { color: #1e8449; }
+.failure { color: #c0392b; }
+.group { border-top: 1px solid #ddd; padding-top: 0.5em; }
+.muted { color: #777; }
+svg { border: 1px solid #ddd; background: #fafafa; }
+svg polyline { fill: none; stroke: #c0392b; stroke-width: 2; }
+svg polygon { fill: rgba(192, 57, 43, 0.2); stroke: #c0392b; stroke-width: 1; }
+svg circle { fill: #c0392b; }
+</style>
+</head>
+<body>
+<h1>Validation report</h1>
+<table>
+<tr><th>File</th><td>{{.Result.File.Val}}</td></tr>
+{{- if .Source}}
+<tr><th>Source path</th><td>{{.Source}}</td></tr>
+{{- end}}
+{{- if .Result.Details.SHA1.Val}}
+<tr><th>SHA1</th><td>{{.Result.Details.SHA1.Val}}</td></tr>
+{{- end}}
+<tr><th>Validator</th><td>{{.Result.Validator.Val}} {{.Result.ValidatorVersion.Val}}</td></tr>
+<tr><th>Reported at</th><td>{{.Result.ReportedAtLocal.Val.Format "2006-01-02 15:04:05"}} {{.Result.ReportedAtLocalTimezone.Val}}</td></tr>
+<tr><th>Result</th><td>{{if .Result.Success.Val}}<span class="success">Success</span>{{else}}<span class="failure">Failed: {{.Result.FailureReason.Val}}</span>{{end}}</td></tr>
+<tr><th>Errors</th><td>{{.Errors}}</td></tr>
+<tr><th>Warnings</th><td>{{.Warnings}}</td></tr>
+</table>
+{{- if .Groups}}
+<h2>Summary</h2>
+<table>
+<tr><th>Level</th><th>File</th><th>Type</th><th>Field</th><th>Count</th></tr>
+{{- range .Groups}}
+<tr><td><span class="level level-{{.Level}}">{{.Level}}</span></td><td>{{.Filename}}</td><td><a href="#{{.Anchor}}">{{.ErrorType}}</a></td><td>{{.Field}}</td><td>{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{- range .Groups}}
+<div class="group" id="{{.Anchor}}">
+<h2><span class="level level-{{.Level}}">{{.Level}}</span> {{.Title}}</h2>
+<p>{{if .File}}<code>{{.File}}</code>: {{end}}{{.Count}} total{{if .GroupKey}}, group key <code>{{.GroupKey}}</code>{{end}}</p>
+{{- if .Errors}}
+<table>
+<tr><th>Line</th><th>Entity</th><th>Value</th><th>Message</th></tr>
+{{- range .Errors}}
+<tr><td>{{if gt .Line 0}}{{.Line}}{{end}}</td><td>{{.EntityID}}</td><td>{{.Value}}</td><td>{{.Message}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if gt .More 0}}
+<p class="muted">{{.More}} more not shown</p>
+{{- end}}
+{{- with .Map}}
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" xmlns="http://www.w3.org/2000/svg">
+{{- range .Polygons}}
+<polygon points="{{.}}"/>
+{{- end}}
+{{- range .Lines}}
+<polyline points="{{.}}"/>
+{{- end}}
+{{- range .Points}}
+<circle cx="{{index . 0}}" cy="{{index . 1}}" r="4"/>
+{{- end}}
+</svg>
+{{- end}}
+</div>
+{{- end}}
+{{- else}}
+<p class="success">No errors or warnings.</p>
+{{- end}}
+</body>
+</html>
+`))
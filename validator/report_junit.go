@@ -0,0 +1,159 @@
+package validator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// JUnit XML output, for CI dashboards.
+// Each GTFS file is a test suite and each error group is a test case.
+// Error groups are reported as failures; warning groups are reported as passing test cases with details in system-out.
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJUnit(w io.Writer, result *Result, opts ReportOptions) error {
+	suites := map[string]*junitTestSuite{}
+	getSuite := func(name string) *junitTestSuite {
+		s, ok := suites[name]
+		if !ok {
+			s = &junitTestSuite{Name: name}
+			suites[name] = s
+		}
+		return s
+	}
+
+	// Feed level result
+	feedCase := junitTestCase{Name: "validate", ClassName: "feed"}
+	if !result.Success.Val {
+		feedCase.Error = &junitFailure{Message: result.FailureReason.Val, Type: "FailureReason"}
+	}
+	getSuite("feed").Cases = append(getSuite("feed").Cases, feedCase)
+
+	// Error groups
+	for _, g := range reportGroups(result) {
+		suiteName := g.Filename
+		if suiteName == "" {
+			suiteName = "feed"
+		}
+		tc := junitTestCase{
+			Name:      g.Title(),
+			ClassName: suiteName,
+			File:      reportFilePath(opts, g.Filename),
+		}
+		var lines []string
+		for _, ex := range g.Errors {
+			if tc.Line == 0 && ex.Line > 0 {
+				tc.Line = ex.Line
+			}
+			lines = append(lines, junitExemplar(ex))
+		}
+		if len(g.Errors) < g.Count {
+			lines = append(lines, fmt.Sprintf("... and %d more", g.Count-len(g.Errors)))
+		}
+		msg := fmt.Sprintf("%d %s", g.Count, g.Level)
+		if g.Count != 1 {
+			msg += "s"
+		}
+		if g.Level == "error" {
+			tc.Failure = &junitFailure{Message: msg, Type: g.ErrorType, Text: strings.Join(lines, "\n")}
+		} else {
+			tc.SystemOut = msg + "\n" + strings.Join(lines, "\n")
+		}
+		s := getSuite(suiteName)
+		s.Cases = append(s.Cases, tc)
+	}
+
+	// Files without any errors or warnings
+	for _, fi := range result.Details.Files {
+		if _, ok := suites[fi.Name]; ok || !fi.CSVLike {
+			continue
+		}
+		getSuite(fi.Name).Cases = append(getSuite(fi.Name).Cases, junitTestCase{
+			Name:      "valid",
+			ClassName: fi.Name,
+			File:      reportFilePath(opts, fi.Name),
+		})
+	}
+
+	// Totals
+	ret := junitTestSuites{Name: strings.TrimSpace(fmt.Sprintf("%s %s", result.Validator.Val, result.File.Val))}
+	var names []string
+	for name := range suites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := suites[name]
+		for _, tc := range s.Cases {
+			s.Tests++
+			if tc.Failure != nil {
+				s.Failures++
+			}
+			if tc.Error != nil {
+				s.Errors++
+			}
+		}
+		ret.Tests += s.Tests
+		ret.Failures += s.Failures
+		ret.Errors += s.Errors
+		ret.Suites = append(ret.Suites, *s)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(ret); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitExemplar(ex ValidationReportErrorExemplar) string {
+	var loc []string
+	if ex.Line > 0 {
+		loc = append(loc, fmt.Sprintf("line %d", ex.Line))
+	}
+	if ex.EntityID != "" {
+		loc = append(loc, fmt.Sprintf("entity '%s'", ex.EntityID))
+	}
+	if len(loc) == 0 {
+		return ex.Message
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(loc, ", "), ex.Message)
+}
@@ -0,0 +1,172 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SARIF 2.1.0 output, for code scanning tools.
+// Only the subset of the format used for validation results is defined here.
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	RuleIndex  int             `json:"ruleIndex"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations,omitempty"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+func writeSARIF(w io.Writer, result *Result, opts ReportOptions) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           result.Validator.Val,
+			Version:        result.ValidatorVersion.Val,
+			InformationURI: "https://github.com/interline-io/transitland-lib",
+			Rules:          []sarifRule{},
+		}},
+		Invocations: []sarifInvocation{{ExecutionSuccessful: result.Success.Val}},
+		Results:     []sarifResult{},
+	}
+	if result.FailureReason.Val != "" {
+		run.Invocations[0].ToolExecutionNotifications = []sarifNotification{{
+			Level:   "error",
+			Message: sarifMessage{Text: result.FailureReason.Val},
+		}}
+	}
+	ruleIndex := map[string]int{}
+	for _, g := range reportGroups(result) {
+		idx, ok := ruleIndex[g.ErrorType]
+		if !ok {
+			idx = len(run.Tool.Driver.Rules)
+			ruleIndex[g.ErrorType] = idx
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               g.ErrorType,
+				ShortDescription: sarifMessage{Text: g.ErrorType},
+			})
+		}
+		fn := reportFilePath(opts, g.Filename)
+		if len(g.Errors) == 0 {
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    g.ErrorType,
+				RuleIndex: idx,
+				Level:     g.Level,
+				Message:   sarifMessage{Text: fmt.Sprintf("%d %s", g.Count, g.Title())},
+				Locations: sarifLocations(fn, 0, ""),
+			})
+			continue
+		}
+		for _, ex := range g.Errors {
+			props := map[string]any{}
+			if g.Field != "" {
+				props["field"] = g.Field
+			}
+			if ex.Value != "" {
+				props["value"] = ex.Value
+			}
+			if g.GroupKey != "" {
+				props["group_key"] = g.GroupKey
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:     g.ErrorType,
+				RuleIndex:  idx,
+				Level:      g.Level,
+				Message:    sarifMessage{Text: ex.Message},
+				Locations:  sarifLocations(fn, ex.Line, ex.EntityID),
+				Properties: props,
+			})
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+func sarifLocations(fn string, line int, entityID string) []sarifLocation {
+	if fn == "" && entityID == "" {
+		return nil
+	}
+	loc := sarifLocation{}
+	if fn != "" {
+		loc.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: fn}}
+		if line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+		}
+	}
+	if entityID != "" {
+		loc.LogicalLocations = []sarifLogicalLocation{{Name: entityID, Kind: "object"}}
+	}
+	return []sarifLocation{loc}
+}
@@ -0,0 +1,138 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/interline-io/transitland-lib/tt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	geom "github.com/twpayne/go-geom"
+)
+
+func newTestReportResult() *Result {
+	result := NewResult(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	result.Success = tt.NewBool(true)
+	result.File = tt.NewString("feed.zip")
+	result.Errors["stops.txt:stop_id:InvalidFieldError"] = &ValidationReportErrorGroup{
+		Filename:  "stops.txt",
+		Field:     "stop_lat",
+		ErrorType: "InvalidFieldError",
+		Count:     3,
+		Errors: []ValidationReportErrorExemplar{
+			{Line: 4, EntityID: "stop1", Value: "abc", Message: "invalid value for field stop_lat 'abc'"},
+		},
+	}
+	line := geom.NewLineStringFlat(geom.XY, []float64{-122.27, 37.80, -122.0, 37.0})
+	result.Warnings["stops.txt::StopTooFarError"] = &ValidationReportErrorGroup{
+		Filename:  "stops.txt",
+		ErrorType: "StopTooFarError",
+		Level:     1,
+		Count:     1,
+		Errors: []ValidationReportErrorExemplar{
+			{Line: 7, EntityID: "platform", Message: "stop 'platform' is too far from parent stop", Geometry: tt.NewGeometry(line)},
+		},
+	}
+	return result
+}
+
+func TestReportFormatFromFilename(t *testing.T) {
+	assert.Equal(t, ReportFormatSARIF, ReportFormatFromFilename("report.sarif"))
+	assert.Equal(t, ReportFormatJUnit, ReportFormatFromFilename("report.xml"))
+	assert.Equal(t, ReportFormatHTML, ReportFormatFromFilename("report.HTML"))
+	assert.Equal(t, ReportFormatJSON, ReportFormatFromFilename("report.json"))
+	assert.Equal(t, ReportFormatJSON, ReportFormatFromFilename(""))
+}
+
+func TestReportSourcePath(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Run("relative", func(t *testing.T) {
+		p, err := ReportSourcePath("gtfs")
+		require.NoError(t, err)
+		assert.Equal(t, "gtfs", p)
+	})
+	t.Run("absolute inside working directory", func(t *testing.T) {
+		p, err := ReportSourcePath(filepath.Join(wd, "a", "gtfs"))
+		require.NoError(t, err)
+		assert.Equal(t, "a/gtfs", p)
+	})
+	t.Run("outside working directory", func(t *testing.T) {
+		dir := filepath.Join(filepath.Dir(wd), "gtfs")
+		p, err := ReportSourcePath(dir)
+		require.NoError(t, err)
+		assert.Equal(t, "file://"+filepath.ToSlash(dir), p)
+		assert.Equal(t, "file://"+filepath.ToSlash(dir)+"/stops.txt", reportFilePath(ReportOptions{SourcePath: p}, "stops.txt"))
+	})
+}
+
+func TestWriteReport_SARIF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, newTestReportResult(), ReportOptions{Format: ReportFormatSARIF, SourcePath: "gtfs"}))
+	log := sarifLog{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Equal(t, 1, len(log.Runs))
+	run := log.Runs[0]
+	assert.Equal(t, 2, len(run.Tool.Driver.Rules))
+	require.Equal(t, 2, len(run.Results))
+	r := run.Results[0]
+	assert.Equal(t, "InvalidFieldError", r.RuleID)
+	assert.Equal(t, "error", r.Level)
+	require.Equal(t, 1, len(r.Locations))
+	assert.Equal(t, "gtfs/stops.txt", r.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, 4, r.Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Equal(t, "stop1", r.Locations[0].LogicalLocations[0].Name)
+	assert.Equal(t, "warning", run.Results[1].Level)
+	assert.Equal(t, 1, run.Results[1].RuleIndex)
+}
+
+func TestWriteReport_JUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, newTestReportResult(), ReportOptions{Format: ReportFormatJUnit}))
+	suites := junitTestSuites{}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	assert.Equal(t, 3, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	assert.Equal(t, 0, suites.Errors)
+	var stops *junitTestSuite
+	for i := range suites.Suites {
+		if suites.Suites[i].Name == "stops.txt" {
+			stops = &suites.Suites[i]
+		}
+	}
+	require.NotNil(t, stops)
+	require.Equal(t, 2, len(stops.Cases))
+	tc := stops.Cases[0]
+	assert.Equal(t, "InvalidFieldError: stop_lat", tc.Name)
+	assert.Equal(t, 4, tc.Line)
+	if assert.NotNil(t, tc.Failure) {
+		assert.Equal(t, "3 errors", tc.Failure.Message)
+		assert.Contains(t, tc.Failure.Text, "line 4, entity 'stop1'")
+		assert.Contains(t, tc.Failure.Text, "and 2 more")
+	}
+	assert.Nil(t, stops.Cases[1].Failure)
+	assert.Contains(t, stops.Cases[1].SystemOut, "too far")
+}
+
+func TestWriteReport_HTML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, newTestReportResult(), ReportOptions{Format: ReportFormatHTML}))
+	out := buf.String()
+	assert.Contains(t, out, "InvalidFieldError")
+	assert.Contains(t, out, "invalid value for field stop_lat &#39;abc&#39;")
+	assert.Contains(t, out, "2 more not shown")
+	assert.Equal(t, 1, strings.Count(out, "<svg"))
+	assert.Contains(t, out, "<polyline")
+	assert.NotContains(t, out, "<script")
+}
+
+func TestWriteReport_UnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, WriteReport(&buf, newTestReportResult(), ReportOptions{Format: "pdf"}))
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/interline-io/transitland-lib/adapters"
 	"github.com/interline-io/transitland-lib/adapters/empty"
 	"github.com/interline-io/transitland-lib/copier"
+	"github.com/interline-io/transitland-lib/dmfr"
 	"github.com/interline-io/transitland-lib/request"
 	"github.com/interline-io/transitland-lib/rt"
 	"github.com/interline-io/transitland-lib/rules"
@@ -47,8 +48,11 @@ type Options struct {
 	ValidateRealtimeMessages []string
 	IncludeRealtimeJson      bool
 	MaxRTMessageSize         uint64
+	RealtimeAuthorization    dmfr.FeedAuthorization // authorization for fetching GTFS-RT urls
+	RealtimeSecret           dmfr.Secret
 	EvaluateAt               time.Time
 	EvaluateAtTimezone       string
+	Profile                  *Profile // severity overrides, rule parameters, and suppressions
 	copier.Options
 }
 
@@ -161,16 +165,31 @@ func (v *Validator) ValidateStatic(reader adapters.Reader, evaluateAt time.Time,
 	details.LatestCalendarDate = fv.LatestCalendarDate
 
 	// Main validation
+	cpOpts := v.copierOptions()
+	var profileResult *copier.Result
+	if v.Options.Profile != nil {
+		// Apply the profile before errors are grouped and counted
+		next := cpOpts.ErrorHandler
+		if next == nil {
+			profileResult = copier.NewResult(cpOpts.ErrorLimit)
+			next = profileResult
+		}
+		cpOpts.ErrorHandler = &profileErrorHandler{profile: v.Options.Profile, evaluateAt: evaluateAtLocal, next: next}
+	}
 	cpResult, err := copier.CopyWithOptions(
 		context.TODO(),
 		reader,
 		&empty.Writer{},
-		v.copierOptions(),
+		cpOpts,
 	)
 	if err != nil {
 		result.FailureReason.Set("failed to validate feed")
 		return result, nil
 	}
+	if profileResult != nil {
+		cpResult.Errors = profileResult.Errors
+		cpResult.Warnings = profileResult.Warnings
+	}
 
 	// Service levels
 	if v.Options.IncludeServiceLevels {
@@ -254,7 +273,11 @@ func (v *Validator) ValidateRTs(ctx context.Context, rtUrls []string, evaluateAt
 		}
 		// Create a temp copier result to handle errors
 		cpResult := copier.NewResult(v.Options.ErrorLimit)
-		cpResult.HandleError(filepath.Base(fn), rtResult.Errors)
+		rtErrs, rtWarns := v.Options.Profile.apply(filepath.Base(fn), "", evaluateAtLocal, rtResult.Errors, nil)
+		cpResult.HandleError(filepath.Base(fn), rtErrs)
+		cpResult.HandleWarning(filepath.Base(fn), rtWarns)
+		// Drop suppressed and disabled errors from the message details
+		rtResult.Errors = append(rtErrs, rtWarns...)
 		if len(rtResult.Errors) > v.Options.ErrorLimit {
 			rtResult.Errors = rtResult.Errors[0:v.Options.ErrorLimit]
 		}
@@ -277,7 +300,11 @@ func (v *Validator) ValidateRT(ctx context.Context, fn string, evaluateAt time.T
 		Url: fn,
 	}
 	var rterrs []error
-	msg, err := rt.ReadURL(ctx, fn, request.WithMaxSize(v.Options.MaxRTMessageSize), request.WithAllowLocal)
+	reqOpts := []request.RequestOption{request.WithMaxSize(v.Options.MaxRTMessageSize), request.WithAllowLocal}
+	if v.Options.RealtimeAuthorization.Type != "" {
+		reqOpts = append(reqOpts, request.WithAuth(v.Options.RealtimeSecret, v.Options.RealtimeAuthorization))
+	}
+	msg, err := rt.ReadURL(ctx, fn, reqOpts...)
 	if err != nil {
 		rterrs = append(rterrs, err)
 	} else {
@@ -356,13 +383,19 @@ func (v *Validator) copierOptions() copier.Options {
 
 	// Best practices extension
 	if v.Options.BestPractices {
+		stopTooFarCheck := &rules.StopTooFarCheck{}
+		fastTravelCheck := &rules.StopTimeFastTravelCheck{}
+		shapeMaxSegmentLengthCheck := &rules.ShapeMaxSegmentLengthCheck{
+			MaxAllowedDistance: 1_000_000, // 1000 km
+		}
+		v.Options.Profile.configureRules(stopTooFarCheck, fastTravelCheck, shapeMaxSegmentLengthCheck)
 		cpOpts.AddExtensionWithLevel(&rules.NoScheduledServiceCheck{}, 1)
 		cpOpts.AddExtensionWithLevel(&rules.StopTooCloseCheck{}, 1)
-		cpOpts.AddExtensionWithLevel(&rules.StopTooFarCheck{}, 1)
+		cpOpts.AddExtensionWithLevel(stopTooFarCheck, 1)
 		cpOpts.AddExtensionWithLevel(&rules.DuplicateRouteNameCheck{}, 1)
 		cpOpts.AddExtensionWithLevel(&rules.FrequencyOverlapCheck{}, 1)
 		cpOpts.AddExtensionWithLevel(&rules.StopTooFarFromShapeCheck{}, 1)
-		cpOpts.AddExtensionWithLevel(&rules.StopTimeFastTravelCheck{}, 1)
+		cpOpts.AddExtensionWithLevel(fastTravelCheck, 1)
 		cpOpts.AddExtensionWithLevel(&rules.BlockOverlapCheck{}, 1)
 		cpOpts.AddExtensionWithLevel(&rules.AgencyIDRecommendedCheck{}, 1)
 		cpOpts.AddExtensionWithLevel(&rules.DescriptionEqualsName{}, 1)
@@ -376,9 +409,12 @@ func (v *Validator) copierOptions() copier.Options {
 		cpOpts.AddExtensionWithLevel(&rules.MinTransferTimeCheck{}, 1)
 		cpOpts.AddExtensionWithLevel(&rules.RouteNamesPrefixCheck{}, 1)
 		cpOpts.AddExtensionWithLevel(&rules.RouteNamesCharactersCheck{}, 1)
-		cpOpts.AddExtensionWithLevel(&rules.ShapeMaxSegmentLengthCheck{
-			MaxAllowedDistance: 1_000_000, // 1000 km
-		}, 1)
+		cpOpts.AddExtensionWithLevel(&rules.FareLegRuleAmbiguousCheck{}, 1)
+		cpOpts.AddExtensionWithLevel(&rules.TimeframeOverlapCheck{}, 1)
+		cpOpts.AddExtensionWithLevel(&rules.AreaWithoutStopsCheck{}, 1)
+		cpOpts.AddExtensionWithLevel(&rules.FareProductMediaCheck{}, 1)
+		cpOpts.AddExtensionWithLevel(&rules.RouteNetworkConflictCheck{}, 1)
+		cpOpts.AddExtensionWithLevel(shapeMaxSegmentLengthCheck, 1)
 	}
 	return cpOpts
 }